@@ -0,0 +1,248 @@
+// Пакет accesslog записывает журнал HTTP-запросов в форматах Apache combined, JSON
+// с фиксированной схемой и logfmt. Успешные запросы могут записываться выборочно,
+// ответы с ошибками и медленные запросы записываются всегда.
+package accesslog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Форматы журнала.
+const (
+	// FormatCombined задает формат Apache combined.
+	FormatCombined = "combined"
+	// FormatJSON задает формат JSON, по одному объекту со схемой jsonEntry в строке.
+	FormatJSON = "json"
+	// FormatLogfmt задает формат logfmt.
+	FormatLogfmt = "logfmt"
+)
+
+// Ошибка неизвестного формата журнала.
+var ErrInvalidFormat = errors.New("invalid access log format")
+
+// ValidateFormat возвращает ошибку, если формат format неизвестен.
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatCombined, FormatJSON, FormatLogfmt:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidFormat, format)
+}
+
+// Структура записи журнала об обработанном HTTP-запросе.
+type Entry struct {
+	// Time задает время получения запроса.
+	Time       time.Time
+	RemoteAddr string
+	Method     string
+	URL        string
+	Proto      string
+	Status     int
+	// Size задает количество байтов тела ответа, переданных клиенту.
+	Size      int
+	Duration  time.Duration
+	Referer   string
+	UserAgent string
+}
+
+// Append добавляет к b запись e в формате format и перевод строки.
+// Неизвестный формат считается форматом FormatJSON.
+func Append(b []byte, format string, e Entry) []byte {
+	switch format {
+	case FormatCombined:
+		return appendCombined(b, e)
+	case FormatLogfmt:
+		return appendLogfmt(b, e)
+	}
+	return appendJSON(b, e)
+}
+
+// host возвращает адрес клиента без порта.
+func host(remoteAddr string) string {
+	if h, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return h
+	}
+	return remoteAddr
+}
+
+// appendCombined добавляет запись в формате Apache combined:
+// host - - [time] "request" status size "referer" "user agent".
+func appendCombined(b []byte, e Entry) []byte {
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	b = append(b, orDash(host(e.RemoteAddr))...)
+	b = append(b, " - - ["...)
+	b = e.Time.AppendFormat(b, "02/Jan/2006:15:04:05 -0700")
+	b = append(b, "] \""...)
+	b = appendEscaped(b, e.Method+" "+e.URL+" "+e.Proto)
+	b = append(b, "\" "...)
+	b = strconv.AppendInt(b, int64(e.Status), 10)
+	b = append(b, ' ')
+	if e.Size > 0 {
+		b = strconv.AppendInt(b, int64(e.Size), 10)
+	} else {
+		b = append(b, '-')
+	}
+	b = append(b, " \""...)
+	b = appendEscaped(b, orDash(e.Referer))
+	b = append(b, "\" \""...)
+	b = appendEscaped(b, orDash(e.UserAgent))
+	return append(b, "\"\n"...)
+}
+
+// appendEscaped добавляет строку s, экранируя кавычки, обратные слеши и непечатаемые байты,
+// как это делает Apache, чтобы значение нельзя было принять за несколько полей или строк.
+func appendEscaped(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '"' || r == '\\':
+			b = append(b, '\\', byte(r))
+		case r == utf8.RuneError && size == 1, r < 0x20, r == 0x7f:
+			b = append(b, '\\', 'x', hex[s[i]>>4], hex[s[i]&0xf])
+		default:
+			b = append(b, s[i:i+size]...)
+		}
+		i += size
+	}
+	return b
+}
+
+// Структура записи журнала в формате JSON. Порядок и названия полей не меняются.
+type jsonEntry struct {
+	Time       string  `json:"time"`
+	RemoteAddr string  `json:"remote_addr"`
+	Method     string  `json:"method"`
+	URL        string  `json:"url"`
+	Proto      string  `json:"proto"`
+	Status     int     `json:"status"`
+	Size       int     `json:"size"`
+	DurationMS float64 `json:"duration_ms"`
+	Referer    string  `json:"referer"`
+	UserAgent  string  `json:"user_agent"`
+}
+
+// appendJSON добавляет запись в формате JSON.
+func appendJSON(b []byte, e Entry) []byte {
+	data, _ := json.Marshal(jsonEntry{
+		Time:       e.Time.Format(time.RFC3339Nano),
+		RemoteAddr: e.RemoteAddr,
+		Method:     e.Method,
+		URL:        e.URL,
+		Proto:      e.Proto,
+		Status:     e.Status,
+		Size:       e.Size,
+		DurationMS: float64(e.Duration) / float64(time.Millisecond),
+		Referer:    e.Referer,
+		UserAgent:  e.UserAgent,
+	})
+	b = append(b, data...)
+	return append(b, '\n')
+}
+
+// appendLogfmt добавляет запись в формате logfmt с теми же ключами, что и в формате JSON.
+func appendLogfmt(b []byte, e Entry) []byte {
+	b = appendPair(b, "time", e.Time.Format(time.RFC3339Nano))
+	b = appendPair(b, "remote_addr", e.RemoteAddr)
+	b = appendPair(b, "method", e.Method)
+	b = appendPair(b, "url", e.URL)
+	b = appendPair(b, "proto", e.Proto)
+	b = appendPair(b, "status", strconv.Itoa(e.Status))
+	b = appendPair(b, "size", strconv.Itoa(e.Size))
+	b = appendPair(b, "duration", e.Duration.String())
+	b = appendPair(b, "referer", e.Referer)
+	b = appendPair(b, "user_agent", e.UserAgent)
+	b[len(b)-1] = '\n'
+	return b
+}
+
+// appendPair добавляет пару key=value и пробел. Пустые значения и значения с пробелами,
+// кавычками, знаком равенства или непечатаемыми символами заключаются в кавычки.
+func appendPair(b []byte, key string, value string) []byte {
+	b = append(b, key...)
+	b = append(b, '=')
+	if value == "" || strings.ContainsFunc(value, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == 0x7f || r == utf8.RuneError
+	}) {
+		b = strconv.AppendQuote(b, value)
+	} else {
+		b = append(b, value...)
+	}
+	return append(b, ' ')
+}
+
+// Параметры журнала.
+type Options struct {
+	// Format задает формат записей, по умолчанию FormatJSON.
+	Format string
+	// SampleRate задает долю записываемых успешных запросов от 0 до 1.
+	// Нулевое значение означает запись всех запросов.
+	SampleRate float64
+	// SlowThreshold задает длительность, начиная с которой запрос записывается независимо
+	// от выборки. Нулевое значение отключает порог.
+	SlowThreshold time.Duration
+}
+
+// Структура журнала HTTP-запросов, безопасная для конкурентного использования.
+type Logger struct {
+	mu   sync.Mutex
+	w    io.Writer
+	opts Options
+	buf  []byte
+	// sample возвращает случайное число из [0, 1) для выборки успешных запросов.
+	sample func() float64
+}
+
+// New возвращает журнал, записывающий записи в w. Каждая запись передается
+// в w одним вызовом Write.
+func New(w io.Writer, opts Options) *Logger {
+	if opts.Format == "" {
+		opts.Format = FormatJSON
+	}
+	if opts.SampleRate <= 0 || opts.SampleRate > 1 {
+		opts.SampleRate = 1
+	}
+	return &Logger{w: w, opts: opts, sample: rand.Float64}
+}
+
+// Log записывает запись e, если запрос завершился ошибкой (код 400 и выше), выполнялся
+// не меньше Options.SlowThreshold или попал в выборку успешных запросов.
+func (l *Logger) Log(e Entry) error {
+	if !l.keep(e) {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buf = Append(l.buf[:0], l.opts.Format, e)
+	_, err := l.w.Write(l.buf)
+	return err
+}
+
+// keep сообщает, записывается ли запись e.
+func (l *Logger) keep(e Entry) bool {
+	switch {
+	case e.Status >= 400:
+		return true
+	case l.opts.SlowThreshold > 0 && e.Duration >= l.opts.SlowThreshold:
+		return true
+	case l.opts.SampleRate >= 1:
+		return true
+	}
+	return l.sample() < l.opts.SampleRate
+}
@@ -0,0 +1,100 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppend(t *testing.T) {
+	e := Entry{
+		Time:       time.Date(2010, 5, 17, 10, 0, 0, 123000000, time.FixedZone("MSK", 4*60*60)),
+		RemoteAddr: "192.0.2.1:51234",
+		Method:     "GET",
+		URL:        "/events_for_day?day=2010-05-17",
+		Proto:      "HTTP/1.1",
+		Status:     200,
+		Size:       512,
+		Duration:   1500 * time.Microsecond,
+		UserAgent:  `curl/8.0 "quoted"` + "\n",
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		e      Entry
+		want   string
+	}{
+		{"Combined", FormatCombined, e, `192.0.2.1 - - [17/May/2010:10:00:00 +0400] "GET /events_for_day?day=2010-05-17 HTTP/1.1" 200 512 "-" "curl/8.0 \"quoted\"\x0a"` + "\n"},
+		{"CombinedEmpty", FormatCombined, Entry{Time: e.Time, RemoteAddr: "@", Method: "GET", URL: "/", Proto: "HTTP/1.1", Status: 204, Referer: "http://example.com/"},
+			`@ - - [17/May/2010:10:00:00 +0400] "GET / HTTP/1.1" 204 - "http://example.com/" "-"` + "\n"},
+		{"JSON", FormatJSON, e, `{"time":"2010-05-17T10:00:00.123+04:00","remote_addr":"192.0.2.1:51234","method":"GET","url":"/events_for_day?day=2010-05-17",` +
+			`"proto":"HTTP/1.1","status":200,"size":512,"duration_ms":1.5,"referer":"","user_agent":"curl/8.0 \"quoted\"\n"}` + "\n"},
+		{"Logfmt", FormatLogfmt, e, `time=2010-05-17T10:00:00.123+04:00 remote_addr=192.0.2.1:51234 method=GET url="/events_for_day?day=2010-05-17" ` +
+			`proto=HTTP/1.1 status=200 size=512 duration=1.5ms referer="" user_agent="curl/8.0 \"quoted\"\n"` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(Append(nil, tt.format, tt.e)); got != tt.want {
+				t.Errorf("Append() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("JSONSchema", func(t *testing.T) {
+		var m map[string]any
+		if err := json.Unmarshal(Append(nil, FormatJSON, Entry{}), &m); err != nil {
+			t.Fatal(err)
+		}
+		if len(m) != 10 {
+			t.Errorf("Append() JSON fields = %v, want all 10 fields of the schema", m)
+		}
+	})
+}
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{FormatCombined, FormatJSON, FormatLogfmt} {
+		if err := ValidateFormat(format); err != nil {
+			t.Errorf("ValidateFormat(%q) error = %v", format, err)
+		}
+	}
+	if err := ValidateFormat("common"); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("ValidateFormat() error = %v, want %v", err, ErrInvalidFormat)
+	}
+}
+
+func TestLogger_Log(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		e    Entry
+		want bool
+	}{
+		{"All", Options{}, Entry{Status: 200}, true},
+		{"Sampled", Options{SampleRate: 0.5}, Entry{Status: 200, Duration: time.Second}, false},
+		{"SampledIn", Options{SampleRate: 0.75}, Entry{Status: 200}, true},
+		{"ClientError", Options{SampleRate: 0.01}, Entry{Status: 404}, true},
+		{"ServerError", Options{SampleRate: 0.01}, Entry{Status: 500}, true},
+		{"Slow", Options{SampleRate: 0.01, SlowThreshold: time.Second}, Entry{Status: 200, Duration: time.Second}, true},
+		{"Fast", Options{SampleRate: 0.01, SlowThreshold: time.Second}, Entry{Status: 200, Duration: time.Millisecond}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			l := New(&b, tt.opts)
+			l.sample = func() float64 { return 0.5 }
+			if err := l.Log(tt.e); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.Len() > 0; got != tt.want {
+				t.Errorf("Logger.Log() written = %v, want %v", got, tt.want)
+			}
+			if tt.want && !strings.HasPrefix(b.String(), `{"time"`) {
+				t.Errorf("Logger.Log() = %q, want JSON by default", b.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package accesslog
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Максимальное количество ошибок, накапливаемых до вызова Close. Последующие ошибки,
+// например при каждой неудачной попытке ротации, отбрасываются.
+const maxErrs = 16
+
+// Формат времени ротации в именах архивных файлов. Имена с одинаковой шириной
+// времени в UTC упорядочиваются лексикографически от старых к новым.
+const rotateTimeLayout = "20060102T150405.000000000"
+
+// Параметры ротации файла журнала.
+type RotateOptions struct {
+	// MaxSize задает размер файла в байтах, при превышении которого файл переименовывается
+	// в архивный и создается новый. Нулевое значение отключает ротацию.
+	MaxSize int64
+	// MaxBackups ограничивает количество архивных файлов, лишние удаляются начиная с самых старых.
+	// Нулевое значение означает хранение всех архивных файлов.
+	MaxBackups int
+	// Compress сжимает архивные файлы gzip в фоне.
+	Compress bool
+}
+
+// Структура файла журнала с ротацией по размеру. Архивные файлы получают имя
+// <path>.<время ротации> и расширение .gz после сжатия. Безопасна для конкурентного использования.
+type RotatingFile struct {
+	mu   sync.Mutex
+	path string
+	opts RotateOptions
+	// file равен nil после закрытия или неудачной ротации, во втором случае файл
+	// открывается заново при следующей записи.
+	file   *os.File
+	closed bool
+	size   int64
+	// now возвращает время ротации.
+	now func() time.Time
+	// rotated задает время последней ротации, после которого именуется следующий архивный файл.
+	rotated time.Time
+	// compressing ожидает завершения фонового сжатия архивных файлов.
+	compressing sync.WaitGroup
+	// errs содержит ошибки ротации, фонового сжатия и удаления архивных файлов,
+	// которые не мешают записи и возвращаются Close.
+	errs []error
+}
+
+// OpenRotatingFile открывает файл журнала path для добавления записей, создавая его при необходимости.
+func OpenRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, opts: opts, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open открывает текущий файл журнала. Вызывается под блокировкой.
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// Write записывает p в файл журнала, предварительно выполняя ротацию, если с p непустой
+// файл превысит RotateOptions.MaxSize. Запись p не разделяется между файлами.
+// Если ротация не удалась, p записывается в текущий файл, а ошибка возвращается Close.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if f.opts.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.opts.MaxSize {
+		if err := f.rotate(); err != nil {
+			if f.file == nil {
+				return 0, err
+			}
+			f.addErr(err)
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// addErr сохраняет ошибку err для Close. Вызывается под блокировкой.
+func (f *RotatingFile) addErr(err error) {
+	if len(f.errs) < maxErrs {
+		f.errs = append(f.errs, err)
+	}
+}
+
+// rotate переименовывает текущий файл в архивный и открывает новый. При ошибке
+// файл по возможности открывается заново, а f.file равен nil, если это не удалось.
+// Вызывается под блокировкой.
+func (f *RotatingFile) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		// Дескриптор освобождается и при ошибке закрытия, поэтому текущий файл открывается заново без ротации.
+		return errors.Join(err, f.open())
+	}
+
+	// Время увеличивается, пока имя не станет уникальным и большим имен предыдущих архивных
+	// файлов, например при нескольких ротациях за время разрешения часов.
+	at := f.now().UTC()
+	if !at.After(f.rotated) {
+		at = f.rotated.Add(time.Nanosecond)
+	}
+	backup := f.path + "." + at.Format(rotateTimeLayout)
+	for exists(backup) || exists(backup+".gz") {
+		at = at.Add(time.Nanosecond)
+		backup = f.path + "." + at.Format(rotateTimeLayout)
+	}
+	f.rotated = at
+	if err := os.Rename(f.path, backup); err != nil {
+		return errors.Join(err, f.open())
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	if !f.opts.Compress {
+		f.prune()
+		return nil
+	}
+	f.compressing.Add(1)
+	go func() {
+		defer f.compressing.Done()
+		f.compress(backup)
+	}()
+	return nil
+}
+
+// compress сжимает архивный файл backup во временный файл, после чего под блокировкой
+// заменяет им архивный файл и удаляет лишние архивные файлы.
+func (f *RotatingFile) compress(backup string) {
+	tmp := backup + ".gz.tmp"
+	err := gzipFile(backup, tmp)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case !exists(backup):
+		// Архивный файл удален до или во время сжатия, так как превысил RotateOptions.MaxBackups.
+		os.Remove(tmp)
+	case err == nil:
+		if err := os.Rename(tmp, backup+".gz"); err != nil {
+			f.addErr(err)
+		} else if err := os.Remove(backup); err != nil {
+			f.addErr(err)
+		}
+	default:
+		os.Remove(tmp)
+		f.addErr(err)
+	}
+	f.prune()
+}
+
+// gzipFile записывает содержимое файла src, сжатое gzip, в файл dst.
+func gzipFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	return errors.Join(err, zw.Close(), out.Sync(), out.Close())
+}
+
+// prune удаляет архивные файлы сверх RotateOptions.MaxBackups. Вызывается под блокировкой.
+func (f *RotatingFile) prune() {
+	if f.opts.MaxBackups <= 0 {
+		return
+	}
+	backups, err := f.Backups()
+	if err != nil {
+		f.addErr(err)
+		return
+	}
+	for _, backup := range backups[:max(len(backups)-f.opts.MaxBackups, 0)] {
+		if err := os.Remove(backup); err != nil {
+			f.addErr(err)
+		}
+	}
+}
+
+// Backups возвращает архивные файлы журнала от старых к новым.
+func (f *RotatingFile) Backups() ([]string, error) {
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, match := range matches {
+		// Временные файлы сжатия не учитываются, пока существует исходный архивный файл.
+		at := strings.TrimSuffix(strings.TrimPrefix(match, f.path+"."), ".gz")
+		if _, err := time.Parse(rotateTimeLayout, at); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	slices.SortFunc(backups, func(a, b string) int {
+		return strings.Compare(strings.TrimSuffix(a, ".gz"), strings.TrimSuffix(b, ".gz"))
+	})
+	return backups, nil
+}
+
+// Close закрывает файл журнала и дожидается завершения фонового сжатия.
+// Возвращает ошибки закрытия, сжатия и удаления архивных файлов.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return os.ErrClosed
+	}
+	// После закрытия файла новые ротации не начинаются.
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.closed = true
+	f.mu.Unlock()
+
+	f.compressing.Wait()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err = errors.Join(append(f.errs, err)...)
+	f.errs = nil
+	return err
+}
+
+// exists сообщает, существует ли файл path.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,169 @@
+package accesslog
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	now := time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC)
+	line := strings.Repeat("x", 9) + "\n"
+
+	tests := []struct {
+		name        string
+		opts        RotateOptions
+		lines       int
+		wantBackups []string
+		wantCurrent int
+	}{
+		{"NoRotation", RotateOptions{}, 5, nil, 5},
+		// Файл из 10 строк по 10 байт переполняется на 4-й строке.
+		{"Rotation", RotateOptions{MaxSize: 30}, 7, []string{"access.log.20100517T100000.000000000", "access.log.20100517T100000.000000001"}, 1},
+		{"MaxBackups", RotateOptions{MaxSize: 30, MaxBackups: 1}, 10, []string{"access.log.20100517T100000.000000002"}, 1},
+		{"Compress", RotateOptions{MaxSize: 30, MaxBackups: 2, Compress: true}, 10,
+			[]string{"access.log.20100517T100000.000000001.gz", "access.log.20100517T100000.000000002.gz"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "access.log")
+			f, err := OpenRotatingFile(path, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.now = func() time.Time { return now }
+			for range tt.lines {
+				if _, err := io.WriteString(f, line); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := f.Close(); err != nil {
+				t.Fatalf("RotatingFile.Close() error = %v", err)
+			}
+
+			backups, err := f.Backups()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, backup := range backups {
+				got = append(got, filepath.Base(backup))
+				if data := readBackup(t, backup); data != strings.Repeat(line, 3) {
+					t.Errorf("backup %s = %q, want 3 lines", backup, data)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantBackups, ",") {
+				t.Errorf("RotatingFile.Backups() = %v, want %v", got, tt.wantBackups)
+			}
+			if data, _ := os.ReadFile(path); string(data) != strings.Repeat(line, tt.wantCurrent) {
+				t.Errorf("current file = %q, want %d lines", data, tt.wantCurrent)
+			}
+			if tmp, _ := filepath.Glob(path + ".*.tmp"); len(tmp) != 0 {
+				t.Errorf("temporary files left: %v", tmp)
+			}
+		})
+	}
+
+	t.Run("Append", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "access.log")
+		os.WriteFile(path, []byte(line+line+line), 0o644)
+		f, err := OpenRotatingFile(path, RotateOptions{MaxSize: 30})
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(f, line)
+		f.Close()
+		if backups, _ := f.Backups(); len(backups) != 1 {
+			t.Errorf("RotatingFile.Backups() = %v, want existing file rotated", backups)
+		}
+	})
+
+	t.Run("PendingError", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "access.log")
+		f, err := OpenRotatingFile(path, RotateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		compressErr := errors.New("compress failed")
+		f.errs = append(f.errs, compressErr)
+
+		// Ошибка фонового сжатия не должна приводить к потере записи.
+		if _, err := io.WriteString(f, line); err != nil {
+			t.Errorf("RotatingFile.Write() error = %v, wantErr %v", err, false)
+		}
+		if err := f.Close(); !errors.Is(err, compressErr) {
+			t.Errorf("RotatingFile.Close() error = %v, want %v", err, compressErr)
+		}
+		if data, _ := os.ReadFile(path); string(data) != line {
+			t.Errorf("current file = %q, want %q", data, line)
+		}
+	})
+
+	t.Run("CloseError", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "access.log")
+		f, err := OpenRotatingFile(path, RotateOptions{MaxSize: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(f, line)
+		// Ротация не сможет закрыть уже закрытый файл.
+		f.file.Close()
+
+		if _, err := io.WriteString(f, line); err != nil {
+			t.Errorf("RotatingFile.Write() error = %v, wantErr %v", err, false)
+		}
+		if _, err := io.WriteString(f, line); err != nil {
+			t.Errorf("RotatingFile.Write() error = %v, wantErr %v", err, false)
+		}
+		if err := f.Close(); !errors.Is(err, os.ErrClosed) {
+			t.Errorf("RotatingFile.Close() error = %v, want %v", err, os.ErrClosed)
+		}
+		// Запись при неудачной ротации добавляется в текущий файл, следующая ротация выполняется.
+		backups, _ := f.Backups()
+		if len(backups) != 1 || readBackup(t, backups[0]) != line+line {
+			t.Errorf("RotatingFile.Backups() = %v, want 1 backup with 2 lines", backups)
+		}
+		if data, _ := os.ReadFile(path); string(data) != line {
+			t.Errorf("current file = %q, want %q", data, line)
+		}
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		f, err := OpenRotatingFile(filepath.Join(t.TempDir(), "access.log"), RotateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		if _, err := io.WriteString(f, line); !errors.Is(err, os.ErrClosed) {
+			t.Errorf("RotatingFile.Write() error = %v, want %v", err, os.ErrClosed)
+		}
+	})
+}
+
+// readBackup возвращает содержимое архивного файла path, распаковывая файлы .gz.
+func readBackup(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = zr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,103 @@
+// Пакет agenda предоставляет отображение повестки пользователя в текст, HTML и Markdown
+// и рассылку повесток по расписанию.
+package agenda
+
+import (
+	"dev11/app/entity"
+	"embed"
+	"errors"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Форматы отображения повестки.
+const (
+	FormatText     = "text"
+	FormatHTML     = "html"
+	FormatMarkdown = "markdown"
+)
+
+// Ошибка неверного формата отображения повестки.
+var ErrFormatInvalid = errors.New("format is invalid")
+
+// Шаблоны повестки: по одному файлу на формат.
+//
+//go:embed templates
+var templates embed.FS
+
+// Функции, доступные в шаблонах повестки.
+var funcs = map[string]any{
+	"periodTitle": Title,
+	"date":        func(t time.Time) string { return t.Format("Mon, 2 Jan 2006") },
+	"clock":       func(t time.Time) string { return t.Format("15:04") },
+	"titles":      titles,
+	"md":          escapeMarkdown,
+}
+
+// Шаблоны форматов и типы содержимого результата.
+var (
+	textTemplate     = template.Must(template.New("").Funcs(funcs).ParseFS(templates, "templates/agenda.txt.tmpl"))
+	markdownTemplate = template.Must(template.New("").Funcs(funcs).ParseFS(templates, "templates/agenda.md.tmpl"))
+	htmlTemplate     = htmltemplate.Must(htmltemplate.New("").Funcs(funcs).ParseFS(templates, "templates/agenda.html.tmpl"))
+
+	contentTypes = map[string]string{
+		FormatText:     "text/plain; charset=utf-8",
+		FormatHTML:     "text/html; charset=utf-8",
+		FormatMarkdown: "text/markdown; charset=utf-8",
+	}
+)
+
+// ContentType возвращает тип содержимого повестки в формате format.
+func ContentType(format string) (string, error) {
+	contentType, ok := contentTypes[format]
+	if !ok {
+		return "", ErrFormatInvalid
+	}
+	return contentType, nil
+}
+
+// Render записывает в w повестку agenda в формате format.
+// В формате HTML текст событий экранируется.
+func Render(w io.Writer, agenda entity.Agenda, format string) error {
+	switch format {
+	case FormatText:
+		return textTemplate.ExecuteTemplate(w, "agenda", agenda)
+	case FormatMarkdown:
+		return markdownTemplate.ExecuteTemplate(w, "agenda", agenda)
+	case FormatHTML:
+		return htmlTemplate.ExecuteTemplate(w, "agenda", agenda)
+	}
+	return ErrFormatInvalid
+}
+
+// Title возвращает заголовок периода повестки agenda.
+func Title(agenda entity.Agenda) string {
+	if agenda.Period == entity.PeriodWeek {
+		return "the week of " + agenda.Start.Format("2 Jan 2006")
+	}
+	return agenda.Start.Format("Monday, 2 Jan 2006")
+}
+
+// titles возвращает названия событий events через запятую.
+func titles(events []entity.Event) string {
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = event.Title
+	}
+	return strings.Join(names, ", ")
+}
+
+// Заменитель специальных символов Markdown.
+var markdownEscaper = func() *strings.Replacer {
+	pairs := []string{"\n", " ", "\r", ""}
+	for _, c := range "\\`*_{}[]()<>#+-.!|~" {
+		pairs = append(pairs, string(c), "\\"+string(c))
+	}
+	return strings.NewReplacer(pairs...)
+}()
+
+// escapeMarkdown экранирует специальные символы Markdown в тексте s и заменяет переводы строк пробелами.
+func escapeMarkdown(s string) string { return markdownEscaper.Replace(s) }
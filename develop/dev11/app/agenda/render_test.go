@@ -0,0 +1,101 @@
+package agenda
+
+import (
+	"dev11/app/entity"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testAgenda возвращает повестку на день с событиями, конфликтом и свободным временем.
+func testAgenda() entity.Agenda {
+	day := time.Date(2010, 5, 17, 0, 0, 0, 0, time.UTC)
+	standup := entity.Event{Title: "<b>standup</b>", Date: day.Add(10 * time.Hour)}
+	review := entity.Event{Title: "review_*", Date: day.Add(10*time.Hour + 30*time.Minute)}
+	return entity.Agenda{
+		Period: entity.PeriodDay, Start: day, End: day.Add(24 * time.Hour),
+		Days: []entity.AgendaDay{{
+			Date:   day,
+			Events: []entity.Event{standup, review},
+			Gaps:   []entity.Gap{{Start: day.Add(9 * time.Hour), End: day.Add(10 * time.Hour)}},
+		}},
+		Conflicts: []entity.Conflict{{Start: standup.Date, End: day.Add(11*time.Hour + 30*time.Minute), Events: []entity.Event{standup, review}}},
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		want   []string
+	}{
+		{"Text", FormatText, []string{
+			"Agenda for Monday, 17 May 2010",
+			"  10:00  <b>standup</b>\n  10:30  review_*\n  Free 09:00–10:00",
+			"Conflicts\n  Mon, 17 May 2010 10:00–11:30: <b>standup</b>, review_*",
+		}},
+		{"Markdown", FormatMarkdown, []string{
+			"# Agenda for Monday, 17 May 2010",
+			"- **10:00** \\<b\\>standup\\</b\\>\n- **10:30** review\\_\\*",
+			"Free time: 09:00–10:00",
+			"## Conflicts",
+		}},
+		{"HTML", FormatHTML, []string{
+			"<h1>Agenda for Monday, 17 May 2010</h1>",
+			"<li><strong>10:00</strong> &lt;b&gt;standup&lt;/b&gt;</li>",
+			"<p>Free time: 09:00–10:00</p>",
+			"<li>Mon, 17 May 2010 10:00–11:30: &lt;b&gt;standup&lt;/b&gt;, review_*</li>",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := Render(&b, testAgenda(), tt.format); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(b.String(), want) {
+					t.Errorf("Render() = %q, want substring %q", b.String(), want)
+				}
+			}
+		})
+	}
+
+	t.Run("NoEvents", func(t *testing.T) {
+		agenda := entity.Agenda{Period: entity.PeriodWeek, Days: []entity.AgendaDay{{}}}
+		var b strings.Builder
+		if err := Render(&b, agenda, FormatText); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); !strings.Contains(got, "the week of") || !strings.Contains(got, "No events") || strings.Contains(got, "Conflicts") {
+			t.Errorf("Render() = %q, want empty week", got)
+		}
+	})
+
+	t.Run("Holiday", func(t *testing.T) {
+		agenda := testAgenda()
+		agenda.Days[0].Holiday = "Whit_Monday"
+		for format, want := range map[string]string{
+			FormatText:     "Mon, 17 May 2010 — Whit_Monday\n",
+			FormatMarkdown: "## Mon, 17 May 2010 — Whit\\_Monday\n",
+			FormatHTML:     "<h2>Mon, 17 May 2010 — Whit_Monday</h2>",
+		} {
+			var b strings.Builder
+			if err := Render(&b, agenda, format); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(b.String(), want) {
+				t.Errorf("Render(%s) = %q, want substring %q", format, b.String(), want)
+			}
+		}
+	})
+
+	t.Run("InvalidFormat", func(t *testing.T) {
+		if err := Render(&strings.Builder{}, testAgenda(), "pdf"); err != ErrFormatInvalid {
+			t.Errorf("Render() error = %v, want %v", err, ErrFormatInvalid)
+		}
+		if _, err := ContentType("pdf"); err != ErrFormatInvalid {
+			t.Errorf("ContentType() error = %v, want %v", err, ErrFormatInvalid)
+		}
+	})
+}
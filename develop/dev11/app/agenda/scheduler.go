@@ -0,0 +1,129 @@
+package agenda
+
+import (
+	"bytes"
+	"context"
+	"dev11/app/entity"
+	"dev11/app/service"
+	"dev11/app/tenant"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"time"
+)
+
+// Длительность дня расписания рассылки.
+const day = 24 * time.Hour
+
+// Структура подписки пользователя на рассылку повестки.
+type Subscription struct {
+	Tenant string
+	UserID string
+	Email  string
+	// Period задает период повестки: ежедневная рассылка для entity.PeriodDay
+	// и рассылка по понедельникам для entity.PeriodWeek.
+	Period string
+	Format string
+}
+
+// Validate проверяет поля подписки.
+func (s Subscription) Validate() error {
+	if err := tenant.Validate(s.Tenant); err != nil {
+		return err
+	}
+	if _, err := mail.ParseAddress(s.Email); err != nil {
+		return fmt.Errorf("%w: %q", ErrAddressInvalid, s.Email)
+	}
+	if s.Period != entity.PeriodDay && s.Period != entity.PeriodWeek {
+		return fmt.Errorf("%w: %q", entity.ErrPeriodInvalid, s.Period)
+	}
+	if _, err := ContentType(s.Format); err != nil {
+		return fmt.Errorf("%w: %q", err, s.Format)
+	}
+	return nil
+}
+
+// Структура планировщика, рассылающего повестки подписчикам каждый день в заданное время.
+type Scheduler struct {
+	agenda service.Agenda
+	sender Sender
+	subs   []Subscription
+	// at задает время рассылки как смещение от полуночи UTC.
+	at     time.Duration
+	logger *slog.Logger
+}
+
+// NewScheduler возвращает планировщик рассылки повесток подписчикам subs во время at
+// (смещение от полуночи UTC), если agenda, sender и logger не равны nil.
+func NewScheduler(agenda service.Agenda, sender Sender, subs []Subscription, at time.Duration, logger *slog.Logger) *Scheduler {
+	if agenda == nil || sender == nil || logger == nil {
+		return nil
+	}
+
+	return &Scheduler{agenda: agenda, sender: sender, subs: subs, at: at, logger: logger}
+}
+
+// Next возвращает ближайшее после now время рассылки.
+func (s *Scheduler) Next(now time.Time) time.Time {
+	next := now.UTC().Truncate(day).Add(s.at)
+	if !next.After(now) {
+		next = next.Add(day)
+	}
+	return next
+}
+
+// Send отправляет повестки подписчикам, рассылка которым приходится на день now.
+// Ошибки отдельных подписок не прерывают рассылку остальным и возвращаются вместе.
+func (s *Scheduler) Send(ctx context.Context, now time.Time) error {
+	now = now.UTC()
+	monday := now.Truncate(7 * day).Equal(now.Truncate(day))
+
+	var errs []error
+	for _, sub := range s.subs {
+		if sub.Period == entity.PeriodWeek && !monday {
+			continue
+		}
+		if err := s.send(ctx, sub, now); err != nil {
+			s.logger.Error("failed to send agenda", "tenant", sub.Tenant, "user_id", sub.UserID, "err", err)
+			errs = append(errs, fmt.Errorf("%s/%s: %w", sub.Tenant, sub.UserID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// send отправляет повестку подписчику sub за период, содержащий now.
+func (s *Scheduler) send(ctx context.Context, sub Subscription, now time.Time) error {
+	agenda, err := s.agenda.Build(tenant.WithTenant(ctx, sub.Tenant), sub.UserID, sub.Period, now)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := Render(&body, agenda, sub.Format); err != nil {
+		return err
+	}
+	contentType, _ := ContentType(sub.Format)
+
+	return s.sender.Send(ctx, Message{
+		To:          []string{sub.Email},
+		Subject:     "Agenda for " + Title(agenda),
+		ContentType: contentType,
+		Body:        body.String(),
+	})
+}
+
+// Run рассылает повестки каждый день во время рассылки, пока не отменен ctx.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		next := s.Next(time.Now())
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			s.Send(ctx, next)
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
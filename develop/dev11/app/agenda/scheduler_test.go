@@ -0,0 +1,152 @@
+package agenda
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/service"
+	"dev11/app/tenant"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// Структура отправителя, запоминающего письма вместо отправки.
+type recordingSender struct {
+	messages []Message
+	err      error
+}
+
+func (s *recordingSender) Send(_ context.Context, msg Message) error {
+	s.messages = append(s.messages, msg)
+	return s.err
+}
+
+func TestSubscription_Validate(t *testing.T) {
+	valid := Subscription{Tenant: tenant.Default, UserID: "1", Email: "user@example.com", Period: entity.PeriodDay, Format: FormatText}
+
+	tests := []struct {
+		name    string
+		modify  func(s *Subscription)
+		wantErr error
+	}{
+		{"Valid", func(s *Subscription) {}, nil},
+		{"InvalidTenant", func(s *Subscription) { s.Tenant = "../x" }, tenant.ErrInvalid},
+		{"InvalidEmail", func(s *Subscription) { s.Email = "user" }, ErrAddressInvalid},
+		{"InvalidPeriod", func(s *Subscription) { s.Period = "month" }, entity.ErrPeriodInvalid},
+		{"InvalidFormat", func(s *Subscription) { s.Format = "pdf" }, ErrFormatInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := valid
+			tt.modify(&sub)
+			if err := sub.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Subscription.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduler_Next(t *testing.T) {
+	s := &Scheduler{at: 7 * time.Hour}
+	day := time.Date(2010, 5, 17, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{day, day.Add(7 * time.Hour)},
+		{day.Add(7 * time.Hour), day.Add(31 * time.Hour)},
+		{day.Add(8 * time.Hour), day.Add(31 * time.Hour)},
+	}
+	for _, tt := range tests {
+		if got := s.Next(tt.now); !got.Equal(tt.want) {
+			t.Errorf("Scheduler.Next(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestScheduler_Send(t *testing.T) {
+	monday := time.Date(2010, 5, 17, 7, 0, 0, 0, time.UTC)
+	daily := Subscription{Tenant: "sales", UserID: "1", Email: "daily@example.com", Period: entity.PeriodDay, Format: FormatHTML}
+	weekly := Subscription{Tenant: tenant.Default, UserID: "2", Email: "weekly@example.com", Period: entity.PeriodWeek, Format: FormatMarkdown}
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("Monday", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		agenda := service.NewMockAgenda(ctrl)
+		agenda.EXPECT().Build(gomock.Any(), "1", entity.PeriodDay, monday).DoAndReturn(
+			func(ctx context.Context, userID string, period string, date time.Time) (entity.Agenda, error) {
+				if got := tenant.FromContext(ctx); got != "sales" {
+					t.Errorf("Build() tenant = %v, want sales", got)
+				}
+				return entity.Agenda{Period: period, Start: date.Truncate(24 * time.Hour)}, nil
+			})
+		agenda.EXPECT().Build(gomock.Any(), "2", entity.PeriodWeek, monday).Return(entity.Agenda{Period: entity.PeriodWeek}, nil)
+		sender := &recordingSender{}
+
+		if err := NewScheduler(agenda, sender, []Subscription{daily, weekly}, 7*time.Hour, logger).Send(context.Background(), monday); err != nil {
+			t.Fatal(err)
+		}
+		if len(sender.messages) != 2 {
+			t.Fatalf("sent %d messages, want 2", len(sender.messages))
+		}
+		msg := sender.messages[0]
+		if !reflect.DeepEqual(msg.To, []string{daily.Email}) || msg.Subject != "Agenda for Monday, 17 May 2010" ||
+			msg.ContentType != "text/html; charset=utf-8" || !strings.Contains(msg.Body, "<h1>") {
+			t.Errorf("daily message = %+v", msg)
+		}
+		if msg := sender.messages[1]; msg.ContentType != "text/markdown; charset=utf-8" || !strings.HasPrefix(msg.Body, "# Agenda") {
+			t.Errorf("weekly message = %+v", msg)
+		}
+	})
+
+	t.Run("Tuesday", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		tuesday := monday.Add(24 * time.Hour)
+		agenda := service.NewMockAgenda(ctrl)
+		agenda.EXPECT().Build(gomock.Any(), "1", entity.PeriodDay, tuesday).Return(entity.Agenda{}, nil)
+		sender := &recordingSender{}
+
+		NewScheduler(agenda, sender, []Subscription{daily, weekly}, 7*time.Hour, logger).Send(context.Background(), tuesday)
+		if len(sender.messages) != 1 {
+			t.Errorf("sent %d messages, want 1", len(sender.messages))
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		buildErr, sendErr := errors.New("build"), errors.New("send")
+		agenda := service.NewMockAgenda(ctrl)
+		agenda.EXPECT().Build(gomock.Any(), "1", entity.PeriodDay, monday).Return(entity.Agenda{}, buildErr)
+		agenda.EXPECT().Build(gomock.Any(), "2", entity.PeriodWeek, monday).Return(entity.Agenda{}, nil)
+		sender := &recordingSender{err: sendErr}
+
+		err := NewScheduler(agenda, sender, []Subscription{daily, weekly}, 0, logger).Send(context.Background(), monday)
+		if !errors.Is(err, buildErr) || !errors.Is(err, sendErr) {
+			t.Errorf("Scheduler.Send() error = %v, want %v and %v", err, buildErr, sendErr)
+		}
+	})
+}
+
+func TestNewScheduler(t *testing.T) {
+	t.Run("NilSender", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		if got := NewScheduler(service.NewMockAgenda(ctrl), nil, nil, 0, slog.Default()); got != nil {
+			t.Errorf("NewScheduler() = %v, want nil", got)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package agenda
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+)
+
+// Ошибка неверного адреса электронной почты.
+var ErrAddressInvalid = errors.New("email address is invalid")
+
+// Структура письма.
+type Message struct {
+	To          []string
+	Subject     string
+	ContentType string
+	Body        string
+}
+
+// Интерфейс отправителя писем.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Структура отправителя писем через SMTP-сервер.
+type smtpSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPSender возвращает отправителя писем от адреса from через SMTP-сервер addr.
+// Если сервер поддерживает STARTTLS, соединение шифруется. auth может быть nil.
+func NewSMTPSender(addr string, from string, auth smtp.Auth) Sender {
+	return &smtpSender{addr: addr, from: from, auth: auth}
+}
+
+// encode возвращает письмо msg в формате RFC 5322 с телом в кодировке quoted-printable.
+func (s *smtpSender) encode(msg Message) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: %s\r\n", msg.ContentType)
+	fmt.Fprintf(&b, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&b)
+	if _, err := w.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Send отправляет письмо msg. Отмена ctx прерывает отправку.
+func (s *smtpSender) Send(ctx context.Context, msg Message) error {
+	for _, addr := range append([]string{s.from}, msg.To...) {
+		if _, err := mail.ParseAddress(addr); err != nil || strings.ContainsAny(addr, "\r\n") {
+			return fmt.Errorf("%w: %q", ErrAddressInvalid, addr)
+		}
+	}
+	data, err := s.encode(msg)
+	if err != nil {
+		return err
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(s.addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if err := c.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.from); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
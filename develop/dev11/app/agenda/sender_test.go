@@ -0,0 +1,135 @@
+package agenda
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Структура письма, принятого тестовым SMTP-сервером.
+type received struct {
+	from string
+	to   []string
+	data string
+}
+
+// startSMTP запускает тестовый SMTP-сервер, принимающий одно письмо, и возвращает его адрес
+// и канал с принятым письмом.
+func startSMTP(t *testing.T) (string, <-chan received) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lis.Close() })
+
+	ch := make(chan received, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var msg received
+		r := bufio.NewReader(conn)
+		io.WriteString(conn, "220 localhost ready\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				io.WriteString(conn, "250 localhost\r\n")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				msg.from = strings.Trim(strings.TrimSpace(line)[10:], "<>")
+				io.WriteString(conn, "250 ok\r\n")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				msg.to = append(msg.to, strings.Trim(strings.TrimSpace(line)[8:], "<>"))
+				io.WriteString(conn, "250 ok\r\n")
+			case cmd == "DATA":
+				io.WriteString(conn, "354 go ahead\r\n")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				msg.data = data.String()
+				io.WriteString(conn, "250 ok\r\n")
+			case cmd == "QUIT":
+				io.WriteString(conn, "221 bye\r\n")
+				ch <- msg
+				return
+			default:
+				io.WriteString(conn, "502 not implemented\r\n")
+			}
+		}
+	}()
+	return lis.Addr().String(), ch
+}
+
+func Test_smtpSender_Send(t *testing.T) {
+	addr, ch := startSMTP(t)
+	sender := NewSMTPSender(addr, "calendar@example.com", nil)
+
+	err := sender.Send(context.Background(), Message{
+		To:          []string{"user@example.com"},
+		Subject:     "Повестка\r\nBcc: evil@example.com",
+		ContentType: "text/plain; charset=utf-8",
+		Body:        "Мероприятия\r\n10:00 standup",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got received
+	select {
+	case got = <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not received")
+	}
+	if got.from != "calendar@example.com" || len(got.to) != 1 || got.to[0] != "user@example.com" {
+		t.Errorf("smtpSender.Send() envelope = %q -> %q", got.from, got.to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(got.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Header.Get("Bcc") != "" {
+		t.Errorf("smtpSender.Send() injected Bcc header")
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "Повестка\r\nBcc: evil@example.com" {
+		t.Errorf("smtpSender.Send() subject = %q", subject)
+	}
+	// Клиент SMTP завершает данные переводом строки.
+	body, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if got := strings.TrimSuffix(string(body), "\r\n"); got != "Мероприятия\r\n10:00 standup" {
+		t.Errorf("smtpSender.Send() body = %q", got)
+	}
+}
+
+func Test_smtpSender_SendInvalidAddress(t *testing.T) {
+	sender := NewSMTPSender("127.0.0.1:0", "calendar@example.com", nil)
+	err := sender.Send(context.Background(), Message{To: []string{"user@example.com\r\nRCPT TO:<evil@example.com>"}})
+	if !errors.Is(err, ErrAddressInvalid) {
+		t.Errorf("smtpSender.Send() error = %v, want %v", err, ErrAddressInvalid)
+	}
+}
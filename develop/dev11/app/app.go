@@ -2,41 +2,366 @@ package app
 
 import (
 	"context"
+	"dev11/app/accesslog"
+	"dev11/app/agenda"
+	"dev11/app/clock"
 	"dev11/app/repo"
 	"dev11/app/service"
+	"dev11/app/transport/grpc"
 	"dev11/app/transport/http"
+	"dev11/app/transport/http/caldav"
+	"dev11/app/workcal"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"net"
+	nethttp "net/http"
+	"net/smtp"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
 
-// Run настраивает и запускает приложение.
-func Run(host string, port string) {
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGKILL)
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+// Время ожидания завершения обрабатываемых запросов при остановке по умолчанию.
+const DefaultShutdownTimeout = 5 * time.Second
 
-	repo := repo.NewEventMemory()
-	service := service.NewEventV1(repo)
+// Конфигурация приложения.
+type Config struct {
+	Host string
+	Port string
+	// GRPCPort задает порт gRPC-сервера. Если порт не задан, gRPC-сервер не запускается.
+	GRPCPort string
+	// DataDir задает директорию для журнала и снимков событий.
+	// Если директория не задана, события хранятся только в памяти.
+	DataDir string
+	// Sync задает политику сброса журнала на диск.
+	Sync repo.SyncPolicy
+	// SyncInterval задает интервал сброса журнала на диск при политике repo.SyncInterval.
+	SyncInterval time.Duration
+	// SnapshotInterval задает интервал сохранения снимков событий.
+	SnapshotInterval time.Duration
+	// TenantDomain задает домен, поддомены которого определяют арендатора HTTP-запроса.
+	TenantDomain string
+	// Tenants задает допустимых арендаторов и ограничивает их количество.
+	Tenants repo.TenantLimits
+	// Quotas задает квоты арендаторов.
+	Quotas service.Quotas
+	// AttachmentDir задает директорию для вложений событий, по умолчанию DataDir/attachments.
+	// Если не заданы ни AttachmentDir, ни DataDir, вложения отключены.
+	AttachmentDir string
+	// MaxAttachmentSize ограничивает размер вложения в байтах, 0 означает отсутствие ограничения.
+	MaxAttachmentSize int64
+	// Agenda задает параметры построения повестки.
+	Agenda service.AgendaOptions
+	// Schedules задает рабочие графики и производственные календари пользователей для повестки,
+	// фильтра рабочих дней и предупреждений о событиях в праздники.
+	// По умолчанию используется workcal.DefaultSchedule без праздников.
+	Schedules *workcal.Schedules
+	// AgendaSubscriptions задает подписки на рассылку повестки. Рассылка выполняется,
+	// если задан SMTPAddr.
+	AgendaSubscriptions []agenda.Subscription
+	// AgendaAt задает время рассылки повестки как смещение от полуночи UTC.
+	AgendaAt time.Duration
+	// SMTPAddr, SMTPFrom задают адрес SMTP-сервера и отправителя писем.
+	// При заданном SMTPUser используется аутентификация PLAIN.
+	SMTPAddr     string
+	SMTPFrom     string
+	SMTPUser     string
+	SMTPPassword string
+	// Clock задает часы сервиса событий и HTTP-обработчиков. По умолчанию используются системные часы.
+	Clock clock.Clock
+	// ShutdownDelay задает время между переводом /readyz в состояние ошибки и закрытием
+	// адресов серверов, чтобы балансировщик успел перестать направлять новые запросы.
+	ShutdownDelay time.Duration
+	// ShutdownTimeout ограничивает ожидание завершения обрабатываемых запросов и потоков
+	// при остановке, по умолчанию DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+	// CompressMinSize задает минимальный размер тела HTTP-ответа в байтах, начиная с которого
+	// ответ сжимается, по умолчанию http.DefaultCompressMinSize. Отрицательное значение отключает сжатие.
+	CompressMinSize int
+	// CORS задает параметры CORS HTTP-сервера. Пустой список источников отключает CORS.
+	CORS http.CORSOptions
+	// Limits задает ограничения обработки HTTP-запросов всех маршрутов, по умолчанию http.DefaultRouteLimits.
+	Limits http.RouteLimits
+	// RouteLimits задает ограничения обработки HTTP-запросов отдельных маршрутов по их шаблонам.
+	RouteLimits map[string]http.RouteLimits
+	// AccessLog задает параметры журнала HTTP-запросов. Если формат не задан,
+	// запросы записываются в журнал приложения.
+	AccessLog accesslog.Options
+	// AccessLogFile задает файл журнала HTTP-запросов, по умолчанию стандартный вывод.
+	AccessLogFile string
+	// AccessLogRotate задает ротацию файла AccessLogFile.
+	AccessLogRotate accesslog.RotateOptions
+}
 
-	server := http.NewServer(host, port, service, logger)
-	server.Start(ctx)
-	logger.Info("http server started", "host", host, "port", port)
+// attachmentDir возвращает директорию для вложений событий или пустую строку, если вложения отключены.
+func (cfg Config) attachmentDir() string {
+	if cfg.AttachmentDir == "" && cfg.DataDir != "" {
+		return filepath.Join(cfg.DataDir, "attachments")
+	}
+	return cfg.AttachmentDir
+}
 
-	select {
-	case <-ctx.Done():
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		err := server.Stop(ctx)
+// LoadCalendar читает производственный календарь из файла path. Файлы с расширением .ics
+// читаются в формате iCalendar, каждое событие в них считается праздником с названием события,
+// остальные файлы читаются в формате JSON, описанном в workcal.ParseJSON.
+func LoadCalendar(path string) (*workcal.Calendar, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if !strings.EqualFold(filepath.Ext(path), ".ics") {
+		return workcal.ParseJSON(f)
+	}
+	events, err := caldav.DecodeEvents(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", workcal.ErrInvalidCalendar, err)
+	}
+	calendar := workcal.NewCalendar()
+	for _, event := range events {
+		calendar.AddHoliday(event.Date, event.Title)
+	}
+	return calendar, nil
+}
+
+// Run настраивает и запускает приложение, пока процесс не получит SIGINT или SIGTERM,
+// после чего плавно останавливает его. Повторный сигнал завершает процесс немедленно.
+// Возвращает ошибки запуска, работы и остановки серверов.
+func Run(cfg Config) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, stop)
+	return run(ctx, cfg, slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+}
+
+// run запускает приложение и плавно останавливает его при отмене ctx или ошибке сервера.
+func run(ctx context.Context, cfg Config, logger *slog.Logger) error {
+	// Фоновые задачи останавливаются после серверов, чтобы не прерывать обрабатываемые запросы.
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+	startWorker := func(work func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			work(workerCtx)
+		}()
+	}
+
+	// Журнал HTTP-запросов открывается до репозитория, чтобы ошибка открытия журнала не требовала закрытия репозитория.
+	var accessLog *accesslog.Logger
+	var accessLogFile *accesslog.RotatingFile
+	if cfg.AccessLog.Format != "" {
+		var w io.Writer = os.Stdout
+		if cfg.AccessLogFile != "" {
+			var err error
+			if accessLogFile, err = accesslog.OpenRotatingFile(cfg.AccessLogFile, cfg.AccessLogRotate); err != nil {
+				logger.Error("failed to open access log", "file", cfg.AccessLogFile, "err", err)
+				return err
+			}
+			w = accessLogFile
+		}
+		accessLog = accesslog.New(w, cfg.AccessLog)
+	}
+
+	var durable repo.EventDurable
+	eventRepo := repo.NewEventTenants(func(string) (repo.Event, error) { return repo.NewEventMemory(), nil }, cfg.Tenants)
+	if cfg.DataDir != "" {
+		var err error
+		durable, err = repo.NewEventTenantsDurable(cfg.DataDir, repo.DurableOptions{Sync: cfg.Sync, SyncInterval: cfg.SyncInterval}, cfg.Tenants)
 		if err != nil {
-			logger.Error("failed to stop http server", "err", err)
-		} else {
-			logger.Info("http server has been stopped")
+			logger.Error("failed to restore event repository", "dir", cfg.DataDir, "err", err)
+			if accessLogFile != nil {
+				accessLogFile.Close()
+			}
+			return err
 		}
-		cancel()
+		logger.Info("event repository restored", "dir", cfg.DataDir)
+		startWorker(func(ctx context.Context) { runSnapshots(ctx, durable, cfg.SnapshotInterval, logger) })
+		eventRepo = durable
+	}
+	clk := clock.OrSystem(cfg.Clock)
+	eventOpts := []service.Option{service.WithQuotas(cfg.Quotas), service.WithClock(clk)}
+	httpOpts := []http.Option{http.WithTenantDomain(cfg.TenantDomain), http.WithClock(clk), http.WithCORS(cfg.CORS), http.WithLimits(cfg.Limits)}
+	for pattern, limits := range cfg.RouteLimits {
+		httpOpts = append(httpOpts, http.WithRouteLimits(pattern, limits))
+	}
+	if cfg.CompressMinSize != 0 {
+		httpOpts = append(httpOpts, http.WithCompression(cfg.CompressMinSize))
+	}
+	if accessLog != nil {
+		httpOpts = append(httpOpts, http.WithAccessLog(accessLog))
+	}
+	if dir := cfg.attachmentDir(); dir != "" {
+		blobs := repo.NewBlobFS(dir)
+		eventOpts = append(eventOpts, service.WithBlobStore(blobs))
+		httpOpts = append(httpOpts, http.WithAttachments(service.NewAttachmentV1(eventRepo, blobs, cfg.MaxAttachmentSize), cfg.MaxAttachmentSize))
+		logger.Info("attachments enabled", "dir", dir)
+	}
+	// Изменения событий через любой транспорт оповещают подписчиков gRPC.
+	eventService := service.NewEventWatcher(service.NewEventV1(eventRepo, eventOpts...))
+	host, port := cfg.Host, cfg.Port
+
+	agendaOpts := cfg.Agenda
+	if agendaOpts.Schedules == nil {
+		agendaOpts.Schedules = cfg.Schedules
+	}
+	agendaService := service.NewAgendaV1(eventService, agendaOpts)
+	httpOpts = append(httpOpts, http.WithAgenda(agendaService), http.WithSchedules(cfg.Schedules),
+		http.WithSlots(service.NewSlotV1(eventRepo, service.SlotOptions{Schedules: cfg.Schedules})),
+		http.WithTemplates(service.NewTemplateV1(repo.NewTemplateMemory(), eventService)))
+	if cfg.SMTPAddr != "" && len(cfg.AgendaSubscriptions) > 0 {
+		scheduler := agenda.NewScheduler(agendaService, newSMTPSender(cfg), cfg.AgendaSubscriptions, cfg.AgendaAt, logger)
+		startWorker(scheduler.Run)
+		logger.Info("agenda scheduler started", "subscriptions", len(cfg.AgendaSubscriptions), "next", scheduler.Next(clk.Now()))
+	}
+
+	// Контексты запросов не отменяются сигналом остановки, чтобы обрабатываемые запросы завершились.
+	serveCtx, cancelServe := context.WithCancel(context.Background())
+	defer cancelServe()
+
+	server := http.NewServer(host, port, eventService, logger, httpOpts...)
+	server.Start(serveCtx)
+	// Адрес не задан, если сервер не смог открыть его. При порте 0 адрес содержит выбранный порт.
+	if addr := server.Addr(); addr != nil {
+		logger.Info("http server started", "host", host, "port", port, "addr", addr.String())
+	}
+
+	var grpcServer *grpc.Server
+	var grpcErr <-chan error
+	if cfg.GRPCPort != "" {
+		grpcServer = grpc.NewServer(host, cfg.GRPCPort, eventService, logger, grpc.WithSchedules(cfg.Schedules))
+		grpcServer.Start(serveCtx)
+		logger.Info("grpc server started", "host", host, "port", cfg.GRPCPort)
+		grpcErr = grpcServer.Err()
+	}
+
+	var errs []error
+	select {
+	case <-ctx.Done():
+		logger.Info("shutdown started", "reason", "signal")
 	case err := <-server.Err():
 		logger.Error("http server returned error", "err", err)
+		logger.Info("shutdown started", "reason", "http server error")
+		errs = append(errs, fmt.Errorf("http server: %w", err))
+	case err := <-grpcErr:
+		logger.Error("grpc server returned error", "err", err)
+		logger.Info("shutdown started", "reason", "grpc server error")
+		errs = append(errs, fmt.Errorf("grpc server: %w", err))
+	}
+
+	// Остановка выполняется по фазам: сначала балансировщик перестает направлять запросы,
+	// затем завершаются запросы и потоки серверов, фоновые задачи и репозиторий.
+	logger.Info("shutdown phase", "phase", "not ready", "delay", cfg.ShutdownDelay)
+	server.Drain()
+	if cfg.ShutdownDelay > 0 && len(errs) == 0 {
+		time.Sleep(cfg.ShutdownDelay)
+	}
+
+	timeout := cfg.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	stopCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	logger.Info("shutdown phase", "phase", "draining http requests", "timeout", timeout)
+	errs = append(errs, stopServer(stopCtx, server, "http", logger))
+	if grpcServer != nil {
+		logger.Info("shutdown phase", "phase", "draining grpc calls and watch streams")
+		errs = append(errs, stopServer(stopCtx, grpcServer, "grpc", logger))
+	}
+	cancelServe()
+
+	if accessLogFile != nil {
+		if err := accessLogFile.Close(); err != nil {
+			logger.Error("failed to close access log", "err", err)
+			errs = append(errs, fmt.Errorf("access log: %w", err))
+		}
+	}
+
+	logger.Info("shutdown phase", "phase", "stopping background workers")
+	stopWorkers()
+	workers.Wait()
+
+	if durable != nil {
+		logger.Info("shutdown phase", "phase", "closing event repository")
+		errs = append(errs, closeDurable(durable, logger))
+	}
+
+	// Ошибки, которые серверы вернули во время остановки.
+	if err := <-server.Err(); err != nil && !errors.Is(err, nethttp.ErrServerClosed) {
+		logger.Error("http server returned error", "err", err)
+		errs = append(errs, fmt.Errorf("http server: %w", err))
+	}
+	if grpcServer != nil {
+		if err := <-grpcErr; err != nil {
+			logger.Error("grpc server returned error", "err", err)
+			errs = append(errs, fmt.Errorf("grpc server: %w", err))
+		}
+	}
+
+	err := errors.Join(errs...)
+	logger.Info("shutdown completed", "err", err)
+	return err
+}
+
+// newSMTPSender возвращает отправителя писем через SMTP-сервер из конфигурации cfg.
+func newSMTPSender(cfg Config) agenda.Sender {
+	var auth smtp.Auth
+	if cfg.SMTPUser != "" {
+		host, _, _ := net.SplitHostPort(cfg.SMTPAddr)
+		auth = smtp.PlainAuth("", cfg.SMTPUser, cfg.SMTPPassword, host)
 	}
+	return agenda.NewSMTPSender(cfg.SMTPAddr, cfg.SMTPFrom, auth)
+}
+
+// stopServer останавливает сервер server с именем name, ожидая завершения запросов, пока не отменен ctx.
+func stopServer(ctx context.Context, server interface{ Stop(context.Context) error }, name string, logger *slog.Logger) error {
+	if err := server.Stop(ctx); err != nil {
+		logger.Error("failed to stop "+name+" server", "err", err)
+		return fmt.Errorf("stop %s server: %w", name, err)
+	}
+	logger.Info(name + " server has been stopped")
+	return nil
+}
 
-	stop()
+// runSnapshots сохраняет снимки событий репозитория durable каждые interval, пока не отменен ctx.
+func runSnapshots(ctx context.Context, durable repo.EventDurable, interval time.Duration, logger *slog.Logger) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := durable.Snapshot(); err != nil {
+				logger.Error("failed to save event snapshot", "err", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// closeDurable сохраняет итоговый снимок событий репозитория durable и закрывает его.
+func closeDurable(durable repo.EventDurable, logger *slog.Logger) error {
+	snapshotErr := durable.Snapshot()
+	if snapshotErr != nil {
+		logger.Error("failed to save event snapshot", "err", snapshotErr)
+	}
+	closeErr := durable.Close()
+	if closeErr != nil {
+		logger.Error("failed to close event repository", "err", closeErr)
+	}
+	return errors.Join(snapshotErr, closeErr)
 }
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"dev11/app/repo"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Буфер логов, безопасный для конкурентной записи и чтения.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+// freePort возвращает свободный TCP-порт на 127.0.0.1.
+func freePort(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	_, port, _ := net.SplitHostPort(lis.Addr().String())
+	return port
+}
+
+// waitFor проверяет условие cond, пока оно не выполнится, не дольше 5 секунд.
+func waitFor(t *testing.T, name string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", name)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// statusCode возвращает код ответа на GET-запрос target или 0 при ошибке соединения.
+func statusCode(target string) int {
+	resp, err := http.Get(target)
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode
+}
+
+// Запрос, начатый до остановки приложения, должен быть обработан до конца.
+func TestRun_GracefulShutdown(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	dir := t.TempDir()
+	port := freePort(t)
+	cfg := Config{
+		Host:            "127.0.0.1",
+		Port:            port,
+		GRPCPort:        freePort(t),
+		DataDir:         dir,
+		Sync:            repo.SyncAlways,
+		ShutdownDelay:   200 * time.Millisecond,
+		ShutdownTimeout: 5 * time.Second,
+	}
+	var logs syncBuffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, cfg, logger) }()
+
+	base := "http://127.0.0.1:" + port
+	waitFor(t, "readiness", func() bool { return statusCode(base+"/readyz") == http.StatusOK })
+
+	// Тело запроса передается частями, чтобы запрос обрабатывался во время остановки.
+	body, bodyWriter := io.Pipe()
+	r, err := http.NewRequest(http.MethodPost, base+"/create_event", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	inFlight := make(chan result, 1)
+	go func() {
+		resp, err := http.DefaultClient.Do(r)
+		inFlight <- result{resp, err}
+	}()
+	if _, err := io.WriteString(bodyWriter, "title=standup&"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	waitFor(t, "readiness failure", func() bool { return statusCode(base+"/readyz") == http.StatusServiceUnavailable })
+	if code := statusCode(base + "/healthz"); code != http.StatusOK {
+		t.Errorf("GET /healthz during shutdown delay = %v, want %v", code, http.StatusOK)
+	}
+	waitFor(t, "http draining", func() bool { return strings.Contains(logs.String(), "draining http requests") })
+
+	form := url.Values{"date": {"2010-05-20T16:00:00Z"}, "user_id": {userID}}
+	io.WriteString(bodyWriter, form.Encode())
+	bodyWriter.Close()
+
+	res := <-inFlight
+	if res.err != nil {
+		t.Fatalf("in-flight request error = %v", res.err)
+	}
+	defer res.resp.Body.Close()
+	if res.resp.StatusCode != http.StatusCreated {
+		t.Fatalf("in-flight request = %v, want %v", res.resp.StatusCode, http.StatusCreated)
+	}
+	var created struct {
+		Result struct{ ID string }
+	}
+	if err := json.NewDecoder(res.resp.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("run() did not return after shutdown")
+	}
+	if code := statusCode(base + "/healthz"); code != 0 {
+		t.Errorf("GET /healthz after shutdown = %v, want connection error", code)
+	}
+
+	// Фазы остановки выполняются по порядку.
+	phases := []string{"shutdown started", "not ready", "draining http requests", "draining grpc calls and watch streams",
+		"stopping background workers", "closing event repository", "shutdown completed"}
+	out, last := logs.String(), -1
+	for _, phase := range phases {
+		i := strings.Index(out, phase)
+		if i <= last {
+			t.Errorf("shutdown phase %q is missing or out of order in logs:\n%s", phase, out)
+			break
+		}
+		last = i
+	}
+
+	// Событие, созданное во время остановки, сохранено в итоговом снимке.
+	durable, err := repo.NewEventTenantsDurable(dir, repo.DurableOptions{}, repo.TenantLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer durable.Close()
+	if _, err := durable.GetByID(context.Background(), userID, created.Result.ID); err != nil {
+		t.Errorf("event created during shutdown: %v", err)
+	}
+}
+
+func TestRun_ServerError(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	_, port, _ := net.SplitHostPort(lis.Addr().String())
+
+	err = run(context.Background(), Config{Host: "127.0.0.1", Port: port}, slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	if err == nil {
+		t.Errorf("run() error = nil, want address in use error")
+	}
+}
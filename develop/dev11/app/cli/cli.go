@@ -0,0 +1,767 @@
+// Пакет cli предоставляет консольные команды для запуска и администрирования календаря.
+// Команды работают с бизнес-логикой напрямую через репозиторий в директории данных,
+// поэтому сервер, использующий ту же директорию, должен быть остановлен.
+
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"dev11/app"
+	"dev11/app/accesslog"
+	"dev11/app/agenda"
+	"dev11/app/entity"
+	"dev11/app/repo"
+	"dev11/app/service"
+	"dev11/app/tenant"
+	"dev11/app/transport/http"
+	"dev11/app/workcal"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Коды завершения команд.
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+// Ошибки команд.
+var (
+	ErrUnknownCommand      = errors.New("unknown command")
+	ErrNoDataDir           = errors.New("-data-dir is required")
+	ErrInvalidFormat       = errors.New("invalid output format")
+	ErrInvalidSync         = errors.New("invalid sync policy")
+	ErrInvalidQuota        = errors.New("invalid tenant quota")
+	ErrInvalidSubscription = errors.New("invalid agenda subscription")
+	ErrInvalidSchedule     = errors.New("invalid user schedule")
+	ErrInvalidRouteTimeout = errors.New("invalid route timeout")
+)
+
+// Форматы вывода команд.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// Максимальное время, используемое как открытая граница диапазона дат.
+var maxTime = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+
+// Использование команд.
+const usage = `Usage: dev11 <command> [flags]
+
+Commands:
+  serve                 start the HTTP and gRPC servers (default)
+  events list           list events of a user in a date range
+  events create         create an event
+  events delete         delete an event
+  export                export events of a user as JSON
+  import                import events from JSON with new IDs
+  migrate               replay the event log and compact it into a snapshot
+  token                 generate a random token (-kind user for a new user_id)
+  help                  show this help
+
+Run 'dev11 <command> -h' for command flags.
+`
+
+// Структура окружения команды: аргументы и потоки ввода-вывода.
+type env struct {
+	args   []string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// Тип функции команды.
+type command func(e env) error
+
+// Run выполняет команду, заданную аргументами args, и возвращает код завершения.
+// Без команды или с флагами вместо команды запускает серверы приложения.
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	commands := map[string]command{
+		"serve":         serve,
+		"events list":   eventsList,
+		"events create": eventsCreate,
+		"events delete": eventsDelete,
+		"export":        export,
+		"import":        importEvents,
+		"migrate":       migrate,
+		"token":         token,
+	}
+
+	name, cmd := "serve", serve
+	switch {
+	case len(args) == 0 || strings.HasPrefix(args[0], "-"):
+	case len(args) > 1 && commands[args[0]+" "+args[1]] != nil:
+		name, cmd, args = args[0]+" "+args[1], commands[args[0]+" "+args[1]], args[2:]
+	case commands[args[0]] != nil:
+		name, cmd, args = args[0], commands[args[0]], args[1:]
+	case args[0] == "help":
+		fmt.Fprint(stdout, usage)
+		return ExitOK
+	default:
+		fmt.Fprintf(stderr, "%s: %q\n\n%s", ErrUnknownCommand, strings.Join(args, " "), usage)
+		return ExitUsage
+	}
+
+	err := cmd(env{args, stdin, stdout, stderr})
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(stderr, "%s: %s\n", name, err)
+		return ExitUsage
+	}
+
+	var externalErr *service.ExternalError
+	if errors.As(err, &externalErr) {
+		err = externalErr.Err
+	}
+	fmt.Fprintf(stderr, "%s: %s\n", name, err)
+	return ExitError
+}
+
+// Ошибка неверного использования команды.
+var errUsage = errors.New("usage")
+
+// usageError оборачивает ошибку err как ошибку использования команды.
+func usageError(err error) error { return fmt.Errorf("%w: %w", errUsage, err) }
+
+// newFlagSet возвращает набор флагов команды name, выводящий ошибки в e.stderr.
+func newFlagSet(e env, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
+
+// parseFlags разбирает флаги fs, оборачивая ошибки как ошибки использования.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError(err)
+	}
+	return nil
+}
+
+// Структура флагов доступа к данным.
+type dataFlags struct {
+	dir    string
+	tenant string
+	format string
+}
+
+// register регистрирует флаги доступа к данным в fs.
+func (d *dataFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&d.dir, "data-dir", "", "directory with event log and snapshots")
+	fs.StringVar(&d.tenant, "tenant", tenant.Default, "tenant whose data is used")
+	fs.StringVar(&d.format, "format", FormatTable, "output format: table or json")
+}
+
+// validate проверяет флаги доступа к данным.
+func (d *dataFlags) validate() error {
+	if d.dir == "" {
+		return usageError(ErrNoDataDir)
+	}
+	if err := tenant.Validate(d.tenant); err != nil {
+		return usageError(err)
+	}
+	if d.format != FormatTable && d.format != FormatJSON {
+		return usageError(fmt.Errorf("%w: %q", ErrInvalidFormat, d.format))
+	}
+	return nil
+}
+
+// open открывает репозиторий в директории данных и возвращает сервис поверх него.
+func (d *dataFlags) open() (service.Event, repo.EventDurable, error) {
+	durable, err := repo.NewEventMemoryDurable(repo.TenantDir(d.dir, d.tenant), repo.DurableOptions{Sync: repo.SyncAlways})
+	if err != nil {
+		return nil, nil, err
+	}
+	return service.NewEventV1(durable), durable, nil
+}
+
+// Тип флага квот отдельных арендаторов в формате tenant:max_events:max_size.
+// Флаг может быть задан несколько раз.
+type quotasFlag map[string]service.Quota
+
+// String возвращает значение флага.
+func (q quotasFlag) String() string {
+	var parts []string
+	for name, quota := range q {
+		parts = append(parts, fmt.Sprintf("%s:%d:%d", name, quota.MaxEventsPerUser, quota.MaxEventSize))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set разбирает квоту арендатора s.
+func (q quotasFlag) Set(s string) error {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 || tenant.Validate(parts[0]) != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidQuota, s)
+	}
+	events, err1 := strconv.Atoi(parts[1])
+	size, err2 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil || events < 0 || size < 0 {
+		return fmt.Errorf("%w: %q", ErrInvalidQuota, s)
+	}
+	q[parts[0]] = service.Quota{MaxEventsPerUser: events, MaxEventSize: size}
+	return nil
+}
+
+// Тип флага времени обработки запросов отдельных маршрутов в формате "METHOD /path=duration".
+// Флаг может быть задан несколько раз.
+type routeTimeoutsFlag map[string]http.RouteLimits
+
+// String возвращает значение флага.
+func (f routeTimeoutsFlag) String() string {
+	var parts []string
+	for pattern, limits := range f {
+		parts = append(parts, fmt.Sprintf("%s=%s", pattern, limits.Timeout))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set разбирает время обработки запросов маршрута s.
+func (f routeTimeoutsFlag) Set(s string) error {
+	pattern, value, ok := strings.Cut(s, "=")
+	if !ok || pattern == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidRouteTimeout, s)
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout == 0 {
+		return fmt.Errorf("%w: %q", ErrInvalidRouteTimeout, s)
+	}
+	f[pattern] = http.RouteLimits{Timeout: timeout}
+	return nil
+}
+
+// Тип флага подписок на рассылку повестки в формате [tenant/]user_id:email:period:format.
+// Флаг может быть задан несколько раз.
+type subscriptionsFlag []agenda.Subscription
+
+// String возвращает значение флага.
+func (f *subscriptionsFlag) String() string {
+	var parts []string
+	for _, sub := range *f {
+		parts = append(parts, fmt.Sprintf("%s/%s:%s:%s:%s", sub.Tenant, sub.UserID, sub.Email, sub.Period, sub.Format))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set разбирает подписку s.
+func (f *subscriptionsFlag) Set(s string) error {
+	name, rest, ok := strings.Cut(s, "/")
+	if !ok {
+		name, rest = tenant.Default, s
+	}
+	parts := strings.Split(rest, ":")
+	if len(parts) != 4 {
+		return fmt.Errorf("%w: %q", ErrInvalidSubscription, s)
+	}
+	sub := agenda.Subscription{Tenant: name, UserID: parts[0], Email: parts[1], Period: parts[2], Format: parts[3]}
+	if err := sub.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSubscription, err)
+	}
+	*f = append(*f, sub)
+	return nil
+}
+
+// Рабочий график пользователя, заданный флагом, с путем к файлу производственного календаря.
+type userSchedule struct {
+	value    string
+	userID   string
+	schedule workcal.Schedule
+	calendar string
+}
+
+// Тип флага рабочих графиков отдельных пользователей в формате
+// user_id/09:00-18:00[tz]/mon-fri[/calendar], где tz — необязательный часовой пояс IANA,
+// например Europe/Moscow. Флаг может быть задан несколько раз.
+type schedulesFlag []userSchedule
+
+// String возвращает значение флага.
+func (f *schedulesFlag) String() string {
+	var parts []string
+	for _, s := range *f {
+		parts = append(parts, s.value)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set разбирает рабочий график пользователя s.
+func (f *schedulesFlag) Set(s string) error {
+	userID, rest, _ := strings.Cut(s, "/")
+	// Часовой пояс IANA может содержать "/", поэтому рабочее время с часовым поясом
+	// заканчивается закрывающей скобкой.
+	end := strings.IndexByte(rest, '/')
+	if open := strings.IndexByte(rest, '['); open >= 0 && (end < 0 || open < end) {
+		end = strings.IndexByte(rest, ']') + 1
+	}
+	if userID == "" || end <= 0 || end >= len(rest) || rest[end] != '/' {
+		return fmt.Errorf("%w: %q", ErrInvalidSchedule, s)
+	}
+	start, finish, location, err := workcal.ParseZonedHours(rest[:end])
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+	}
+	days, calendar, _ := strings.Cut(rest[end+1:], "/")
+	weekdays, err := workcal.ParseWeekdays(days)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+	}
+	us := userSchedule{value: s, userID: userID, calendar: calendar,
+		schedule: workcal.Schedule{Start: start, End: finish, Weekdays: weekdays, Location: location}}
+	*f = append(*f, us)
+	return nil
+}
+
+// newSchedules возвращает рабочие графики с графиком по умолчанию defaultSchedule и календарем
+// из файла calendar, который используется и графиками пользователей users без собственного календаря.
+func newSchedules(defaultSchedule workcal.Schedule, calendar string, users schedulesFlag) (*workcal.Schedules, error) {
+	calendars := make(map[string]*workcal.Calendar)
+	load := func(path string) (*workcal.Calendar, error) {
+		if path == "" {
+			return nil, nil
+		}
+		if c, ok := calendars[path]; ok {
+			return c, nil
+		}
+		c, err := app.LoadCalendar(path)
+		if err != nil {
+			return nil, fmt.Errorf("work calendar %s: %w", path, err)
+		}
+		calendars[path] = c
+		return c, nil
+	}
+
+	var err error
+	schedules := &workcal.Schedules{Default: defaultSchedule, Users: make(map[string]workcal.Schedule)}
+	if schedules.Default.Calendar, err = load(calendar); err != nil {
+		return nil, err
+	}
+	for _, us := range users {
+		schedule := us.schedule
+		if schedule.Calendar, err = load(us.calendar); err != nil {
+			return nil, err
+		}
+		if schedule.Calendar == nil {
+			schedule.Calendar = schedules.Default.Calendar
+		}
+		schedules.Users[us.userID] = schedule
+	}
+	return schedules, nil
+}
+
+// parseSync возвращает политику сброса журнала на диск по ее названию.
+func parseSync(s string) (repo.SyncPolicy, error) {
+	switch s {
+	case "always":
+		return repo.SyncAlways, nil
+	case "interval":
+		return repo.SyncInterval, nil
+	case "never":
+		return repo.SyncNever, nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrInvalidSync, s)
+}
+
+// parseTime разбирает дату в формате 2006-01-02 или время в формате RFC 3339.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// serve запускает HTTP-сервер.
+func serve(e env) error {
+	var cfg app.Config
+	var sync string
+
+	fs := newFlagSet(e, "serve")
+	fs.StringVar(&cfg.Host, "host", "localhost", "host for the server to listen on")
+	fs.StringVar(&cfg.Port, "port", "3000", "port for the server to listen on")
+	fs.StringVar(&cfg.GRPCPort, "grpc-port", "3001", "port for the gRPC server to listen on (disabled if empty)")
+	fs.StringVar(&cfg.DataDir, "data-dir", "", "directory for event log and snapshots (in-memory only if empty)")
+	fs.StringVar(&sync, "sync", "always", "event log fsync policy: always, interval or never")
+	fs.DurationVar(&cfg.SyncInterval, "sync-interval", time.Second, "event log fsync interval for the interval policy")
+	fs.DurationVar(&cfg.SnapshotInterval, "snapshot-interval", 5*time.Minute, "interval between event snapshots")
+	fs.StringVar(&cfg.TenantDomain, "tenant-domain", "", "domain whose subdomains select the tenant (X-Tenant-ID header only if empty)")
+	var tenants string
+	fs.StringVar(&tenants, "tenants", "", "comma-separated tenants allowed besides default (any tenant if empty)")
+	fs.IntVar(&cfg.Tenants.MaxTenants, "max-tenants", repo.DefaultMaxTenants, "maximum number of tenants with data (unlimited if negative)")
+	fs.IntVar(&cfg.Quotas.Default.MaxEventsPerUser, "max-events-per-user", 0, "default maximum number of events per user (unlimited if 0)")
+	fs.IntVar(&cfg.Quotas.Default.MaxEventSize, "max-event-size", 0, "default maximum size of event title and description in bytes (unlimited if 0)")
+	tenantQuotas := make(quotasFlag)
+	fs.Var(tenantQuotas, "tenant-quota", "quota of a tenant as tenant:max_events:max_size, may be repeated")
+	fs.StringVar(&cfg.AttachmentDir, "attachment-dir", "", "directory for event attachments (data-dir/attachments if empty, disabled without data-dir)")
+	fs.Int64Var(&cfg.MaxAttachmentSize, "max-attachment-size", 10<<20, "maximum size of an event attachment in bytes (unlimited if 0)")
+	var subscriptions subscriptionsFlag
+	fs.Var(&subscriptions, "agenda-subscription", "agenda mailing as [tenant/]user_id:email:day|week:text|html|markdown, may be repeated")
+	fs.DurationVar(&cfg.AgendaAt, "agenda-at", 7*time.Hour, "time of day (UTC) to mail agendas, weekly agendas are mailed on Mondays")
+	var workCalendar, workingHours, workingDays string
+	fs.StringVar(&workCalendar, "work-calendar", "", "production calendar with holidays and transferred workdays as .ics or .json file")
+	fs.StringVar(&workingHours, "working-hours", "09:00-18:00", "default working hours with an optional IANA time zone, e.g. 09:00-18:00[Europe/Moscow] (UTC if omitted)")
+	fs.StringVar(&workingDays, "working-days", "mon-fri", "default working days of the week")
+	var userSchedules schedulesFlag
+	fs.Var(&userSchedules, "user-schedule", "schedule of a user as user_id/09:00-18:00[tz]/mon-fri[/calendar] with an optional IANA time zone tz, may be repeated")
+	fs.StringVar(&cfg.SMTPAddr, "smtp-addr", "", "SMTP server host:port for agenda mailing (disabled if empty)")
+	fs.StringVar(&cfg.SMTPFrom, "smtp-from", "calendar@localhost", "sender address of agenda emails")
+	fs.StringVar(&cfg.SMTPUser, "smtp-user", "", "SMTP user, the password is read from the SMTP_PASSWORD environment variable")
+	fs.IntVar(&cfg.CompressMinSize, "compress-min-size", 1024, "minimum HTTP response size in bytes to compress with br or gzip (disabled if negative)")
+	var corsOrigins string
+	fs.StringVar(&corsOrigins, "cors-origins", "", "comma-separated origins allowed to call the HTTP API from browsers, * for any (CORS disabled if empty)")
+	fs.BoolVar(&cfg.CORS.AllowCredentials, "cors-credentials", false, "allow browsers to send credentials in CORS requests")
+	fs.DurationVar(&cfg.CORS.MaxAge, "cors-max-age", 10*time.Minute, "time browsers may cache CORS preflight responses")
+	fs.DurationVar(&cfg.ShutdownDelay, "shutdown-delay", 0, "time between failing /readyz and closing listeners on shutdown")
+	fs.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", app.DefaultShutdownTimeout, "maximum time to drain in-flight requests and streams on shutdown")
+	fs.DurationVar(&cfg.Limits.Timeout, "request-timeout", http.DefaultRouteLimits.Timeout, "maximum time to handle an HTTP request except downloads, exports and CalDAV (unlimited if negative)")
+	fs.Int64Var(&cfg.Limits.MaxBodySize, "max-body-size", http.DefaultRouteLimits.MaxBodySize, "maximum size of an HTTP request body in bytes except uploads (unlimited if negative)")
+	routeTimeouts := make(routeTimeoutsFlag)
+	fs.Var(routeTimeouts, "route-timeout", "maximum time to handle requests of a route as 'METHOD /path=duration', may be repeated")
+	fs.StringVar(&cfg.AccessLog.Format, "access-log-format", "", "access log format: combined, json or logfmt (requests are logged to the application log if empty)")
+	fs.StringVar(&cfg.AccessLogFile, "access-log-file", "", "file for the access log (standard output if empty)")
+	fs.Int64Var(&cfg.AccessLogRotate.MaxSize, "access-log-max-size", 100<<20, "size of the access log file in bytes to rotate at (never rotated if 0)")
+	fs.IntVar(&cfg.AccessLogRotate.MaxBackups, "access-log-max-backups", 7, "number of rotated access log files to keep (all if 0)")
+	fs.BoolVar(&cfg.AccessLogRotate.Compress, "access-log-compress", false, "compress rotated access log files with gzip")
+	fs.Float64Var(&cfg.AccessLog.SampleRate, "access-log-sample", 1, "fraction of successful requests to log, errors and slow requests are always logged")
+	fs.DurationVar(&cfg.AccessLog.SlowThreshold, "access-log-slow", time.Second, "duration of a request to always log it (disabled if 0)")
+	if err := parseFlags(fs, e.args); err != nil {
+		return err
+	}
+	cfg.Quotas.Tenants = tenantQuotas
+	cfg.RouteLimits = routeTimeouts
+	cfg.AgendaSubscriptions = subscriptions
+	cfg.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	if corsOrigins != "" {
+		cfg.CORS.AllowedOrigins = strings.Split(corsOrigins, ",")
+	}
+	if tenants != "" {
+		cfg.Tenants.Allowed = strings.Split(tenants, ",")
+		for _, name := range cfg.Tenants.Allowed {
+			if err := tenant.Validate(name); err != nil {
+				return usageError(err)
+			}
+		}
+	}
+
+	policy, err := parseSync(sync)
+	if err != nil {
+		return usageError(err)
+	}
+	cfg.Sync = policy
+
+	if cfg.AccessLog.Format != "" {
+		if err := accesslog.ValidateFormat(cfg.AccessLog.Format); err != nil {
+			return usageError(err)
+		}
+	}
+
+	var schedule workcal.Schedule
+	if schedule.Start, schedule.End, schedule.Location, err = workcal.ParseZonedHours(workingHours); err != nil {
+		return usageError(err)
+	}
+	if schedule.Weekdays, err = workcal.ParseWeekdays(workingDays); err != nil {
+		return usageError(err)
+	}
+	if cfg.Schedules, err = newSchedules(schedule, workCalendar, userSchedules); err != nil {
+		return err
+	}
+
+	return app.Run(cfg)
+}
+
+// eventsList выводит события пользователя в диапазоне дат.
+func eventsList(e env) error {
+	var data dataFlags
+	var userID, from, to string
+
+	fs := newFlagSet(e, "events list")
+	data.register(fs)
+	fs.StringVar(&userID, "user-id", "", "user id")
+	fs.StringVar(&from, "from", "", "range start as 2006-01-02 or RFC 3339 (unbounded if empty)")
+	fs.StringVar(&to, "to", "", "range end as 2006-01-02 or RFC 3339 (unbounded if empty)")
+	if err := parseFlags(fs, e.args); err != nil {
+		return err
+	}
+	if err := data.validate(); err != nil {
+		return err
+	}
+
+	dateStart, dateEnd := time.Time{}, maxTime
+	var err error
+	if from != "" {
+		if dateStart, err = parseTime(from); err != nil {
+			return usageError(err)
+		}
+	}
+	if to != "" {
+		if dateEnd, err = parseTime(to); err != nil {
+			return usageError(err)
+		}
+	}
+
+	svc, durable, err := data.open()
+	if err != nil {
+		return err
+	}
+	defer durable.Close()
+
+	events, err := svc.GetForRange(context.Background(), userID, dateStart, dateEnd)
+	if err != nil {
+		return err
+	}
+	return writeEvents(e.stdout, data.format, events...)
+}
+
+// eventsCreate создает событие.
+func eventsCreate(e env) error {
+	var data dataFlags
+	var event entity.Event
+	var date string
+
+	fs := newFlagSet(e, "events create")
+	data.register(fs)
+	fs.StringVar(&event.UserID, "user-id", "", "user id")
+	fs.StringVar(&event.Title, "title", "", "event title")
+	fs.StringVar(&event.Description, "description", "", "event description")
+	fs.StringVar(&date, "date", "", "event date as 2006-01-02 or RFC 3339")
+	if err := parseFlags(fs, e.args); err != nil {
+		return err
+	}
+	if err := data.validate(); err != nil {
+		return err
+	}
+
+	var err error
+	if event.Date, err = parseTime(date); err != nil {
+		return usageError(err)
+	}
+
+	svc, durable, err := data.open()
+	if err != nil {
+		return err
+	}
+	defer durable.Close()
+
+	event, err = svc.Create(context.Background(), event)
+	if err != nil {
+		return err
+	}
+	return writeEvents(e.stdout, data.format, event)
+}
+
+// eventsDelete удаляет событие.
+func eventsDelete(e env) error {
+	var data dataFlags
+	var userID, id string
+
+	fs := newFlagSet(e, "events delete")
+	data.register(fs)
+	fs.StringVar(&userID, "user-id", "", "user id")
+	fs.StringVar(&id, "id", "", "event id")
+	if err := parseFlags(fs, e.args); err != nil {
+		return err
+	}
+	if err := data.validate(); err != nil {
+		return err
+	}
+
+	svc, durable, err := data.open()
+	if err != nil {
+		return err
+	}
+	defer durable.Close()
+
+	return svc.Delete(context.Background(), userID, id)
+}
+
+// export выводит все события пользователя в формате JSON.
+func export(e env) error {
+	var data dataFlags
+	var userID, out string
+
+	fs := newFlagSet(e, "export")
+	data.register(fs)
+	fs.StringVar(&userID, "user-id", "", "user id")
+	fs.StringVar(&out, "out", "", "output file (stdout if empty)")
+	if err := parseFlags(fs, e.args); err != nil {
+		return err
+	}
+	data.format = FormatJSON
+	if err := data.validate(); err != nil {
+		return err
+	}
+
+	svc, durable, err := data.open()
+	if err != nil {
+		return err
+	}
+	defer durable.Close()
+
+	events, err := svc.GetForRange(context.Background(), userID, time.Time{}, maxTime)
+	if err != nil {
+		return err
+	}
+
+	w := e.stdout
+	if out != "" {
+		file, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return writeEvents(w, FormatJSON, events...)
+}
+
+// importEvents создает события из массива JSON. Идентификаторы событий генерируются заново,
+// события с ошибками пропускаются и выводятся в e.stderr.
+func importEvents(e env) error {
+	var data dataFlags
+	var in string
+
+	fs := newFlagSet(e, "import")
+	data.register(fs)
+	fs.StringVar(&in, "in", "", "input file (stdin if empty)")
+	if err := parseFlags(fs, e.args); err != nil {
+		return err
+	}
+	if err := data.validate(); err != nil {
+		return err
+	}
+
+	r := e.stdin
+	if in != "" {
+		file, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var events []entity.Event
+	if err := json.NewDecoder(r).Decode(&events); err != nil {
+		return err
+	}
+
+	svc, durable, err := data.open()
+	if err != nil {
+		return err
+	}
+	defer durable.Close()
+
+	created := make([]entity.Event, 0, len(events))
+	failed := 0
+	for i, event := range events {
+		event.ID = ""
+		event, err := svc.Create(context.Background(), event)
+		if err != nil {
+			fmt.Fprintf(e.stderr, "event %d: %s\n", i, err)
+			failed++
+			continue
+		}
+		created = append(created, event)
+	}
+
+	if err := writeEvents(e.stdout, data.format, created...); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d events were not imported", failed, len(events))
+	}
+	return nil
+}
+
+// migrate восстанавливает события из директории данных, отбрасывая обрезанные записи
+// журнала, и сохраняет их в новый снимок, удаляя старые журналы.
+func migrate(e env) error {
+	var data dataFlags
+
+	fs := newFlagSet(e, "migrate")
+	data.register(fs)
+	if err := parseFlags(fs, e.args); err != nil {
+		return err
+	}
+	if err := data.validate(); err != nil {
+		return err
+	}
+
+	_, durable, err := data.open()
+	if err != nil {
+		return err
+	}
+	defer durable.Close()
+
+	if err := durable.Snapshot(); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "migrated %s\n", data.dir)
+	return nil
+}
+
+// token выводит случайный токен. API идентифицирует пользователей по UUID,
+// поэтому с -kind user выводится новый идентификатор пользователя.
+func token(e env) error {
+	var kind string
+	var size int
+
+	fs := newFlagSet(e, "token")
+	fs.StringVar(&kind, "kind", "secret", "token kind: secret (random hex) or user (new user_id)")
+	fs.IntVar(&size, "size", 32, "secret size in bytes")
+	if err := parseFlags(fs, e.args); err != nil {
+		return err
+	}
+
+	switch kind {
+	case "user":
+		fmt.Fprintln(e.stdout, uuid.NewString())
+	case "secret":
+		if size <= 0 {
+			return usageError(fmt.Errorf("invalid size %d", size))
+		}
+		buf := make([]byte, size)
+		rand.Read(buf)
+		fmt.Fprintln(e.stdout, hex.EncodeToString(buf))
+	default:
+		return usageError(fmt.Errorf("invalid kind %q", kind))
+	}
+	return nil
+}
+
+// writeEvents выводит события events в w в формате format.
+func writeEvents(w io.Writer, format string, events ...entity.Event) error {
+	if format == FormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if events == nil {
+			events = []entity.Event{}
+		}
+		return enc.Encode(events)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDATE\tUSER_ID\tTITLE")
+	for _, event := range events {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", event.ID, event.Date.Format(time.RFC3339), event.UserID, event.Title)
+	}
+	return tw.Flush()
+}
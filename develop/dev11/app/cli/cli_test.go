@@ -0,0 +1,297 @@
+package cli
+
+import (
+	"bytes"
+	"dev11/app/agenda"
+	"dev11/app/entity"
+	"dev11/app/tenant"
+	"dev11/app/workcal"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// run выполняет команду args и возвращает код завершения и вывод.
+func run(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Events(t *testing.T) {
+	dir := t.TempDir()
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+
+	code, stdout, stderr := run("", "events", "create", "-data-dir", dir, "-format", "json",
+		"-user-id", userID, "-title", "standup", "-date", "2010-05-20T10:00:00Z")
+	if code != ExitOK {
+		t.Fatalf("Run(events create) = %v, stderr %q", code, stderr)
+	}
+	var created []entity.Event
+	if err := json.Unmarshal([]byte(stdout), &created); err != nil || len(created) != 1 {
+		t.Fatalf("Run(events create) stdout = %q", stdout)
+	}
+
+	t.Run("ListTable", func(t *testing.T) {
+		code, stdout, _ := run("", "events", "list", "-data-dir", dir, "-user-id", userID, "-from", "2010-05-20", "-to", "2010-05-21")
+		if code != ExitOK {
+			t.Errorf("Run(events list) = %v, want %v", code, ExitOK)
+		}
+		if !strings.HasPrefix(stdout, "ID") || !strings.Contains(stdout, created[0].ID) || !strings.Contains(stdout, "standup") {
+			t.Errorf("Run(events list) stdout = %q", stdout)
+		}
+	})
+
+	t.Run("ListOutOfRange", func(t *testing.T) {
+		_, stdout, _ := run("", "events", "list", "-data-dir", dir, "-format", "json", "-user-id", userID, "-to", "2010-05-19")
+		if got, want := strings.TrimSpace(stdout), "[]"; got != want {
+			t.Errorf("Run(events list) stdout = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("ExportImport", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "events.json")
+		if code, _, stderr := run("", "export", "-data-dir", dir, "-user-id", userID, "-out", out); code != ExitOK {
+			t.Fatalf("Run(export) = %v, stderr %q", code, stderr)
+		}
+
+		other := t.TempDir()
+		code, _, stderr := run("", "import", "-data-dir", other, "-in", out)
+		if code != ExitOK {
+			t.Fatalf("Run(import) = %v, stderr %q", code, stderr)
+		}
+		_, stdout, _ := run("", "events", "list", "-data-dir", other, "-user-id", userID)
+		if !strings.Contains(stdout, "standup") {
+			t.Errorf("Run(events list) stdout = %q", stdout)
+		}
+	})
+
+	t.Run("Reimport", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "events.json")
+		if code, _, stderr := run("", "export", "-data-dir", dir, "-user-id", userID, "-out", out); code != ExitOK {
+			t.Fatalf("Run(export) = %v, stderr %q", code, stderr)
+		}
+
+		other := t.TempDir()
+		for range 2 {
+			if code, _, stderr := run("", "import", "-data-dir", other, "-in", out); code != ExitOK {
+				t.Fatalf("Run(import) = %v, stderr %q", code, stderr)
+			}
+		}
+		_, stdout, _ := run("", "events", "list", "-data-dir", other, "-format", "json", "-user-id", userID)
+		var imported []entity.Event
+		if err := json.Unmarshal([]byte(stdout), &imported); err != nil || len(imported) != 2 {
+			t.Fatalf("Run(events list) stdout = %q", stdout)
+		}
+		for _, event := range imported {
+			if event.ID == created[0].ID {
+				t.Errorf("Run(import) kept ID %q", event.ID)
+			}
+		}
+		if imported[0].ID == imported[1].ID {
+			t.Errorf("Run(import) ID = %q twice", imported[0].ID)
+		}
+	})
+
+	t.Run("ImportInvalid", func(t *testing.T) {
+		code, _, stderr := run(`[{"title":""}]`, "import", "-data-dir", t.TempDir())
+		if code != ExitError {
+			t.Errorf("Run(import) = %v, want %v", code, ExitError)
+		}
+		if !strings.Contains(stderr, "event 0") {
+			t.Errorf("Run(import) stderr = %q", stderr)
+		}
+	})
+
+	t.Run("Tenant", func(t *testing.T) {
+		_, stdout, _ := run("", "events", "list", "-data-dir", dir, "-tenant", "sales", "-format", "json", "-user-id", userID)
+		if got, want := strings.TrimSpace(stdout), "[]"; got != want {
+			t.Errorf("Run(events list) stdout = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Migrate", func(t *testing.T) {
+		if code, _, stderr := run("", "migrate", "-data-dir", dir); code != ExitOK {
+			t.Errorf("Run(migrate) = %v, stderr %q", code, stderr)
+		}
+		_, stdout, _ := run("", "events", "list", "-data-dir", dir, "-user-id", userID)
+		if !strings.Contains(stdout, created[0].ID) {
+			t.Errorf("Run(events list) stdout = %q", stdout)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if code, _, stderr := run("", "events", "delete", "-data-dir", dir, "-user-id", userID, "-id", created[0].ID); code != ExitOK {
+			t.Errorf("Run(events delete) = %v, stderr %q", code, stderr)
+		}
+		code, _, stderr := run("", "events", "delete", "-data-dir", dir, "-user-id", userID, "-id", created[0].ID)
+		if code != ExitError {
+			t.Errorf("Run(events delete) = %v, want %v", code, ExitError)
+		}
+		if want := "events delete: does not exist\n"; stderr != want {
+			t.Errorf("Run(events delete) stderr = %q, want %q", stderr, want)
+		}
+	})
+}
+
+func TestRun_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"UnknownCommand", []string{"events", "rename"}, ExitUsage},
+		{"NoDataDir", []string{"events", "list"}, ExitUsage},
+		{"InvalidFormat", []string{"events", "list", "-data-dir", "dir", "-format", "xml"}, ExitUsage},
+		{"InvalidFlag", []string{"export", "-unknown"}, ExitUsage},
+		{"InvalidSync", []string{"serve", "-sync", "sometimes"}, ExitUsage},
+		{"InvalidAccessLogFormat", []string{"serve", "-access-log-format", "xml"}, ExitUsage},
+		{"InvalidRouteTimeout", []string{"serve", "-route-timeout", "POST /create_event"}, ExitUsage},
+		{"InvalidTenant", []string{"events", "list", "-data-dir", "dir", "-tenant", "../sales"}, ExitUsage},
+		{"InvalidTenantQuota", []string{"serve", "-tenant-quota", "sales:many:0"}, ExitUsage},
+		{"InvalidTenants", []string{"serve", "-tenants", "sales,../hr"}, ExitUsage},
+		{"InvalidAgendaSubscription", []string{"serve", "-agenda-subscription", "1:user@example.com:month:text"}, ExitUsage},
+		{"InvalidWorkingHours", []string{"serve", "-working-hours", "18:00-09:00"}, ExitUsage},
+		{"InvalidWorkingDays", []string{"serve", "-working-days", "monday"}, ExitUsage},
+		{"InvalidUserSchedule", []string{"serve", "-user-schedule", "1/09:00-18:00"}, ExitUsage},
+		{"Help", []string{"help"}, ExitOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _, _ := run("", tt.args...); got != tt.want {
+				t.Errorf("Run() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_subscriptionsFlag_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    agenda.Subscription
+		wantErr bool
+	}{
+		{"DefaultTenant", "1:user@example.com:day:html",
+			agenda.Subscription{Tenant: tenant.Default, UserID: "1", Email: "user@example.com", Period: "day", Format: "html"}, false},
+		{"Tenant", "sales/1:user@example.com:week:markdown",
+			agenda.Subscription{Tenant: "sales", UserID: "1", Email: "user@example.com", Period: "week", Format: "markdown"}, false},
+		{"MissingFormat", "1:user@example.com:day", agenda.Subscription{}, true},
+		{"InvalidEmail", "1:user:day:text", agenda.Subscription{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f subscriptionsFlag
+			err := f.Set(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("subscriptionsFlag.Set() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (len(f) != 1 || f[0] != tt.want) {
+				t.Errorf("subscriptionsFlag.Set() = %v, want %v", f, tt.want)
+			}
+		})
+	}
+}
+
+func Test_schedulesFlag_Set(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		name    string
+		value   string
+		want    userSchedule
+		wantErr bool
+	}{
+		{"NoCalendar", "1/10:00-19:00/sat,sun", userSchedule{userID: "1", schedule: workcal.Schedule{
+			Start: 10 * time.Hour, End: 19 * time.Hour, Weekdays: []time.Weekday{time.Saturday, time.Sunday}}}, false},
+		{"Calendar", "1/09:00-18:00/mon/calendars/ru.json", userSchedule{userID: "1", schedule: workcal.Schedule{
+			Start: 9 * time.Hour, End: 18 * time.Hour, Weekdays: []time.Weekday{time.Monday}}, calendar: "calendars/ru.json"}, false},
+		{"Zone", "1/09:00-18:00[Europe/Moscow]/mon/calendars/ru.json", userSchedule{userID: "1", schedule: workcal.Schedule{
+			Start: 9 * time.Hour, End: 18 * time.Hour, Weekdays: []time.Weekday{time.Monday}, Location: moscow}, calendar: "calendars/ru.json"}, false},
+		{"ZoneWithoutSlash", "1/09:00-18:00[UTC]/mon", userSchedule{userID: "1", schedule: workcal.Schedule{
+			Start: 9 * time.Hour, End: 18 * time.Hour, Weekdays: []time.Weekday{time.Monday}, Location: time.UTC}}, false},
+		{"UnclosedZone", "1/09:00-18:00[Europe/Moscow/mon", userSchedule{}, true},
+		{"InvalidZone", "1/09:00-18:00[Mars/Olympus]/mon", userSchedule{}, true},
+		{"NoUser", "/09:00-18:00/mon-fri", userSchedule{}, true},
+		{"InvalidHours", "1/9-18/mon-fri", userSchedule{}, true},
+		{"InvalidWeekdays", "1/09:00-18:00/weekdays", userSchedule{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f schedulesFlag
+			err := f.Set(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("schedulesFlag.Set() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			tt.want.value = tt.value
+			if len(f) != 1 || !reflect.DeepEqual(f[0], tt.want) {
+				t.Errorf("schedulesFlag.Set() = %v, want %v", f, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newSchedules(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "ru.json")
+	icsPath := filepath.Join(dir, "us.ics")
+	os.WriteFile(jsonPath, []byte(`{"holidays": [{"date": "2010-05-10", "name": "Victory Day"}], "workdays": ["2010-11-13"]}`), 0o644)
+	os.WriteFile(icsPath, []byte("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:Memorial Day\r\nDTSTART;VALUE=DATE:20100531\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"), 0o644)
+
+	var users schedulesFlag
+	users.Set("us/09:00-17:00/mon-fri/" + icsPath)
+	users.Set("ru/09:00-18:00/mon-sat")
+	schedules, err := newSchedules(workcal.DefaultSchedule, jsonPath, users)
+	if err != nil {
+		t.Fatalf("newSchedules() error = %v", err)
+	}
+
+	victoryDay := time.Date(2010, 5, 10, 0, 0, 0, 0, time.UTC)
+	memorialDay := time.Date(2010, 5, 31, 0, 0, 0, 0, time.UTC)
+	if name, ok := schedules.For("other").Holiday(victoryDay); !ok || name != "Victory Day" {
+		t.Errorf("default schedule holiday = %q, %v, want Victory Day", name, ok)
+	}
+	if !schedules.For("other").IsBusinessDay(time.Date(2010, 11, 13, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("default schedule transferred workday is not a business day")
+	}
+	if name, ok := schedules.For("us").Holiday(memorialDay); !ok || name != "Memorial Day" {
+		t.Errorf("user calendar holiday = %q, %v, want Memorial Day", name, ok)
+	}
+	if _, ok := schedules.For("ru").Holiday(victoryDay); !ok {
+		t.Errorf("user schedule without calendar does not use the default calendar")
+	}
+
+	if _, err := newSchedules(workcal.DefaultSchedule, filepath.Join(dir, "missing.json"), nil); err == nil {
+		t.Errorf("newSchedules() with missing calendar error = nil")
+	}
+}
+
+func TestRun_Token(t *testing.T) {
+	t.Run("User", func(t *testing.T) {
+		code, stdout, _ := run("", "token", "-kind", "user")
+		if code != ExitOK {
+			t.Errorf("Run(token) = %v, want %v", code, ExitOK)
+		}
+		if err := uuid.Validate(strings.TrimSpace(stdout)); err != nil {
+			t.Errorf("Run(token) stdout = %q: %v", stdout, err)
+		}
+	})
+
+	t.Run("Secret", func(t *testing.T) {
+		_, stdout, _ := run("", "token", "-size", "16")
+		if got, want := len(strings.TrimSpace(stdout)), 32; got != want {
+			t.Errorf("Run(token) len = %v, want %v", got, want)
+		}
+	})
+}
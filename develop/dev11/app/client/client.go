@@ -0,0 +1,395 @@
+// Пакет client предоставляет клиент HTTP API календаря, реализующий интерфейс service.Event.
+// Ошибки бизнес-логики сервера возвращаются как *service.ExternalError, а ошибки сети
+// и внутренние ошибки сервера как *service.InternalError, поэтому клиент можно
+// использовать вместо локального сервиса событий.
+package client
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/repo"
+	"dev11/app/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Параметры клиента по умолчанию.
+const (
+	DefaultTimeout = 10 * time.Second
+	DefaultRetries = 2
+	DefaultBackoff = 100 * time.Millisecond
+)
+
+// Заголовки запросов, которые клиент передает серверу.
+const (
+	tenantHeader         = "X-Tenant-ID"
+	idempotencyKeyHeader = "Idempotency-Key"
+)
+
+// Ошибки клиента.
+var (
+	ErrInvalidURL      = errors.New("invalid base url")
+	ErrInvalidResponse = errors.New("invalid response")
+)
+
+// serviceErrors содержит ошибки бизнес-логики, которые восстанавливаются по тексту ответа сервера.
+var serviceErrors = []error{service.ErrInvalidRange, service.ErrEmptyQuery, service.ErrInvalidLimit}
+
+// causes содержит причины ошибок бизнес-логики, которые сохраняются в цепочке ошибки,
+// чтобы errors.Is работал так же, как с локальным сервисом.
+var causes = []error{
+	entity.ErrTitleEmpty, entity.ErrIdInvalid, entity.ErrFormatInvalid, entity.ErrPeriodInvalid,
+	service.ErrQuotaExceeded, repo.ErrNotExist, repo.ErrExists,
+}
+
+// Структура ошибки, которую вернул сервер.
+type Error struct {
+	// StatusCode содержит код HTTP-ответа.
+	StatusCode int
+	// Message содержит текст ошибки из ответа или пустую строку, если ответ без тела.
+	Message string
+	cause   error
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return http.StatusText(e.StatusCode)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.cause }
+
+// Параметры клиента.
+type options struct {
+	httpClient *http.Client
+	tenant     string
+	timeout    time.Duration
+	retries    int
+	backoff    time.Duration
+}
+
+// Тип функции, изменяющей параметры клиента.
+type Option func(o *options)
+
+// WithHTTPClient задает HTTP-клиент, через который выполняются запросы.
+// По умолчанию используется http.DefaultClient.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) { o.httpClient = c }
+}
+
+// WithTenant задает арендатора, от имени которого выполняются запросы.
+func WithTenant(name string) Option {
+	return func(o *options) { o.tenant = name }
+}
+
+// WithTimeout задает время ожидания ответа на одну попытку запроса, по умолчанию DefaultTimeout.
+// Нулевое значение отключает ограничение, оставляя только контекст вызова.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithRetries задает количество повторов запроса retries после временной ошибки
+// и задержку перед первым повтором backoff, которая удваивается с каждым повтором.
+// По умолчанию DefaultRetries и DefaultBackoff.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.retries = retries
+		o.backoff = backoff
+	}
+}
+
+// Структура клиента HTTP API календаря.
+type Client struct {
+	baseURL *url.URL
+	opts    options
+}
+
+var _ service.Event = (*Client)(nil)
+
+// New возвращает клиент сервера с адресом baseURL вида http://localhost:3000.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidURL, baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	o := options{httpClient: http.DefaultClient, timeout: DefaultTimeout, retries: DefaultRetries, backoff: DefaultBackoff}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Client{baseURL: u, opts: o}, nil
+}
+
+// GetByID возвращает Event по его userID и id.
+func (c *Client) GetByID(ctx context.Context, userID string, id string) (entity.Event, error) {
+	var event entity.Event
+	err := c.get(ctx, "/event", url.Values{"user_id": {userID}, "id": {id}}, &event)
+	return event, err
+}
+
+// GetForRange возвращает []Event по его userID и диапазону дат.
+func (c *Client) GetForRange(ctx context.Context, userID string, dateStart, dateEnd time.Time) ([]entity.Event, error) {
+	return c.getEvents(ctx, "/events_for_range", url.Values{
+		"user_id": {userID},
+		"start":   {dateStart.Format(time.RFC3339Nano)},
+		"end":     {dateEnd.Format(time.RFC3339Nano)},
+	})
+}
+
+// GetForDay возвращает []Event по его userID и дню day.
+func (c *Client) GetForDay(ctx context.Context, userID string, day time.Time) ([]entity.Event, error) {
+	return c.getEvents(ctx, "/events_for_day", url.Values{"user_id": {userID}, "day": {day.Format(time.DateOnly)}})
+}
+
+// GetForWeek возвращает []Event по его userID и неделе, содержащей день week.
+func (c *Client) GetForWeek(ctx context.Context, userID string, week time.Time) ([]entity.Event, error) {
+	return c.getEvents(ctx, "/events_for_week", url.Values{"user_id": {userID}, "week": {week.Format(time.DateOnly)}})
+}
+
+// GetForMonth возвращает []Event по его userID и месяцу month.
+func (c *Client) GetForMonth(ctx context.Context, userID string, month time.Time) ([]entity.Event, error) {
+	return c.getEvents(ctx, "/events_for_month", url.Values{"user_id": {userID}, "month": {month.Format("2006-01")}})
+}
+
+// Search возвращает []Event пользователя userID, найденные по запросу query.
+func (c *Client) Search(ctx context.Context, userID string, query string) ([]entity.Event, error) {
+	return c.getEvents(ctx, "/search_events", url.Values{"user_id": {userID}, "q": {query}})
+}
+
+// GetUpcoming возвращает не более limit ближайших событий пользователя userID.
+func (c *Client) GetUpcoming(ctx context.Context, userID string, limit int) ([]entity.Event, error) {
+	return c.getEvents(ctx, "/upcoming_events", url.Values{"user_id": {userID}, "limit": {strconv.Itoa(limit)}})
+}
+
+// GetForToday возвращает события пользователя userID за текущий день сервера.
+func (c *Client) GetForToday(ctx context.Context, userID string) ([]entity.Event, error) {
+	return c.getEvents(ctx, "/events_for_today", url.Values{"user_id": {userID}})
+}
+
+// Create создает Event. Повторы запроса используют один ключ идемпотентности,
+// поэтому событие не создается дважды.
+func (c *Client) Create(ctx context.Context, event entity.Event) (entity.Event, error) {
+	var created entity.Event
+	header := http.Header{idempotencyKeyHeader: {uuid.NewString()}}
+	err := c.do(ctx, http.MethodPost, "/create_event", eventForm(event), header, true, &created)
+	return created, err
+}
+
+// Update обновляет Event.
+func (c *Client) Update(ctx context.Context, event entity.Event) (entity.Event, error) {
+	var updated entity.Event
+	err := c.do(ctx, http.MethodPost, "/update_event", eventForm(event), nil, true, &updated)
+	return updated, err
+}
+
+// Delete удаляет Event по его userID и id. Запрос не повторяется: повтор после
+// потерянного ответа вернул бы ошибку об отсутствии уже удаленного события.
+func (c *Client) Delete(ctx context.Context, userID string, id string) error {
+	return c.do(ctx, http.MethodPost, "/delete_event", url.Values{"user_id": {userID}, "id": {id}}, nil, false, nil)
+}
+
+// Transfer передает события пользователя fromUserID, выбранные filter, пользователю toUserID.
+// Запрос не повторяется: повтор после потерянного ответа передал бы уже переданные события заново.
+func (c *Client) Transfer(ctx context.Context, fromUserID string, toUserID string, filter entity.EventFilter) ([]entity.Event, error) {
+	form := url.Values{"from_user_id": {fromUserID}, "to_user_id": {toUserID}}
+	if len(filter.IDs) > 0 {
+		form["id"] = filter.IDs
+	}
+	if !filter.DateStart.IsZero() {
+		form.Set("start", filter.DateStart.Format(time.RFC3339Nano))
+	}
+	if !filter.DateEnd.IsZero() {
+		form.Set("end", filter.DateEnd.Format(time.RFC3339Nano))
+	}
+	var events []entity.Event
+	if err := c.do(ctx, http.MethodPost, "/transfer_events", form, nil, false, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// DeleteByUser удаляет все данные пользователя userID и возвращает его удаленные события.
+// Запрос не повторяется, так как повтор вернул бы пустой список событий.
+func (c *Client) DeleteByUser(ctx context.Context, userID string) ([]entity.Event, error) {
+	var data struct {
+		Events []entity.Event `json:"events"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/delete_user", url.Values{"user_id": {userID}}, nil, false, &data); err != nil {
+		return nil, err
+	}
+	return data.Events, nil
+}
+
+// eventForm возвращает поля события event для формы запроса.
+func eventForm(event entity.Event) url.Values {
+	form := url.Values{
+		"title":       {event.Title},
+		"description": {event.Description},
+		"date":        {event.Date.UTC().Format("2006-01-02T15:04:05Z")},
+		"user_id":     {event.UserID},
+	}
+	if event.ID != "" {
+		form.Set("id", event.ID)
+	}
+	if event.DescriptionFormat != "" {
+		form.Set("description_format", event.DescriptionFormat)
+	}
+	return form
+}
+
+// getEvents выполняет GET-запрос к методу path, возвращающему список событий.
+func (c *Client) getEvents(ctx context.Context, path string, query url.Values) ([]entity.Event, error) {
+	var events []entity.Event
+	if err := c.get(ctx, path, query, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// get выполняет GET-запрос к методу path и декодирует результат в res.
+func (c *Client) get(ctx context.Context, path string, query url.Values, res any) error {
+	return c.do(ctx, http.MethodGet, path, query, nil, true, res)
+}
+
+// do выполняет запрос к методу path с параметрами params и декодирует результат в res.
+// Запросы с retry повторяются после временных ошибок, пока не отменен ctx.
+func (c *Client) do(ctx context.Context, method string, path string, params url.Values, header http.Header, retry bool, res any) error {
+	backoff := c.opts.backoff
+	for attempt := 0; ; attempt++ {
+		err := c.attempt(ctx, method, path, params, header, res)
+		if err == nil || !retry || attempt >= c.opts.retries || ctx.Err() != nil || !temporary(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &service.InternalError{Err: ctx.Err()}
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// attempt выполняет одну попытку запроса.
+func (c *Client) attempt(ctx context.Context, method string, path string, params url.Values, header http.Header, res any) error {
+	if c.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.timeout)
+		defer cancel()
+	}
+
+	u := *c.baseURL
+	u.Path += path
+	var body io.Reader
+	if method == http.MethodGet {
+		u.RawQuery = params.Encode()
+	} else {
+		body = strings.NewReader(params.Encode())
+	}
+
+	r, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return &service.InternalError{Err: err}
+	}
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	if body != nil {
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if c.opts.tenant != "" {
+		r.Header.Set(tenantHeader, c.opts.tenant)
+	}
+
+	resp, err := c.opts.httpClient.Do(r)
+	if err != nil {
+		return &service.InternalError{Err: err}
+	}
+	defer resp.Body.Close()
+	return decodeResponse(resp, res)
+}
+
+// decodeResponse декодирует конверт {"result": ...} ответа resp в res
+// или возвращает ошибку из конверта {"error": "..."}.
+func decodeResponse(resp *http.Response, res any) error {
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  *string         `json:"error"`
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &service.InternalError{Err: err}
+	}
+	// Ответы с внутренней ошибкой и ответы прокси могут не содержать конверта.
+	decodeErr := json.Unmarshal(data, &envelope)
+
+	if resp.StatusCode >= 300 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		if decodeErr == nil && envelope.Error != nil {
+			apiErr.Message = *envelope.Error
+		}
+		return responseError(apiErr)
+	}
+	if resp.StatusCode == http.StatusNoContent || res == nil {
+		return nil
+	}
+	if decodeErr != nil {
+		return &service.InternalError{Err: fmt.Errorf("%w: %w", ErrInvalidResponse, decodeErr)}
+	}
+	if err := json.Unmarshal(envelope.Result, res); err != nil {
+		return &service.InternalError{Err: fmt.Errorf("%w: %w", ErrInvalidResponse, err)}
+	}
+	return nil
+}
+
+// responseError преобразует ошибку сервера apiErr в ошибку бизнес-логики.
+// Ответы с кодом 4xx и 503 содержат ошибки входных данных и бизнес-логики,
+// остальные ответы считаются внутренними ошибками.
+func responseError(apiErr *Error) error {
+	if apiErr.StatusCode >= 500 && apiErr.StatusCode != http.StatusServiceUnavailable {
+		return &service.InternalError{Err: apiErr}
+	}
+
+	for _, err := range serviceErrors {
+		var externalErr *service.ExternalError
+		if errors.As(err, &externalErr) && externalErr.Err.Error() == apiErr.Message {
+			return err
+		}
+	}
+	for _, cause := range causes {
+		if strings.Contains(apiErr.Message, cause.Error()) {
+			apiErr.cause = cause
+			break
+		}
+	}
+	return &service.ExternalError{Err: apiErr}
+}
+
+// temporary сообщает, может ли повтор запроса с ошибкой err завершиться успешно.
+// Повторяются ошибки сети, включая истечение времени попытки, и ответы прокси
+// с кодами 429, 502 и 504. Код 503 означает ошибку бизнес-логики и не повторяется.
+func temporary(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var externalErr *service.ExternalError
+	return !errors.As(err, &externalErr)
+}
@@ -0,0 +1,345 @@
+package client
+
+import (
+	"context"
+	"dev11/app/clock"
+	"dev11/app/entity"
+	"dev11/app/repo"
+	"dev11/app/service"
+	transport "dev11/app/transport/http"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+const userID = "18310e71-4df6-42c0-adf4-1a280013dd08"
+
+// newServer возвращает тестовый сервер с сервисом событий in-memory и часами now.
+// Функция wrap позволяет изменить обработчик сервера.
+func newServer(t *testing.T, now time.Time, wrap func(http.Handler) http.Handler) *httptest.Server {
+	t.Helper()
+	events := service.NewEventV1(repo.NewEventTenants(func(string) (repo.Event, error) { return repo.NewEventMemory(), nil }, repo.TenantLimits{}),
+		service.WithClock(clock.NewFake(now)))
+	handler := transport.NewServer("", "", events, slog.New(slog.NewJSONHandler(io.Discard, nil))).Handler()
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		wantErr bool
+	}{
+		{"Valid", "http://localhost:3000", false},
+		{"TrailingSlash", "https://calendar.example.com/api/", false},
+		{"NoScheme", "localhost:3000", true},
+		{"UnsupportedScheme", "ftp://localhost", true},
+		{"Invalid", "http://[::1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(tt.baseURL)
+			if (err != nil) != tt.wantErr || (c == nil) != tt.wantErr {
+				t.Errorf("New() = %v, %v, wantErr %v", c, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidURL) {
+				t.Errorf("New() error = %v, want %v", err, ErrInvalidURL)
+			}
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	now := time.Date(2010, 5, 17, 9, 0, 0, 0, time.UTC)
+	srv := newServer(t, now, nil)
+	c, err := New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	event, err := c.Create(ctx, entity.Event{Title: "Code review", Description: "**parser**", DescriptionFormat: entity.FormatMarkdown, Date: now.Add(time.Hour), UserID: userID})
+	if err != nil {
+		t.Fatalf("Client.Create() error = %v", err)
+	}
+	want := entity.Event{ID: event.ID, Title: "Code review", Description: "**parser**", DescriptionFormat: entity.FormatMarkdown, Date: now.Add(time.Hour), UserID: userID, UpdatedAt: now}
+	if !reflect.DeepEqual(event, want) {
+		t.Fatalf("Client.Create() = %v, want %v", event, want)
+	}
+	other, _ := c.Create(ctx, entity.Event{Title: "Planning", Date: now.AddDate(0, 0, 3), UserID: userID})
+
+	t.Run("GetByID", func(t *testing.T) {
+		if got, err := c.GetByID(ctx, userID, event.ID); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Client.GetByID() = %v, %v, want %v", got, err, want)
+		}
+	})
+
+	lists := []struct {
+		name string
+		get  func() ([]entity.Event, error)
+		want []entity.Event
+	}{
+		{"GetForRange", func() ([]entity.Event, error) { return c.GetForRange(ctx, userID, now, now.Add(2*time.Hour)) }, []entity.Event{want}},
+		{"GetForDay", func() ([]entity.Event, error) { return c.GetForDay(ctx, userID, now) }, []entity.Event{want}},
+		{"GetForWeek", func() ([]entity.Event, error) { return c.GetForWeek(ctx, userID, now) }, []entity.Event{want, other}},
+		{"GetForMonth", func() ([]entity.Event, error) { return c.GetForMonth(ctx, userID, now) }, []entity.Event{want, other}},
+		{"Search", func() ([]entity.Event, error) { return c.Search(ctx, userID, "review") }, []entity.Event{want}},
+		{"GetUpcoming", func() ([]entity.Event, error) { return c.GetUpcoming(ctx, userID, 1) }, []entity.Event{want}},
+		{"GetForToday", func() ([]entity.Event, error) { return c.GetForToday(ctx, userID) }, []entity.Event{want}},
+		{"Empty", func() ([]entity.Event, error) { return c.GetForDay(ctx, userID, now.AddDate(1, 0, 0)) }, []entity.Event{}},
+	}
+	for _, tt := range lists {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.get()
+			if err != nil {
+				t.Fatalf("Client.%s() error = %v", tt.name, err)
+			}
+			if len(got) > 1 && got[0].ID != want.ID {
+				got[0], got[1] = got[1], got[0]
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Client.%s() = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Update", func(t *testing.T) {
+		updated := want
+		updated.Title = "Design review"
+		got, err := c.Update(ctx, updated)
+		if err != nil || got.Title != updated.Title {
+			t.Errorf("Client.Update() = %v, %v, want title %q", got, err, updated.Title)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := c.Delete(ctx, userID, event.ID); err != nil {
+			t.Fatalf("Client.Delete() error = %v", err)
+		}
+		if _, err := c.GetByID(ctx, userID, event.ID); !errors.Is(err, repo.ErrNotExist) {
+			t.Errorf("Client.GetByID() error = %v, want %v", err, repo.ErrNotExist)
+		}
+	})
+
+	receiverID := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	t.Run("Transfer", func(t *testing.T) {
+		got, err := c.Transfer(ctx, userID, receiverID, entity.EventFilter{IDs: []string{other.ID}, DateStart: now})
+		if err != nil || len(got) != 1 || got[0].ID != other.ID || got[0].UserID != receiverID {
+			t.Errorf("Client.Transfer() = %v, %v, want event %s of %s", got, err, other.ID, receiverID)
+		}
+	})
+
+	t.Run("DeleteByUser", func(t *testing.T) {
+		got, err := c.DeleteByUser(ctx, receiverID)
+		if err != nil || len(got) != 1 || got[0].ID != other.ID {
+			t.Errorf("Client.DeleteByUser() = %v, %v, want event %s", got, err, other.ID)
+		}
+		if _, err := c.GetByID(ctx, receiverID, other.ID); !errors.Is(err, repo.ErrNotExist) {
+			t.Errorf("Client.GetByID() error = %v, want %v", err, repo.ErrNotExist)
+		}
+	})
+}
+
+func TestClient_Errors(t *testing.T) {
+	now := time.Date(2010, 5, 17, 9, 0, 0, 0, time.UTC)
+	c, err := New(newServer(t, now, nil).URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"NotExist", func() error {
+			_, err := c.GetByID(ctx, userID, userID)
+			return err
+		}, repo.ErrNotExist},
+		{"TitleEmpty", func() error {
+			_, err := c.Create(ctx, entity.Event{Date: now, UserID: userID})
+			return err
+		}, entity.ErrTitleEmpty},
+		{"IdInvalid", func() error {
+			_, err := c.Update(ctx, entity.Event{ID: "1", Title: "event", Date: now, UserID: userID})
+			return err
+		}, entity.ErrIdInvalid},
+		{"InvalidRange", func() error {
+			_, err := c.GetForRange(ctx, userID, now, now.Add(-time.Hour))
+			return err
+		}, service.ErrInvalidRange},
+		{"EmptyQuery", func() error {
+			_, err := c.Search(ctx, userID, " ")
+			return err
+		}, service.ErrEmptyQuery},
+		{"InvalidLimit", func() error {
+			_, err := c.GetUpcoming(ctx, userID, 0)
+			return err
+		}, service.ErrInvalidLimit},
+		{"DeleteNotExist", func() error { return c.Delete(ctx, userID, userID) }, repo.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			var externalErr *service.ExternalError
+			if !errors.Is(err, tt.want) || !errors.As(err, &externalErr) {
+				t.Errorf("error = %v, want external %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_InternalError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	events := service.NewMockEvent(ctrl)
+	events.EXPECT().GetForToday(gomock.Any(), userID).Return(nil, &service.InternalError{Err: io.EOF})
+	srv := httptest.NewServer(transport.NewServer("", "", events, slog.New(slog.NewJSONHandler(io.Discard, nil))).Handler())
+	defer srv.Close()
+
+	c, _ := New(srv.URL, WithRetries(3, time.Millisecond))
+	_, err := c.GetForToday(context.Background(), userID)
+	var internalErr *service.InternalError
+	var apiErr *Error
+	if !errors.As(err, &internalErr) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Client.GetForToday() error = %v, want internal error with code %v", err, http.StatusInternalServerError)
+	}
+}
+
+func TestClient_Tenant(t *testing.T) {
+	now := time.Date(2010, 5, 17, 9, 0, 0, 0, time.UTC)
+	srv := newServer(t, now, nil)
+	sales, _ := New(srv.URL, WithTenant("sales"))
+	support, _ := New(srv.URL, WithTenant("support"))
+	ctx := context.Background()
+
+	event, err := sales.Create(ctx, entity.Event{Title: "event", Date: now, UserID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sales.GetByID(ctx, userID, event.ID); err != nil {
+		t.Errorf("Client.GetByID() same tenant error = %v", err)
+	}
+	if _, err := support.GetByID(ctx, userID, event.ID); !errors.Is(err, repo.ErrNotExist) {
+		t.Errorf("Client.GetByID() other tenant error = %v, want %v", err, repo.ErrNotExist)
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	now := time.Date(2010, 5, 17, 9, 0, 0, 0, time.UTC)
+
+	// Прокси передает запрос серверу, но теряет первые два ответа.
+	var mu sync.Mutex
+	var attempts int
+	var keys []string
+	srv := newServer(t, now, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			attempts++
+			n := attempts
+			keys = append(keys, r.Header.Get("Idempotency-Key"))
+			mu.Unlock()
+			if n <= 2 {
+				next.ServeHTTP(httptest.NewRecorder(), r)
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	ctx := context.Background()
+
+	t.Run("Create", func(t *testing.T) {
+		c, _ := New(srv.URL, WithRetries(2, time.Millisecond))
+		event, err := c.Create(ctx, entity.Event{Title: "event", Date: now, UserID: userID})
+		if err != nil {
+			t.Fatalf("Client.Create() error = %v", err)
+		}
+		if attempts != 3 || keys[0] == "" || keys[0] != keys[1] || keys[1] != keys[2] {
+			t.Errorf("Client.Create() attempts = %v, keys %v, want 3 with one key", attempts, keys)
+		}
+		if got, _ := c.GetForDay(ctx, userID, now); len(got) != 1 || got[0].ID != event.ID {
+			t.Errorf("Client.GetForDay() = %v, want only %v", got, event)
+		}
+	})
+
+	t.Run("Exhausted", func(t *testing.T) {
+		attempts = 0
+		c, _ := New(srv.URL, WithRetries(1, time.Millisecond))
+		_, err := c.GetForDay(ctx, userID, now)
+		var apiErr *Error
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway || attempts != 2 {
+			t.Errorf("Client.GetForDay() error = %v, attempts %v, want %v after 2 attempts", err, attempts, http.StatusBadGateway)
+		}
+	})
+
+	t.Run("ServiceErrorNotRetried", func(t *testing.T) {
+		attempts = 2
+		c, _ := New(srv.URL, WithRetries(2, time.Millisecond))
+		if _, err := c.GetUpcoming(ctx, userID, 0); !errors.Is(err, service.ErrInvalidLimit) || attempts != 3 {
+			t.Errorf("Client.GetUpcoming() error = %v, attempts %v, want %v after 1 attempt", err, attempts-2, service.ErrInvalidLimit)
+		}
+	})
+
+	t.Run("DeleteNotRetried", func(t *testing.T) {
+		attempts = 0
+		c, _ := New(srv.URL, WithRetries(2, time.Millisecond))
+		if err := c.Delete(ctx, userID, userID); err == nil || attempts != 1 {
+			t.Errorf("Client.Delete() error = %v, attempts %v, want error after 1 attempt", err, attempts)
+		}
+	})
+}
+
+func TestClient_Timeout(t *testing.T) {
+	now := time.Date(2010, 5, 17, 9, 0, 0, 0, time.UTC)
+	var attempts atomic.Int32
+	release := make(chan struct{})
+	srv := newServer(t, now, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) == 1 {
+				select {
+				case <-release:
+				case <-r.Context().Done():
+				}
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	defer close(release)
+
+	t.Run("RetriedAfterTimeout", func(t *testing.T) {
+		c, _ := New(srv.URL, WithTimeout(50*time.Millisecond), WithRetries(1, time.Millisecond))
+		if _, err := c.GetForToday(context.Background(), userID); err != nil || attempts.Load() != 2 {
+			t.Errorf("Client.GetForToday() error = %v, attempts %v, want success after 2 attempts", err, attempts.Load())
+		}
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		attempts.Store(0)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		c, _ := New(srv.URL, WithTimeout(0), WithRetries(3, time.Millisecond))
+		_, err := c.GetForToday(ctx, userID)
+		var internalErr *service.InternalError
+		if !errors.Is(err, context.DeadlineExceeded) || !errors.As(err, &internalErr) || attempts.Load() != 1 {
+			t.Errorf("Client.GetForToday() error = %v, attempts %v, want deadline exceeded after 1 attempt", err, attempts.Load())
+		}
+	})
+}
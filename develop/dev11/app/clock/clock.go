@@ -0,0 +1,60 @@
+// Пакет clock предоставляет источник текущего времени, который можно подменить в тестах.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Интерфейс источника текущего времени.
+type Clock interface {
+	Now() time.Time
+}
+
+// Структура системных часов, возвращающих время time.Now.
+type system struct{}
+
+func (system) Now() time.Time { return time.Now() }
+
+// System возвращает системные часы.
+func System() Clock { return system{} }
+
+// OrSystem возвращает c, если c не равен nil, иначе системные часы.
+func OrSystem(c Clock) Clock {
+	if c == nil {
+		return System()
+	}
+	return c
+}
+
+// Структура поддельных часов, время которых изменяется только явно.
+// Безопасна для конкурентного использования.
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake возвращает поддельные часы, показывающие время now.
+func NewFake(now time.Time) *Fake { return &Fake{now: now} }
+
+// Now возвращает текущее время часов.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Set устанавливает время часов now.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	f.now = now
+	f.mu.Unlock()
+}
+
+// Advance переводит часы вперед на d и возвращает новое время.
+func (f *Fake) Advance(d time.Duration) time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	return f.now
+}
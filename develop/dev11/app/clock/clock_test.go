@@ -0,0 +1,31 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2010, 5, 17, 9, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+	if got := f.Now(); !got.Equal(start) {
+		t.Errorf("Fake.Now() = %v, want %v", got, start)
+	}
+	if got, want := f.Advance(90*time.Minute), start.Add(90*time.Minute); !got.Equal(want) || !f.Now().Equal(want) {
+		t.Errorf("Fake.Advance() = %v, want %v", got, want)
+	}
+	f.Set(start)
+	if got := f.Now(); !got.Equal(start) {
+		t.Errorf("Fake.Now() after Set = %v, want %v", got, start)
+	}
+}
+
+func TestOrSystem(t *testing.T) {
+	f := NewFake(time.Time{})
+	if got := OrSystem(f); got != f {
+		t.Errorf("OrSystem() = %v, want %v", got, f)
+	}
+	if _, ok := OrSystem(nil).(system); !ok {
+		t.Errorf("OrSystem(nil) is not system clock")
+	}
+}
@@ -0,0 +1,388 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"dev11/app/client"
+	"dev11/app/clock"
+	"dev11/app/entity"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Структура запроса e2e-теста, записанного в журнал приложения.
+type e2eRequest struct {
+	method string
+	url    string
+	code   int
+}
+
+// Структура клиента e2e-теста, отмечающего маршруты, по которым прошли запросы.
+type e2eClient struct {
+	t       *testing.T
+	base    string
+	mu      sync.Mutex
+	routes  map[string]bool
+	history []e2eRequest
+}
+
+// do отправляет запрос method к target с телом body типа contentType, отмечает маршрут route
+// и возвращает тело ответа, проверяя код ответа want.
+func (c *e2eClient) do(route string, method string, target string, contentType string, body io.Reader, want int) *http.Response {
+	c.t.Helper()
+	r, err := http.NewRequest(method, c.base+target, body)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		c.t.Fatalf("%s %s error = %v", method, target, err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	c.mu.Lock()
+	c.routes[route] = true
+	c.history = append(c.history, e2eRequest{method, target, resp.StatusCode})
+	c.mu.Unlock()
+	if resp.StatusCode != want {
+		c.t.Fatalf("%s %s code = %v, want %v: %s", method, target, resp.StatusCode, want, data)
+	}
+	return resp
+}
+
+// get отправляет GET-запрос к path с параметрами query.
+func (c *e2eClient) get(path string, query url.Values, want int) *http.Response {
+	c.t.Helper()
+	return c.do("GET "+path, http.MethodGet, path+"?"+query.Encode(), "", nil, want)
+}
+
+// post отправляет POST-запрос к path с формой form.
+func (c *e2eClient) post(path string, form url.Values, want int) *http.Response {
+	c.t.Helper()
+	return c.do("POST "+path, http.MethodPost, path, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()), want)
+}
+
+// upload отправляет POST-запрос к path с полями fields и файлом file в формате multipart/form-data.
+func (c *e2eClient) upload(path string, fields url.Values, name string, file string, want int) *http.Response {
+	c.t.Helper()
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	for k, values := range fields {
+		for _, v := range values {
+			mw.WriteField(k, v)
+		}
+	}
+	fw, _ := mw.CreateFormFile("file", name)
+	io.WriteString(fw, file)
+	mw.Close()
+	return c.do("POST "+path, http.MethodPost, path, mw.FormDataContentType(), &b, want)
+}
+
+// decode декодирует поле result ответа resp в v.
+func decode[T any](t *testing.T, resp *http.Response) T {
+	t.Helper()
+	var body struct{ Result T }
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("%s: decode response: %v", resp.Request.URL, err)
+	}
+	return body.Result
+}
+
+// Каждый маршрут приложения проходит через реальные репозитории, сервисы и промежуточные слои.
+func TestE2E_Routes(t *testing.T) {
+	userID, otherID := "18310e71-4df6-42c0-adf4-1a280013dd08", "28310e71-4df6-42c0-adf4-1a280013dd08"
+	// 2010-05-17 — понедельник.
+	now := time.Date(2010, 5, 17, 8, 0, 0, 0, time.UTC)
+	srv := startTestServer(t, Config{DataDir: t.TempDir(), Clock: clock.NewFake(now)})
+	c := &e2eClient{t: t, base: srv.URL, routes: make(map[string]bool)}
+	user := url.Values{"user_id": {userID}}
+	with := func(values url.Values, kv ...string) url.Values {
+		result := url.Values{}
+		for k, v := range values {
+			result[k] = slices.Clone(v)
+		}
+		for i := 0; i+1 < len(kv); i += 2 {
+			result.Add(kv[i], kv[i+1])
+		}
+		return result
+	}
+
+	c.get("/healthz", nil, http.StatusOK)
+	c.get("/readyz", nil, http.StatusOK)
+	spec := decodeSpec(t, c.get("/openapi.json", nil, http.StatusOK))
+
+	// События.
+	created := decode[entity.Event](t, c.post("/create_event", with(user, "title", "Standup", "date", "2010-05-17T10:00:00Z"), http.StatusCreated))
+	event := with(user, "id", created.ID)
+	updated := decode[entity.Event](t, c.post("/update_event", with(event, "title", "Daily standup", "date", "2010-05-17T10:00:00Z"), http.StatusOK))
+	if updated.Title != "Daily standup" {
+		t.Errorf("POST /update_event title = %q", updated.Title)
+	}
+	if got := decode[entity.Event](t, c.get("/event", event, http.StatusOK)); got.Title != updated.Title {
+		t.Errorf("GET /event = %+v, want %+v", got, updated)
+	}
+	for _, tt := range []struct {
+		path  string
+		query url.Values
+	}{
+		{"/events_for_day", with(user, "day", "2010-05-17")},
+		{"/events_for_week", with(user, "week", "2010-05-17", "business_days", "true")},
+		{"/events_for_month", with(user, "month", "2010-05")},
+		{"/events_for_range", with(user, "start", "2010-05-17T00:00:00Z", "end", "2010-05-18T00:00:00Z")},
+		{"/search_events", with(user, "q", "daily")},
+		{"/upcoming_events", with(user, "limit", "5")},
+		{"/events_for_today", user},
+	} {
+		if got := decode[[]entity.Event](t, c.get(tt.path, tt.query, http.StatusOK)); len(got) != 1 || got[0].ID != created.ID {
+			t.Errorf("GET %s = %+v, want event %s", tt.path, got, created.ID)
+		}
+	}
+
+	// CSV.
+	resp := c.get("/export.csv", user, http.StatusOK)
+	if data, _ := io.ReadAll(resp.Body); !strings.Contains(string(data), "Daily standup") {
+		t.Errorf("GET /export.csv = %s, want the event", data)
+	}
+	report := decode[struct{ Events []entity.Event }](t, c.upload("/import_csv", user, "events.csv", "title,date\nRetro,2010-05-20 16:00\n", http.StatusOK))
+	if len(report.Events) != 1 || report.Events[0].ID == "" {
+		t.Errorf("POST /import_csv events = %+v, want 1 created event", report.Events)
+	}
+
+	// Вложения.
+	attachment := decode[entity.Attachment](t, c.upload("/create_event_attachment", with(user, "event_id", created.ID), "notes.txt", "agenda", http.StatusCreated))
+	attachments := with(user, "event_id", created.ID)
+	if got := decode[[]entity.Attachment](t, c.get("/event_attachments", attachments, http.StatusOK)); len(got) != 1 {
+		t.Errorf("GET /event_attachments = %+v, want 1 attachment", got)
+	}
+	resp = c.get("/event_attachment", with(attachments, "id", attachment.ID), http.StatusOK)
+	if data, _ := io.ReadAll(resp.Body); string(data) != "agenda" {
+		t.Errorf("GET /event_attachment = %q, want %q", data, "agenda")
+	}
+
+	// Повестка, поиск времени встречи и быстрое добавление.
+	resp = c.get("/agenda", with(user, "date", "2010-05-17"), http.StatusOK)
+	if data, _ := io.ReadAll(resp.Body); !strings.Contains(string(data), "Daily standup") {
+		t.Errorf("GET /agenda = %s, want the event", data)
+	}
+	slots := decode[[]entity.Slot](t, c.post("/find_slot", with(user, "user_id", otherID, "duration", "30m",
+		"start", "2010-05-17T09:00:00Z", "end", "2010-05-17T18:00:00Z", "limit", "1"), http.StatusOK))
+	if len(slots) != 1 {
+		t.Errorf("POST /find_slot = %+v, want 1 slot", slots)
+	}
+	quick := decode[struct{ Event entity.Event }](t, c.post("/quick_add", with(user, "text", "Review tomorrow 15:00"), http.StatusCreated))
+	if want := time.Date(2010, 5, 18, 15, 0, 0, 0, time.UTC); !quick.Event.Date.Equal(want) {
+		t.Errorf("POST /quick_add date = %v, want %v", quick.Event.Date, want)
+	}
+
+	// Шаблоны.
+	template := decode[entity.Template](t, c.post("/create_template", with(user, "title", "Standup", "duration", "15m", "tag", "work"), http.StatusCreated))
+	c.post("/update_template", with(user, "id", template.ID, "title", "Standup {{date}}", "duration", "15m"), http.StatusOK)
+	if got := decode[[]entity.Template](t, c.get("/templates", user, http.StatusOK)); len(got) != 1 || got[0].Title != "Standup {{date}}" {
+		t.Errorf("GET /templates = %+v, want the updated template", got)
+	}
+	fromTemplate := decode[struct{ Event entity.Event }](t, c.post("/create_event_from_template",
+		with(user, "template_id", template.ID, "date", "2010-05-19T10:00:00Z"), http.StatusCreated))
+	if fromTemplate.Event.Title != "Standup 2010-05-19" {
+		t.Errorf("POST /create_event_from_template title = %q, want %q", fromTemplate.Event.Title, "Standup 2010-05-19")
+	}
+	c.post("/delete_template", with(user, "id", template.ID), http.StatusNoContent)
+
+	// CalDAV.
+	calendar := "/dav/calendars/" + userID + "/default/"
+	ics := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nUID:client-uid\r\nDTSTART:20100521T160000Z\r\nSUMMARY:Demo\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	resp = c.do("PUT /dav/{path}", http.MethodPut, calendar+"new.ics", "text/calendar", strings.NewReader(ics), http.StatusCreated)
+	location := resp.Header.Get("Location")
+	resp = c.do("GET /dav/{path}", http.MethodGet, location, "", nil, http.StatusOK)
+	if data, _ := io.ReadAll(resp.Body); !strings.Contains(string(data), "SUMMARY:Demo") {
+		t.Errorf("GET %s = %s, want the event", location, data)
+	}
+	c.do("DELETE /dav/{path}", http.MethodDelete, location, "", nil, http.StatusNoContent)
+
+	c.post("/delete_event", event, http.StatusNoContent)
+	c.get("/event", event, http.StatusServiceUnavailable)
+
+	// Передача событий и удаление данных пользователя.
+	transferred := decode[[]entity.Event](t, c.post("/transfer_events", url.Values{
+		"from_user_id": {userID}, "to_user_id": {otherID}, "start": {"2010-05-19T00:00:00Z"},
+	}, http.StatusOK))
+	if len(transferred) != 2 || transferred[0].UserID != otherID {
+		t.Errorf("POST /transfer_events = %+v, want 2 events of %s", transferred, otherID)
+	}
+	other := url.Values{"user_id": {otherID}}
+	if got := decode[entity.UserData](t, c.get("/export_user", other, http.StatusOK)); len(got.Events) != 2 {
+		t.Errorf("GET /export_user events = %+v, want the transferred events", got.Events)
+	}
+	if got := decode[entity.UserData](t, c.post("/delete_user", other, http.StatusOK)); len(got.Events) != 2 {
+		t.Errorf("POST /delete_user events = %+v, want the transferred events", got.Events)
+	}
+	if got := decode[entity.UserData](t, c.get("/export_user", other, http.StatusOK)); len(got.Events) != 0 {
+		t.Errorf("GET /export_user after delete events = %+v, want none", got.Events)
+	}
+
+	for _, route := range spec {
+		if !c.routes[route] {
+			t.Errorf("route %q is not covered by the e2e test", route)
+		}
+	}
+
+	// Каждый запрос записан в журнал с кодом ответа, а остановка завершена.
+	if err := srv.Shutdown(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	logged := loggedRequests(t, srv.Logs.String())
+	for _, r := range c.history {
+		if !slices.Contains(logged, r) {
+			t.Errorf("request %s %s with code %d is missing in logs", r.method, r.url, r.code)
+		}
+	}
+	if !strings.Contains(srv.Logs.String(), "shutdown completed") {
+		t.Errorf("shutdown is missing in logs:\n%s", srv.Logs)
+	}
+}
+
+// decodeSpec возвращает операции спецификации OpenAPI из ответа resp в виде "GET /path".
+func decodeSpec(t *testing.T, resp *http.Response) []string {
+	t.Helper()
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+	var routes []string
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			if method != "parameters" && method != "description" {
+				routes = append(routes, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+	slices.Sort(routes)
+	return routes
+}
+
+// loggedRequests возвращает запросы, записанные LoggerMiddleware в журнал logs.
+func loggedRequests(t *testing.T, logs string) []e2eRequest {
+	t.Helper()
+	var requests []e2eRequest
+	dec := json.NewDecoder(strings.NewReader(logs))
+	for dec.More() {
+		var record struct {
+			Method string `json:"method"`
+			URL    string `json:"url"`
+			Code   int    `json:"code"`
+		}
+		if err := dec.Decode(&record); err != nil {
+			t.Fatalf("invalid log record: %v", err)
+		}
+		if record.Method != "" {
+			requests = append(requests, e2eRequest{record.Method, record.URL, record.Code})
+		}
+	}
+	return requests
+}
+
+// Конкурентные создание, обновление, чтение и удаление событий через HTTP-клиент
+// не теряют и не повреждают события. Тест предназначен для запуска с -race.
+func TestE2E_ConcurrentLoad(t *testing.T) {
+	const (
+		workers = 8
+		events  = 20
+	)
+	srv := startTestServer(t, Config{DataDir: t.TempDir()})
+	c, err := client.New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	date := time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC)
+	shared := "38310e71-4df6-42c0-adf4-1a280013dd08"
+
+	// Все обработчики одновременно обновляют общее событие.
+	sharedEvent, err := c.Create(ctx, entity.Event{Title: "shared", Date: date, UserID: shared})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*events)
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Половина обработчиков работает с событиями общего пользователя.
+			userID := shared
+			if w%2 == 1 {
+				userID = fmt.Sprintf("%08d-4df6-42c0-adf4-1a280013dd08", w)
+			}
+			for i := range events {
+				title := fmt.Sprintf("worker %d event %d", w, i)
+				event, err := c.Create(ctx, entity.Event{Title: title, Date: date.Add(time.Duration(i) * time.Hour), UserID: userID})
+				if err != nil {
+					errs <- err
+					return
+				}
+				event.Description = "updated"
+				if _, err := c.Update(ctx, event); err != nil {
+					errs <- err
+					return
+				}
+				if got, err := c.GetByID(ctx, userID, event.ID); err != nil || got.Title != title || got.Description != "updated" {
+					errs <- fmt.Errorf("GetByID(%s) = %+v, %v", event.ID, got, err)
+					return
+				}
+				// Нечетные события удаляются.
+				if i%2 == 1 {
+					if err := c.Delete(ctx, userID, event.ID); err != nil {
+						errs <- err
+						return
+					}
+				}
+				update := sharedEvent
+				update.Description = title
+				if _, err := c.Update(ctx, update); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	end := date.AddDate(0, 0, 2)
+	got, err := c.GetForRange(ctx, shared, date, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Четные события каждого обработчика общего пользователя и общее событие.
+	if want := workers/2*events/2 + 1; len(got) != want {
+		t.Errorf("GetForRange(shared) = %d events, want %d", len(got), want)
+	}
+	for w := 1; w < workers; w += 2 {
+		userID := fmt.Sprintf("%08d-4df6-42c0-adf4-1a280013dd08", w)
+		if got, err := c.GetForRange(ctx, userID, date, end); err != nil || len(got) != events/2 {
+			t.Errorf("GetForRange(%s) = %d events, %v, want %d", userID, len(got), err, events/2)
+		}
+	}
+	if got, err := c.GetByID(ctx, shared, sharedEvent.ID); err != nil || !strings.HasPrefix(got.Description, "worker ") {
+		t.Errorf("GetByID(shared event) = %+v, %v", got, err)
+	}
+}
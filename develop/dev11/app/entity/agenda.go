@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// Ошибка неверного периода повестки.
+var ErrPeriodInvalid = errors.New("period is invalid")
+
+// Периоды повестки.
+const (
+	PeriodDay  = "day"
+	PeriodWeek = "week"
+)
+
+// Структура повестки (сводки событий) пользователя за период [Start, End).
+type Agenda struct {
+	UserID    string      `json:"user_id"`
+	Period    string      `json:"period"`
+	Start     time.Time   `json:"start"`
+	End       time.Time   `json:"end"`
+	Days      []AgendaDay `json:"days"`
+	Conflicts []Conflict  `json:"conflicts"`
+}
+
+// Структура дня повестки: события дня, свободные промежутки рабочего времени
+// и название праздника, если день праздничный.
+type AgendaDay struct {
+	Date    time.Time `json:"date"`
+	Holiday string    `json:"holiday,omitempty"`
+	Events  []Event   `json:"events"`
+	Gaps    []Gap     `json:"gaps"`
+}
+
+// Структура конфликта: пересекающиеся по времени события и общий интервал [Start, End) их проведения.
+type Conflict struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Events []Event   `json:"events"`
+}
+
+// Структура свободного промежутка времени [Start, End).
+type Gap struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Duration возвращает длительность свободного промежутка.
+func (g Gap) Duration() time.Duration { return g.End.Sub(g.Start) }
@@ -0,0 +1,11 @@
+package entity
+
+// Структура сущности "вложение" - файла, прикрепленного к событию.
+type Attachment struct {
+	ID          string `json:"id"`
+	EventID     string `json:"event_id"`
+	UserID      string `json:"user_id"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,21 +15,32 @@ import (
 
 // Ошибки валидации сущностей.
 var (
-	ErrTitleEmpty = errors.New("title is empty")
-	ErrIdInvalid  = errors.New("id is invalid")
+	ErrTitleEmpty    = errors.New("title is empty")
+	ErrIdInvalid     = errors.New("id is invalid")
+	ErrFormatInvalid = errors.New("format is invalid")
+)
+
+// Форматы описания события. Пустой формат означает простой текст.
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
 )
 
 var (
 	EmptyEvent = Event{}
 )
 
-// Структура сущности "событие".
+// Структура сущности "событие". Описание в формате FormatMarkdown
+// преобразуется в HTML только при выводе. UpdatedAt задается бизнес-логикой
+// при создании и обновлении события и равен нулю у событий, сохраненных до его появления.
 type Event struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Date        time.Time `json:"date"`
-	UserID      string    `json:"user_id"`
+	ID                string    `json:"id"`
+	Title             string    `json:"title"`
+	Description       string    `json:"description"`
+	DescriptionFormat string    `json:"description_format,omitempty"`
+	Date              time.Time `json:"date"`
+	UserID            string    `json:"user_id"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 // Encode сериализует Event в json и записывает в w.
@@ -53,6 +65,12 @@ func (e *Event) ValidateCreate() error {
 		return fmt.Errorf("user_id: %w", ErrIdInvalid)
 	}
 
+	switch e.DescriptionFormat {
+	case "", FormatText, FormatMarkdown:
+	default:
+		return fmt.Errorf("description_format: %w", ErrFormatInvalid)
+	}
+
 	return nil
 }
 
@@ -65,3 +83,25 @@ func (e *Event) ValidateUpdate() error {
 
 	return e.ValidateCreate()
 }
+
+// Структура фильтра событий пользователя. Пустые поля не ограничивают выборку.
+type EventFilter struct {
+	// IDs ограничивает выборку событиями с заданными идентификаторами.
+	IDs []string
+	// DateStart и DateEnd ограничивают выборку диапазоном дат [DateStart, DateEnd].
+	DateStart time.Time
+	DateEnd   time.Time
+}
+
+// Match сообщает, соответствует ли событие event фильтру.
+func (f EventFilter) Match(event Event) bool {
+	switch {
+	case len(f.IDs) > 0 && !slices.Contains(f.IDs, event.ID):
+		return false
+	case !f.DateStart.IsZero() && event.Date.Before(f.DateStart):
+		return false
+	case !f.DateEnd.IsZero() && event.Date.After(f.DateEnd):
+		return false
+	}
+	return true
+}
@@ -14,10 +14,10 @@ import (
 
 func TestEvent_Encode(t *testing.T) {
 	date, _ := time.Parse(time.DateOnly, "2010-05-20")
-	e := Event{Title: "event", Date: date}
+	e := Event{Title: "event", Date: date, UpdatedAt: date.Add(time.Hour)}
 
 	var w bytes.Buffer
-	wantW := "{\"id\":\"\",\"title\":\"event\",\"description\":\"\",\"date\":\"2010-05-20T00:00:00Z\",\"user_id\":\"\"}\n"
+	wantW := "{\"id\":\"\",\"title\":\"event\",\"description\":\"\",\"date\":\"2010-05-20T00:00:00Z\",\"user_id\":\"\",\"updated_at\":\"2010-05-20T01:00:00Z\"}\n"
 
 	e.Encode(&w)
 	if gotW := w.String(); gotW != wantW {
@@ -48,6 +48,8 @@ func TestEvent_ValidateCreate(t *testing.T) {
 		{"ValidEvent", &Event{Title: "event", UserID: id}, false},
 		{"InvalidTitle", &Event{UserID: id}, true},
 		{"InvalidUserID", &Event{Title: "event"}, true},
+		{"MarkdownFormat", &Event{Title: "event", DescriptionFormat: FormatMarkdown, UserID: id}, false},
+		{"InvalidFormat", &Event{Title: "event", DescriptionFormat: "html", UserID: id}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -78,3 +80,27 @@ func TestEvent_ValidateUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestEventFilter_Match(t *testing.T) {
+	date := time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC)
+	event := Event{ID: "1", Date: date}
+	tests := []struct {
+		name   string
+		filter EventFilter
+		want   bool
+	}{
+		{"Empty", EventFilter{}, true},
+		{"ID", EventFilter{IDs: []string{"2", "1"}}, true},
+		{"OtherID", EventFilter{IDs: []string{"2"}}, false},
+		{"Range", EventFilter{DateStart: date, DateEnd: date}, true},
+		{"Before", EventFilter{DateStart: date.Add(time.Second)}, false},
+		{"After", EventFilter{DateEnd: date.Add(-time.Second)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(event); got != tt.want {
+				t.Errorf("EventFilter.Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package entity
+
+import "time"
+
+// Структура свободного для всех участников встречи промежутка времени [Start, End).
+type Slot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ошибки валидации шаблонов событий.
+var (
+	ErrDurationInvalid = errors.New("duration is invalid")
+	ErrAttendeeInvalid = errors.New("attendee is invalid")
+	ErrReminderInvalid = errors.New("reminder is invalid")
+)
+
+// Тип длительности, сериализуемой в формате time.Duration.String, например 1h30m0s.
+type Duration time.Duration
+
+// MarshalText возвращает длительность в формате time.Duration.String.
+func (d Duration) MarshalText() ([]byte, error) { return []byte(time.Duration(d).String()), nil }
+
+// UnmarshalText разбирает длительность в формате time.ParseDuration.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Структура сущности "шаблон события" для быстрого создания повторяющихся встреч.
+// Заголовок и описание могут содержать подстановки {{date}}, {{time}} и {{weekday}},
+// которые заменяются датой создаваемого события. Длительность, теги, участники
+// и напоминания (за сколько до начала) описывают встречу и не сохраняются в событии.
+type Template struct {
+	ID                string     `json:"id"`
+	UserID            string     `json:"user_id"`
+	Title             string     `json:"title"`
+	Description       string     `json:"description"`
+	DescriptionFormat string     `json:"description_format,omitempty"`
+	Duration          Duration   `json:"duration,omitempty"`
+	Tags              []string   `json:"tags,omitempty"`
+	Attendees         []string   `json:"attendees,omitempty"`
+	Reminders         []Duration `json:"reminders,omitempty"`
+}
+
+// ValidateCreate валидирует поля сущности "шаблон события" при создании.
+func (t *Template) ValidateCreate() error {
+	event := Event{Title: t.Title, UserID: t.UserID, DescriptionFormat: t.DescriptionFormat}
+	if err := event.ValidateCreate(); err != nil {
+		return err
+	}
+
+	if t.Duration < 0 {
+		return fmt.Errorf("duration: %w", ErrDurationInvalid)
+	}
+	for _, attendee := range t.Attendees {
+		if _, err := mail.ParseAddress(attendee); err != nil {
+			return fmt.Errorf("attendees: %w: %q", ErrAttendeeInvalid, attendee)
+		}
+	}
+	for _, reminder := range t.Reminders {
+		if reminder < 0 {
+			return fmt.Errorf("reminders: %w", ErrReminderInvalid)
+		}
+	}
+
+	return nil
+}
+
+// ValidateUpdate валидирует поля сущности "шаблон события" при обновлении.
+func (t *Template) ValidateUpdate() error {
+	if err := uuid.Validate(t.ID); err != nil {
+		return fmt.Errorf("id: %w", ErrIdInvalid)
+	}
+
+	return t.ValidateCreate()
+}
+
+// Event возвращает событие пользователя шаблона в момент date с заголовком и описанием,
+// в которых подстановки заменены датой (2006-01-02), временем (15:04) и днем недели date в UTC.
+func (t Template) Event(date time.Time) Event {
+	date = date.UTC()
+	r := strings.NewReplacer(
+		"{{date}}", date.Format(time.DateOnly),
+		"{{time}}", date.Format("15:04"),
+		"{{weekday}}", date.Weekday().String(),
+	)
+	return Event{
+		Title:             r.Replace(t.Title),
+		Description:       r.Replace(t.Description),
+		DescriptionFormat: t.DescriptionFormat,
+		Date:              date,
+		UserID:            t.UserID,
+	}
+}
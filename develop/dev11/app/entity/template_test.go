@@ -0,0 +1,67 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTemplate_ValidateCreate(t *testing.T) {
+	id := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	tests := []struct {
+		name    string
+		t       *Template
+		wantErr error
+	}{
+		{"ValidTemplate", &Template{Title: "standup", UserID: id, Duration: Duration(15 * time.Minute),
+			Attendees: []string{"Team <team@example.com>"}, Reminders: []Duration{0, Duration(time.Hour)}}, nil},
+		{"InvalidTitle", &Template{UserID: id}, ErrTitleEmpty},
+		{"InvalidUserID", &Template{Title: "standup"}, ErrIdInvalid},
+		{"InvalidDuration", &Template{Title: "standup", UserID: id, Duration: -1}, ErrDurationInvalid},
+		{"InvalidAttendee", &Template{Title: "standup", UserID: id, Attendees: []string{"team"}}, ErrAttendeeInvalid},
+		{"InvalidReminder", &Template{Title: "standup", UserID: id, Reminders: []Duration{-1}}, ErrReminderInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.t.ValidateCreate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Template.ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTemplate_Event(t *testing.T) {
+	id := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	date := time.Date(2010, 5, 17, 13, 30, 0, 0, time.FixedZone("MSK", 3*60*60))
+	template := Template{
+		ID:          id,
+		UserID:      id,
+		Title:       "Standup {{date}} {{time}}",
+		Description: "{{weekday}} notes, {{unknown}}",
+		Duration:    Duration(15 * time.Minute),
+	}
+
+	want := Event{Title: "Standup 2010-05-17 10:30", Description: "Monday notes, {{unknown}}", Date: date.UTC(), UserID: id}
+	if got := template.Event(date); !reflect.DeepEqual(got, want) {
+		t.Errorf("Template.Event() = %v, want %v", got, want)
+	}
+}
+
+func TestDuration_JSON(t *testing.T) {
+	data, err := json.Marshal(Template{Duration: Duration(90 * time.Minute), Reminders: []Duration{Duration(time.Minute)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Template
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal(%s) error = %v", data, err)
+	}
+	if got.Duration != Duration(90*time.Minute) || len(got.Reminders) != 1 || got.Reminders[0] != Duration(time.Minute) {
+		t.Errorf("json round trip = %+v, data %s", got, data)
+	}
+	if err := json.Unmarshal([]byte(`{"duration":"soon"}`), &got); err == nil {
+		t.Errorf("json.Unmarshal() invalid duration error = nil")
+	}
+}
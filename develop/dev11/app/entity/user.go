@@ -0,0 +1,10 @@
+package entity
+
+// Структура всех данных пользователя для экспорта: события, шаблоны событий
+// и метаданные вложений событий.
+type UserData struct {
+	UserID      string       `json:"user_id"`
+	Events      []Event      `json:"events"`
+	Templates   []Template   `json:"templates"`
+	Attachments []Attachment `json:"attachments"`
+}
@@ -0,0 +1,263 @@
+// Пакет eventcsv читает и записывает события в формате CSV, в котором их ведут в электронных
+// таблицах. Колонки сопоставляются полям событий по заголовку, даты разбираются по списку форматов.
+// У событий есть только время начала и нет тегов, поэтому колонки end и tags при чтении
+// проверяются, но не сохраняются в событиях, а строки с непустыми значениями в них получают
+// предупреждения.
+package eventcsv
+
+import (
+	"dev11/app/entity"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Поля событий, которые сопоставляются колонкам CSV.
+const (
+	FieldID          = "id"
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldDate        = "date"
+	FieldEnd         = "end"
+	FieldTags        = "tags"
+)
+
+// Поля, которые читаются из CSV.
+var readFields = []string{FieldTitle, FieldDescription, FieldDate, FieldEnd, FieldTags}
+
+// Поля, которые записываются в CSV.
+var writeFields = []string{FieldID, FieldTitle, FieldDescription, FieldDate}
+
+// Ошибки чтения CSV.
+var (
+	ErrInvalidDelimiter = errors.New("invalid delimiter")
+	ErrUnknownField     = errors.New("unknown field")
+	ErrMissingColumn    = errors.New("column is missing")
+	ErrDuplicateColumn  = errors.New("column is duplicated")
+	ErrInvalidDate      = errors.New("date does not match any format")
+	ErrEndBeforeDate    = errors.New("end is before date")
+)
+
+// Форматы дат по умолчанию.
+var DefaultDateFormats = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02T15:04", "02.01.2006 15:04", time.DateOnly}
+
+// Параметры чтения и записи CSV. Нулевые значения заменяются значениями по умолчанию.
+type Options struct {
+	// Delimiter задает разделитель колонок, по умолчанию запятая.
+	Delimiter rune
+	// DateFormats задает форматы дат в нотации пакета time. Даты читаются по первому подходящему
+	// формату и записываются в первом формате. По умолчанию DefaultDateFormats.
+	DateFormats []string
+	// Location задает часовой пояс дат без смещения, по умолчанию UTC.
+	Location *time.Location
+	// Columns задает заголовки колонок полей, по умолчанию заголовок совпадает с названием поля.
+	Columns map[string]string
+}
+
+// withDefaults возвращает параметры с подставленными значениями по умолчанию.
+func (o Options) withDefaults() (Options, error) {
+	if o.Delimiter == 0 {
+		o.Delimiter = ','
+	}
+	if o.Delimiter == '"' || o.Delimiter == '\r' || o.Delimiter == '\n' || o.Delimiter == utf8.RuneError || !utf8.ValidRune(o.Delimiter) {
+		return o, fmt.Errorf("%w: %q", ErrInvalidDelimiter, o.Delimiter)
+	}
+	if len(o.DateFormats) == 0 {
+		o.DateFormats = DefaultDateFormats
+	}
+	if o.Location == nil {
+		o.Location = time.UTC
+	}
+	for field := range o.Columns {
+		if !slices.Contains(readFields, field) && !slices.Contains(writeFields, field) {
+			return o, fmt.Errorf("%w: %q", ErrUnknownField, field)
+		}
+	}
+	return o, nil
+}
+
+// Validate проверяет параметры, не записывая и не читая данные.
+func (o Options) Validate() error {
+	_, err := o.withDefaults()
+	return err
+}
+
+// column возвращает заголовок колонки поля field.
+func (o Options) column(field string) string {
+	if column, ok := o.Columns[field]; ok {
+		return column
+	}
+	return field
+}
+
+// parseDate разбирает дату s по первому подходящему формату.
+func (o Options) parseDate(s string) (time.Time, error) {
+	for _, layout := range o.DateFormats {
+		if t, err := time.ParseInLocation(layout, s, o.Location); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidDate, s)
+}
+
+// Структура прочитанной строки CSV: событие без пользователя или ошибка разбора строки.
+type Row struct {
+	// Line задает номер строки файла, начиная с 1 для заголовка.
+	Line  int
+	Event entity.Event
+	// Warnings содержит предупреждения о значениях строки, не сохраненных в событии.
+	Warnings []string
+	Err      error
+}
+
+// Read читает события из r. Первая строка содержит заголовки колонок, колонки title и date
+// обязательны, остальные колонки и колонки неизвестных полей могут отсутствовать.
+// Ошибки отдельных строк возвращаются в Row.Err, ошибка возвращается, если файл
+// нельзя прочитать или в нем нет обязательных колонок.
+func Read(r io.Reader, opts Options) ([]Row, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(r)
+	cr.Comma = opts.Delimiter
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	if len(header) > 0 {
+		// Электронные таблицы сохраняют UTF-8 с BOM.
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	index := make(map[string]int)
+	for _, field := range readFields {
+		column := opts.column(field)
+		i := slices.Index(header, column)
+		if i < 0 {
+			continue
+		}
+		if slices.Index(header[i+1:], column) >= 0 {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateColumn, column)
+		}
+		index[field] = i
+	}
+	for _, field := range []string{FieldTitle, FieldDate} {
+		if _, ok := index[field]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrMissingColumn, opts.column(field))
+		}
+	}
+
+	var rows []Row
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, Row{Line: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isEmpty(record) {
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		event, warnings, err := parseRecord(record, index, opts)
+		rows = append(rows, Row{Line: line, Event: event, Warnings: warnings, Err: err})
+	}
+}
+
+// parseRecord возвращает событие из записи record с колонками полей index
+// и предупреждения о значениях, не сохраненных в событии.
+func parseRecord(record []string, index map[string]int, opts Options) (entity.Event, []string, error) {
+	value := func(field string) string {
+		if i, ok := index[field]; ok && i < len(record) {
+			return unescapeFormula(strings.TrimSpace(record[i]))
+		}
+		return ""
+	}
+
+	event := entity.Event{Title: value(FieldTitle), Description: value(FieldDescription)}
+	var err error
+	if event.Date, err = opts.parseDate(value(FieldDate)); err != nil {
+		return event, nil, fmt.Errorf("%s: %w", opts.column(FieldDate), err)
+	}
+	var warnings []string
+	if end := value(FieldEnd); end != "" {
+		endDate, err := opts.parseDate(end)
+		if err != nil {
+			return event, nil, fmt.Errorf("%s: %w", opts.column(FieldEnd), err)
+		}
+		if endDate.Before(event.Date) {
+			return event, nil, fmt.Errorf("%s: %w", opts.column(FieldEnd), ErrEndBeforeDate)
+		}
+		warnings = append(warnings, opts.column(FieldEnd)+": not stored, events have only a start date")
+	}
+	if value(FieldTags) != "" {
+		warnings = append(warnings, opts.column(FieldTags)+": not stored, events have no tags")
+	}
+	return event, warnings, nil
+}
+
+// isEmpty сообщает, пуста ли запись record, например строка из одних разделителей.
+func isEmpty(record []string) bool {
+	return !slices.ContainsFunc(record, func(s string) bool { return strings.TrimSpace(s) != "" })
+}
+
+// Write записывает события events в w с заголовком в первой строке.
+func Write(w io.Writer, events []entity.Event, opts Options) error {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.Delimiter
+	header := make([]string, len(writeFields))
+	for i, field := range writeFields {
+		header[i] = opts.column(field)
+	}
+	cw.Write(header)
+	for _, event := range events {
+		cw.Write([]string{
+			event.ID,
+			escapeFormula(event.Title),
+			escapeFormula(event.Description),
+			event.Date.In(opts.Location).Format(opts.DateFormats[0]),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Начальные символы, с которыми электронные таблицы считают значение формулой.
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeFormula экранирует значение s апострофом, чтобы электронная таблица
+// не выполнила его как формулу.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune(formulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// unescapeFormula удаляет апостроф, добавленный escapeFormula.
+func unescapeFormula(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
@@ -0,0 +1,106 @@
+package eventcsv
+
+import (
+	"bytes"
+	"dev11/app/entity"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRead(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	date := time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		data    string
+		opts    Options
+		want    []Row
+		wantErr error
+	}{
+		{"Default", "\ufefftitle,description,date,id\n" +
+			"Standup,daily,2010-05-17T10:00:00Z,ignored\n" +
+			"\"Review, final\",,2010-05-17 10:00\n" +
+			",,\n" +
+			"'=SUM(A1),,2010-05-17\n", Options{}, []Row{
+			{Line: 2, Event: entity.Event{Title: "Standup", Description: "daily", Date: date}},
+			{Line: 3, Event: entity.Event{Title: "Review, final", Date: date}},
+			{Line: 5, Event: entity.Event{Title: "=SUM(A1)", Date: date.Truncate(24 * time.Hour)}},
+		}, nil},
+		{"Mapping", "Событие;Начало;Конец;Теги\nПланерка;17.05.2010 13:00;17.05.2010 14:00;work\n",
+			Options{Delimiter: ';', DateFormats: []string{"02.01.2006 15:04"}, Location: moscow,
+				Columns: map[string]string{FieldTitle: "Событие", FieldDate: "Начало", FieldEnd: "Конец", FieldTags: "Теги"}},
+			[]Row{{Line: 2, Event: entity.Event{Title: "Планерка", Date: date},
+				Warnings: []string{"Конец: not stored, events have only a start date", "Теги: not stored, events have no tags"}}}, nil},
+		{"RowErrors", "title,date,end\nA,tomorrow,\nB,2010-05-17,2010-05-16\nC,\"2010-05-17\n", Options{}, []Row{
+			{Line: 2, Event: entity.Event{Title: "A"}, Err: ErrInvalidDate},
+			{Line: 3, Event: entity.Event{Title: "B", Date: date.Truncate(24 * time.Hour)}, Err: ErrEndBeforeDate},
+			{Line: 4, Err: errors.New("extraneous or missing \" in quoted-field")},
+		}, nil},
+		{"MissingColumn", "title,start\nA,2010-05-17\n", Options{}, nil, ErrMissingColumn},
+		{"DuplicateColumn", "title,date,date\nA,2010-05-17,2010-05-18\n", Options{}, nil, ErrDuplicateColumn},
+		{"UnknownField", "title,date\n", Options{Columns: map[string]string{"owner": "Owner"}}, nil, ErrUnknownField},
+		{"InvalidDelimiter", "title,date\n", Options{Delimiter: '"'}, nil, ErrInvalidDelimiter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(strings.NewReader(tt.data), tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Read() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Read() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].Line != tt.want[i].Line || !reflect.DeepEqual(got[i].Event, tt.want[i].Event) ||
+					!reflect.DeepEqual(got[i].Warnings, tt.want[i].Warnings) {
+					t.Errorf("Read() row %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+				if (got[i].Err == nil) != (tt.want[i].Err == nil) ||
+					(got[i].Err != nil && !errors.Is(got[i].Err, tt.want[i].Err) && got[i].Err.Error() != tt.want[i].Err.Error()) {
+					t.Errorf("Read() row %d error = %v, want %v", i, got[i].Err, tt.want[i].Err)
+				}
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	events := []entity.Event{
+		{ID: "1", Title: "Standup", Description: "daily; short", Date: time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC)},
+		{ID: "2", Title: "=cmd|' /C calc'!A0", Date: time.Date(2010, 5, 18, 21, 30, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"Default", Options{}, "id,title,description,date\n" +
+			"1,Standup,daily; short,2010-05-17T10:00:00Z\n" +
+			"2,'=cmd|' /C calc'!A0,,2010-05-18T21:30:00Z\n"},
+		{"Options", Options{Delimiter: ';', DateFormats: []string{"02.01.2006 15:04"}, Location: time.FixedZone("MSK", 3*60*60),
+			Columns: map[string]string{FieldTitle: "Событие", FieldDate: "Начало"}}, "id;Событие;description;Начало\n" +
+			"1;Standup;\"daily; short\";17.05.2010 13:00\n" +
+			"2;'=cmd|' /C calc'!A0;;19.05.2010 00:30\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := Write(&b, events, tt.opts); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("Write() = %q, want %q", got, tt.want)
+			}
+
+			rows, err := Read(&b, tt.opts)
+			if err != nil || len(rows) != len(events) || rows[1].Event.Title != events[1].Title {
+				t.Errorf("Read(Write()) = %v, %v", rows, err)
+			}
+		})
+	}
+}
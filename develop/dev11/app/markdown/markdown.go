@@ -0,0 +1,28 @@
+// Пакет markdown преобразует текст в формате Markdown в безопасный HTML.
+package markdown
+
+import (
+	"bytes"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	// Конвертер Markdown без поддержки произвольного HTML во входном тексте.
+	converter = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	// Политика очистки HTML, допускающая только форматирование пользовательского текста.
+	policy = bluemonday.UGCPolicy().RequireNoFollowOnLinks(true).AddTargetBlankToFullyQualifiedLinks(true)
+)
+
+// ToHTML возвращает безопасный HTML, полученный из текста src в формате Markdown.
+// Встроенный HTML, скрипты и небезопасные ссылки удаляются.
+func ToHTML(src string) string {
+	var b bytes.Buffer
+	if err := converter.Convert([]byte(src), &b); err != nil {
+		// Конвертер не возвращает ошибок при записи в bytes.Buffer.
+		return policy.Sanitize(src)
+	}
+	return policy.Sanitize(b.String())
+}
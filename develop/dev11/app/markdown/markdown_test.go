@@ -0,0 +1,37 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    []string
+		notWant []string
+	}{
+		{"Emphasis", "**bold** and *italic*", []string{"<strong>bold</strong>", "<em>italic</em>"}, nil},
+		{"List", "- one\n- two", []string{"<ul>", "<li>one</li>"}, nil},
+		{"Link", "[site](https://example.com)", []string{`href="https://example.com"`, `rel="nofollow noopener"`}, nil},
+		{"Script", "<script>alert(1)</script>\n\ntext", []string{"text"}, []string{"<script", "alert(1)</script>"}},
+		{"JavaScriptLink", "[x](javascript:alert(1))", nil, []string{"javascript:"}},
+		{"EventHandler", `<img src="x" onerror="alert(1)">`, nil, []string{"onerror"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToHTML(tt.src)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("ToHTML() = %q, want %q", got, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("ToHTML() = %q, must not contain %q", got, notWant)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,476 @@
+// Пакет quickadd разбирает описание события на естественном языке (английском или русском),
+// например "Standup tomorrow 10:00 for 15m" или "Планерка каждый понедельник в 9:30".
+// Разбор детерминирован относительно переданного текущего времени.
+package quickadd
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Время начала события, если в тексте не указано время.
+const DefaultHour = 9
+
+// Ошибки разбора.
+var (
+	ErrEmptyTitle          = errors.New("event title is empty")
+	ErrDuplicateDate       = errors.New("date is specified more than once")
+	ErrDuplicateTime       = errors.New("time is specified more than once")
+	ErrInvalidDate         = errors.New("date is invalid")
+	ErrInvalidTime         = errors.New("time is invalid")
+	ErrDuplicateRecurrence = errors.New("recurrence is specified more than once")
+	ErrDuplicateDuration   = errors.New("duration is specified more than once")
+)
+
+// Частоты повторения события.
+const (
+	Daily  = "daily"
+	Weekly = "weekly"
+)
+
+// Структура длительности, сериализуемой в виде строки, например "1h30m0s".
+type Duration time.Duration
+
+// MarshalText возвращает длительность в формате time.Duration.String.
+func (d Duration) MarshalText() ([]byte, error) { return []byte(time.Duration(d).String()), nil }
+
+// Структура правила повторения события.
+type Recurrence struct {
+	Frequency string `json:"frequency"`
+	// Weekday задается для еженедельного повторения.
+	Weekday string `json:"weekday,omitempty"`
+}
+
+// Структура результата разбора: интерпретация текста.
+type Result struct {
+	Title      string      `json:"title"`
+	Date       time.Time   `json:"date"`
+	Duration   Duration    `json:"duration,omitempty"`
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+}
+
+// Структура состояния разбора текста.
+type parser struct {
+	now    time.Time
+	tokens []string
+	// lower содержит токены в нижнем регистре без знаков препинания по краям.
+	lower    []string
+	consumed []bool
+
+	date     *time.Time
+	clock    *time.Duration
+	duration time.Duration
+	repeat   *Recurrence
+	// repeatDate означает, что дата задана днем недели правила повторения.
+	repeatDate bool
+	// exact задает дату и время целиком, например "через 2 часа".
+	exact *time.Time
+}
+
+// Parse разбирает текст text относительно текущего времени now. Даты и время
+// интерпретируются в часовом поясе now.
+func Parse(text string, now time.Time) (Result, error) {
+	p := &parser{now: now, tokens: strings.Fields(text)}
+	p.lower = make([]string, len(p.tokens))
+	p.consumed = make([]bool, len(p.tokens))
+	for i, token := range p.tokens {
+		p.lower[i] = strings.ToLower(strings.TrimFunc(token, func(r rune) bool { return unicode.IsPunct(r) && r != ':' }))
+	}
+
+	for i := 0; i < len(p.tokens); {
+		n, err := p.match(i)
+		if err != nil {
+			return Result{}, err
+		}
+		if n == 0 {
+			i++
+			continue
+		}
+		for j := i; j < i+n; j++ {
+			p.consumed[j] = true
+		}
+		i += n
+	}
+
+	var title []string
+	for i, token := range p.tokens {
+		if !p.consumed[i] {
+			title = append(title, token)
+		}
+	}
+	result := Result{
+		Title:      strings.TrimRight(strings.Join(title, " "), ",;:-–— "),
+		Duration:   Duration(p.duration),
+		Recurrence: p.repeat,
+	}
+	if result.Title == "" {
+		return Result{}, ErrEmptyTitle
+	}
+	result.Date = p.resolve()
+	return result, nil
+}
+
+// resolve возвращает дату и время начала события.
+func (p *parser) resolve() time.Time {
+	if p.exact != nil {
+		return *p.exact
+	}
+
+	clock := time.Duration(DefaultHour) * time.Hour
+	if p.clock != nil {
+		clock = *p.clock
+	}
+	date := startOfDay(p.now)
+	if p.date != nil {
+		date = *p.date
+	}
+	at := date.Add(clock)
+
+	// Прошедшее сегодня время без явной даты означает следующий подходящий день.
+	if (p.date == nil || p.repeatDate) && at.Before(p.now) {
+		step := 1
+		if p.repeat != nil && p.repeat.Frequency == Weekly {
+			step = 7
+		}
+		at = addDays(date, step).Add(clock)
+	}
+	return at
+}
+
+// startOfDay возвращает начало дня t в его часовом поясе.
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// addDays возвращает начало дня date, сдвинутого на n календарных дней.
+func addDays(date time.Time, n int) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day()+n, 0, 0, 0, 0, date.Location())
+}
+
+// token возвращает токен i в нижнем регистре или пустую строку за пределами текста.
+func (p *parser) token(i int) string {
+	if i < 0 || i >= len(p.lower) {
+		return ""
+	}
+	return p.lower[i]
+}
+
+// setDate задает дату события.
+func (p *parser) setDate(date time.Time) error {
+	if p.date != nil || p.exact != nil {
+		return ErrDuplicateDate
+	}
+	p.date = &date
+	return nil
+}
+
+// setClock задает время события.
+func (p *parser) setClock(clock time.Duration) error {
+	if p.clock != nil || p.exact != nil {
+		return ErrDuplicateTime
+	}
+	p.clock = &clock
+	return nil
+}
+
+// Предлоги, которые относятся к следующему за ними выражению даты, времени или длительности.
+var prepositions = map[string]bool{"at": true, "on": true, "for": true, "в": true, "во": true, "на": true, "с": true}
+
+// match сопоставляет выражение, начинающееся с токена i, и возвращает количество его токенов.
+func (p *parser) match(i int) (int, error) {
+	matchers := []func(i int, prep string) (int, error){
+		p.matchRecurrence, p.matchRelative, p.matchWeekday, p.matchDate, p.matchTime, p.matchDuration,
+	}
+
+	prep := ""
+	start := i
+	if prepositions[p.token(i)] {
+		prep, start = p.token(i), i+1
+	}
+	for _, m := range matchers {
+		n, err := m(start, prep)
+		if err != nil || n > 0 {
+			return n + start - i, err
+		}
+	}
+	return 0, nil
+}
+
+// Дни недели в английском и русском языках, включая формы винительного и дательного падежей.
+var weekdays = map[string]time.Weekday{
+	"monday": time.Monday, "понедельник": time.Monday, "понедельникам": time.Monday,
+	"tuesday": time.Tuesday, "вторник": time.Tuesday, "вторникам": time.Tuesday,
+	"wednesday": time.Wednesday, "среда": time.Wednesday, "среду": time.Wednesday, "средам": time.Wednesday,
+	"thursday": time.Thursday, "четверг": time.Thursday, "четвергам": time.Thursday,
+	"friday": time.Friday, "пятница": time.Friday, "пятницу": time.Friday, "пятницам": time.Friday,
+	"saturday": time.Saturday, "суббота": time.Saturday, "субботу": time.Saturday, "субботам": time.Saturday,
+	"sunday": time.Sunday, "воскресенье": time.Sunday, "воскресеньям": time.Sunday,
+}
+
+// nextWeekday возвращает ближайший к сегодняшнему день недели weekday, не раньше сегодняшнего,
+// или строго после сегодняшнего, если strict.
+func (p *parser) nextWeekday(weekday time.Weekday, strict bool) time.Time {
+	days := (int(weekday) - int(p.now.Weekday()) + 7) % 7
+	if days == 0 && strict {
+		days = 7
+	}
+	return addDays(p.now, days)
+}
+
+// matchRecurrence сопоставляет правило повторения: "every Monday", "каждую среду",
+// "по пятницам", "every day", "ежедневно".
+func (p *parser) matchRecurrence(i int, prep string) (int, error) {
+	if prep != "" {
+		return 0, nil
+	}
+
+	var repeat Recurrence
+	n := 0
+	switch word := p.token(i); word {
+	case "daily", "ежедневно":
+		repeat, n = Recurrence{Frequency: Daily}, 1
+	case "weekly", "еженедельно":
+		repeat, n = Recurrence{Frequency: Weekly}, 1
+	case "every", "каждый", "каждую", "каждое", "каждые", "по":
+		next := p.token(i + 1)
+		if weekday, ok := weekdays[next]; ok && (word != "по" || strings.HasSuffix(next, "ам") || strings.HasSuffix(next, "ям")) {
+			if err := p.setDate(p.nextWeekday(weekday, false)); err != nil {
+				return 0, err
+			}
+			p.repeatDate = true
+			repeat, n = Recurrence{Frequency: Weekly, Weekday: strings.ToLower(weekday.String())}, 2
+			break
+		}
+		switch next {
+		case "day", "день":
+			repeat, n = Recurrence{Frequency: Daily}, 2
+		case "week", "неделю":
+			repeat, n = Recurrence{Frequency: Weekly}, 2
+		}
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	if p.repeat != nil {
+		return 0, ErrDuplicateRecurrence
+	}
+	p.repeat = &repeat
+	return n, nil
+}
+
+// matchRelative сопоставляет относительную дату: "today", "tomorrow", "послезавтра",
+// "day after tomorrow", "in 2 days", "через 3 часа".
+func (p *parser) matchRelative(i int, prep string) (int, error) {
+	switch p.token(i) {
+	case "today", "сегодня":
+		return 1, p.setDate(startOfDay(p.now))
+	case "tomorrow", "завтра":
+		return 1, p.setDate(addDays(p.now, 1))
+	case "послезавтра":
+		return 1, p.setDate(addDays(p.now, 2))
+	case "day":
+		if p.token(i+1) == "after" && p.token(i+2) == "tomorrow" {
+			return 3, p.setDate(addDays(p.now, 2))
+		}
+	case "in", "через":
+		return p.matchOffset(i + 1)
+	}
+	return 0, nil
+}
+
+// matchOffset сопоставляет смещение от текущего времени после "in" или "через": "2 days", "неделю", "3 часа".
+// Возвращает количество токенов вместе с предшествующим словом.
+func (p *parser) matchOffset(i int) (int, error) {
+	count, n := 1, 0
+	if v, err := strconv.Atoi(p.token(i)); err == nil && v > 0 {
+		count, n = v, 1
+	}
+	switch unit := unitOf(p.token(i + n)); unit {
+	case unitDay, unitWeek:
+		days := count
+		if unit == unitWeek {
+			days *= 7
+		}
+		return n + 2, p.setDate(addDays(p.now, days))
+	case unitHour, unitMinute:
+		if p.date != nil || p.clock != nil || p.exact != nil {
+			return 0, ErrDuplicateDate
+		}
+		exact := p.now.Add(time.Duration(count) * time.Duration(unit)).Truncate(time.Minute)
+		p.exact = &exact
+		return n + 2, nil
+	}
+	return 0, nil
+}
+
+// Единицы смещения и длительности.
+const (
+	unitNone   = 0
+	unitMinute = int64(time.Minute)
+	unitHour   = int64(time.Hour)
+	unitDay    = 24 * unitHour
+	unitWeek   = 7 * unitDay
+)
+
+// unitOf возвращает единицу времени, названную словом word.
+func unitOf(word string) int64 {
+	switch word {
+	case "minute", "minutes", "min", "mins", "минута", "минуту", "минуты", "минут", "мин":
+		return unitMinute
+	case "hour", "hours", "h", "hr", "hrs", "час", "часа", "часов", "ч":
+		return unitHour
+	case "day", "days", "день", "дня", "дней":
+		return unitDay
+	case "week", "weeks", "неделя", "неделю", "недели", "недель":
+		return unitWeek
+	}
+	return unitNone
+}
+
+// matchWeekday сопоставляет день недели: "Monday", "on Friday", "next Tuesday", "в следующую среду".
+func (p *parser) matchWeekday(i int, prep string) (int, error) {
+	strict, n := false, 0
+	switch p.token(i) {
+	case "next", "следующий", "следующую", "следующее":
+		strict, n = true, 1
+	}
+	weekday, ok := weekdays[p.token(i+n)]
+	if !ok {
+		return 0, nil
+	}
+	return n + 1, p.setDate(p.nextWeekday(weekday, strict))
+}
+
+// Форматы дат: 2024-05-20, 20.05.2024 и 20.05.
+var (
+	isoDatePattern = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})$`)
+	dotDatePattern = regexp.MustCompile(`^(\d{1,2})\.(\d{1,2})(?:\.(\d{4}))?$`)
+)
+
+// matchDate сопоставляет дату в формате 2024-05-20, 20.05.2024 или 20.05 (ближайшая такая дата).
+func (p *parser) matchDate(i int, prep string) (int, error) {
+	token := p.token(i)
+	var year, month, day int
+	if m := isoDatePattern.FindStringSubmatch(token); m != nil {
+		year, month, day = atoi(m[1]), atoi(m[2]), atoi(m[3])
+	} else if m := dotDatePattern.FindStringSubmatch(token); m != nil {
+		day, month, year = atoi(m[1]), atoi(m[2]), p.now.Year()
+		if m[3] != "" {
+			year = atoi(m[3])
+		}
+	} else {
+		return 0, nil
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, p.now.Location())
+	if date.Day() != day || int(date.Month()) != month {
+		return 0, ErrInvalidDate
+	}
+	// Дата без года, прошедшая в этом году, относится к следующему году.
+	if strings.Count(token, ".") == 1 && date.Before(startOfDay(p.now)) {
+		date = date.AddDate(1, 0, 0)
+	}
+	return 1, p.setDate(date)
+}
+
+// atoi преобразует строку из цифр s в число.
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// Форматы времени: 10:00, 10am, 10:30pm, 15ч.
+var timePattern = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm|ч)?$`)
+
+// matchTime сопоставляет время: "10:00", "3pm", "10 am", "at 10", "в 7 вечера".
+// Число без минут и суффикса считается временем только после предлога.
+func (p *parser) matchTime(i int, prep string) (int, error) {
+	m := timePattern.FindStringSubmatch(p.token(i))
+	if m == nil {
+		return 0, nil
+	}
+	hour, minute, suffix := atoi(m[1]), atoi(m[2]), m[3]
+	n := 1
+	if suffix == "" {
+		switch next := p.token(i + 1); next {
+		case "am", "pm", "утра", "дня", "вечера", "ночи":
+			suffix, n = next, 2
+		}
+	}
+	// Число без минут или с суффиксом "ч" считается временем только после предлога времени.
+	atPrep := prep == "at" || prep == "в" || prep == "во" || prep == "с"
+	if (m[2] == "" && suffix == "" || suffix == "ч") && !atPrep {
+		return 0, nil
+	}
+	// Число перед единицей времени - длительность или смещение, а не время.
+	if m[2] == "" && suffix == "" && unitOf(p.token(i+1)) != unitNone {
+		return 0, nil
+	}
+
+	switch suffix {
+	case "am", "утра", "ночи":
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm", "дня", "вечера":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 || (suffix == "am" || suffix == "pm") && atoi(m[1]) > 12 {
+		return 0, ErrInvalidTime
+	}
+	return n, p.setClock(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+}
+
+// Форматы длительности: 15m, 1h30m, 90min, 2ч, 15мин.
+var durationPattern = regexp.MustCompile(`^(?:(\d+)(?:h|ч))?(?:(\d+)(?:m|min|м|мин))?$`)
+
+// matchDuration сопоставляет длительность: "for 15m", "1h30m", "for 2 hours", "на 30 минут", "на час", "полчаса".
+func (p *parser) matchDuration(i int, prep string) (int, error) {
+	token := p.token(i)
+	if token == "" {
+		return 0, nil
+	}
+
+	var d time.Duration
+	n := 0
+	switch {
+	case token == "полчаса" || token == "half" && p.token(i+1) == "an" && p.token(i+2) == "hour":
+		d, n = 30*time.Minute, 1
+		if token == "half" {
+			n = 3
+		}
+	case durationPattern.MatchString(token):
+		m := durationPattern.FindStringSubmatch(token)
+		d, n = time.Duration(atoi(m[1]))*time.Hour+time.Duration(atoi(m[2]))*time.Minute, 1
+	default:
+		count := 1
+		if v, err := strconv.Atoi(token); err == nil {
+			count, n = v, 1
+		} else if token == "an" || token == "a" {
+			n = 1
+		}
+		unit := unitOf(p.token(i + n))
+		if unit != unitMinute && unit != unitHour {
+			return 0, nil
+		}
+		// Единица без числа считается длительностью только после предлога: "на час", "for an hour".
+		if n == 0 && prep == "" {
+			return 0, nil
+		}
+		d, n = time.Duration(count)*time.Duration(unit), n+1
+	}
+	if d <= 0 {
+		return 0, nil
+	}
+	if p.duration != 0 {
+		return 0, ErrDuplicateDuration
+	}
+	p.duration = d
+	return n, nil
+}
@@ -0,0 +1,102 @@
+package quickadd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// Среда, 11:00.
+	now := time.Date(2010, 5, 19, 11, 0, 0, 0, time.UTC)
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2010, 5, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		want    Result
+		wantErr error
+	}{
+		{"TomorrowDuration", "Standup tomorrow 10:00 for 15m",
+			Result{Title: "Standup", Date: at(20, 10, 0), Duration: Duration(15 * time.Minute)}, nil},
+		{"RussianTomorrow", "Созвон завтра в 15:30 на 30 минут",
+			Result{Title: "Созвон", Date: at(20, 15, 30), Duration: Duration(30 * time.Minute)}, nil},
+		{"DefaultTime", "Отчет послезавтра",
+			Result{Title: "Отчет", Date: at(21, DefaultHour, 0)}, nil},
+		{"PastTimeToday", "Lunch at 10am",
+			Result{Title: "Lunch", Date: at(20, 10, 0)}, nil},
+		{"FutureTimeToday", "Lunch at 1pm for an hour",
+			Result{Title: "Lunch", Date: at(19, 13, 0), Duration: Duration(time.Hour)}, nil},
+		{"ExplicitToday", "Retro today 10:00",
+			Result{Title: "Retro", Date: at(19, 10, 0)}, nil},
+		{"Weekday", "Demo on Friday at 4 pm",
+			Result{Title: "Demo", Date: at(21, 16, 0)}, nil},
+		{"WeekdayToday", "Sync wednesday 12:00",
+			Result{Title: "Sync", Date: at(19, 12, 0)}, nil},
+		{"NextWeekday", "Sync next Wednesday 12:00",
+			Result{Title: "Sync", Date: at(26, 12, 0)}, nil},
+		{"RussianWeekday", "Ревью в пятницу в 7 вечера 1h30m",
+			Result{Title: "Ревью", Date: at(21, 19, 0), Duration: Duration(90 * time.Minute)}, nil},
+		{"EveryMonday", "Planning every Monday 9:30",
+			Result{Title: "Planning", Date: at(24, 9, 30), Recurrence: &Recurrence{Frequency: Weekly, Weekday: "monday"}}, nil},
+		{"EveryWednesdayPassed", "Планерка каждую среду в 10:00 на полчаса",
+			Result{Title: "Планерка", Date: at(26, 10, 0), Duration: Duration(30 * time.Minute), Recurrence: &Recurrence{Frequency: Weekly, Weekday: "wednesday"}}, nil},
+		{"ByWeekdays", "Йога по пятницам с 18:00",
+			Result{Title: "Йога", Date: at(21, 18, 0), Recurrence: &Recurrence{Frequency: Weekly, Weekday: "friday"}}, nil},
+		{"Daily", "Зарядка ежедневно в 8 утра",
+			Result{Title: "Зарядка", Date: at(20, 8, 0), Recurrence: &Recurrence{Frequency: Daily}}, nil},
+		{"EveryDay", "Check mail every day at 17:00",
+			Result{Title: "Check mail", Date: at(19, 17, 0), Recurrence: &Recurrence{Frequency: Daily}}, nil},
+		{"InDays", "Release in 3 days",
+			Result{Title: "Release", Date: at(22, DefaultHour, 0)}, nil},
+		{"InHours", "Call mom через 2 часа",
+			Result{Title: "Call mom", Date: at(19, 13, 0)}, nil},
+		{"InWeek", "Отпуск через неделю",
+			Result{Title: "Отпуск", Date: at(26, DefaultHour, 0)}, nil},
+		{"DayAfterTomorrow", "Dentist day after tomorrow 08:15",
+			Result{Title: "Dentist", Date: at(21, 8, 15)}, nil},
+		{"ISODate", "Conference 2010-06-01 10:00 for 8 hours",
+			Result{Title: "Conference", Date: time.Date(2010, 6, 1, 10, 0, 0, 0, time.UTC), Duration: Duration(8 * time.Hour)}, nil},
+		{"DotDate", "День рождения 25.05",
+			Result{Title: "День рождения", Date: at(25, DefaultHour, 0)}, nil},
+		{"DotDateNextYear", "Новый год 01.01 в 0:00",
+			Result{Title: "Новый год", Date: time.Date(2011, 1, 1, 0, 0, 0, 0, time.UTC)}, nil},
+		{"Punctuation", "Standup, tomorrow, 10:00.",
+			Result{Title: "Standup", Date: at(20, 10, 0)}, nil},
+		{"NumbersInTitle", "Buy 2 apples tomorrow",
+			Result{Title: "Buy 2 apples", Date: at(20, DefaultHour, 0)}, nil},
+		{"PrepositionInTitle", "Встреча на кухне завтра",
+			Result{Title: "Встреча на кухне", Date: at(20, DefaultHour, 0)}, nil},
+		{"EmptyTitle", "tomorrow 10:00", Result{}, ErrEmptyTitle},
+		{"DuplicateDate", "Standup today tomorrow", Result{}, ErrDuplicateDate},
+		{"DuplicateTime", "Standup 10:00 11:00", Result{}, ErrDuplicateTime},
+		{"DuplicateDuration", "Standup for 15m for 1h", Result{}, ErrDuplicateDuration},
+		{"InvalidDate", "Standup 31.02", Result{}, ErrInvalidDate},
+		{"InvalidTime", "Standup 25:00", Result{}, ErrInvalidTime},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.text, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Location", func(t *testing.T) {
+		moscow := time.FixedZone("MSK", 3*60*60)
+		got, err := Parse("Standup tomorrow 10:00", now.In(moscow))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := time.Date(2010, 5, 20, 7, 0, 0, 0, time.UTC); !got.Date.Equal(want) {
+			t.Errorf("Parse() date = %v, want %v", got.Date, want)
+		}
+	})
+}
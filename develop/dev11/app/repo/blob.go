@@ -0,0 +1,36 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Ошибка неверного ключа объекта хранилища.
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Структура объекта хранилища: ключ, метаданные и размер содержимого.
+// Ключ состоит из сегментов, разделенных символом "/".
+type Blob struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// Интерфейс хранилища объектов (файлов). Объекты разных арендаторов из контекста
+// хранятся раздельно.
+type BlobStore interface {
+	// Put сохраняет объект blob с содержимым из r и возвращает его с заполненным размером.
+	// При ошибке чтения r объект не сохраняется.
+	Put(ctx context.Context, blob Blob, r io.Reader) (Blob, error)
+	// Get возвращает объект с ключом key и его содержимое или ErrNotExist.
+	Get(ctx context.Context, key string) (Blob, io.ReadCloser, error)
+	// List возвращает объекты, ключи которых состоят из prefix и одного сегмента.
+	List(ctx context.Context, prefix string) ([]Blob, error)
+	// DeletePrefix удаляет все объекты с ключами, начинающимися с сегментов prefix.
+	DeletePrefix(ctx context.Context, prefix string) error
+	// MovePrefix переносит все объекты с ключами, начинающимися с сегментов prefix, под префикс newPrefix.
+	// Возвращает ErrExists, если объекты с префиксом newPrefix уже есть.
+	MovePrefix(ctx context.Context, prefix string, newPrefix string) error
+}
@@ -0,0 +1,221 @@
+package repo
+
+import (
+	"context"
+	"dev11/app/tenant"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Расширения файлов содержимого и метаданных объекта.
+const (
+	blobDataExt = ".data"
+	blobMetaExt = ".json"
+)
+
+// Допустимый сегмент ключа объекта.
+var blobSegmentPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Структура хранилища объектов в локальной файловой системе.
+// Объект хранится в двух файлах: содержимое и метаданные в формате json.
+type blobFS struct {
+	dir string
+}
+
+// NewBlobFS возвращает хранилище объектов в директории dir.
+func NewBlobFS(dir string) BlobStore {
+	return &blobFS{dir: dir}
+}
+
+// path возвращает путь к объекту или префиксу key в директории арендатора из ctx.
+func (b *blobFS) path(ctx context.Context, key string) (string, error) {
+	name := tenant.FromContext(ctx)
+	if err := tenant.Validate(name); err != nil {
+		return "", err
+	}
+
+	segments := strings.Split(key, "/")
+	for _, segment := range segments {
+		if !blobSegmentPattern.MatchString(segment) {
+			return "", ErrInvalidKey
+		}
+	}
+	return filepath.Join(append([]string{b.dir, name}, segments...)...), nil
+}
+
+// Put сохраняет объект blob с содержимым из r. Содержимое записывается во временный файл,
+// который переименовывается после успешного чтения r.
+func (b *blobFS) Put(ctx context.Context, blob Blob, r io.Reader) (Blob, error) {
+	path, err := b.path(ctx, blob.Key)
+	if err != nil {
+		return Blob{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return Blob{}, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return Blob{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	blob.Size, err = io.Copy(tmp, r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Blob{}, err
+	}
+
+	meta, err := json.Marshal(blob)
+	if err != nil {
+		return Blob{}, err
+	}
+	if err := os.Rename(tmp.Name(), path+blobDataExt); err != nil {
+		return Blob{}, err
+	}
+	// Метаданные записываются последними: объект без них не виден.
+	if err := writeFileAtomic(path+blobMetaExt, meta); err != nil {
+		return Blob{}, err
+	}
+	return blob, nil
+}
+
+// readMeta читает метаданные объекта с ключом key по пути path. Ключ определяется
+// расположением объекта, так как объекты могут быть перенесены под другой префикс.
+func readMeta(path string, key string) (Blob, error) {
+	data, err := readFileChecked(path + blobMetaExt)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Blob{}, ErrNotExist
+		}
+		return Blob{}, err
+	}
+
+	var blob Blob
+	if err := json.Unmarshal(data, &blob); err != nil {
+		return Blob{}, err
+	}
+	blob.Key = key
+	return blob, nil
+}
+
+// Get возвращает объект с ключом key и его содержимое.
+func (b *blobFS) Get(ctx context.Context, key string) (Blob, io.ReadCloser, error) {
+	path, err := b.path(ctx, key)
+	if err != nil {
+		return Blob{}, nil, err
+	}
+
+	blob, err := readMeta(path, key)
+	if err != nil {
+		return Blob{}, nil, err
+	}
+	file, err := os.Open(path + blobDataExt)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Blob{}, nil, ErrNotExist
+		}
+		return Blob{}, nil, err
+	}
+	return blob, file, nil
+}
+
+// List возвращает объекты с ключами prefix/<сегмент>, упорядоченные по ключу.
+func (b *blobFS) List(ctx context.Context, prefix string) ([]Blob, error) {
+	dir, err := b.path(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Blob{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	blobs := make([]Blob, 0)
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), blobMetaExt)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		blob, err := readMeta(filepath.Join(dir, name), prefix+"/"+name)
+		if err != nil {
+			return nil, err
+		}
+		blobs = append(blobs, blob)
+	}
+	slices.SortFunc(blobs, func(a, b Blob) int { return strings.Compare(a.Key, b.Key) })
+	return blobs, nil
+}
+
+// DeletePrefix удаляет объекты с ключами, начинающимися с сегментов prefix.
+func (b *blobFS) DeletePrefix(ctx context.Context, prefix string) error {
+	path, err := b.path(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{os.RemoveAll(path)}
+	for _, ext := range []string{blobDataExt, blobMetaExt} {
+		if err := os.Remove(path + ext); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MovePrefix переносит объекты с ключами, начинающимися с сегментов prefix, под префикс newPrefix
+// переименованием директории и файлов объекта prefix.
+func (b *blobFS) MovePrefix(ctx context.Context, prefix string, newPrefix string) error {
+	path, err := b.path(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	newPath, err := b.path(ctx, newPrefix)
+	if err != nil {
+		return err
+	}
+
+	var moves [][2]string
+	for _, ext := range []string{"", blobDataExt, blobMetaExt} {
+		if _, err := os.Stat(path + ext); errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if _, err := os.Stat(newPath + ext); err == nil {
+			return ErrExists
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		moves = append(moves, [2]string{path + ext, newPath + ext})
+	}
+	if len(moves) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newPath), 0o755); err != nil {
+		return err
+	}
+	for _, move := range moves {
+		if err := os.Rename(move[0], move[1]); err != nil {
+			return err
+		}
+	}
+	return syncDir(filepath.Dir(newPath))
+}
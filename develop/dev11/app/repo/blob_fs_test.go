@@ -0,0 +1,122 @@
+package repo
+
+import (
+	"context"
+	"dev11/app/tenant"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_blobFS(t *testing.T) {
+	ctx := context.Background()
+	b := NewBlobFS(t.TempDir())
+
+	blob := Blob{Key: "user/event/1", Name: "a.txt", ContentType: "text/plain"}
+	got, err := b.Put(ctx, blob, strings.NewReader("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob.Size = 4
+	if got != blob {
+		t.Errorf("blobFS.Put() = %v, want %v", got, blob)
+	}
+	other, _ := b.Put(ctx, Blob{Key: "user/event/2", Name: "b.txt"}, strings.NewReader(""))
+
+	got, r, err := b.Get(ctx, blob.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if got != blob || string(data) != "data" {
+		t.Errorf("blobFS.Get() = %v, %q, want %v, %q", got, data, blob, "data")
+	}
+
+	list, err := b.List(ctx, "user/event")
+	if err != nil || !reflect.DeepEqual(list, []Blob{blob, other}) {
+		t.Errorf("blobFS.List() = %v, %v, want %v", list, err, []Blob{blob, other})
+	}
+	if list, _ := b.List(tenant.WithTenant(ctx, "sales"), "user/event"); len(list) != 0 {
+		t.Errorf("blobFS.List() other tenant = %v, want empty", list)
+	}
+
+	if err := b.DeletePrefix(ctx, "user/event"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := b.Get(ctx, blob.Key); err != ErrNotExist {
+		t.Errorf("blobFS.Get() after DeletePrefix error = %v, want %v", err, ErrNotExist)
+	}
+	if err := b.DeletePrefix(ctx, "user/event"); err != nil {
+		t.Errorf("blobFS.DeletePrefix() missing error = %v", err)
+	}
+}
+
+func Test_blobFS_MovePrefix(t *testing.T) {
+	ctx := context.Background()
+	b := NewBlobFS(t.TempDir())
+
+	b.Put(ctx, Blob{Key: "user/event/1", Name: "a.txt"}, strings.NewReader("data"))
+	b.Put(ctx, Blob{Key: "user/other/1", Name: "b.txt"}, strings.NewReader(""))
+	if err := b.MovePrefix(ctx, "user/event", "colleague/event"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Blob{{Key: "colleague/event/1", Name: "a.txt", Size: 4}}
+	if list, err := b.List(ctx, "colleague/event"); err != nil || !reflect.DeepEqual(list, want) {
+		t.Errorf("blobFS.List() = %v, %v, want %v", list, err, want)
+	}
+	if got, r, err := b.Get(ctx, "colleague/event/1"); err != nil || got != want[0] {
+		t.Errorf("blobFS.Get() = %v, %v, want %v", got, err, want[0])
+	} else {
+		r.Close()
+	}
+	if list, _ := b.List(ctx, "user/event"); len(list) != 0 {
+		t.Errorf("blobFS.List() old prefix = %v, want empty", list)
+	}
+	if err := b.MovePrefix(ctx, "user/missing", "colleague/missing"); err != nil {
+		t.Errorf("blobFS.MovePrefix() missing error = %v", err)
+	}
+	b.Put(ctx, Blob{Key: "user/event/2"}, strings.NewReader(""))
+	if err := b.MovePrefix(ctx, "user/event", "colleague/event"); err != ErrExists {
+		t.Errorf("blobFS.MovePrefix() existing error = %v, want %v", err, ErrExists)
+	}
+}
+
+func Test_blobFS_PutFailed(t *testing.T) {
+	ctx := context.Background()
+	b := NewBlobFS(t.TempDir())
+	readErr := errors.New("read failed")
+
+	_, err := b.Put(ctx, Blob{Key: "user/1"}, io.MultiReader(strings.NewReader("data"), errReader{readErr}))
+	if !errors.Is(err, readErr) {
+		t.Fatalf("blobFS.Put() error = %v, want %v", err, readErr)
+	}
+	if _, _, err := b.Get(ctx, "user/1"); err != ErrNotExist {
+		t.Errorf("blobFS.Get() error = %v, want %v", err, ErrNotExist)
+	}
+	if list, _ := b.List(ctx, "user"); len(list) != 0 {
+		t.Errorf("blobFS.List() = %v, want empty", list)
+	}
+}
+
+func Test_blobFS_InvalidKey(t *testing.T) {
+	ctx := context.Background()
+	b := NewBlobFS(t.TempDir())
+
+	for _, key := range []string{"", "../etc", "user//1", "user/1.json", "/user"} {
+		if _, err := b.Put(ctx, Blob{Key: key}, strings.NewReader("")); err != ErrInvalidKey {
+			t.Errorf("blobFS.Put(%q) error = %v, want %v", key, err, ErrInvalidKey)
+		}
+	}
+	if _, _, err := b.Get(tenant.WithTenant(ctx, "../x"), "user/1"); !errors.Is(err, tenant.ErrInvalid) {
+		t.Errorf("blobFS.Get() invalid tenant error = %v, want %v", err, tenant.ErrInvalid)
+	}
+}
+
+// Структура читателя, всегда возвращающего ошибку.
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -0,0 +1,115 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: blob.go
+//
+// Generated by this command:
+//
+//	mockgen -source blob.go -destination blob_mock.go -package repo
+//
+
+// Package repo is a generated GoMock package.
+package repo
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBlobStore is a mock of BlobStore interface.
+type MockBlobStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockBlobStoreMockRecorder
+}
+
+// MockBlobStoreMockRecorder is the mock recorder for MockBlobStore.
+type MockBlobStoreMockRecorder struct {
+	mock *MockBlobStore
+}
+
+// NewMockBlobStore creates a new mock instance.
+func NewMockBlobStore(ctrl *gomock.Controller) *MockBlobStore {
+	mock := &MockBlobStore{ctrl: ctrl}
+	mock.recorder = &MockBlobStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBlobStore) EXPECT() *MockBlobStoreMockRecorder {
+	return m.recorder
+}
+
+// DeletePrefix mocks base method.
+func (m *MockBlobStore) DeletePrefix(ctx context.Context, prefix string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePrefix", ctx, prefix)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePrefix indicates an expected call of DeletePrefix.
+func (mr *MockBlobStoreMockRecorder) DeletePrefix(ctx, prefix any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePrefix", reflect.TypeOf((*MockBlobStore)(nil).DeletePrefix), ctx, prefix)
+}
+
+// Get mocks base method.
+func (m *MockBlobStore) Get(ctx context.Context, key string) (Blob, io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(Blob)
+	ret1, _ := ret[1].(io.ReadCloser)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockBlobStoreMockRecorder) Get(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockBlobStore)(nil).Get), ctx, key)
+}
+
+// List mocks base method.
+func (m *MockBlobStore) List(ctx context.Context, prefix string) ([]Blob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, prefix)
+	ret0, _ := ret[0].([]Blob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockBlobStoreMockRecorder) List(ctx, prefix any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockBlobStore)(nil).List), ctx, prefix)
+}
+
+// MovePrefix mocks base method.
+func (m *MockBlobStore) MovePrefix(ctx context.Context, prefix, newPrefix string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MovePrefix", ctx, prefix, newPrefix)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MovePrefix indicates an expected call of MovePrefix.
+func (mr *MockBlobStoreMockRecorder) MovePrefix(ctx, prefix, newPrefix any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MovePrefix", reflect.TypeOf((*MockBlobStore)(nil).MovePrefix), ctx, prefix, newPrefix)
+}
+
+// Put mocks base method.
+func (m *MockBlobStore) Put(ctx context.Context, blob Blob, r io.Reader) (Blob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", ctx, blob, r)
+	ret0, _ := ret[0].(Blob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockBlobStoreMockRecorder) Put(ctx, blob, r any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockBlobStore)(nil).Put), ctx, blob, r)
+}
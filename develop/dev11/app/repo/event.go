@@ -20,7 +20,20 @@ var (
 type Event interface {
 	GetByID(ctx context.Context, userID string, id string) (entity.Event, error)
 	GetForRange(ctx context.Context, userID string, dateStart, dateEnd time.Time) ([]entity.Event, error)
+	// GetUpcoming возвращает не более limit событий пользователя userID с датой не раньше
+	// dateStart, упорядоченные по дате, или пустой срез при limit <= 0.
+	GetUpcoming(ctx context.Context, userID string, dateStart time.Time, limit int) ([]entity.Event, error)
+	// Create добавляет событие, генерируя идентификатор, если он не задан. Возвращает ErrExists,
+	// если у пользователя уже есть событие с заданным идентификатором.
 	Create(ctx context.Context, event entity.Event) (entity.Event, error)
 	Update(ctx context.Context, event entity.Event) (entity.Event, error)
 	Delete(ctx context.Context, userID string, id string) error
+	Search(ctx context.Context, userID string, query string) ([]entity.Event, error)
+	CountByUser(ctx context.Context, userID string) (int, error)
+	// Transfer передает события пользователя fromUserID, соответствующие filter, пользователю toUserID
+	// одной операцией и устанавливает им время изменения updatedAt. Возвращает переданные события
+	// или ErrExists, если у пользователя toUserID уже есть событие с тем же идентификатором.
+	Transfer(ctx context.Context, fromUserID string, toUserID string, filter entity.EventFilter, updatedAt time.Time) ([]entity.Event, error)
+	// DeleteByUser удаляет все события пользователя userID одной операцией и возвращает их.
+	DeleteByUser(ctx context.Context, userID string) ([]entity.Event, error)
 }
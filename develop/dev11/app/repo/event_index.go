@@ -0,0 +1,265 @@
+package repo
+
+import (
+	"dev11/app/entity"
+	"iter"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Суффиксы для простого стемминга русских слов, упорядоченные по убыванию длины.
+var suffixesRU = []string{
+	"иями", "ться",
+	"ями", "ами", "ией", "ого", "его", "ому", "ему", "ыми", "ими", "тся", "ешь", "ишь",
+	"ет", "ит", "ют", "ут", "ат", "ят", "ая", "яя", "ое", "ее", "ые", "ие", "ый", "ий", "ой", "ей",
+	"ом", "ем", "ам", "ям", "ах", "ях", "ов", "ев", "ью", "ия", "ии",
+	"а", "я", "о", "е", "ы", "и", "у", "ю", "ь", "й",
+}
+
+// Суффиксы для простого стемминга английских слов, упорядоченные по убыванию длины.
+var suffixesEN = []string{"ings", "ing", "ies", "ied", "es", "ed", "ly", "s"}
+
+// Минимальная длина основы слова в символах после отсечения суффикса.
+const minStemLen = 3
+
+// stem отсекает от слова word наиболее длинный подходящий суффикс.
+func stem(word string) string {
+	if r, _ := utf8.DecodeRuneInString(word); unicode.Is(unicode.Cyrillic, r) {
+		return trimSuffix(word, suffixesRU)
+	}
+	return stemEN(word)
+}
+
+// trimSuffix отсекает от слова word наиболее длинный суффикс из suffixes,
+// оставляющий основу не короче minStemLen.
+func trimSuffix(word string, suffixes []string) string {
+	for _, suffix := range suffixes {
+		if base, ok := strings.CutSuffix(word, suffix); ok && utf8.RuneCountInString(base) >= minStemLen {
+			return base
+		}
+	}
+	return word
+}
+
+// stemEN выполняет стемминг английского слова word так, чтобы формы единственного
+// и множественного числа давали одну основу: "notes" и "note" -> "not",
+// "releases" и "release" -> "releas", "classes" и "class" -> "class".
+func stemEN(word string) string {
+	base := word
+	for _, suffix := range suffixesEN {
+		trimmed, ok := strings.CutSuffix(word, suffix)
+		if !ok || utf8.RuneCountInString(trimmed) < minStemLen {
+			continue
+		}
+		// "es" отсекается только после шипящих и "o" (boxes, matches, heroes),
+		// в остальных словах это "e" основы и окончание "s" (notes).
+		if suffix == "es" && !hasAnySuffix(trimmed, "s", "x", "z", "ch", "sh", "o") {
+			continue
+		}
+		// Конечное "ss" относится к основе (class, business).
+		if suffix == "s" && strings.HasSuffix(trimmed, "s") {
+			continue
+		}
+		if suffix == "ies" || suffix == "ied" {
+			trimmed += "y"
+		}
+		base = trimmed
+		break
+	}
+
+	// Конечное "e" отбрасывается, чтобы основа совпадала с формами на -es, -ed и -ing.
+	if trimmed, ok := strings.CutSuffix(base, "e"); ok && utf8.RuneCountInString(trimmed) >= minStemLen {
+		return trimmed
+	}
+	return base
+}
+
+// hasAnySuffix сообщает, оканчивается ли s одним из суффиксов suffixes.
+func hasAnySuffix(s string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenize разбивает текст s на слова, приводит их к нижнему регистру и выполняет стемминг.
+func tokenize(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = stem(strings.ReplaceAll(word, "ё", "е"))
+	}
+	return words
+}
+
+// Структура поискового запроса: отдельные слова и фразы в кавычках.
+type searchQuery struct {
+	terms   []string
+	phrases [][]string
+}
+
+// parseQuery разбирает строку запроса q, выделяя фразы, заключенные в двойные кавычки.
+func parseQuery(q string) searchQuery {
+	var query searchQuery
+	parts := strings.Split(q, `"`)
+	for i, part := range parts {
+		tokens := tokenize(part)
+		// Нечетные части находятся внутри кавычек.
+		if i%2 == 1 && len(tokens) > 1 {
+			query.phrases = append(query.phrases, tokens)
+		}
+		query.terms = append(query.terms, tokens...)
+	}
+	return query
+}
+
+// Структура инвертированного индекса событий по словам из названия и описания.
+// Индекс не потокобезопасен, синхронизация выполняется репозиторием.
+type eventIndex struct {
+	// postings хранит позиции слова в каждом событии: слово -> событие -> позиции.
+	postings map[string]map[docKey][]int
+	// tokens хранит слова каждого события для удаления из индекса.
+	tokens map[docKey][]string
+}
+
+// Ключ события в индексе. Идентификаторы событий уникальны только в пределах
+// пользователя, например события приглашения CalDAV с общим UID.
+type docKey struct {
+	userID string
+	id     string
+}
+
+// newEventIndex возвращает пустой индекс.
+func newEventIndex() *eventIndex {
+	return &eventIndex{
+		postings: make(map[string]map[docKey][]int),
+		tokens:   make(map[docKey][]string),
+	}
+}
+
+// rebuild перестраивает индекс по всем событиям events.
+func (idx *eventIndex) rebuild(events iter.Seq[entity.Event]) {
+	*idx = *newEventIndex()
+	for event := range events {
+		idx.add(event)
+	}
+}
+
+// add добавляет событие в индекс, предварительно удаляя его старую версию.
+func (idx *eventIndex) add(event entity.Event) {
+	key := docKey{event.UserID, event.ID}
+	idx.remove(event.UserID, event.ID)
+
+	// Разделяем название и описание, чтобы фразы не склеивались на границе полей.
+	tokens := tokenize(event.Title)
+	tokens = append(tokens, "")
+	tokens = append(tokens, tokenize(event.Description)...)
+
+	for pos, token := range tokens {
+		if token == "" {
+			continue
+		}
+		docs, ok := idx.postings[token]
+		if !ok {
+			docs = make(map[docKey][]int)
+			idx.postings[token] = docs
+		}
+		docs[key] = append(docs[key], pos)
+	}
+	idx.tokens[key] = tokens
+}
+
+// remove удаляет событие пользователя userID с идентификатором id из индекса.
+func (idx *eventIndex) remove(userID string, id string) {
+	key := docKey{userID, id}
+	for _, token := range idx.tokens[key] {
+		if docs, ok := idx.postings[token]; ok {
+			delete(docs, key)
+			if len(docs) == 0 {
+				delete(idx.postings, token)
+			}
+		}
+	}
+	delete(idx.tokens, key)
+}
+
+// search возвращает идентификаторы событий пользователя userID, содержащих все слова
+// и фразы запроса q, упорядоченные по убыванию релевантности (TF-IDF).
+func (idx *eventIndex) search(userID string, q string) []string {
+	query := parseQuery(q)
+	if len(query.terms) == 0 {
+		return nil
+	}
+
+	// Кандидатами являются события пользователя, содержащие первое слово запроса.
+	scores := make(map[docKey]float64)
+	for key := range idx.postings[query.terms[0]] {
+		if key.userID == userID {
+			scores[key] = 0
+		}
+	}
+
+	total := float64(len(idx.tokens))
+	for _, term := range query.terms {
+		docs := idx.postings[term]
+		idf := math.Log(1 + total/float64(len(docs)+1))
+		for key := range scores {
+			positions, ok := docs[key]
+			if !ok {
+				delete(scores, key)
+				continue
+			}
+			scores[key] += float64(len(positions)) * idf
+		}
+	}
+
+	for _, phrase := range query.phrases {
+		for key := range scores {
+			if !idx.containsPhrase(key, phrase) {
+				delete(scores, key)
+			}
+		}
+	}
+
+	keys := make([]docKey, 0, len(scores))
+	for key := range scores {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b docKey) int {
+		if scores[a] != scores[b] {
+			if scores[a] > scores[b] {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.id, b.id)
+	})
+	ids := make([]string, len(keys))
+	for i, key := range keys {
+		ids[i] = key.id
+	}
+	return ids
+}
+
+// containsPhrase проверяет, что слова phrase идут в событии key подряд.
+func (idx *eventIndex) containsPhrase(key docKey, phrase []string) bool {
+	for _, start := range idx.postings[phrase[0]][key] {
+		found := true
+		for i, token := range phrase[1:] {
+			if !slices.Contains(idx.postings[token][key], start+i+1) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
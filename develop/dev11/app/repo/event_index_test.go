@@ -0,0 +1,114 @@
+package repo
+
+import (
+	"dev11/app/entity"
+	"maps"
+	"reflect"
+	"testing"
+)
+
+func Test_tokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []string
+	}{
+		{"English", "Meetings, weekly!", []string{"meet", "week"}},
+		{"Russian", "Встречи с командой", []string{"встреч", "с", "команд"}},
+		{"Yo", "Ёлка", []string{"елк"}},
+		{"Short", "go is", []string{"go", "is"}},
+		{"Empty", " ,.", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenize(tt.s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_stem(t *testing.T) {
+	tests := []struct {
+		singular string
+		plural   string
+		want     string
+	}{
+		{"note", "notes", "not"},
+		{"release", "releases", "releas"},
+		{"class", "classes", "class"},
+		{"box", "boxes", "box"},
+		{"match", "matches", "match"},
+		{"hero", "heroes", "hero"},
+		{"shoe", "shoes", "sho"},
+		{"party", "parties", "party"},
+		{"meeting", "meetings", "meet"},
+		{"employee", "employees", "employe"},
+		{"review", "reviews", "review"},
+		{"встреча", "встречи", "встреч"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.singular, func(t *testing.T) {
+			if got := stem(tt.singular); got != tt.want {
+				t.Errorf("stem(%q) = %v, want %v", tt.singular, got, tt.want)
+			}
+			if got := stem(tt.plural); got != tt.want {
+				t.Errorf("stem(%q) = %v, want %v", tt.plural, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseQuery(t *testing.T) {
+	want := searchQuery{
+		terms:   []string{"review", "cod", "review", "ежедневн"},
+		phrases: [][]string{{"cod", "review"}},
+	}
+	if got := parseQuery(`review "code review" ежедневная`); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseQuery() = %v, want %v", got, want)
+	}
+}
+
+func Test_eventIndex_search(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	events := map[string]entity.Event{
+		"1": {ID: "1", UserID: userID, Title: "Code review", Description: "Review of the parser"},
+		"2": {ID: "2", UserID: userID, Title: "Review planning", Description: "Code freeze"},
+		"3": {ID: "3", UserID: userID, Title: "Встреча с командой", Description: "Обсуждение встреч"},
+		"4": {ID: "4", UserID: "other", Title: "Code review"},
+	}
+
+	idx := newEventIndex()
+	idx.rebuild(maps.Values(events))
+
+	tests := []struct {
+		name string
+		q    string
+		want []string
+	}{
+		{"Term", "review", []string{"1", "2"}},
+		{"AllTerms", "code review", []string{"1", "2"}},
+		{"Phrase", `"code review"`, []string{"1"}},
+		{"Russian", "встречи", []string{"3"}},
+		{"NoMatch", "lunch", []string{}},
+		{"Empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := idx.search(userID, tt.q); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("eventIndex.search() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Remove", func(t *testing.T) {
+		idx.remove(userID, "1")
+		want := []string{"2"}
+		if got := idx.search(userID, "review"); !reflect.DeepEqual(got, want) {
+			t.Errorf("eventIndex.search() = %v, want %v", got, want)
+		}
+		if _, ok := idx.tokens[docKey{userID, "1"}]; ok {
+			t.Errorf("eventIndex.remove() did not remove tokens")
+		}
+	})
+}
@@ -3,77 +3,491 @@ package repo
 import (
 	"context"
 	"dev11/app/entity"
+	"hash/fnv"
+	"iter"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/btree"
 	"github.com/google/uuid"
 )
 
+// Количество сегментов репозитория. Пользователи распределяются по сегментам
+// по хешу идентификатора, и каждый сегмент блокируется независимо.
+const eventShards = 64
+
+// Количество событий, после обхода которых проверяется отмена контекста.
+const ctxCheckInterval = 1024
+
+// Степень B-дерева событий пользователя.
+const eventTreeDegree = 16
+
 // Структура репозитория для сущности "событие", реализующая интерфейс
-// и работающая с данными in-memory.
+// и работающая с данными in-memory. Методы возвращают ошибку ctx.Err(), если ctx отменен.
+//
+// Изменения пользователей разных сегментов выполняются параллельно. При сохранении
+// на диск записи журнала сериализуются его блокировкой, поэтому пропускная способность
+// записи ограничена журналом (см. BenchmarkCreateParallel).
 type eventMemory struct {
-	mu     sync.RWMutex
-	events map[string]entity.Event
+	shards [eventShards]eventShard
+	// wal журналирует изменения перед их применением, если репозиторий сохраняет данные на диск.
+	// Заменяется только под блокировкой всех сегментов.
+	wal *wal
+}
+
+// Структура сегмента репозитория с событиями части пользователей.
+type eventShard struct {
+	mu    sync.RWMutex
+	users map[string]*userEvents
+	// index — полнотекстовый индекс событий сегмента, защищенный mu.
+	// Релевантность поэтому оценивается по статистике слов сегмента.
+	index *eventIndex
+}
+
+// Структура событий одного пользователя, проиндексированных по идентификатору и дате.
+type userEvents struct {
+	byID map[string]entity.Event
+	// byDate упорядочивает события по дате, а при равных датах по идентификатору.
+	byDate *btree.BTreeG[entity.Event]
+}
+
+// newUserEvents возвращает пустой набор событий пользователя.
+func newUserEvents() *userEvents {
+	return &userEvents{byID: make(map[string]entity.Event), byDate: btree.NewG(eventTreeDegree, eventLess)}
+}
+
+// eventLess сравнивает события по дате и идентификатору.
+func eventLess(a, b entity.Event) bool {
+	if c := a.Date.Compare(b.Date); c != 0 {
+		return c < 0
+	}
+	return strings.Compare(a.ID, b.ID) < 0
 }
 
 // NewEventMemory возвращает in-memory репозиторий, реализующий интерфейс.
-func NewEventMemory() Event { return &eventMemory{events: make(map[string]entity.Event)} }
+func NewEventMemory() Event {
+	return newEventMemory()
+}
+
+// newEventMemory возвращает пустой in-memory репозиторий.
+func newEventMemory() *eventMemory {
+	e := &eventMemory{}
+	for i := range e.shards {
+		e.shards[i].users = make(map[string]*userEvents)
+		e.shards[i].index = newEventIndex()
+	}
+	return e
+}
+
+// shardIndex возвращает номер сегмента, в котором хранятся события пользователя userID.
+func shardIndex(userID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(userID))
+	return int(h.Sum32() % eventShards)
+}
+
+// shard возвращает сегмент, в котором хранятся события пользователя userID.
+func (e *eventMemory) shard(userID string) *eventShard {
+	return &e.shards[shardIndex(userID)]
+}
+
+// lockUsers блокирует на запись сегменты пользователей a и b в порядке их номеров,
+// чтобы одновременные операции над парами пользователей не взаимоблокировались.
+// Возвращает функцию снятия блокировки.
+func (e *eventMemory) lockUsers(a string, b string) (unlock func()) {
+	i, j := shardIndex(a), shardIndex(b)
+	if i == j {
+		e.shards[i].mu.Lock()
+		return e.shards[i].mu.Unlock
+	}
+	if i > j {
+		i, j = j, i
+	}
+	e.shards[i].mu.Lock()
+	e.shards[j].mu.Lock()
+	return func() {
+		e.shards[j].mu.Unlock()
+		e.shards[i].mu.Unlock()
+	}
+}
+
+// lockAll блокирует все сегменты на запись в порядке их номеров.
+func (e *eventMemory) lockAll() {
+	for i := range e.shards {
+		e.shards[i].mu.Lock()
+	}
+}
+
+// unlockAll снимает блокировку всех сегментов.
+func (e *eventMemory) unlockAll() {
+	for i := range e.shards {
+		e.shards[i].mu.Unlock()
+	}
+}
+
+// all возвращает все события репозитория. Вызывается под блокировкой всех сегментов.
+func (e *eventMemory) all() iter.Seq[entity.Event] {
+	return func(yield func(entity.Event) bool) {
+		for i := range e.shards {
+			for event := range e.shards[i].all() {
+				if !yield(event) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// all возвращает все события сегмента s. Вызывается под блокировкой сегмента.
+func (s *eventShard) all() iter.Seq[entity.Event] {
+	return func(yield func(entity.Event) bool) {
+		for _, user := range s.users {
+			for _, event := range user.byID {
+				if !yield(event) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// put добавляет или заменяет событие в сегменте s без журналирования и обновления
+// полнотекстового индекса. Вызывается под блокировкой сегмента.
+func (s *eventShard) put(event entity.Event) {
+	user, ok := s.users[event.UserID]
+	if !ok {
+		user = newUserEvents()
+		s.users[event.UserID] = user
+	}
+	if old, ok := user.byID[event.ID]; ok {
+		user.byDate.Delete(old)
+	}
+	user.byID[event.ID] = event
+	user.byDate.ReplaceOrInsert(event)
+}
+
+// remove удаляет событие из сегмента s без журналирования и обновления
+// полнотекстового индекса. Вызывается под блокировкой сегмента.
+func (s *eventShard) remove(event entity.Event) {
+	user, ok := s.users[event.UserID]
+	if !ok {
+		return
+	}
+	if old, ok := user.byID[event.ID]; ok {
+		user.byDate.Delete(old)
+		delete(user.byID, event.ID)
+	}
+	if len(user.byID) == 0 {
+		delete(s.users, event.UserID)
+	}
+}
+
+// removeUser удаляет все события пользователя userID из сегмента s без журналирования
+// и обновления полнотекстового индекса. Вызывается под блокировкой сегмента.
+func (s *eventShard) removeUser(userID string) {
+	delete(s.users, userID)
+}
+
+// move переносит события ids пользователя from в сегмент dst пользователю to, устанавливая
+// им время изменения updatedAt, без журналирования и обновления полнотекстового индекса.
+// Возвращает перенесенные события. Вызывается под блокировкой обоих сегментов.
+func (s *eventShard) move(dst *eventShard, from string, to string, ids []string, updatedAt time.Time) []entity.Event {
+	moved := make([]entity.Event, 0, len(ids))
+	for _, id := range ids {
+		event, ok := s.get(from, id)
+		if !ok {
+			continue
+		}
+		s.remove(event)
+		event.UserID = to
+		event.UpdatedAt = updatedAt
+		dst.put(event)
+		moved = append(moved, event)
+	}
+	return moved
+}
+
+// find возвращает события пользователя userID, соответствующие filter, упорядоченные по дате.
+// Вызывается под блокировкой сегмента.
+func (s *eventShard) find(userID string, filter entity.EventFilter) []entity.Event {
+	events := make([]entity.Event, 0)
+	user, ok := s.users[userID]
+	if !ok {
+		return events
+	}
+	if len(filter.IDs) > 0 {
+		for _, id := range filter.IDs {
+			if event, ok := user.byID[id]; ok && filter.Match(event) {
+				events = append(events, event)
+			}
+		}
+		slices.SortFunc(events, func(a, b entity.Event) int {
+			if c := a.Date.Compare(b.Date); c != 0 {
+				return c
+			}
+			return strings.Compare(a.ID, b.ID)
+		})
+		return slices.CompactFunc(events, func(a, b entity.Event) bool { return a.ID == b.ID })
+	}
+	user.byDate.AscendGreaterOrEqual(entity.Event{Date: filter.DateStart}, func(event entity.Event) bool {
+		if !filter.DateEnd.IsZero() && event.Date.After(filter.DateEnd) {
+			return false
+		}
+		events = append(events, event)
+		return true
+	})
+	return events
+}
+
+// get возвращает событие пользователя userID с идентификатором id.
+// Вызывается под блокировкой сегмента.
+func (s *eventShard) get(userID string, id string) (entity.Event, bool) {
+	user, ok := s.users[userID]
+	if !ok {
+		return entity.EmptyEvent, false
+	}
+	event, ok := user.byID[id]
+	return event, ok
+}
+
+// reindex перестраивает полнотекстовые индексы сегментов по их событиям.
+func (e *eventMemory) reindex() {
+	for i := range e.shards {
+		s := &e.shards[i]
+		s.mu.Lock()
+		s.index.rebuild(s.all())
+		s.mu.Unlock()
+	}
+}
 
 // GetByID возвращает Event по его userID и id или ошибку, если Event не найден.
 func (e *eventMemory) GetByID(ctx context.Context, userID string, id string) (entity.Event, error) {
-	e.mu.RLock()
-	event, ok := e.events[id]
-	e.mu.RUnlock()
-	if !ok || userID != event.UserID {
+	if err := ctx.Err(); err != nil {
+		return entity.EmptyEvent, err
+	}
+	s := e.shard(userID)
+	s.mu.RLock()
+	event, ok := s.get(userID, id)
+	s.mu.RUnlock()
+	if !ok {
 		return event, ErrNotExist
 	}
 	return event, nil
 }
 
-// GetForRange возвращает []Event по его userID и диапазону дат.
+// GetForRange возвращает []Event по его userID и диапазону дат, упорядоченные по дате.
+// Время выполнения O(log n + k), где n — количество событий пользователя,
+// а k — количество найденных событий.
 func (e *eventMemory) GetForRange(ctx context.Context, userID string, dateStart, dateEnd time.Time) ([]entity.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	events := make([]entity.Event, 0)
-	e.mu.RLock()
-	for _, event := range e.events {
-		if event.UserID == userID && event.Date.Compare(dateStart) >= 0 && event.Date.Compare(dateEnd) <= 0 {
-			events = append(events, event)
+	s := e.shard(userID)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, ok := s.users[userID]
+	if !ok {
+		return events, nil
+	}
+	// Пустой идентификатор меньше любого другого, поэтому событие с датой dateStart попадает в диапазон.
+	var err error
+	user.byDate.AscendGreaterOrEqual(entity.Event{Date: dateStart}, func(event entity.Event) bool {
+		if event.Date.Compare(dateEnd) > 0 {
+			return false
 		}
+		if len(events)%ctxCheckInterval == ctxCheckInterval-1 {
+			if err = ctx.Err(); err != nil {
+				return false
+			}
+		}
+		events = append(events, event)
+		return true
+	})
+	if err != nil {
+		return nil, err
 	}
-	e.mu.RUnlock()
 	return events, nil
 }
 
-// Create добавляет новый Event в репозиторий, генерируя для него случайный id.
-// Возвращает созданный и добавленный Event.
+// GetUpcoming возвращает не более limit событий пользователя userID с датой не раньше
+// dateStart, упорядоченные по дате. Обход дерева останавливается после limit событий,
+// поэтому время выполнения O(log n + limit). При limit <= 0 событий нет.
+func (e *eventMemory) GetUpcoming(ctx context.Context, userID string, dateStart time.Time, limit int) ([]entity.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	events := make([]entity.Event, 0)
+	if limit <= 0 {
+		return events, nil
+	}
+	s := e.shard(userID)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, ok := s.users[userID]
+	if !ok {
+		return events, nil
+	}
+	user.byDate.AscendGreaterOrEqual(entity.Event{Date: dateStart}, func(event entity.Event) bool {
+		events = append(events, event)
+		return len(events) < limit
+	})
+	return events, nil
+}
+
+// Create добавляет новый Event в репозиторий, генерируя для него случайный id, если id не задан.
+// Возвращает созданный и добавленный Event или ErrExists, если у пользователя уже есть
+// событие с заданным id.
 func (e *eventMemory) Create(ctx context.Context, event entity.Event) (entity.Event, error) {
-	event.ID = uuid.NewString()
-	e.mu.Lock()
-	e.events[event.ID] = event
-	e.mu.Unlock()
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	s := e.shard(event.UserID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Изменение не применяется, если ctx отменен во время ожидания блокировки.
+	if err := ctx.Err(); err != nil {
+		return entity.EmptyEvent, err
+	}
+	if _, ok := s.get(event.UserID, event.ID); ok {
+		return entity.EmptyEvent, ErrExists
+	}
+	if err := e.log(walOpPut, event); err != nil {
+		return entity.EmptyEvent, err
+	}
+	s.put(event)
+	s.index.add(event)
 	return event, nil
 }
 
 // Update обновляет Event в репозитории.
 // Возвращает обновленный Event, если Event существует, иначе возвращает ошибку.
 func (e *eventMemory) Update(ctx context.Context, event entity.Event) (entity.Event, error) {
-	_, err := e.GetByID(ctx, event.UserID, event.ID)
-	if err != nil {
+	s := e.shard(event.UserID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
 		return entity.EmptyEvent, err
 	}
-	e.mu.Lock()
-	e.events[event.ID] = event
-	e.mu.Unlock()
+	if _, ok := s.get(event.UserID, event.ID); !ok {
+		return entity.EmptyEvent, ErrNotExist
+	}
+	if err := e.log(walOpPut, event); err != nil {
+		return entity.EmptyEvent, err
+	}
+	s.put(event)
+	s.index.add(event)
 	return event, nil
 }
 
 // Delete удаляет Event из репозитория, если Event существует, иначе возвращает ошибку.
 func (e *eventMemory) Delete(ctx context.Context, userID string, id string) error {
-	_, err := e.GetByID(ctx, userID, id)
-	if err != nil {
+	s := e.shard(userID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	event, ok := s.get(userID, id)
+	if !ok {
+		return ErrNotExist
+	}
+	if err := e.log(walOpDelete, event); err != nil {
 		return err
 	}
-	e.mu.Lock()
-	delete(e.events, id)
-	e.mu.Unlock()
+	s.remove(event)
+	s.index.remove(userID, id)
 	return nil
 }
+
+// Search возвращает []Event пользователя userID, в названии или описании которых
+// встречаются все слова и фразы запроса query, упорядоченные по релевантности.
+func (e *eventMemory) Search(ctx context.Context, userID string, query string) ([]entity.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s := e.shard(userID)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := s.index.search(userID, query)
+	events := make([]entity.Event, 0, len(ids))
+	for _, id := range ids {
+		if event, ok := s.get(userID, id); ok {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// CountByUser возвращает количество Event пользователя userID.
+func (e *eventMemory) CountByUser(ctx context.Context, userID string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s := e.shard(userID)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if user, ok := s.users[userID]; ok {
+		return len(user.byID), nil
+	}
+	return 0, nil
+}
+
+// Transfer передает события пользователя fromUserID, соответствующие filter, пользователю toUserID.
+// Изменение журналируется одной записью, поэтому после сбоя события не разделяются между пользователями.
+func (e *eventMemory) Transfer(ctx context.Context, fromUserID string, toUserID string, filter entity.EventFilter, updatedAt time.Time) ([]entity.Event, error) {
+	unlock := e.lockUsers(fromUserID, toUserID)
+	defer unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	src, dst := e.shard(fromUserID), e.shard(toUserID)
+	events := src.find(fromUserID, filter)
+	if len(events) == 0 {
+		return events, nil
+	}
+	ids := make([]string, len(events))
+	for i, event := range events {
+		if _, ok := dst.get(toUserID, event.ID); ok {
+			return nil, ErrExists
+		}
+		ids[i] = event.ID
+	}
+
+	entry := walEntry{Op: walOpMove, Event: entity.Event{UserID: fromUserID, UpdatedAt: updatedAt}, To: toUserID, IDs: ids}
+	if err := e.logEntry(entry); err != nil {
+		return nil, err
+	}
+	moved := src.move(dst, fromUserID, toUserID, ids, updatedAt)
+	for _, event := range moved {
+		src.index.remove(fromUserID, event.ID)
+		dst.index.add(event)
+	}
+	return moved, nil
+}
+
+// DeleteByUser удаляет все события пользователя userID и возвращает их, упорядоченные по дате.
+func (e *eventMemory) DeleteByUser(ctx context.Context, userID string) ([]entity.Event, error) {
+	s := e.shard(userID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	events := s.find(userID, entity.EventFilter{})
+	if len(events) == 0 {
+		return events, nil
+	}
+	if err := e.logEntry(walEntry{Op: walOpDeleteUser, Event: entity.Event{UserID: userID}}); err != nil {
+		return nil, err
+	}
+	s.removeUser(userID)
+	for _, event := range events {
+		s.index.remove(userID, event.ID)
+	}
+	return events, nil
+}
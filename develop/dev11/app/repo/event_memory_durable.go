@@ -0,0 +1,296 @@
+package repo
+
+import (
+	"dev11/app/entity"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Операции, записываемые в журнал репозитория.
+const (
+	walOpPut        = "put"
+	walOpDelete     = "delete"
+	walOpMove       = "move"
+	walOpDeleteUser = "delete_user"
+)
+
+// Структура записи журнала репозитория. Операции над всеми событиями пользователя
+// записываются одной записью, в которой Event содержит только UserID и UpdatedAt.
+type walEntry struct {
+	Op    string       `json:"op"`
+	Event entity.Event `json:"event"`
+	// To и IDs задают получателя и идентификаторы событий, передаваемых операцией walOpMove.
+	To  string   `json:"to,omitempty"`
+	IDs []string `json:"ids,omitempty"`
+}
+
+// log записывает изменение в журнал, если он используется. Вызывается под блокировкой
+// сегмента события, поэтому порядок записей одного события совпадает с порядком изменений.
+func (e *eventMemory) log(op string, event entity.Event) error {
+	return e.logEntry(walEntry{Op: op, Event: event})
+}
+
+// logEntry записывает запись entry в журнал, если он используется.
+// Вызывается под блокировкой сегментов всех пользователей записи.
+func (e *eventMemory) logEntry(entry walEntry) error {
+	if e.wal == nil {
+		return nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return e.wal.Append(data)
+}
+
+// apply применяет запись журнала data к событиям без журналирования.
+func (e *eventMemory) apply(data []byte) error {
+	var entry walEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return fmt.Errorf("wal: %w", ErrCorrupted)
+	}
+	switch entry.Op {
+	case walOpPut:
+		e.shard(entry.Event.UserID).put(entry.Event)
+	case walOpDelete:
+		e.shard(entry.Event.UserID).remove(entry.Event)
+	case walOpMove:
+		e.shard(entry.Event.UserID).move(e.shard(entry.To), entry.Event.UserID, entry.To, entry.IDs, entry.Event.UpdatedAt)
+	case walOpDeleteUser:
+		e.shard(entry.Event.UserID).removeUser(entry.Event.UserID)
+	default:
+		return fmt.Errorf("wal: unknown operation %q: %w", entry.Op, ErrCorrupted)
+	}
+	return nil
+}
+
+// Параметры репозитория, сохраняющего данные на диск.
+type DurableOptions struct {
+	// Sync задает политику сброса журнала на диск.
+	Sync SyncPolicy
+	// SyncInterval задает интервал сброса журнала на диск при политике SyncInterval.
+	SyncInterval time.Duration
+}
+
+// Интерфейс репозитория для сущности "событие", сохраняющего данные на диск.
+type EventDurable interface {
+	Event
+	// Snapshot сохраняет снимок всех событий и удаляет журналы, вошедшие в него.
+	Snapshot() error
+	// Close сбрасывает журнал на диск и закрывает его.
+	Close() error
+}
+
+// Структура in-memory репозитория для сущности "событие", который журналирует
+// изменения в директорию dir и периодически сохраняет в нее снимки событий.
+// Файлы снимков и журналов нумеруются поколениями: снимок поколения N содержит
+// все изменения из журналов поколений меньше N.
+type eventMemoryDurable struct {
+	*eventMemory
+	dir  string
+	opts DurableOptions
+	// snapshotMu запрещает одновременное создание нескольких снимков.
+	snapshotMu sync.Mutex
+	gen        uint64
+	lock       *os.File
+}
+
+// NewEventMemoryDurable возвращает in-memory репозиторий, восстанавливая события
+// из последнего снимка и журналов в директории dir.
+// Обрезанная последняя запись журнала отбрасывается, при повреждении данных
+// возвращается ошибка, оборачивающая ErrCorrupted.
+func NewEventMemoryDurable(dir string, opts DurableOptions) (EventDurable, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	lock, err := lockDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := openEventMemoryDurable(dir, opts)
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	e.lock = lock
+	return e, nil
+}
+
+// openEventMemoryDurable восстанавливает события из директории dir и открывает журнал.
+func openEventMemoryDurable(dir string, opts DurableOptions) (*eventMemoryDurable, error) {
+	snapshots, err := listGenerations(dir, "snapshot-*.json")
+	if err != nil {
+		return nil, err
+	}
+	segments, err := listGenerations(dir, "wal-*.log")
+	if err != nil {
+		return nil, err
+	}
+
+	e := &eventMemoryDurable{
+		eventMemory: newEventMemory(),
+		dir:         dir,
+		opts:        opts,
+	}
+
+	if len(snapshots) > 0 {
+		e.gen = snapshots[len(snapshots)-1]
+		if err := e.loadSnapshot(e.gen); err != nil {
+			return nil, err
+		}
+	}
+
+	segments = slices.DeleteFunc(segments, func(gen uint64) bool { return gen < e.gen })
+	for i, gen := range segments {
+		path := e.walPath(gen)
+		size, err := replayWAL(path, e.apply)
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if size < info.Size() {
+			// Обрезанная запись допустима только в конце последнего журнала.
+			if i != len(segments)-1 {
+				return nil, fmt.Errorf("wal %s: %w", path, ErrCorrupted)
+			}
+			if err := os.Truncate(path, size); err != nil {
+				return nil, err
+			}
+		}
+		e.gen = gen
+	}
+	e.reindex()
+
+	e.wal, err = openWAL(e.walPath(e.gen), opts.Sync, opts.SyncInterval)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// listGenerations возвращает отсортированные номера поколений файлов в dir по шаблону pattern.
+func listGenerations(dir string, pattern string) ([]uint64, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, suffix, _ := strings.Cut(pattern, "*")
+	gens := make([]uint64, 0, len(paths))
+	for _, path := range paths {
+		var gen uint64
+		if _, err := fmt.Sscanf(filepath.Base(path), prefix+"%d"+suffix, &gen); err != nil {
+			continue
+		}
+		gens = append(gens, gen)
+	}
+	slices.Sort(gens)
+	return gens, nil
+}
+
+// snapshotPath возвращает путь к снимку поколения gen.
+// Снимок записывается writeFileAtomic: после 4 байт контрольной суммы следует JSON-массив событий,
+// поэтому расширение .json относится только к данным, а не ко всему файлу.
+func (e *eventMemoryDurable) snapshotPath(gen uint64) string {
+	return filepath.Join(e.dir, fmt.Sprintf("snapshot-%020d.json", gen))
+}
+
+// walPath возвращает путь к журналу поколения gen.
+func (e *eventMemoryDurable) walPath(gen uint64) string {
+	return filepath.Join(e.dir, fmt.Sprintf("wal-%020d.log", gen))
+}
+
+// loadSnapshot загружает события из снимка поколения gen.
+func (e *eventMemoryDurable) loadSnapshot(gen uint64) error {
+	data, err := readFileChecked(e.snapshotPath(gen))
+	if err != nil {
+		return err
+	}
+
+	var events []entity.Event
+	if err := json.Unmarshal(data, &events); err != nil {
+		return fmt.Errorf("snapshot: %w", ErrCorrupted)
+	}
+	for _, event := range events {
+		e.shard(event.UserID).put(event)
+	}
+	return nil
+}
+
+// Snapshot сохраняет снимок всех событий и удаляет журналы, вошедшие в него.
+// Запись в репозиторий блокируется только на время копирования событий и смены журнала.
+func (e *eventMemoryDurable) Snapshot() error {
+	e.snapshotMu.Lock()
+	defer e.snapshotMu.Unlock()
+
+	e.lockAll()
+	events := slices.Collect(e.all())
+	gen := e.gen + 1
+	next, err := openWAL(e.walPath(gen), e.opts.Sync, e.opts.SyncInterval)
+	if err != nil {
+		e.unlockAll()
+		return err
+	}
+	prev := e.wal
+	e.wal, e.gen = next, gen
+	e.unlockAll()
+
+	if err := prev.Close(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(e.snapshotPath(gen), data); err != nil {
+		return err
+	}
+	if err := syncDir(e.dir); err != nil {
+		return err
+	}
+
+	// Снимок сохранен, поэтому предыдущие снимки и журналы больше не нужны.
+	return errors.Join(
+		e.removeBefore(gen, "snapshot-*.json", e.snapshotPath),
+		e.removeBefore(gen, "wal-*.log", e.walPath),
+	)
+}
+
+// removeBefore удаляет файлы по шаблону pattern с поколениями меньше gen.
+func (e *eventMemoryDurable) removeBefore(gen uint64, pattern string, path func(gen uint64) string) error {
+	gens, err := listGenerations(e.dir, pattern)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, g := range gens {
+		if g >= gen {
+			break
+		}
+		if err := os.Remove(path(g)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close сбрасывает журнал на диск, закрывает его и снимает блокировку директории.
+func (e *eventMemoryDurable) Close() error {
+	e.lockAll()
+	defer e.unlockAll()
+	return errors.Join(e.wal.Close(), e.lock.Close())
+}
@@ -0,0 +1,233 @@
+package repo
+
+import (
+	"context"
+	"dev11/app/entity"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewEventMemoryDurable(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 20, 0, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+
+	e, err := NewEventMemoryDurable(dir, DurableOptions{Sync: SyncAlways})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := e.Create(ctx, entity.Event{UserID: userID, Title: "first", Date: date})
+	second, _ := e.Create(ctx, entity.Event{UserID: userID, Title: "second", Date: date})
+	if err := e.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	second.Title = "second updated"
+	e.Update(ctx, second)
+	e.Delete(ctx, userID, first.ID)
+	third, _ := e.Create(ctx, entity.Event{UserID: userID, Title: "third", Date: date})
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Restored", func(t *testing.T) {
+		e, err := NewEventMemoryDurable(dir, DurableOptions{Sync: SyncNever})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer e.Close()
+
+		for _, want := range []entity.Event{second, third} {
+			got, err := e.GetByID(ctx, userID, want.ID)
+			if err != nil {
+				t.Errorf("eventMemoryDurable.GetByID() error = %v, wantErr %v", err, false)
+				continue
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("eventMemoryDurable.GetByID() = %v, want %v", got, want)
+			}
+		}
+		if _, err := e.GetByID(ctx, userID, first.ID); err != ErrNotExist {
+			t.Errorf("eventMemoryDurable.GetByID() error = %v, want %v", err, ErrNotExist)
+		}
+		if got, _ := e.Search(ctx, userID, "updated"); len(got) != 1 {
+			t.Errorf("eventMemoryDurable.Search() = %v, want 1 event", got)
+		}
+	})
+
+	t.Run("SnapshotRemovesOldFiles", func(t *testing.T) {
+		e, err := NewEventMemoryDurable(dir, DurableOptions{Sync: SyncAlways})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Snapshot(); err != nil {
+			t.Fatal(err)
+		}
+		e.Close()
+
+		snapshots, _ := listGenerations(dir, "snapshot-*.json")
+		segments, _ := listGenerations(dir, "wal-*.log")
+		if len(snapshots) != 1 || len(segments) != 1 || snapshots[0] != segments[0] {
+			t.Errorf("eventMemoryDurable.Snapshot() snapshots = %v, segments = %v", snapshots, segments)
+		}
+	})
+}
+
+func TestNewEventMemoryDurable_TruncatedTail(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	dir := t.TempDir()
+
+	e, err := NewEventMemoryDurable(dir, DurableOptions{Sync: SyncInterval, SyncInterval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := e.Create(ctx, entity.Event{UserID: userID, Title: "first"})
+	second, _ := e.Create(ctx, entity.Event{UserID: userID, Title: "second"})
+	e.Close()
+
+	path := filepath.Join(dir, "wal-00000000000000000000.log")
+	info, _ := os.Stat(path)
+	os.Truncate(path, info.Size()-1)
+
+	e, err = NewEventMemoryDurable(dir, DurableOptions{})
+	if err != nil {
+		t.Fatalf("NewEventMemoryDurable() error = %v, wantErr %v", err, false)
+	}
+	if _, err := e.GetByID(ctx, userID, first.ID); err != nil {
+		t.Errorf("eventMemoryDurable.GetByID() error = %v, wantErr %v", err, false)
+	}
+	if _, err := e.GetByID(ctx, userID, second.ID); err != ErrNotExist {
+		t.Errorf("eventMemoryDurable.GetByID() error = %v, want %v", err, ErrNotExist)
+	}
+
+	// Новые записи должны добавляться после последней корректной записи.
+	third, _ := e.Create(ctx, entity.Event{UserID: userID, Title: "third"})
+	e.Close()
+
+	e, err = NewEventMemoryDurable(dir, DurableOptions{})
+	if err != nil {
+		t.Fatalf("NewEventMemoryDurable() error = %v, wantErr %v", err, false)
+	}
+	defer e.Close()
+	if _, err := e.GetByID(ctx, userID, third.ID); err != nil {
+		t.Errorf("eventMemoryDurable.GetByID() error = %v, wantErr %v", err, false)
+	}
+}
+
+func TestNewEventMemoryDurable_CorruptedLength(t *testing.T) {
+	ctx := context.Background()
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	dir := t.TempDir()
+
+	e, err := NewEventMemoryDurable(dir, DurableOptions{Sync: SyncAlways})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Create(ctx, entity.Event{UserID: userID, Title: "first"})
+	e.Create(ctx, entity.Event{UserID: userID, Title: "second"})
+	e.Create(ctx, entity.Event{UserID: userID, Title: "third"})
+	e.Close()
+
+	path := filepath.Join(dir, "wal-00000000000000000000.log")
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Старший байт длины второй записи делает ее длиннее оставшейся части журнала.
+	corrupt(t, path, walHeaderSize+int64(binary.LittleEndian.Uint32(buf[0:4]))+2)
+	before, _ := os.Stat(path)
+
+	if _, err := NewEventMemoryDurable(dir, DurableOptions{}); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("NewEventMemoryDurable() error = %v, want %v", err, ErrCorrupted)
+	}
+	if after, _ := os.Stat(path); after.Size() != before.Size() {
+		t.Errorf("wal size = %v, want %v", after.Size(), before.Size())
+	}
+}
+
+func TestNewEventMemoryDurable_CorruptedSnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	e, err := NewEventMemoryDurable(dir, DurableOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Create(context.Background(), entity.Event{Title: "event"})
+	e.Snapshot()
+	e.Close()
+
+	corrupt(t, filepath.Join(dir, "snapshot-00000000000000000001.json"), 5)
+
+	if _, err := NewEventMemoryDurable(dir, DurableOptions{}); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("NewEventMemoryDurable() error = %v, want %v", err, ErrCorrupted)
+	}
+}
+
+func TestNewEventMemoryDurable_Locked(t *testing.T) {
+	dir := t.TempDir()
+
+	e, err := NewEventMemoryDurable(dir, DurableOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewEventMemoryDurable(dir, DurableOptions{}); !errors.Is(err, ErrLocked) {
+		t.Errorf("NewEventMemoryDurable() error = %v, want %v", err, ErrLocked)
+	}
+
+	e.Close()
+	e, err = NewEventMemoryDurable(dir, DurableOptions{})
+	if err != nil {
+		t.Errorf("NewEventMemoryDurable() error = %v, wantErr %v", err, false)
+		return
+	}
+	e.Close()
+}
+
+func TestNewEventMemoryDurable_TransferAndDeleteByUser(t *testing.T) {
+	ctx := context.Background()
+	fromID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	toID := "28310e71-4df6-42c0-adf4-1a280013dd08"
+	otherID := "38310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 20, 0, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+
+	e, err := NewEventMemoryDurable(dir, DurableOptions{Sync: SyncAlways})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, _ := e.Create(ctx, entity.Event{UserID: fromID, Title: "moved", Date: date})
+	e.Create(ctx, entity.Event{UserID: otherID, Title: "deleted", Date: date})
+	moved, err := e.Transfer(ctx, fromID, toID, entity.EventFilter{}, date)
+	if err != nil || len(moved) != 1 {
+		t.Fatalf("eventMemoryDurable.Transfer() = %v, %v", moved, err)
+	}
+	if _, err := e.DeleteByUser(ctx, otherID); err != nil {
+		t.Fatal(err)
+	}
+	e.Close()
+
+	e, err = NewEventMemoryDurable(dir, DurableOptions{Sync: SyncNever})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	if got, err := e.GetByID(ctx, toID, event.ID); err != nil || !reflect.DeepEqual(got, moved[0]) {
+		t.Errorf("eventMemoryDurable.GetByID() = %v, %v, want %v", got, err, moved[0])
+	}
+	for _, userID := range []string{fromID, otherID} {
+		if n, _ := e.CountByUser(ctx, userID); n != 0 {
+			t.Errorf("eventMemoryDurable.CountByUser(%q) = %v, want 0", userID, n)
+		}
+	}
+}
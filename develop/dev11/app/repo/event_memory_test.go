@@ -7,12 +7,14 @@ import (
 	"reflect"
 	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
 func TestNewEventMemory(t *testing.T) {
-	want := &eventMemory{events: make(map[string]entity.Event)}
+	want := newEventMemory()
 	if got := NewEventMemory(); !reflect.DeepEqual(got, want) {
 		t.Errorf("NewEventMemory() = %v, want %v", got, want)
 	}
@@ -89,6 +91,89 @@ func Test_eventMemory_GetForRange(t *testing.T) {
 	}
 }
 
+func Test_eventMemory_GetUpcoming(t *testing.T) {
+	ctx := context.Background()
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC)
+
+	e := NewEventMemory()
+	e.Create(ctx, entity.Event{Title: "past", Date: date.Add(-time.Hour), UserID: userID})
+	late, _ := e.Create(ctx, entity.Event{Title: "late", Date: date.Add(2 * time.Hour), UserID: userID})
+	first, _ := e.Create(ctx, entity.Event{Title: "first", Date: date, UserID: userID})
+	second, _ := e.Create(ctx, entity.Event{Title: "second", Date: date, UserID: userID})
+	if second.ID < first.ID {
+		first, second = second, first
+	}
+
+	tests := []struct {
+		name   string
+		userID string
+		limit  int
+		want   []entity.Event
+	}{
+		{"All", userID, 10, []entity.Event{first, second, late}},
+		{"Limit", userID, 2, []entity.Event{first, second}},
+		{"UnknownUser", "unknown", 10, []entity.Event{}},
+		{"ZeroLimit", userID, 0, []entity.Event{}},
+		{"NegativeLimit", userID, -1, []entity.Event{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := e.GetUpcoming(ctx, tt.userID, date, tt.limit)
+			if err != nil {
+				t.Fatalf("eventMemory.GetUpcoming() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("eventMemory.GetUpcoming() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_eventMemory_GetForRangeOrder(t *testing.T) {
+	ctx := context.Background()
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC)
+
+	e := NewEventMemory()
+	late, _ := e.Create(ctx, entity.Event{Title: "late", Date: date.Add(2 * time.Hour), UserID: userID})
+	first, _ := e.Create(ctx, entity.Event{Title: "first", Date: date, UserID: userID})
+	second, _ := e.Create(ctx, entity.Event{Title: "second", Date: date, UserID: userID})
+	e.Create(ctx, entity.Event{Title: "other", Date: date, UserID: "other"})
+	if second.ID < first.ID {
+		first, second = second, first
+	}
+	moved := late
+	moved.Date = date.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		prepare func()
+		start   time.Time
+		end     time.Time
+		want    []entity.Event
+	}{
+		{"InclusiveBounds", func() {}, date, date.Add(2 * time.Hour), []entity.Event{first, second, late}},
+		{"EmptyRange", func() {}, date.Add(time.Minute), date.Add(time.Hour), []entity.Event{}},
+		{"UnknownUser", func() {}, date, date, []entity.Event{}},
+		{"UpdatedDate", func() { e.Update(ctx, moved) }, date.Add(-time.Hour), date, []entity.Event{moved, first, second}},
+		{"Deleted", func() { e.Delete(ctx, userID, first.ID) }, date, date, []entity.Event{second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare()
+			user := userID
+			if tt.name == "UnknownUser" {
+				user = "unknown"
+			}
+			got, err := e.GetForRange(ctx, user, tt.start, tt.end)
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("eventMemory.GetForRange() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
 func Test_eventMemory_Create(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -107,6 +192,60 @@ func Test_eventMemory_Create(t *testing.T) {
 	}
 }
 
+func Test_eventMemory_CreateWithID(t *testing.T) {
+	ctx := context.Background()
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	id := "28310e71-4df6-42c0-adf4-1a280013dd08"
+
+	e := NewEventMemory()
+
+	got, err := e.Create(ctx, entity.Event{ID: id, UserID: userID})
+	if err != nil || got.ID != id {
+		t.Fatalf("eventMemory.Create() = %v, %v, want id %v", got, err, id)
+	}
+	if _, err := e.Create(ctx, entity.Event{ID: id, UserID: userID}); err != ErrExists {
+		t.Errorf("eventMemory.Create() error = %v, want %v", err, ErrExists)
+	}
+	if n, _ := e.CountByUser(ctx, userID); n != 1 {
+		t.Errorf("eventMemory.CountByUser() = %v, want 1", n)
+	}
+}
+
+func Test_eventMemory_SharedID(t *testing.T) {
+	ctx := context.Background()
+	id := "28310e71-4df6-42c0-adf4-1a280013dd08"
+
+	// Пользователи одного сегмента с событием приглашения с общим идентификатором.
+	userA := benchUserID(0)
+	userB := userA
+	for i := 1; shardIndex(userB) != shardIndex(userA) || userB == userA; i++ {
+		userB = benchUserID(i)
+	}
+
+	e := NewEventMemory()
+	eventA, err := e.Create(ctx, entity.Event{ID: id, UserID: userA, Title: "Planning"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Create(ctx, entity.Event{ID: id, UserID: userB, Title: "Planning"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []entity.Event{eventA}
+	if got, _ := e.Search(ctx, userA, "planning"); !reflect.DeepEqual(got, want) {
+		t.Errorf("eventMemory.Search() = %v, want %v", got, want)
+	}
+	if err := e.Delete(ctx, userB, id); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := e.Search(ctx, userA, "planning"); !reflect.DeepEqual(got, want) {
+		t.Errorf("eventMemory.Search() after other user's Delete = %v, want %v", got, want)
+	}
+	if got, _ := e.Search(ctx, userB, "planning"); len(got) != 0 {
+		t.Errorf("eventMemory.Search() deleted = %v, want empty", got)
+	}
+}
+
 func Test_eventMemory_Update(t *testing.T) {
 	t.Run("EventExists", func(t *testing.T) {
 		userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
@@ -178,3 +317,404 @@ func Test_eventMemory_Delete(t *testing.T) {
 		}
 	})
 }
+
+func Test_eventMemory_Search(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	e := NewEventMemory()
+	event, _ := e.Create(ctx, entity.Event{UserID: userID, Title: "Daily standup"})
+	e.Create(ctx, entity.Event{UserID: userID, Title: "Retrospective"})
+
+	t.Run("Created", func(t *testing.T) {
+		want := []entity.Event{event}
+		got, err := e.Search(ctx, userID, "standup")
+		if err != nil {
+			t.Errorf("eventMemory.Search() error = %v, wantErr %v", err, false)
+			return
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("eventMemory.Search() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Updated", func(t *testing.T) {
+		event.Title = "Weekly sync"
+		e.Update(ctx, event)
+
+		if got, _ := e.Search(ctx, userID, "standup"); len(got) != 0 {
+			t.Errorf("eventMemory.Search() = %v, want empty", got)
+		}
+		want := []entity.Event{event}
+		if got, _ := e.Search(ctx, userID, "sync"); !reflect.DeepEqual(got, want) {
+			t.Errorf("eventMemory.Search() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Reindexed", func(t *testing.T) {
+		m := e.(*eventMemory)
+		for i := range m.shards {
+			m.shards[i].index = newEventIndex()
+		}
+		m.reindex()
+
+		want := []entity.Event{event}
+		if got, _ := e.Search(ctx, userID, "weekly"); !reflect.DeepEqual(got, want) {
+			t.Errorf("eventMemory.Search() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		e.Delete(ctx, userID, event.ID)
+
+		if got, _ := e.Search(ctx, userID, "sync"); len(got) != 0 {
+			t.Errorf("eventMemory.Search() = %v, want empty", got)
+		}
+	})
+}
+
+func Test_eventMemory_CountByUser(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	otherID := "28310e71-4df6-42c0-adf4-1a280013dd08"
+	ctx := context.Background()
+
+	e := NewEventMemory()
+	e.Create(ctx, entity.Event{UserID: userID})
+	e.Create(ctx, entity.Event{UserID: userID})
+	e.Create(ctx, entity.Event{UserID: otherID})
+
+	for _, tt := range []struct {
+		userID string
+		want   int
+	}{{userID, 2}, {otherID, 1}, {"", 0}} {
+		if got, err := e.CountByUser(ctx, tt.userID); err != nil || got != tt.want {
+			t.Errorf("eventMemory.CountByUser(%q) = %v, %v, want %v", tt.userID, got, err, tt.want)
+		}
+	}
+}
+
+func Test_eventMemory_Canceled(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	e := NewEventMemory()
+	event, _ := e.Create(context.Background(), entity.Event{UserID: userID})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := map[string]func() error{
+		"GetByID": func() error { _, err := e.GetByID(ctx, userID, event.ID); return err },
+		"GetForRange": func() error {
+			_, err := e.GetForRange(ctx, userID, time.Time{}, time.Now())
+			return err
+		},
+		"Create":      func() error { _, err := e.Create(ctx, entity.Event{UserID: userID}); return err },
+		"Update":      func() error { _, err := e.Update(ctx, event); return err },
+		"Delete":      func() error { return e.Delete(ctx, userID, event.ID) },
+		"Search":      func() error { _, err := e.Search(ctx, userID, "event"); return err },
+		"CountByUser": func() error { _, err := e.CountByUser(ctx, userID); return err },
+	}
+	for name, call := range calls {
+		if err := call(); err != context.Canceled {
+			t.Errorf("eventMemory.%s() error = %v, want %v", name, err, context.Canceled)
+		}
+	}
+	if n, _ := e.CountByUser(context.Background(), userID); n != 1 {
+		t.Errorf("eventMemory.CountByUser() = %v, want 1", n)
+	}
+}
+
+func Test_eventMemory_Transfer(t *testing.T) {
+	fromID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	toID := "28310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC)
+	updatedAt := date.Add(time.Hour)
+	ctx := context.Background()
+
+	newRepo := func() (Event, []entity.Event) {
+		e := NewEventMemory()
+		var events []entity.Event
+		for i := range 3 {
+			event, _ := e.Create(ctx, entity.Event{UserID: fromID, Title: fmt.Sprintf("event %d", i), Date: date.AddDate(0, 0, i)})
+			events = append(events, event)
+		}
+		return e, events
+	}
+
+	tests := []struct {
+		name   string
+		filter func(events []entity.Event) entity.EventFilter
+		want   []int
+	}{
+		{"All", func([]entity.Event) entity.EventFilter { return entity.EventFilter{} }, []int{0, 1, 2}},
+		{"IDs", func(events []entity.Event) entity.EventFilter {
+			return entity.EventFilter{IDs: []string{events[2].ID, events[0].ID, events[2].ID}}
+		}, []int{0, 2}},
+		{"Range", func([]entity.Event) entity.EventFilter {
+			return entity.EventFilter{DateStart: date.AddDate(0, 0, 1), DateEnd: date.AddDate(0, 0, 1)}
+		}, []int{1}},
+		{"Nothing", func([]entity.Event) entity.EventFilter { return entity.EventFilter{IDs: []string{"missing"}} }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, events := newRepo()
+			got, err := e.Transfer(ctx, fromID, toID, tt.filter(events), updatedAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := make([]entity.Event, 0)
+			for _, i := range tt.want {
+				event := events[i]
+				event.UserID, event.UpdatedAt = toID, updatedAt
+				want = append(want, event)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("eventMemory.Transfer() = %v, want %v", got, want)
+			}
+			if got, _ := e.GetForRange(ctx, toID, time.Time{}, date.AddDate(1, 0, 0)); !reflect.DeepEqual(got, want) {
+				t.Errorf("eventMemory.GetForRange() receiver = %v, want %v", got, want)
+			}
+			if n, _ := e.CountByUser(ctx, fromID); n != len(events)-len(want) {
+				t.Errorf("eventMemory.CountByUser() sender = %v, want %v", n, len(events)-len(want))
+			}
+			if found, _ := e.Search(ctx, toID, "event"); len(found) != len(want) {
+				t.Errorf("eventMemory.Search() receiver = %v, want %v events", found, len(want))
+			}
+			if found, _ := e.Search(ctx, fromID, "event"); len(found) != len(events)-len(want) {
+				t.Errorf("eventMemory.Search() sender = %v, want %v events", found, len(events)-len(want))
+			}
+		})
+	}
+
+	t.Run("Exists", func(t *testing.T) {
+		e, events := newRepo()
+		e.Transfer(ctx, fromID, toID, entity.EventFilter{IDs: []string{events[0].ID}}, updatedAt)
+		e.(*eventMemory).shard(fromID).put(events[0])
+
+		if _, err := e.Transfer(ctx, fromID, toID, entity.EventFilter{}, updatedAt); err != ErrExists {
+			t.Errorf("eventMemory.Transfer() error = %v, want %v", err, ErrExists)
+		}
+		if n, _ := e.CountByUser(ctx, fromID); n != len(events) {
+			t.Errorf("eventMemory.CountByUser() sender = %v, want %v", n, len(events))
+		}
+	})
+}
+
+func Test_eventMemory_TransferConcurrent(t *testing.T) {
+	userIDs := []string{"18310e71-4df6-42c0-adf4-1a280013dd08", "28310e71-4df6-42c0-adf4-1a280013dd08"}
+	ctx := context.Background()
+
+	e := NewEventMemory()
+	for _, userID := range userIDs {
+		for range 10 {
+			e.Create(ctx, entity.Event{UserID: userID})
+		}
+	}
+
+	// Встречные передачи блокируют сегменты в одном порядке и не взаимоблокируются.
+	var wg sync.WaitGroup
+	for i := range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				e.Transfer(ctx, userIDs[i], userIDs[1-i], entity.EventFilter{}, time.Time{})
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for _, userID := range userIDs {
+		n, _ := e.CountByUser(ctx, userID)
+		total += n
+	}
+	if total != 20 {
+		t.Errorf("eventMemory.CountByUser() total = %v, want 20", total)
+	}
+}
+
+func Test_eventMemory_DeleteByUser(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	otherID := "28310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	e := NewEventMemory()
+	second, _ := e.Create(ctx, entity.Event{UserID: userID, Title: "event", Date: date.Add(time.Hour)})
+	first, _ := e.Create(ctx, entity.Event{UserID: userID, Title: "event", Date: date})
+	other, _ := e.Create(ctx, entity.Event{UserID: otherID, Title: "event", Date: date})
+
+	got, err := e.DeleteByUser(ctx, userID)
+	if want := []entity.Event{first, second}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("eventMemory.DeleteByUser() = %v, %v, want %v", got, err, want)
+	}
+	if n, _ := e.CountByUser(ctx, userID); n != 0 {
+		t.Errorf("eventMemory.CountByUser() = %v, want 0", n)
+	}
+	if found, _ := e.Search(ctx, userID, "event"); len(found) != 0 {
+		t.Errorf("eventMemory.Search() = %v, want empty", found)
+	}
+	if _, err := e.GetByID(ctx, otherID, other.ID); err != nil {
+		t.Errorf("eventMemory.GetByID() other user error = %v", err)
+	}
+	if got, err := e.DeleteByUser(ctx, userID); err != nil || len(got) != 0 {
+		t.Errorf("eventMemory.DeleteByUser() again = %v, %v, want empty", got, err)
+	}
+}
+
+// Структура репозитория с линейным поиском по всем событиям под одной блокировкой,
+// с которым сравнивается eventMemory в бенчмарках.
+type eventScan struct {
+	mu     sync.RWMutex
+	events map[string]entity.Event
+}
+
+// GetForRange возвращает события пользователя userID в диапазоне дат, просматривая все события.
+func (e *eventScan) GetForRange(ctx context.Context, userID string, dateStart, dateEnd time.Time) ([]entity.Event, error) {
+	events := make([]entity.Event, 0)
+	e.mu.RLock()
+	for _, event := range e.events {
+		if event.UserID == userID && event.Date.Compare(dateStart) >= 0 && event.Date.Compare(dateEnd) <= 0 {
+			events = append(events, event)
+		}
+	}
+	e.mu.RUnlock()
+	return events, nil
+}
+
+// Update заменяет событие.
+func (e *eventScan) Update(ctx context.Context, event entity.Event) (entity.Event, error) {
+	e.mu.Lock()
+	e.events[event.ID] = event
+	e.mu.Unlock()
+	return event, nil
+}
+
+// Параметры данных бенчмарков.
+const (
+	benchUsers  = 10_000
+	benchEvents = 1_000_000
+)
+
+var (
+	benchOnce    sync.Once
+	benchScan    *eventScan
+	benchIndexed *eventMemory
+	benchStart   = time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// benchRepos возвращает репозитории с benchEvents событиями benchUsers пользователей,
+// равномерно распределенными по году. Репозитории создаются один раз для всех бенчмарков.
+func benchRepos(b *testing.B) (*eventScan, *eventMemory) {
+	b.Helper()
+	benchOnce.Do(func() {
+		benchScan = &eventScan{events: make(map[string]entity.Event, benchEvents)}
+		benchIndexed = newEventMemory()
+		for i := range benchEvents {
+			event := entity.Event{
+				ID:     fmt.Sprintf("event-%d", i),
+				Title:  "event",
+				Date:   benchStart.Add(time.Duration(i/benchUsers) * 365 * 24 * time.Hour / (benchEvents / benchUsers)),
+				UserID: benchUserID(i % benchUsers),
+			}
+			benchScan.events[event.ID] = event
+			benchIndexed.shard(event.UserID).put(event)
+		}
+	})
+	return benchScan, benchIndexed
+}
+
+// benchUserID возвращает идентификатор i-го пользователя бенчмарков.
+func benchUserID(i int) string { return fmt.Sprintf("user-%d", i) }
+
+// Интерфейс репозитория в бенчмарках.
+type benchRepo interface {
+	GetForRange(ctx context.Context, userID string, dateStart, dateEnd time.Time) ([]entity.Event, error)
+	Update(ctx context.Context, event entity.Event) (entity.Event, error)
+}
+
+// BenchmarkGetForRange сравнивает запрос недели событий пользователя
+// при линейном поиске и при индексе по дате.
+func BenchmarkGetForRange(b *testing.B) {
+	scan, indexed := benchRepos(b)
+	ctx := context.Background()
+	start := benchStart.AddDate(0, 6, 0)
+
+	for _, bm := range []struct {
+		name string
+		repo benchRepo
+	}{{"Scan", scan}, {"Indexed", indexed}} {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := range b.N {
+				events, _ := bm.repo.GetForRange(ctx, benchUserID(i%benchUsers), start, start.AddDate(0, 0, 7))
+				if len(events) == 0 {
+					b.Fatal("GetForRange() = empty")
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkGetForRangeParallel сравнивает параллельные запросы недели событий,
+// перемежаемые изменением событий, при одной блокировке и при блокировке сегментов.
+func BenchmarkGetForRangeParallel(b *testing.B) {
+	scan, indexed := benchRepos(b)
+	ctx := context.Background()
+	start := benchStart.AddDate(0, 6, 0)
+
+	for _, bm := range []struct {
+		name string
+		repo benchRepo
+	}{{"Scan", scan}, {"Indexed", indexed}} {
+		b.Run(bm.name, func(b *testing.B) {
+			var next atomic.Int64
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := int(next.Add(1))
+					userID := benchUserID(i % benchUsers)
+					// Каждый десятый запрос изменяет событие пользователя.
+					if i%10 == 0 {
+						event := entity.Event{ID: fmt.Sprintf("event-%d", i%benchEvents), Title: "event", UserID: benchUserID(i % benchEvents % benchUsers)}
+						event.Date = benchStart.Add(time.Duration(i%benchEvents/benchUsers) * 365 * 24 * time.Hour / (benchEvents / benchUsers))
+						bm.repo.Update(ctx, event)
+						continue
+					}
+					bm.repo.GetForRange(ctx, userID, start, start.AddDate(0, 0, 7))
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkCreateParallel измеряет параллельное создание событий разными пользователями
+// в памяти, где изменения сегментов не блокируют друг друга, и с журналом на диске,
+// записи в который выполняются последовательно.
+func BenchmarkCreateParallel(b *testing.B) {
+	ctx := context.Background()
+	durable, err := NewEventMemoryDurable(b.TempDir(), DurableOptions{Sync: SyncNever})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer durable.Close()
+
+	for _, bm := range []struct {
+		name string
+		repo Event
+	}{{"Memory", NewEventMemory()}, {"Durable", durable}} {
+		b.Run(bm.name, func(b *testing.B) {
+			var next atomic.Int64
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := int(next.Add(1))
+					event := entity.Event{Title: "weekly standup notes", Date: benchStart, UserID: benchUserID(i % benchUsers)}
+					if _, err := bm.repo.Create(ctx, event); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
@@ -41,6 +41,21 @@ func (m *MockEvent) EXPECT() *MockEventMockRecorder {
 	return m.recorder
 }
 
+// CountByUser mocks base method.
+func (m *MockEvent) CountByUser(ctx context.Context, userID string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByUser", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByUser indicates an expected call of CountByUser.
+func (mr *MockEventMockRecorder) CountByUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByUser", reflect.TypeOf((*MockEvent)(nil).CountByUser), ctx, userID)
+}
+
 // Create mocks base method.
 func (m *MockEvent) Create(ctx context.Context, event entity.Event) (entity.Event, error) {
 	m.ctrl.T.Helper()
@@ -70,6 +85,21 @@ func (mr *MockEventMockRecorder) Delete(ctx, userID, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockEvent)(nil).Delete), ctx, userID, id)
 }
 
+// DeleteByUser mocks base method.
+func (m *MockEvent) DeleteByUser(ctx context.Context, userID string) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUser", ctx, userID)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByUser indicates an expected call of DeleteByUser.
+func (mr *MockEventMockRecorder) DeleteByUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUser", reflect.TypeOf((*MockEvent)(nil).DeleteByUser), ctx, userID)
+}
+
 // GetByID mocks base method.
 func (m *MockEvent) GetByID(ctx context.Context, userID, id string) (entity.Event, error) {
 	m.ctrl.T.Helper()
@@ -100,6 +130,51 @@ func (mr *MockEventMockRecorder) GetForRange(ctx, userID, dateStart, dateEnd any
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForRange", reflect.TypeOf((*MockEvent)(nil).GetForRange), ctx, userID, dateStart, dateEnd)
 }
 
+// GetUpcoming mocks base method.
+func (m *MockEvent) GetUpcoming(ctx context.Context, userID string, dateStart time.Time, limit int) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpcoming", ctx, userID, dateStart, limit)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpcoming indicates an expected call of GetUpcoming.
+func (mr *MockEventMockRecorder) GetUpcoming(ctx, userID, dateStart, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpcoming", reflect.TypeOf((*MockEvent)(nil).GetUpcoming), ctx, userID, dateStart, limit)
+}
+
+// Search mocks base method.
+func (m *MockEvent) Search(ctx context.Context, userID, query string) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, userID, query)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockEventMockRecorder) Search(ctx, userID, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockEvent)(nil).Search), ctx, userID, query)
+}
+
+// Transfer mocks base method.
+func (m *MockEvent) Transfer(ctx context.Context, fromUserID, toUserID string, filter entity.EventFilter, updatedAt time.Time) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfer", ctx, fromUserID, toUserID, filter, updatedAt)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Transfer indicates an expected call of Transfer.
+func (mr *MockEventMockRecorder) Transfer(ctx, fromUserID, toUserID, filter, updatedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockEvent)(nil).Transfer), ctx, fromUserID, toUserID, filter, updatedAt)
+}
+
 // Update mocks base method.
 func (m *MockEvent) Update(ctx context.Context, event entity.Event) (entity.Event, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,242 @@
+package repo
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/tenant"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Ошибки ограничения арендаторов.
+var (
+	ErrTenantNotAllowed = errors.New("tenant is not allowed")
+	ErrTooManyTenants   = errors.New("too many tenants")
+)
+
+// Максимальное количество арендаторов по умолчанию.
+const DefaultMaxTenants = 100
+
+// Структура ограничений арендаторов. Репозитории арендаторов открыты до остановки,
+// поэтому без ограничений клиент, перебирающий имена арендаторов, исчерпал бы память,
+// место на диске и файловые дескрипторы.
+type TenantLimits struct {
+	// Allowed задает допустимых арендаторов помимо tenant.Default. Пустой список допускает любых арендаторов.
+	Allowed []string
+	// MaxTenants ограничивает количество открытых арендаторов. Нулевое значение означает
+	// DefaultMaxTenants, отрицательное — отсутствие ограничения.
+	MaxTenants int
+}
+
+// allows сообщает, допустим ли арендатор name.
+func (l TenantLimits) allows(name string) bool {
+	return len(l.Allowed) == 0 || name == tenant.Default || slices.Contains(l.Allowed, name)
+}
+
+// Структура репозитория для сущности "событие", разделяющая данные арендаторов.
+// Каждый арендатор из контекста запроса получает собственный репозиторий,
+// поэтому события одного арендатора недоступны другим.
+type eventTenants struct {
+	mu      sync.RWMutex
+	repos   map[string]Event
+	newRepo func(tenant string) (Event, error)
+	limits  TenantLimits
+}
+
+// NewEventTenants возвращает репозиторий, создающий репозиторий допустимого limits арендатора
+// с помощью newRepo при первом обращении к нему.
+func NewEventTenants(newRepo func(tenant string) (Event, error), limits TenantLimits) Event {
+	return newEventTenants(newRepo, limits)
+}
+
+// newEventTenants возвращает репозиторий арендаторов с ограничениями limits.
+func newEventTenants(newRepo func(tenant string) (Event, error), limits TenantLimits) *eventTenants {
+	if limits.MaxTenants == 0 {
+		limits.MaxTenants = DefaultMaxTenants
+	}
+	return &eventTenants{repos: make(map[string]Event), newRepo: newRepo, limits: limits}
+}
+
+// TenantDir возвращает директорию данных арендатора name внутри директории данных dir.
+// Данные арендатора по умолчанию хранятся в самой директории dir.
+func TenantDir(dir string, name string) string {
+	if name == tenant.Default {
+		return dir
+	}
+	return filepath.Join(dir, "tenants", name)
+}
+
+// NewEventTenantsDurable возвращает репозиторий арендаторов, сохраняющий данные каждого
+// допустимого limits арендатора в его директории TenantDir. Репозиторий арендатора
+// по умолчанию открывается сразу.
+func NewEventTenantsDurable(dir string, opts DurableOptions, limits TenantLimits) (EventDurable, error) {
+	e := newEventTenants(func(name string) (Event, error) {
+		return NewEventMemoryDurable(TenantDir(dir, name), opts)
+	}, limits)
+	if _, err := e.repo(tenant.WithTenant(context.Background(), tenant.Default)); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// repo возвращает репозиторий арендатора из ctx, создавая его при необходимости.
+// Возвращает ErrTenantNotAllowed или ErrTooManyTenants, если арендатор не допускается ограничениями.
+func (e *eventTenants) repo(ctx context.Context) (Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	name := tenant.FromContext(ctx)
+	if err := tenant.Validate(name); err != nil {
+		return nil, err
+	}
+
+	e.mu.RLock()
+	r, ok := e.repos[name]
+	e.mu.RUnlock()
+	if ok {
+		return r, nil
+	}
+
+	if !e.limits.allows(name) {
+		return nil, fmt.Errorf("%w: %q", ErrTenantNotAllowed, name)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if r, ok := e.repos[name]; ok {
+		return r, nil
+	}
+	if e.limits.MaxTenants > 0 && len(e.repos) >= e.limits.MaxTenants {
+		return nil, fmt.Errorf("%w: limit is %d", ErrTooManyTenants, e.limits.MaxTenants)
+	}
+	r, err := e.newRepo(name)
+	if err != nil {
+		return nil, err
+	}
+	e.repos[name] = r
+	return r, nil
+}
+
+// GetByID возвращает Event арендатора по его userID и id.
+func (e *eventTenants) GetByID(ctx context.Context, userID string, id string) (entity.Event, error) {
+	r, err := e.repo(ctx)
+	if err != nil {
+		return entity.EmptyEvent, err
+	}
+	return r.GetByID(ctx, userID, id)
+}
+
+// GetForRange возвращает []Event арендатора по его userID и диапазону дат.
+func (e *eventTenants) GetForRange(ctx context.Context, userID string, dateStart, dateEnd time.Time) ([]entity.Event, error) {
+	r, err := e.repo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetForRange(ctx, userID, dateStart, dateEnd)
+}
+
+// GetUpcoming возвращает не более limit событий арендатора по его userID с датой не раньше dateStart.
+func (e *eventTenants) GetUpcoming(ctx context.Context, userID string, dateStart time.Time, limit int) ([]entity.Event, error) {
+	r, err := e.repo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetUpcoming(ctx, userID, dateStart, limit)
+}
+
+// Create добавляет новый Event в репозиторий арендатора.
+func (e *eventTenants) Create(ctx context.Context, event entity.Event) (entity.Event, error) {
+	r, err := e.repo(ctx)
+	if err != nil {
+		return entity.EmptyEvent, err
+	}
+	return r.Create(ctx, event)
+}
+
+// Update обновляет Event в репозитории арендатора.
+func (e *eventTenants) Update(ctx context.Context, event entity.Event) (entity.Event, error) {
+	r, err := e.repo(ctx)
+	if err != nil {
+		return entity.EmptyEvent, err
+	}
+	return r.Update(ctx, event)
+}
+
+// Delete удаляет Event из репозитория арендатора.
+func (e *eventTenants) Delete(ctx context.Context, userID string, id string) error {
+	r, err := e.repo(ctx)
+	if err != nil {
+		return err
+	}
+	return r.Delete(ctx, userID, id)
+}
+
+// Search возвращает []Event арендатора, найденные по полнотекстовому запросу query.
+func (e *eventTenants) Search(ctx context.Context, userID string, query string) ([]entity.Event, error) {
+	r, err := e.repo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.Search(ctx, userID, query)
+}
+
+// CountByUser возвращает количество Event пользователя userID арендатора.
+func (e *eventTenants) CountByUser(ctx context.Context, userID string) (int, error) {
+	r, err := e.repo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return r.CountByUser(ctx, userID)
+}
+
+// Transfer передает события арендатора между пользователями fromUserID и toUserID.
+func (e *eventTenants) Transfer(ctx context.Context, fromUserID string, toUserID string, filter entity.EventFilter, updatedAt time.Time) ([]entity.Event, error) {
+	r, err := e.repo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.Transfer(ctx, fromUserID, toUserID, filter, updatedAt)
+}
+
+// DeleteByUser удаляет все события пользователя userID арендатора.
+func (e *eventTenants) DeleteByUser(ctx context.Context, userID string) ([]entity.Event, error) {
+	r, err := e.repo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.DeleteByUser(ctx, userID)
+}
+
+// durables возвращает открытые репозитории арендаторов, сохраняющие данные на диск.
+func (e *eventTenants) durables() []EventDurable {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	var durables []EventDurable
+	for _, r := range e.repos {
+		if d, ok := r.(EventDurable); ok {
+			durables = append(durables, d)
+		}
+	}
+	return durables
+}
+
+// Snapshot сохраняет снимки событий всех открытых арендаторов.
+func (e *eventTenants) Snapshot() error {
+	var errs []error
+	for _, d := range e.durables() {
+		errs = append(errs, d.Snapshot())
+	}
+	return errors.Join(errs...)
+}
+
+// Close закрывает репозитории всех открытых арендаторов.
+func (e *eventTenants) Close() error {
+	var errs []error
+	for _, d := range e.durables() {
+		errs = append(errs, d.Close())
+	}
+	return errors.Join(errs...)
+}
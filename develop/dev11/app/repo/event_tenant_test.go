@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/tenant"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_eventTenants_Isolation(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 20, 16, 0, 0, 0, time.UTC)
+	sales := tenant.WithTenant(context.Background(), "sales")
+	support := tenant.WithTenant(context.Background(), "support")
+
+	e := NewEventTenants(func(string) (Event, error) { return NewEventMemory(), nil }, TenantLimits{})
+	event, err := e.Create(sales, entity.Event{Title: "event", Date: date, UserID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := e.GetByID(support, userID, event.ID); err != ErrNotExist {
+		t.Errorf("eventTenants.GetByID() other tenant error = %v, want %v", err, ErrNotExist)
+	}
+	if err := e.Delete(support, userID, event.ID); err != ErrNotExist {
+		t.Errorf("eventTenants.Delete() other tenant error = %v, want %v", err, ErrNotExist)
+	}
+	if events, _ := e.GetForRange(support, userID, date, date); len(events) != 0 {
+		t.Errorf("eventTenants.GetForRange() other tenant = %v, want empty", events)
+	}
+	if events, _ := e.Search(support, userID, "event"); len(events) != 0 {
+		t.Errorf("eventTenants.Search() other tenant = %v, want empty", events)
+	}
+	if n, _ := e.CountByUser(support, userID); n != 0 {
+		t.Errorf("eventTenants.CountByUser() other tenant = %v, want 0", n)
+	}
+
+	if got, err := e.GetByID(sales, userID, event.ID); err != nil || got != event {
+		t.Errorf("eventTenants.GetByID() = %v, %v, want %v", got, err, event)
+	}
+	if n, _ := e.CountByUser(sales, userID); n != 1 {
+		t.Errorf("eventTenants.CountByUser() = %v, want 1", n)
+	}
+}
+
+func Test_eventTenants_InvalidTenant(t *testing.T) {
+	e := NewEventTenants(func(string) (Event, error) { return NewEventMemory(), nil }, TenantLimits{})
+	ctx := tenant.WithTenant(context.Background(), "../other")
+	if _, err := e.Create(ctx, entity.Event{}); !errors.Is(err, tenant.ErrInvalid) {
+		t.Errorf("eventTenants.Create() error = %v, want %v", err, tenant.ErrInvalid)
+	}
+}
+
+func Test_eventTenants_Limits(t *testing.T) {
+	ctx := func(name string) context.Context { return tenant.WithTenant(context.Background(), name) }
+
+	tests := []struct {
+		name    string
+		limits  TenantLimits
+		tenants []string
+		wantErr error
+	}{
+		{"Allowed", TenantLimits{Allowed: []string{"sales"}}, []string{tenant.Default, "sales"}, nil},
+		{"NotAllowed", TenantLimits{Allowed: []string{"sales"}}, []string{"support"}, ErrTenantNotAllowed},
+		{"UnderLimit", TenantLimits{MaxTenants: 2}, []string{"sales", "support", "sales"}, nil},
+		{"OverLimit", TenantLimits{MaxTenants: 2}, []string{"sales", "support", "hr"}, ErrTooManyTenants},
+		{"Unlimited", TenantLimits{MaxTenants: -1}, []string{"sales", "support", "hr"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created := 0
+			e := NewEventTenants(func(string) (Event, error) {
+				created++
+				return NewEventMemory(), nil
+			}, tt.limits)
+
+			var err error
+			for _, name := range tt.tenants {
+				_, err = e.CountByUser(ctx(name), "")
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("eventTenants.CountByUser() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && created > max(tt.limits.MaxTenants, len(tt.limits.Allowed)) {
+				t.Errorf("eventTenants created %d repositories, want at most %d", created, max(tt.limits.MaxTenants, len(tt.limits.Allowed)))
+			}
+		})
+	}
+}
+
+func Test_eventTenants_DefaultMaxTenants(t *testing.T) {
+	e := NewEventTenants(func(string) (Event, error) { return NewEventMemory(), nil }, TenantLimits{})
+	var err error
+	for i := range DefaultMaxTenants + 1 {
+		_, err = e.CountByUser(tenant.WithTenant(context.Background(), fmt.Sprintf("t%d", i)), "")
+	}
+	if !errors.Is(err, ErrTooManyTenants) {
+		t.Errorf("eventTenants.CountByUser() error = %v, want %v", err, ErrTooManyTenants)
+	}
+}
+
+func TestNewEventTenantsDurable(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	dir := t.TempDir()
+	sales := tenant.WithTenant(context.Background(), "sales")
+
+	e, err := NewEventTenantsDurable(dir, DurableOptions{}, TenantLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := e.Create(sales, entity.Event{Title: "event", UserID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tenants", "sales")); err != nil {
+		t.Errorf("tenant directory: %v", err)
+	}
+
+	e, err = NewEventTenantsDurable(dir, DurableOptions{}, TenantLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	if got, err := e.GetByID(sales, userID, event.ID); err != nil || got != event {
+		t.Errorf("eventTenants.GetByID() after reopen = %v, %v, want %v", got, err, event)
+	}
+	if _, err := e.GetByID(context.Background(), userID, event.ID); err != ErrNotExist {
+		t.Errorf("eventTenants.GetByID() default tenant error = %v, want %v", err, ErrNotExist)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package repo
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// lockDir открывает файл блокировки директории dir.
+// На платформах без flock эксклюзивность доступа не проверяется.
+func lockDir(dir string) (*os.File, error) {
+	return os.OpenFile(filepath.Join(dir, "LOCK"), os.O_CREATE|os.O_RDWR, 0o644)
+}
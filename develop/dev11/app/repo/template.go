@@ -0,0 +1,18 @@
+package repo
+
+import (
+	"context"
+	"dev11/app/entity"
+)
+
+// Интерфейс репозитория для сущности "шаблон события".
+type Template interface {
+	GetByID(ctx context.Context, userID string, id string) (entity.Template, error)
+	// GetByUser возвращает шаблоны пользователя userID, упорядоченные по заголовку.
+	GetByUser(ctx context.Context, userID string) ([]entity.Template, error)
+	Create(ctx context.Context, template entity.Template) (entity.Template, error)
+	Update(ctx context.Context, template entity.Template) (entity.Template, error)
+	Delete(ctx context.Context, userID string, id string) error
+	// DeleteByUser удаляет все шаблоны пользователя userID и возвращает их, упорядоченные по заголовку.
+	DeleteByUser(ctx context.Context, userID string) ([]entity.Template, error)
+}
@@ -0,0 +1,156 @@
+package repo
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/tenant"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Структура ключа шаблонов одного пользователя арендатора.
+type templateOwner struct {
+	tenant string
+	userID string
+}
+
+// Структура репозитория для сущности "шаблон события", реализующая интерфейс
+// и работающая с данными in-memory. Шаблоны арендаторов из контекста запроса хранятся раздельно.
+type templateMemory struct {
+	mu    sync.RWMutex
+	users map[templateOwner]map[string]entity.Template
+}
+
+// NewTemplateMemory возвращает in-memory репозиторий, реализующий интерфейс.
+func NewTemplateMemory() Template {
+	return &templateMemory{users: make(map[templateOwner]map[string]entity.Template)}
+}
+
+// templateOwnerOf возвращает ключ шаблонов пользователя userID арендатора из ctx.
+func templateOwnerOf(ctx context.Context, userID string) templateOwner {
+	return templateOwner{tenant: tenant.FromContext(ctx), userID: userID}
+}
+
+// cloneTemplate возвращает копию шаблона, не разделяющую срезы с шаблоном template.
+func cloneTemplate(template entity.Template) entity.Template {
+	template.Tags = slices.Clone(template.Tags)
+	template.Attendees = slices.Clone(template.Attendees)
+	template.Reminders = slices.Clone(template.Reminders)
+	return template
+}
+
+// GetByID возвращает Template по его userID и id или ошибку, если Template не найден.
+func (t *templateMemory) GetByID(ctx context.Context, userID string, id string) (entity.Template, error) {
+	if err := ctx.Err(); err != nil {
+		return entity.Template{}, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	template, ok := t.users[templateOwnerOf(ctx, userID)][id]
+	if !ok {
+		return entity.Template{}, ErrNotExist
+	}
+	return cloneTemplate(template), nil
+}
+
+// GetByUser возвращает []Template пользователя userID, упорядоченные по заголовку и id.
+func (t *templateMemory) GetByUser(ctx context.Context, userID string) ([]entity.Template, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	templates := sortedTemplates(t.users[templateOwnerOf(ctx, userID)])
+	t.mu.RUnlock()
+	return templates, nil
+}
+
+// sortedTemplates возвращает копии шаблонов user, упорядоченные по заголовку и id.
+func sortedTemplates(user map[string]entity.Template) []entity.Template {
+	templates := make([]entity.Template, 0, len(user))
+	for _, template := range user {
+		templates = append(templates, cloneTemplate(template))
+	}
+	slices.SortFunc(templates, func(a, b entity.Template) int {
+		if c := strings.Compare(a.Title, b.Title); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return templates
+}
+
+// Create добавляет новый Template в репозиторий, генерируя для него случайный id.
+// Возвращает созданный и добавленный Template.
+func (t *templateMemory) Create(ctx context.Context, template entity.Template) (entity.Template, error) {
+	if err := ctx.Err(); err != nil {
+		return entity.Template{}, err
+	}
+	template = cloneTemplate(template)
+	template.ID = uuid.NewString()
+	key := templateOwnerOf(ctx, template.UserID)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	user, ok := t.users[key]
+	if !ok {
+		user = make(map[string]entity.Template)
+		t.users[key] = user
+	}
+	user[template.ID] = template
+	return cloneTemplate(template), nil
+}
+
+// Update обновляет Template в репозитории.
+// Возвращает обновленный Template, если Template существует, иначе возвращает ошибку.
+func (t *templateMemory) Update(ctx context.Context, template entity.Template) (entity.Template, error) {
+	if err := ctx.Err(); err != nil {
+		return entity.Template{}, err
+	}
+	template = cloneTemplate(template)
+	key := templateOwnerOf(ctx, template.UserID)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.users[key][template.ID]; !ok {
+		return entity.Template{}, ErrNotExist
+	}
+	t.users[key][template.ID] = template
+	return cloneTemplate(template), nil
+}
+
+// Delete удаляет Template из репозитория, если Template существует, иначе возвращает ошибку.
+func (t *templateMemory) Delete(ctx context.Context, userID string, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	key := templateOwnerOf(ctx, userID)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	user := t.users[key]
+	if _, ok := user[id]; !ok {
+		return ErrNotExist
+	}
+	delete(user, id)
+	if len(user) == 0 {
+		delete(t.users, key)
+	}
+	return nil
+}
+
+// DeleteByUser удаляет все Template пользователя userID и возвращает их.
+func (t *templateMemory) DeleteByUser(ctx context.Context, userID string) ([]entity.Template, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	key := templateOwnerOf(ctx, userID)
+
+	t.mu.Lock()
+	user := t.users[key]
+	delete(t.users, key)
+	t.mu.Unlock()
+	return sortedTemplates(user), nil
+}
@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/tenant"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_templateMemory(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	ctx := context.Background()
+	r := NewTemplateMemory()
+
+	review, err := r.Create(ctx, entity.Template{UserID: userID, Title: "review", Tags: []string{"work"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	standup, _ := r.Create(ctx, entity.Template{UserID: userID, Title: "standup"})
+	r.Create(tenant.WithTenant(ctx, "acme"), entity.Template{UserID: userID, Title: "other tenant"})
+
+	t.Run("GetByID", func(t *testing.T) {
+		got, err := r.GetByID(ctx, userID, review.ID)
+		if err != nil || !reflect.DeepEqual(got, review) {
+			t.Errorf("templateMemory.GetByID() = %v, %v, want %v", got, err, review)
+		}
+		// Изменение возвращенного шаблона не изменяет шаблон в репозитории.
+		got.Tags[0] = "home"
+		if got, _ := r.GetByID(ctx, userID, review.ID); got.Tags[0] != "work" {
+			t.Errorf("templateMemory.GetByID() tags = %v, want shared copy", got.Tags)
+		}
+		if _, err := r.GetByID(ctx, "other", review.ID); !errors.Is(err, ErrNotExist) {
+			t.Errorf("templateMemory.GetByID() other user error = %v, want %v", err, ErrNotExist)
+		}
+	})
+
+	t.Run("GetByUser", func(t *testing.T) {
+		got, err := r.GetByUser(ctx, userID)
+		if want := []entity.Template{review, standup}; err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("templateMemory.GetByUser() = %v, %v, want %v", got, err, want)
+		}
+		if got, _ := r.GetByUser(ctx, "other"); len(got) != 0 {
+			t.Errorf("templateMemory.GetByUser() other user = %v, want empty", got)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		standup.Title = "daily"
+		if _, err := r.Update(ctx, standup); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := r.GetByID(ctx, userID, standup.ID); got.Title != "daily" {
+			t.Errorf("templateMemory.Update() title = %q, want %q", got.Title, "daily")
+		}
+		if _, err := r.Update(ctx, entity.Template{ID: "missing", UserID: userID}); !errors.Is(err, ErrNotExist) {
+			t.Errorf("templateMemory.Update() error = %v, want %v", err, ErrNotExist)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := r.Delete(ctx, userID, review.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Delete(ctx, userID, review.ID); !errors.Is(err, ErrNotExist) {
+			t.Errorf("templateMemory.Delete() error = %v, want %v", err, ErrNotExist)
+		}
+		if got, _ := r.GetByUser(tenant.WithTenant(ctx, "acme"), userID); len(got) != 1 {
+			t.Errorf("templateMemory.GetByUser() tenant = %v, want 1 template", got)
+		}
+	})
+	t.Run("DeleteByUser", func(t *testing.T) {
+		got, err := r.DeleteByUser(ctx, userID)
+		if want := []entity.Template{standup}; err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("templateMemory.DeleteByUser() = %v, %v, want %v", got, err, want)
+		}
+		if got, _ := r.GetByUser(ctx, userID); len(got) != 0 {
+			t.Errorf("templateMemory.GetByUser() after DeleteByUser = %v, want empty", got)
+		}
+		if got, _ := r.GetByUser(tenant.WithTenant(ctx, "acme"), userID); len(got) != 1 {
+			t.Errorf("templateMemory.GetByUser() tenant = %v, want 1 template", got)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: template.go
+//
+// Generated by this command:
+//
+//	mockgen -source template.go -destination template_mock.go -package repo
+//
+
+// Package repo is a generated GoMock package.
+package repo
+
+import (
+	context "context"
+	entity "dev11/app/entity"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTemplate is a mock of Template interface.
+type MockTemplate struct {
+	ctrl     *gomock.Controller
+	recorder *MockTemplateMockRecorder
+}
+
+// MockTemplateMockRecorder is the mock recorder for MockTemplate.
+type MockTemplateMockRecorder struct {
+	mock *MockTemplate
+}
+
+// NewMockTemplate creates a new mock instance.
+func NewMockTemplate(ctrl *gomock.Controller) *MockTemplate {
+	mock := &MockTemplate{ctrl: ctrl}
+	mock.recorder = &MockTemplateMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTemplate) EXPECT() *MockTemplateMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockTemplate) Create(ctx context.Context, template entity.Template) (entity.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, template)
+	ret0, _ := ret[0].(entity.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockTemplateMockRecorder) Create(ctx, template any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTemplate)(nil).Create), ctx, template)
+}
+
+// Delete mocks base method.
+func (m *MockTemplate) Delete(ctx context.Context, userID, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockTemplateMockRecorder) Delete(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTemplate)(nil).Delete), ctx, userID, id)
+}
+
+// DeleteByUser mocks base method.
+func (m *MockTemplate) DeleteByUser(ctx context.Context, userID string) ([]entity.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUser", ctx, userID)
+	ret0, _ := ret[0].([]entity.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByUser indicates an expected call of DeleteByUser.
+func (mr *MockTemplateMockRecorder) DeleteByUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUser", reflect.TypeOf((*MockTemplate)(nil).DeleteByUser), ctx, userID)
+}
+
+// GetByID mocks base method.
+func (m *MockTemplate) GetByID(ctx context.Context, userID, id string) (entity.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, userID, id)
+	ret0, _ := ret[0].(entity.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockTemplateMockRecorder) GetByID(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockTemplate)(nil).GetByID), ctx, userID, id)
+}
+
+// GetByUser mocks base method.
+func (m *MockTemplate) GetByUser(ctx context.Context, userID string) ([]entity.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUser", ctx, userID)
+	ret0, _ := ret[0].([]entity.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUser indicates an expected call of GetByUser.
+func (mr *MockTemplateMockRecorder) GetByUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUser", reflect.TypeOf((*MockTemplate)(nil).GetByUser), ctx, userID)
+}
+
+// Update mocks base method.
+func (m *MockTemplate) Update(ctx context.Context, template entity.Template) (entity.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, template)
+	ret0, _ := ret[0].(entity.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockTemplateMockRecorder) Update(ctx, template any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTemplate)(nil).Update), ctx, template)
+}
@@ -0,0 +1,295 @@
+package repo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Ошибки журнала упреждающей записи.
+var (
+	ErrCorrupted = errors.New("data is corrupted")
+	ErrLocked    = errors.New("data directory is used by another process")
+)
+
+// Политика сброса журнала на диск.
+type SyncPolicy int
+
+const (
+	// SyncAlways сбрасывает журнал на диск после каждой записи.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval сбрасывает журнал на диск с заданным интервалом.
+	SyncInterval
+	// SyncNever оставляет сброс журнала на диск операционной системе.
+	SyncNever
+)
+
+// Размер заголовка записи журнала: длина и контрольная сумма.
+const walHeaderSize = 8
+
+// Максимальный размер записи журнала.
+const walMaxRecordSize = 16 << 20
+
+// Таблица для вычисления контрольных сумм CRC-32C.
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Интерфейс файла журнала, позволяющий подменить файл в тестах.
+type walFile interface {
+	io.WriteCloser
+	Sync() error
+	Truncate(size int64) error
+}
+
+// Структура журнала упреждающей записи, добавляющая записи в конец файла.
+// Каждая запись состоит из длины, контрольной суммы CRC-32C и данных.
+type wal struct {
+	mu     sync.Mutex
+	file   walFile
+	size   int64
+	policy SyncPolicy
+	dirty  bool
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// openWAL открывает файл журнала path для записи в конец, создавая его при необходимости.
+// При политике SyncInterval запускает сброс журнала на диск каждые interval.
+func openWAL(path string, policy SyncPolicy, interval time.Duration) (*wal, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	w := &wal{file: file, size: info.Size(), policy: policy, done: make(chan struct{})}
+	if policy == SyncInterval && interval > 0 {
+		w.wg.Add(1)
+		go w.syncLoop(interval)
+	}
+	return w, nil
+}
+
+// syncLoop сбрасывает журнал на диск каждые interval, пока журнал не закрыт.
+func (w *wal) syncLoop(interval time.Duration) {
+	defer w.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.Sync()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Append добавляет запись data в конец журнала.
+// Если запись не удалось записать целиком, журнал обрезается до прежнего размера,
+// чтобы последующие записи не оказались после поврежденной.
+func (w *wal) Append(data []byte) error {
+	if len(data) > walMaxRecordSize {
+		return fmt.Errorf("wal: record size %d exceeds limit", len(data))
+	}
+
+	buf := make([]byte, walHeaderSize+len(data))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(data)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(data, crcTable))
+	copy(buf[walHeaderSize:], data)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n, err := w.file.Write(buf)
+	if err == nil && n < len(buf) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		if n > 0 {
+			if truncErr := w.file.Truncate(w.size); truncErr != nil {
+				return errors.Join(err, truncErr)
+			}
+		}
+		return err
+	}
+	w.size += int64(n)
+	w.dirty = true
+	if w.policy == SyncAlways {
+		return w.sync()
+	}
+	return nil
+}
+
+// Sync сбрасывает журнал на диск, если в него были записи после последнего сброса.
+func (w *wal) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sync()
+}
+
+// sync сбрасывает журнал на диск без блокировки.
+func (w *wal) sync() error {
+	if !w.dirty {
+		return nil
+	}
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	w.dirty = false
+	return nil
+}
+
+// Close сбрасывает журнал на диск и закрывает его файл.
+func (w *wal) Close() error {
+	close(w.done)
+	w.wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.sync(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// replayWAL последовательно читает записи журнала path и передает их в apply.
+// Возвращает размер корректной части журнала. Если запись в конце журнала обрезана
+// или повреждена (например, при сбое во время записи) и за ней нет корректных записей,
+// чтение останавливается на ней, а при повреждении записи в середине журнала
+// возвращается ErrCorrupted.
+func replayWAL(path string, apply func(data []byte) error) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+
+	r := bufio.NewReader(file)
+	header := make([]byte, walHeaderSize)
+	var offset int64
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return offset, nil
+			}
+			return offset, err
+		}
+
+		length := int64(binary.LittleEndian.Uint32(header[0:4]))
+		checksum := binary.LittleEndian.Uint32(header[4:8])
+		if length > walMaxRecordSize {
+			return offset, fmt.Errorf("wal: record at offset %d: %w", offset, ErrCorrupted)
+		}
+		end := offset + walHeaderSize + length
+		if end > size {
+			// Запись обрезана при сбое во время записи, только если за ней нет корректных
+			// записей. Иначе повреждена длина записи, и обрезка журнала потеряла бы данные.
+			rest, err := io.ReadAll(r)
+			if err != nil {
+				return offset, err
+			}
+			if hasValidRecord(append(header, rest...)[1:]) {
+				return offset, fmt.Errorf("wal: record at offset %d: %w", offset, ErrCorrupted)
+			}
+			return offset, nil
+		}
+
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return offset, err
+		}
+		if crc32.Checksum(data, crcTable) != checksum {
+			if end == size {
+				// Последняя запись записана не полностью.
+				return offset, nil
+			}
+			return offset, fmt.Errorf("wal: record at offset %d: %w", offset, ErrCorrupted)
+		}
+
+		if err := apply(data); err != nil {
+			return offset, err
+		}
+		offset = end
+	}
+}
+
+// hasValidRecord сообщает, начинается ли с какого-либо байта buf непустая запись журнала
+// с корректной контрольной суммой. Пустые записи не пишутся в журнал, поэтому заполненный
+// нулями при сбое хвост не считается корректной записью.
+func hasValidRecord(buf []byte) bool {
+	for i := 0; i+walHeaderSize < len(buf); i++ {
+		length := int(binary.LittleEndian.Uint32(buf[i : i+4]))
+		if length == 0 || length > walMaxRecordSize || i+walHeaderSize+length > len(buf) {
+			continue
+		}
+		data := buf[i+walHeaderSize : i+walHeaderSize+length]
+		if crc32.Checksum(data, crcTable) == binary.LittleEndian.Uint32(buf[i+4:i+8]) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFileAtomic записывает данные data с контрольной суммой в файл path
+// через временный файл, сбрасывает его на диск и атомарно переименовывает.
+// Файл начинается с 4 байт контрольной суммы CRC-32C, за которыми следуют данные.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 4)
+	binary.LittleEndian.PutUint32(header, crc32.Checksum(data, crcTable))
+	_, err = file.Write(append(header, data...))
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// readFileChecked читает файл path, записанный writeFileAtomic, и проверяет контрольную сумму.
+func readFileChecked(path string) ([]byte, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) < 4 || crc32.Checksum(buf[4:], crcTable) != binary.LittleEndian.Uint32(buf[:4]) {
+		return nil, fmt.Errorf("snapshot %s: %w", path, ErrCorrupted)
+	}
+	return buf[4:], nil
+}
+
+// syncDir сбрасывает на диск содержимое директории dir, чтобы сохранить переименования файлов.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
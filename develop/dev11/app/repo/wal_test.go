@@ -0,0 +1,228 @@
+package repo
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_replayWAL(t *testing.T) {
+	records := [][]byte{[]byte("first"), []byte("second"), []byte("third")}
+
+	write := func(t *testing.T) (string, []int64) {
+		path := filepath.Join(t.TempDir(), "wal.log")
+		w, err := openWAL(path, SyncAlways, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		offsets := make([]int64, 0, len(records))
+		var offset int64
+		for _, record := range records {
+			if err := w.Append(record); err != nil {
+				t.Fatal(err)
+			}
+			offset += walHeaderSize + int64(len(record))
+			offsets = append(offsets, offset)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return path, offsets
+	}
+
+	replay := func(path string) ([][]byte, int64, error) {
+		var got [][]byte
+		size, err := replayWAL(path, func(data []byte) error {
+			got = append(got, data)
+			return nil
+		})
+		return got, size, err
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		path, offsets := write(t)
+		got, size, err := replay(path)
+		if err != nil {
+			t.Errorf("replayWAL() error = %v, wantErr %v", err, false)
+			return
+		}
+		if !reflect.DeepEqual(got, records) {
+			t.Errorf("replayWAL() got = %q, want %q", got, records)
+		}
+		if want := offsets[2]; size != want {
+			t.Errorf("replayWAL() size = %v, want %v", size, want)
+		}
+	})
+
+	t.Run("TruncatedTail", func(t *testing.T) {
+		path, offsets := write(t)
+		os.Truncate(path, offsets[2]-2)
+
+		got, size, err := replay(path)
+		if err != nil {
+			t.Errorf("replayWAL() error = %v, wantErr %v", err, false)
+			return
+		}
+		if !reflect.DeepEqual(got, records[:2]) {
+			t.Errorf("replayWAL() got = %q, want %q", got, records[:2])
+		}
+		if want := offsets[1]; size != want {
+			t.Errorf("replayWAL() size = %v, want %v", size, want)
+		}
+	})
+
+	t.Run("CorruptedTail", func(t *testing.T) {
+		path, offsets := write(t)
+		corrupt(t, path, offsets[2]-1)
+
+		got, size, err := replay(path)
+		if err != nil {
+			t.Errorf("replayWAL() error = %v, wantErr %v", err, false)
+			return
+		}
+		if !reflect.DeepEqual(got, records[:2]) {
+			t.Errorf("replayWAL() got = %q, want %q", got, records[:2])
+		}
+		if want := offsets[1]; size != want {
+			t.Errorf("replayWAL() size = %v, want %v", size, want)
+		}
+	})
+
+	t.Run("CorruptedMiddleLength", func(t *testing.T) {
+		path, offsets := write(t)
+		// Длина второй записи превышает оставшийся размер журнала, но за ней есть корректная запись.
+		corrupt(t, path, offsets[0]+1)
+
+		if _, _, err := replay(path); !errors.Is(err, ErrCorrupted) {
+			t.Errorf("replayWAL() error = %v, want %v", err, ErrCorrupted)
+		}
+	})
+
+	t.Run("LengthOverLimit", func(t *testing.T) {
+		path, offsets := write(t)
+		corrupt(t, path, offsets[1]+3)
+
+		if _, _, err := replay(path); !errors.Is(err, ErrCorrupted) {
+			t.Errorf("replayWAL() error = %v, want %v", err, ErrCorrupted)
+		}
+	})
+
+	t.Run("ZeroFilledTail", func(t *testing.T) {
+		path, offsets := write(t)
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Заголовок обрезанной записи и заполненный нулями хвост после сбоя.
+		file.Write([]byte{100, 0, 0, 0, 1, 2, 3, 4})
+		file.Write(make([]byte, 32))
+		file.Close()
+
+		got, size, err := replay(path)
+		if err != nil {
+			t.Errorf("replayWAL() error = %v, wantErr %v", err, false)
+			return
+		}
+		if !reflect.DeepEqual(got, records) || size != offsets[2] {
+			t.Errorf("replayWAL() = %q, %v, want %q, %v", got, size, records, offsets[2])
+		}
+	})
+
+	t.Run("CorruptedMiddle", func(t *testing.T) {
+		path, offsets := write(t)
+		corrupt(t, path, offsets[0]-1)
+
+		if _, _, err := replay(path); !errors.Is(err, ErrCorrupted) {
+			t.Errorf("replayWAL() error = %v, want %v", err, ErrCorrupted)
+		}
+	})
+}
+
+func Test_wal_AppendShortWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal.log")
+	w, err := openWAL(path, SyncAlways, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Append([]byte("first")); err != nil {
+		t.Fatal(err)
+	}
+
+	file := &shortWriteFile{walFile: w.file}
+	w.file = file
+	file.short = true
+	if err := w.Append([]byte("second")); !errors.Is(err, io.ErrShortWrite) {
+		t.Errorf("wal.Append() error = %v, want %v", err, io.ErrShortWrite)
+	}
+	file.short = false
+	if err := w.Append([]byte("third")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][]byte
+	if _, err := replayWAL(path, func(data []byte) error {
+		got = append(got, data)
+		return nil
+	}); err != nil {
+		t.Errorf("replayWAL() error = %v, wantErr %v", err, false)
+		return
+	}
+	if want := [][]byte{[]byte("first"), []byte("third")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("replayWAL() got = %q, want %q", got, want)
+	}
+}
+
+// shortWriteFile записывает только половину данных, пока установлен флаг short.
+type shortWriteFile struct {
+	walFile
+	short bool
+}
+
+func (f *shortWriteFile) Write(p []byte) (int, error) {
+	if f.short {
+		return f.walFile.Write(p[:len(p)/2])
+	}
+	return f.walFile.Write(p)
+}
+
+func Test_writeFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	want := []byte("[]")
+
+	if err := writeFileAtomic(path, want); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readFileChecked(path)
+	if err != nil {
+		t.Errorf("readFileChecked() error = %v, wantErr %v", err, false)
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readFileChecked() = %q, want %q", got, want)
+	}
+
+	corrupt(t, path, 4)
+	if _, err := readFileChecked(path); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("readFileChecked() error = %v, want %v", err, ErrCorrupted)
+	}
+}
+
+// corrupt инвертирует байт файла path по смещению offset.
+func corrupt(t *testing.T, path string, offset int64) {
+	t.Helper()
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf[offset] ^= 0xff
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
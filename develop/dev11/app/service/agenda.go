@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/workcal"
+	"time"
+)
+
+// Интерфейс сервиса (бизнес-логики) для повестки пользователя.
+type Agenda interface {
+	// Build возвращает повестку пользователя userID за период period ("day" или "week"), содержащий date.
+	Build(ctx context.Context, userID string, period string, date time.Time) (entity.Agenda, error)
+}
+
+// Параметры построения повестки. Нулевые значения заменяются значениями по умолчанию.
+type AgendaOptions struct {
+	// EventDuration задает длительность события, так как у событий есть только время начала.
+	EventDuration time.Duration
+	// DayStart и DayEnd задают рабочее время дня (смещение от полуночи UTC),
+	// в котором ищутся свободные промежутки.
+	DayStart time.Duration
+	DayEnd   time.Duration
+	// Schedules задает рабочие графики пользователей. Если графики заданы, свободные
+	// промежутки ищутся в рабочем времени графика пользователя вместо DayStart и DayEnd,
+	// а в нерабочие дни не ищутся.
+	Schedules *workcal.Schedules
+}
+
+// Параметры построения повестки по умолчанию.
+var DefaultAgendaOptions = AgendaOptions{
+	EventDuration: time.Hour,
+	DayStart:      9 * time.Hour,
+	DayEnd:        18 * time.Hour,
+}
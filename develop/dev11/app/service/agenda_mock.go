@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: agenda.go
+//
+// Generated by this command:
+//
+//	mockgen -source agenda.go -destination agenda_mock.go -package service
+//
+
+// Package service is a generated GoMock package.
+package service
+
+import (
+	context "context"
+	entity "dev11/app/entity"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAgenda is a mock of Agenda interface.
+type MockAgenda struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgendaMockRecorder
+}
+
+// MockAgendaMockRecorder is the mock recorder for MockAgenda.
+type MockAgendaMockRecorder struct {
+	mock *MockAgenda
+}
+
+// NewMockAgenda creates a new mock instance.
+func NewMockAgenda(ctrl *gomock.Controller) *MockAgenda {
+	mock := &MockAgenda{ctrl: ctrl}
+	mock.recorder = &MockAgendaMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAgenda) EXPECT() *MockAgendaMockRecorder {
+	return m.recorder
+}
+
+// Build mocks base method.
+func (m *MockAgenda) Build(ctx context.Context, userID, period string, date time.Time) (entity.Agenda, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Build", ctx, userID, period, date)
+	ret0, _ := ret[0].(entity.Agenda)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Build indicates an expected call of Build.
+func (mr *MockAgendaMockRecorder) Build(ctx, userID, period, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockAgenda)(nil).Build), ctx, userID, period, date)
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"slices"
+	"time"
+)
+
+// Длительность дня повестки.
+const agendaDay = 24 * time.Hour
+
+// Структура сервиса повестки, представляющая первую версию реализации интерфейса.
+// События получаются через сервис событий.
+type agendaV1 struct {
+	events Event
+	opts   AgendaOptions
+}
+
+// NewAgendaV1 возвращает сервис v1, реализующий интерфейс.
+func NewAgendaV1(events Event, opts AgendaOptions) Agenda {
+	if events == nil {
+		return nil
+	}
+
+	if opts.EventDuration <= 0 {
+		opts.EventDuration = DefaultAgendaOptions.EventDuration
+	}
+	if opts.DayStart <= 0 && opts.DayEnd <= 0 {
+		opts.DayStart, opts.DayEnd = DefaultAgendaOptions.DayStart, DefaultAgendaOptions.DayEnd
+	}
+	return agendaV1{events: events, opts: opts}
+}
+
+// Build возвращает повестку пользователя userID за день или неделю, содержащие date,
+// с конфликтами событий, свободными промежутками рабочего времени и праздниками.
+func (a agendaV1) Build(ctx context.Context, userID string, period string, date time.Time) (entity.Agenda, error) {
+	var (
+		events []entity.Event
+		err    error
+		agenda = entity.Agenda{UserID: userID, Period: period}
+	)
+	switch period {
+	case entity.PeriodDay:
+		agenda.Start = date.Truncate(agendaDay)
+		agenda.End = agenda.Start.Add(agendaDay)
+		events, err = a.events.GetForDay(ctx, userID, date)
+	case entity.PeriodWeek:
+		agenda.Start = date.Truncate(7 * agendaDay)
+		agenda.End = agenda.Start.Add(7 * agendaDay)
+		events, err = a.events.GetForWeek(ctx, userID, date)
+	default:
+		return agenda, &ExternalError{entity.ErrPeriodInvalid}
+	}
+	if err != nil {
+		return agenda, err
+	}
+
+	events = slices.Clone(events)
+	slices.SortStableFunc(events, func(x, y entity.Event) int { return x.Date.Compare(y.Date) })
+	busy := a.busy(events)
+	for _, c := range busy {
+		if len(c.Events) > 1 {
+			agenda.Conflicts = append(agenda.Conflicts, c)
+		}
+	}
+
+	schedule := a.opts.Schedules.For(userID)
+	for start := agenda.Start; start.Before(agenda.End); start = start.Add(agendaDay) {
+		// Дни повестки задаются в UTC, а праздники и рабочее время берутся
+		// для того же дня в часовом поясе графика.
+		day := schedule.Date(start.Date())
+		d := entity.AgendaDay{Date: start}
+		d.Holiday, _ = schedule.Holiday(day)
+		for _, event := range events {
+			if !event.Date.Before(start) && event.Date.Before(start.Add(agendaDay)) {
+				d.Events = append(d.Events, event)
+			}
+		}
+		if a.opts.Schedules == nil {
+			d.Gaps = gaps(busy, start.Add(a.opts.DayStart), start.Add(a.opts.DayEnd))
+		} else if dayStart, dayEnd, ok := schedule.Hours(day); ok {
+			d.Gaps = gaps(busy, dayStart, dayEnd)
+		}
+		agenda.Days = append(agenda.Days, d)
+	}
+	return agenda, nil
+}
+
+// busy возвращает интервалы занятости по событиям events, упорядоченным по времени начала.
+// Пересекающиеся события объединяются в один интервал.
+func (a agendaV1) busy(events []entity.Event) []entity.Conflict {
+	var busy []entity.Conflict
+	for _, event := range events {
+		end := event.Date.Add(a.opts.EventDuration)
+		if n := len(busy); n > 0 && event.Date.Before(busy[n-1].End) {
+			last := &busy[n-1]
+			last.Events = append(last.Events, event)
+			if end.After(last.End) {
+				last.End = end
+			}
+			continue
+		}
+		busy = append(busy, entity.Conflict{Start: event.Date, End: end, Events: []entity.Event{event}})
+	}
+	return busy
+}
+
+// gaps возвращает свободные промежутки интервала [start, end) между интервалами занятости busy.
+func gaps(busy []entity.Conflict, start time.Time, end time.Time) []entity.Gap {
+	var gaps []entity.Gap
+	cursor := start
+	for _, b := range busy {
+		if !b.End.After(cursor) {
+			continue
+		}
+		if !b.Start.Before(end) {
+			break
+		}
+		if b.Start.After(cursor) {
+			gaps = append(gaps, entity.Gap{Start: cursor, End: b.Start})
+		}
+		cursor = b.End
+	}
+	if cursor.Before(end) {
+		gaps = append(gaps, entity.Gap{Start: cursor, End: end})
+	}
+	return gaps
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/workcal"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewAgendaV1(t *testing.T) {
+	t.Run("NilService", func(t *testing.T) {
+		var want Agenda = nil
+
+		if got := NewAgendaV1(nil, AgendaOptions{}); got != want {
+			t.Errorf("NewAgendaV1() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("DefaultOptions", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		events := NewMockEvent(ctrl)
+		want := agendaV1{events: events, opts: DefaultAgendaOptions}
+
+		if got := NewAgendaV1(events, AgendaOptions{}); !reflect.DeepEqual(got, want) {
+			t.Errorf("NewAgendaV1() = %v, want %v", got, want)
+		}
+	})
+}
+
+func Test_agendaV1_Build(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	monday := time.Date(2010, 5, 17, 0, 0, 0, 0, time.UTC)
+	at := func(days int, hour int, minute int) time.Time {
+		return monday.AddDate(0, 0, days).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	event := func(title string, date time.Time) entity.Event {
+		return entity.Event{ID: title, Title: title, Date: date, UserID: userID}
+	}
+	standup, review, lunch := event("standup", at(0, 10, 0)), event("review", at(0, 10, 30)), event("lunch", at(0, 13, 0))
+	late := event("late", at(0, 17, 30))
+
+	t.Run("Day", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		events := NewMockEvent(ctrl)
+		events.EXPECT().GetForDay(gomock.Any(), userID, at(0, 12, 0)).Return([]entity.Event{lunch, late, review, standup}, nil)
+		a := NewAgendaV1(events, AgendaOptions{})
+
+		got, err := a.Build(context.Background(), userID, entity.PeriodDay, at(0, 12, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := entity.Agenda{
+			UserID: userID, Period: entity.PeriodDay, Start: monday, End: at(1, 0, 0),
+			Days: []entity.AgendaDay{{
+				Date:   monday,
+				Events: []entity.Event{standup, review, lunch, late},
+				Gaps: []entity.Gap{
+					{Start: at(0, 9, 0), End: at(0, 10, 0)},
+					{Start: at(0, 11, 30), End: at(0, 13, 0)},
+					{Start: at(0, 14, 0), End: at(0, 17, 30)},
+				},
+			}},
+			Conflicts: []entity.Conflict{{Start: at(0, 10, 0), End: at(0, 11, 30), Events: []entity.Event{standup, review}}},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("agendaV1.Build() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("Week", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		tuesday := event("tuesday", at(1, 9, 0))
+		events := NewMockEvent(ctrl)
+		events.EXPECT().GetForWeek(gomock.Any(), userID, at(2, 0, 0)).Return([]entity.Event{tuesday}, nil)
+		a := NewAgendaV1(events, AgendaOptions{EventDuration: 9 * time.Hour})
+
+		got, err := a.Build(context.Background(), userID, entity.PeriodWeek, at(2, 0, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Days) != 7 || !got.Start.Equal(monday) || !got.End.Equal(at(7, 0, 0)) || len(got.Conflicts) != 0 {
+			t.Fatalf("agendaV1.Build() = %+v, want 7 days from %v without conflicts", got, monday)
+		}
+		if days := got.Days[1]; !reflect.DeepEqual(days.Events, []entity.Event{tuesday}) || days.Gaps != nil {
+			t.Errorf("agendaV1.Build() tuesday = %+v, want event and no gaps", days)
+		}
+		if gaps := got.Days[0].Gaps; len(gaps) != 1 || gaps[0].Duration() != 9*time.Hour {
+			t.Errorf("agendaV1.Build() monday gaps = %v, want whole working day", gaps)
+		}
+	})
+
+	t.Run("Schedules", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		holidays := workcal.NewCalendar()
+		holidays.AddHoliday(at(2, 0, 0), "holiday")
+		schedule := workcal.Schedule{Start: 10 * time.Hour, End: 12 * time.Hour, Weekdays: workcal.DefaultSchedule.Weekdays, Calendar: holidays}
+		events := NewMockEvent(ctrl)
+		events.EXPECT().GetForWeek(gomock.Any(), userID, monday).Return([]entity.Event{standup}, nil)
+		a := NewAgendaV1(events, AgendaOptions{Schedules: &workcal.Schedules{Users: map[string]workcal.Schedule{userID: schedule}}})
+
+		got, err := a.Build(context.Background(), userID, entity.PeriodWeek, monday)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gaps := got.Days[0].Gaps; !reflect.DeepEqual(gaps, []entity.Gap{{Start: at(0, 11, 0), End: at(0, 12, 0)}}) {
+			t.Errorf("agendaV1.Build() monday gaps = %v, want 11:00-12:00", gaps)
+		}
+		if day := got.Days[2]; day.Holiday != "holiday" || day.Gaps != nil {
+			t.Errorf("agendaV1.Build() wednesday = %+v, want holiday without gaps", day)
+		}
+		if day := got.Days[5]; day.Holiday != "" || day.Gaps != nil {
+			t.Errorf("agendaV1.Build() saturday = %+v, want no gaps", day)
+		}
+	})
+
+	t.Run("InvalidPeriod", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		a := NewAgendaV1(NewMockEvent(ctrl), AgendaOptions{})
+		if _, err := a.Build(context.Background(), userID, "month", monday); !errors.Is(err, entity.ErrPeriodInvalid) {
+			t.Errorf("agendaV1.Build() error = %v, want %v", err, entity.ErrPeriodInvalid)
+		}
+	})
+
+	t.Run("ServiceError", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		events := NewMockEvent(ctrl)
+		serviceErr := &InternalError{fmt.Errorf("")}
+		events.EXPECT().GetForDay(gomock.Any(), userID, monday).Return(nil, serviceErr)
+		a := NewAgendaV1(events, AgendaOptions{})
+		if _, err := a.Build(context.Background(), userID, entity.PeriodDay, monday); err != serviceErr {
+			t.Errorf("agendaV1.Build() error = %v, want %v", err, serviceErr)
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"errors"
+	"io"
+)
+
+// Ошибка превышения максимального размера вложения.
+var ErrAttachmentTooLarge = errors.New("attachment is too large")
+
+// Интерфейс сервиса (бизнес-логики) для сущности "вложение".
+type Attachment interface {
+	// Upload сохраняет содержимое r как вложение с именем name к событию id пользователя userID.
+	Upload(ctx context.Context, userID string, eventID string, name string, r io.Reader) (entity.Attachment, error)
+	// Download возвращает вложение id события eventID и его содержимое, которое нужно закрыть.
+	Download(ctx context.Context, userID string, eventID string, id string) (entity.Attachment, io.ReadCloser, error)
+	// List возвращает вложения события eventID пользователя userID.
+	List(ctx context.Context, userID string, eventID string) ([]entity.Attachment, error)
+}
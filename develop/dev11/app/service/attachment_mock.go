@@ -0,0 +1,88 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: attachment.go
+//
+// Generated by this command:
+//
+//	mockgen -source attachment.go -destination attachment_mock.go -package service
+//
+
+// Package service is a generated GoMock package.
+package service
+
+import (
+	context "context"
+	entity "dev11/app/entity"
+	io "io"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAttachment is a mock of Attachment interface.
+type MockAttachment struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttachmentMockRecorder
+}
+
+// MockAttachmentMockRecorder is the mock recorder for MockAttachment.
+type MockAttachmentMockRecorder struct {
+	mock *MockAttachment
+}
+
+// NewMockAttachment creates a new mock instance.
+func NewMockAttachment(ctrl *gomock.Controller) *MockAttachment {
+	mock := &MockAttachment{ctrl: ctrl}
+	mock.recorder = &MockAttachmentMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttachment) EXPECT() *MockAttachmentMockRecorder {
+	return m.recorder
+}
+
+// Download mocks base method.
+func (m *MockAttachment) Download(ctx context.Context, userID, eventID, id string) (entity.Attachment, io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Download", ctx, userID, eventID, id)
+	ret0, _ := ret[0].(entity.Attachment)
+	ret1, _ := ret[1].(io.ReadCloser)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Download indicates an expected call of Download.
+func (mr *MockAttachmentMockRecorder) Download(ctx, userID, eventID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockAttachment)(nil).Download), ctx, userID, eventID, id)
+}
+
+// List mocks base method.
+func (m *MockAttachment) List(ctx context.Context, userID, eventID string) ([]entity.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, userID, eventID)
+	ret0, _ := ret[0].([]entity.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAttachmentMockRecorder) List(ctx, userID, eventID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAttachment)(nil).List), ctx, userID, eventID)
+}
+
+// Upload mocks base method.
+func (m *MockAttachment) Upload(ctx context.Context, userID, eventID, name string, r io.Reader) (entity.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upload", ctx, userID, eventID, name, r)
+	ret0, _ := ret[0].(entity.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upload indicates an expected call of Upload.
+func (mr *MockAttachmentMockRecorder) Upload(ctx, userID, eventID, name, r any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockAttachment)(nil).Upload), ctx, userID, eventID, name, r)
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"dev11/app/entity"
+	"dev11/app/repo"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Количество первых байт содержимого, по которым определяется его тип.
+const sniffLen = 512
+
+// Имя вложения, если имя файла не задано.
+const defaultAttachmentName = "attachment"
+
+// Структура сервиса (бизнес-логики) для сущности "вложение",
+// представляющая первую версию реализации интерфейса.
+type attachmentV1 struct {
+	events repo.Event
+	blobs  repo.BlobStore
+	// maxSize ограничивает размер вложения в байтах, нулевое значение означает отсутствие ограничения.
+	maxSize int64
+}
+
+// NewAttachmentV1 возвращает сервис v1, реализующий интерфейс.
+func NewAttachmentV1(events repo.Event, blobs repo.BlobStore, maxSize int64) Attachment {
+	if events == nil || blobs == nil {
+		return nil
+	}
+
+	return attachmentV1{events: events, blobs: blobs, maxSize: maxSize}
+}
+
+// WithBlobStore задает хранилище вложений, из которого удаляются вложения удаленных событий.
+func WithBlobStore(blobs repo.BlobStore) Option {
+	return func(e *eventV1) { e.blobs = blobs }
+}
+
+// attachmentPrefix возвращает префикс ключей вложений события eventID пользователя userID.
+func attachmentPrefix(userID string, eventID string) string {
+	return userID + "/" + eventID
+}
+
+// toAttachment возвращает вложение, соответствующее объекту хранилища blob.
+func toAttachment(userID string, eventID string, blob repo.Blob) entity.Attachment {
+	return entity.Attachment{
+		ID:          path.Base(blob.Key),
+		EventID:     eventID,
+		UserID:      userID,
+		Name:        blob.Name,
+		ContentType: blob.ContentType,
+		Size:        blob.Size,
+	}
+}
+
+// sanitizeName возвращает имя файла name без пути и управляющих символов.
+func sanitizeName(name string) string {
+	name = name[strings.LastIndexAny(name, `/\`)+1:]
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name))
+	if name == "" || name == "." || name == ".." {
+		return defaultAttachmentName
+	}
+	return name
+}
+
+// Структура читателя, возвращающего ErrAttachmentTooLarge при чтении больше n байт.
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrAttachmentTooLarge
+	}
+	return n, err
+}
+
+// checkEvent проверяет, что событие id пользователя userID существует.
+func (a attachmentV1) checkEvent(ctx context.Context, userID string, id string) error {
+	if _, err := a.events.GetByID(ctx, userID, id); err != nil {
+		if errors.Is(err, repo.ErrNotExist) {
+			return &ExternalError{err}
+		}
+		return &InternalError{err}
+	}
+	return nil
+}
+
+// blobError возвращает ошибку бизнес-логики для ошибки хранилища или чтения вложения err.
+func (a attachmentV1) blobError(err error) error {
+	if errors.Is(err, ErrAttachmentTooLarge) {
+		return &ExternalError{fmt.Errorf("%w: limit is %d bytes", err, a.maxSize)}
+	}
+	if errors.Is(err, repo.ErrNotExist) || errors.Is(err, repo.ErrInvalidKey) {
+		return &ExternalError{err}
+	}
+	return &InternalError{err}
+}
+
+// Upload проверяет существование события, определяет тип содержимого по первым байтам
+// и сохраняет вложение, ограничивая его размер.
+func (a attachmentV1) Upload(ctx context.Context, userID string, eventID string, name string, r io.Reader) (entity.Attachment, error) {
+	if err := a.checkEvent(ctx, userID, eventID); err != nil {
+		return entity.Attachment{}, err
+	}
+
+	if a.maxSize > 0 {
+		r = &limitReader{r: r, n: a.maxSize}
+	}
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return entity.Attachment{}, a.blobError(err)
+	}
+	head = head[:n]
+
+	blob := repo.Blob{
+		Key:         attachmentPrefix(userID, eventID) + "/" + uuid.NewString(),
+		Name:        sanitizeName(name),
+		ContentType: http.DetectContentType(head),
+	}
+	blob, err = a.blobs.Put(ctx, blob, io.MultiReader(bytes.NewReader(head), r))
+	if err != nil {
+		return entity.Attachment{}, a.blobError(err)
+	}
+
+	return toAttachment(userID, eventID, blob), nil
+}
+
+// Download возвращает вложение id события eventID пользователя userID и его содержимое.
+func (a attachmentV1) Download(ctx context.Context, userID string, eventID string, id string) (entity.Attachment, io.ReadCloser, error) {
+	blob, r, err := a.blobs.Get(ctx, attachmentPrefix(userID, eventID)+"/"+id)
+	if err != nil {
+		return entity.Attachment{}, nil, a.blobError(err)
+	}
+
+	return toAttachment(userID, eventID, blob), r, nil
+}
+
+// List возвращает вложения существующего события eventID пользователя userID.
+func (a attachmentV1) List(ctx context.Context, userID string, eventID string) ([]entity.Attachment, error) {
+	if err := a.checkEvent(ctx, userID, eventID); err != nil {
+		return nil, err
+	}
+
+	blobs, err := a.blobs.List(ctx, attachmentPrefix(userID, eventID))
+	if err != nil {
+		return nil, a.blobError(err)
+	}
+
+	attachments := make([]entity.Attachment, len(blobs))
+	for i, blob := range blobs {
+		attachments[i] = toAttachment(userID, eventID, blob)
+	}
+	return attachments, nil
+}
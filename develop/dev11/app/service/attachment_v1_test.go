@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/repo"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewAttachmentV1(t *testing.T) {
+	t.Run("NilRepo", func(t *testing.T) {
+		var want Attachment = nil
+
+		if got := NewAttachmentV1(nil, repo.NewBlobFS(t.TempDir()), 0); got != want {
+			t.Errorf("NewAttachmentV1() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("NilBlobs", func(t *testing.T) {
+		var want Attachment = nil
+
+		if got := NewAttachmentV1(repo.NewEventMemory(), nil, 0); got != want {
+			t.Errorf("NewAttachmentV1() = %v, want %v", got, want)
+		}
+	})
+}
+
+func Test_attachmentV1_Upload(t *testing.T) {
+	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	validEvent := entity.Event{ID: validUUID, Title: "event", UserID: validUUID}
+
+	tests := []struct {
+		name     string
+		prepare  func(r *repo.MockEvent)
+		fileName string
+		data     string
+		want     entity.Attachment
+		wantErr  error
+	}{
+		{"Text", func(r *repo.MockEvent) {
+			r.EXPECT().GetByID(gomock.Any(), validUUID, validUUID).Return(validEvent, nil)
+		}, "notes.txt", "hello", entity.Attachment{
+			EventID: validUUID, UserID: validUUID, Name: "notes.txt", ContentType: "text/plain; charset=utf-8", Size: 5,
+		}, nil},
+		{"SniffedPNG", func(r *repo.MockEvent) {
+			r.EXPECT().GetByID(gomock.Any(), validUUID, validUUID).Return(validEvent, nil)
+		}, `C:\photos\..\image.txt`, "\x89PNG\r\n\x1a\n" + strings.Repeat("x", 600), entity.Attachment{
+			EventID: validUUID, UserID: validUUID, Name: "image.txt", ContentType: "image/png", Size: 608,
+		}, nil},
+		{"EmptyName", func(r *repo.MockEvent) {
+			r.EXPECT().GetByID(gomock.Any(), validUUID, validUUID).Return(validEvent, nil)
+		}, "../\x00", "", entity.Attachment{
+			EventID: validUUID, UserID: validUUID, Name: defaultAttachmentName, ContentType: "text/plain; charset=utf-8",
+		}, nil},
+		{"TooLarge", func(r *repo.MockEvent) {
+			r.EXPECT().GetByID(gomock.Any(), validUUID, validUUID).Return(validEvent, nil)
+		}, "big.bin", strings.Repeat("x", 1025), entity.Attachment{}, ErrAttachmentTooLarge},
+		{"EventNotExist", func(r *repo.MockEvent) {
+			r.EXPECT().GetByID(gomock.Any(), validUUID, validUUID).Return(entity.EmptyEvent, repo.ErrNotExist)
+		}, "a.txt", "", entity.Attachment{}, repo.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			events := repo.NewMockEvent(ctrl)
+			tt.prepare(events)
+			blobs := repo.NewBlobFS(t.TempDir())
+			a := NewAttachmentV1(events, blobs, 1024)
+
+			got, err := a.Upload(context.Background(), validUUID, validUUID, tt.fileName, strings.NewReader(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("attachmentV1.Upload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var external *ExternalError
+				if !errors.As(err, &external) {
+					t.Errorf("attachmentV1.Upload() error = %v, want ExternalError", err)
+				}
+				if list, _ := blobs.List(context.Background(), attachmentPrefix(validUUID, validUUID)); len(list) != 0 {
+					t.Errorf("attachments after error = %v, want empty", list)
+				}
+				return
+			}
+
+			tt.want.ID = got.ID
+			if got.ID == "" || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("attachmentV1.Upload() = %v, want %v", got, tt.want)
+			}
+			_, r, err := a.Download(context.Background(), validUUID, validUUID, got.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			if data, _ := io.ReadAll(r); string(data) != tt.data {
+				t.Errorf("attachmentV1.Download() data = %q, want %q", data, tt.data)
+			}
+		})
+	}
+}
+
+func Test_attachmentV1_Download(t *testing.T) {
+	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	a := NewAttachmentV1(repo.NewEventMemory(), repo.NewBlobFS(t.TempDir()), 0)
+
+	for _, id := range []string{validUUID, "../" + validUUID} {
+		_, _, err := a.Download(context.Background(), validUUID, validUUID, id)
+		var external *ExternalError
+		if !errors.As(err, &external) {
+			t.Errorf("attachmentV1.Download(%q) error = %v, want ExternalError", id, err)
+		}
+	}
+}
+
+func Test_attachmentV1_List(t *testing.T) {
+	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	validEvent := entity.Event{ID: validUUID, Title: "event", UserID: validUUID}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	events := repo.NewMockEvent(ctrl)
+	events.EXPECT().GetByID(gomock.Any(), validUUID, validUUID).Return(validEvent, nil).Times(3)
+	events.EXPECT().GetByID(gomock.Any(), validUUID, "").Return(entity.EmptyEvent, fmt.Errorf(""))
+	a := NewAttachmentV1(events, repo.NewBlobFS(t.TempDir()), 0)
+
+	if got, err := a.List(context.Background(), validUUID, validUUID); err != nil || len(got) != 0 {
+		t.Errorf("attachmentV1.List() = %v, %v, want empty", got, err)
+	}
+	first, _ := a.Upload(context.Background(), validUUID, validUUID, "a.txt", strings.NewReader("a"))
+	if got, err := a.List(context.Background(), validUUID, validUUID); err != nil || !reflect.DeepEqual(got, []entity.Attachment{first}) {
+		t.Errorf("attachmentV1.List() = %v, %v, want %v", got, err, []entity.Attachment{first})
+	}
+
+	_, err := a.List(context.Background(), validUUID, "")
+	var internal *InternalError
+	if !errors.As(err, &internal) {
+		t.Errorf("attachmentV1.List() error = %v, want InternalError", err)
+	}
+}
+
+func Test_eventV1_DeleteAttachments(t *testing.T) {
+	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	ctx := context.Background()
+
+	events := repo.NewEventMemory()
+	blobs := repo.NewBlobFS(t.TempDir())
+	e := NewEventV1(events, WithBlobStore(blobs))
+	a := NewAttachmentV1(events, blobs, 0)
+
+	event, err := e.Create(ctx, entity.Event{Title: "event", UserID: validUUID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachment, err := a.Upload(ctx, validUUID, event.ID, "a.txt", strings.NewReader("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Delete(ctx, validUUID, event.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := a.Download(ctx, validUUID, event.ID, attachment.ID); !errors.Is(err, repo.ErrNotExist) {
+		t.Errorf("attachmentV1.Download() after delete error = %v, want %v", err, repo.ErrNotExist)
+	}
+}
+
+func Test_eventV1_TransferAttachments(t *testing.T) {
+	from, to := "18310e71-4df6-42c0-adf4-1a280013dd08", "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	ctx := context.Background()
+
+	events := repo.NewEventMemory()
+	blobs := repo.NewBlobFS(t.TempDir())
+	e := NewEventV1(events, WithBlobStore(blobs))
+	a := NewAttachmentV1(events, blobs, 0)
+
+	event, err := e.Create(ctx, entity.Event{Title: "event", UserID: from})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachment, err := a.Upload(ctx, from, event.ID, "a.txt", strings.NewReader("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := e.Transfer(ctx, from, to, entity.EventFilter{}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := a.List(ctx, to, event.ID)
+	attachment.UserID = to
+	if err != nil || !reflect.DeepEqual(got, []entity.Attachment{attachment}) {
+		t.Errorf("attachmentV1.List() after transfer = %v, %v, want %v", got, err, []entity.Attachment{attachment})
+	}
+
+	if _, err := e.DeleteByUser(ctx, to); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := a.Download(ctx, to, event.ID, attachment.ID); !errors.Is(err, repo.ErrNotExist) {
+		t.Errorf("attachmentV1.Download() after user delete error = %v, want %v", err, repo.ErrNotExist)
+	}
+}
+
+// Репозиторий событий, передача событий в котором завершается ошибкой.
+type failingTransferRepo struct {
+	repo.Event
+}
+
+func (failingTransferRepo) Transfer(context.Context, string, string, entity.EventFilter, time.Time) ([]entity.Event, error) {
+	return nil, errors.New("transfer failed")
+}
+
+// Хранилище вложений, перенос объектов с префиксом fail в котором завершается ошибкой.
+type failingMoveBlobs struct {
+	repo.BlobStore
+	fail string
+}
+
+func (b failingMoveBlobs) MovePrefix(ctx context.Context, prefix string, newPrefix string) error {
+	if prefix == b.fail {
+		return errors.New("move failed")
+	}
+	return b.BlobStore.MovePrefix(ctx, prefix, newPrefix)
+}
+
+func Test_eventV1_TransferAttachmentsRollback(t *testing.T) {
+	from, to := "18310e71-4df6-42c0-adf4-1a280013dd08", "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	date := time.Date(2010, 5, 20, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		wrap func(events repo.Event, blobs repo.BlobStore, second string) (repo.Event, repo.BlobStore)
+	}{
+		{"RepoError", func(events repo.Event, blobs repo.BlobStore, second string) (repo.Event, repo.BlobStore) {
+			return failingTransferRepo{events}, blobs
+		}},
+		{"BlobError", func(events repo.Event, blobs repo.BlobStore, second string) (repo.Event, repo.BlobStore) {
+			return events, failingMoveBlobs{blobs, attachmentPrefix(from, second)}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := repo.NewEventMemory()
+			blobs := repo.NewBlobFS(t.TempDir())
+			a := NewAttachmentV1(events, blobs, 0)
+
+			// Вложения второго по дате события переносятся после вложений первого.
+			var created []entity.Event
+			var attachments []entity.Attachment
+			for i, title := range []string{"first", "second"} {
+				event, err := events.Create(ctx, entity.Event{Title: title, UserID: from, Date: date.Add(time.Duration(i) * time.Hour)})
+				if err != nil {
+					t.Fatal(err)
+				}
+				attachment, err := a.Upload(ctx, from, event.ID, "a.txt", strings.NewReader(title))
+				if err != nil {
+					t.Fatal(err)
+				}
+				created, attachments = append(created, event), append(attachments, attachment)
+			}
+			wrappedEvents, wrappedBlobs := tt.wrap(events, blobs, created[1].ID)
+			e := NewEventV1(wrappedEvents, WithBlobStore(wrappedBlobs))
+			if _, err := e.Transfer(ctx, from, to, entity.EventFilter{}); err == nil {
+				t.Fatalf("eventV1.Transfer() error = %v, wantErr %v", err, true)
+			}
+			for i, event := range created {
+				got, err := a.List(ctx, from, event.ID)
+				if err != nil || !reflect.DeepEqual(got, attachments[i:i+1]) {
+					t.Errorf("attachmentV1.List() after failed transfer = %v, %v, want %v", got, err, attachments[i:i+1])
+				}
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ package service
 import (
 	"context"
 	"dev11/app/entity"
+	"dev11/app/repo"
 	"errors"
 	"fmt"
 	"time"
@@ -16,18 +17,40 @@ type ExternalError struct{ Err error }
 
 func (e *ExternalError) Error() string { return fmt.Sprintf("external: %s", e.Err) }
 
+func (e *ExternalError) Unwrap() error { return e.Err }
+
 // Структура внутренней ошибки бизнес-логики.
 type InternalError struct{ Err error }
 
 func (e *InternalError) Error() string { return fmt.Sprintf("internal: %s", e.Err) }
 
+func (e *InternalError) Unwrap() error { return e.Err }
+
+// repoError возвращает ошибку бизнес-логики для ошибки репозитория err. Отмена или истечение
+// времени ctx являются внешними ошибками, так как вызваны клиентом или ограничением времени запроса,
+// как и недопустимый ограничениями арендатор.
+func repoError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, repo.ErrTenantNotAllowed) || errors.Is(err, repo.ErrTooManyTenants) {
+		return &ExternalError{err}
+	}
+	return &InternalError{err}
+}
+
 // Ошибки бизнес-логики.
 var (
+	ErrSameUser     error = &ExternalError{errors.New("users must be different")}
 	ErrInvalidRange error = &ExternalError{errors.New("invalid date range")}
+	ErrEmptyQuery   error = &ExternalError{errors.New("search query is empty")}
+	ErrInvalidLimit error = &ExternalError{fmt.Errorf("limit must be between 1 and %d", MaxUpcomingLimit)}
 )
 
+// Максимальное количество предстоящих событий, возвращаемых GetUpcoming.
+const MaxUpcomingLimit = 100
+
 // Интерфейс сервиса (бизнес-логики) для сущности "событие".
 type Event interface {
+	GetByID(ctx context.Context, userID string, id string) (entity.Event, error)
 	GetForRange(ctx context.Context, userID string, dateStart, dateEnd time.Time) ([]entity.Event, error)
 	GetForDay(ctx context.Context, userID string, day time.Time) ([]entity.Event, error)
 	GetForWeek(ctx context.Context, userID string, week time.Time) ([]entity.Event, error)
@@ -35,4 +58,38 @@ type Event interface {
 	Create(ctx context.Context, event entity.Event) (entity.Event, error)
 	Update(ctx context.Context, event entity.Event) (entity.Event, error)
 	Delete(ctx context.Context, userID string, id string) error
+	Search(ctx context.Context, userID string, query string) ([]entity.Event, error)
+	// GetUpcoming возвращает не более limit ближайших событий, начинающихся не раньше текущего времени.
+	GetUpcoming(ctx context.Context, userID string, limit int) ([]entity.Event, error)
+	// GetForToday возвращает события текущего дня (UTC).
+	GetForToday(ctx context.Context, userID string) ([]entity.Event, error)
+	// Transfer передает события пользователя fromUserID, выбранные filter, пользователю toUserID
+	// вместе с их вложениями и возвращает переданные события.
+	Transfer(ctx context.Context, fromUserID string, toUserID string, filter entity.EventFilter) ([]entity.Event, error)
+	// DeleteByUser удаляет все события пользователя userID вместе с их вложениями и возвращает удаленные события.
+	DeleteByUser(ctx context.Context, userID string) ([]entity.Event, error)
+}
+
+// Типы изменений событий.
+type ChangeType int
+
+const (
+	ChangeCreated ChangeType = iota + 1
+	ChangeUpdated
+	ChangeDeleted
+)
+
+// Структура изменения события. Для удаленного события заполнены только ID и UserID.
+type Change struct {
+	Type  ChangeType
+	Event entity.Event
+}
+
+// Интерфейс сервиса для сущности "событие" с подпиской на изменения событий.
+type EventWatcher interface {
+	Event
+	// Watch возвращает канал изменений событий пользователя userID, произошедших после вызова.
+	// Изменения поступают в порядке их применения.
+	// Канал закрывается при отмене ctx или если получатель не успевает читать изменения.
+	Watch(ctx context.Context, userID string) <-chan Change
 }
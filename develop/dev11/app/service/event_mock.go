@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: event.go
+// Source: service/event.go
 //
 // Generated by this command:
 //
-//	mockgen -source event.go -destination event_mock.go -package service
+//	mockgen -source service/event.go -destination service/event_mock.go -package service
 //
 
 // Package service is a generated GoMock package.
@@ -70,6 +70,36 @@ func (mr *MockEventMockRecorder) Delete(ctx, userID, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockEvent)(nil).Delete), ctx, userID, id)
 }
 
+// DeleteByUser mocks base method.
+func (m *MockEvent) DeleteByUser(ctx context.Context, userID string) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUser", ctx, userID)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByUser indicates an expected call of DeleteByUser.
+func (mr *MockEventMockRecorder) DeleteByUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUser", reflect.TypeOf((*MockEvent)(nil).DeleteByUser), ctx, userID)
+}
+
+// GetByID mocks base method.
+func (m *MockEvent) GetByID(ctx context.Context, userID, id string) (entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, userID, id)
+	ret0, _ := ret[0].(entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockEventMockRecorder) GetByID(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockEvent)(nil).GetByID), ctx, userID, id)
+}
+
 // GetForDay mocks base method.
 func (m *MockEvent) GetForDay(ctx context.Context, userID string, day time.Time) ([]entity.Event, error) {
 	m.ctrl.T.Helper()
@@ -115,6 +145,21 @@ func (mr *MockEventMockRecorder) GetForRange(ctx, userID, dateStart, dateEnd any
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForRange", reflect.TypeOf((*MockEvent)(nil).GetForRange), ctx, userID, dateStart, dateEnd)
 }
 
+// GetForToday mocks base method.
+func (m *MockEvent) GetForToday(ctx context.Context, userID string) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForToday", ctx, userID)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForToday indicates an expected call of GetForToday.
+func (mr *MockEventMockRecorder) GetForToday(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForToday", reflect.TypeOf((*MockEvent)(nil).GetForToday), ctx, userID)
+}
+
 // GetForWeek mocks base method.
 func (m *MockEvent) GetForWeek(ctx context.Context, userID string, week time.Time) ([]entity.Event, error) {
 	m.ctrl.T.Helper()
@@ -130,6 +175,51 @@ func (mr *MockEventMockRecorder) GetForWeek(ctx, userID, week any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForWeek", reflect.TypeOf((*MockEvent)(nil).GetForWeek), ctx, userID, week)
 }
 
+// GetUpcoming mocks base method.
+func (m *MockEvent) GetUpcoming(ctx context.Context, userID string, limit int) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpcoming", ctx, userID, limit)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpcoming indicates an expected call of GetUpcoming.
+func (mr *MockEventMockRecorder) GetUpcoming(ctx, userID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpcoming", reflect.TypeOf((*MockEvent)(nil).GetUpcoming), ctx, userID, limit)
+}
+
+// Search mocks base method.
+func (m *MockEvent) Search(ctx context.Context, userID, query string) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, userID, query)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockEventMockRecorder) Search(ctx, userID, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockEvent)(nil).Search), ctx, userID, query)
+}
+
+// Transfer mocks base method.
+func (m *MockEvent) Transfer(ctx context.Context, fromUserID, toUserID string, filter entity.EventFilter) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfer", ctx, fromUserID, toUserID, filter)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Transfer indicates an expected call of Transfer.
+func (mr *MockEventMockRecorder) Transfer(ctx, fromUserID, toUserID, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockEvent)(nil).Transfer), ctx, fromUserID, toUserID, filter)
+}
+
 // Update mocks base method.
 func (m *MockEvent) Update(ctx context.Context, event entity.Event) (entity.Event, error) {
 	m.ctrl.T.Helper()
@@ -144,3 +234,234 @@ func (mr *MockEventMockRecorder) Update(ctx, event any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockEvent)(nil).Update), ctx, event)
 }
+
+// MockEventWatcher is a mock of EventWatcher interface.
+type MockEventWatcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventWatcherMockRecorder
+}
+
+// MockEventWatcherMockRecorder is the mock recorder for MockEventWatcher.
+type MockEventWatcherMockRecorder struct {
+	mock *MockEventWatcher
+}
+
+// NewMockEventWatcher creates a new mock instance.
+func NewMockEventWatcher(ctrl *gomock.Controller) *MockEventWatcher {
+	mock := &MockEventWatcher{ctrl: ctrl}
+	mock.recorder = &MockEventWatcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventWatcher) EXPECT() *MockEventWatcherMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockEventWatcher) Create(ctx context.Context, event entity.Event) (entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, event)
+	ret0, _ := ret[0].(entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockEventWatcherMockRecorder) Create(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockEventWatcher)(nil).Create), ctx, event)
+}
+
+// Delete mocks base method.
+func (m *MockEventWatcher) Delete(ctx context.Context, userID, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockEventWatcherMockRecorder) Delete(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockEventWatcher)(nil).Delete), ctx, userID, id)
+}
+
+// DeleteByUser mocks base method.
+func (m *MockEventWatcher) DeleteByUser(ctx context.Context, userID string) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUser", ctx, userID)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByUser indicates an expected call of DeleteByUser.
+func (mr *MockEventWatcherMockRecorder) DeleteByUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUser", reflect.TypeOf((*MockEventWatcher)(nil).DeleteByUser), ctx, userID)
+}
+
+// GetByID mocks base method.
+func (m *MockEventWatcher) GetByID(ctx context.Context, userID, id string) (entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, userID, id)
+	ret0, _ := ret[0].(entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockEventWatcherMockRecorder) GetByID(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockEventWatcher)(nil).GetByID), ctx, userID, id)
+}
+
+// GetForDay mocks base method.
+func (m *MockEventWatcher) GetForDay(ctx context.Context, userID string, day time.Time) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForDay", ctx, userID, day)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForDay indicates an expected call of GetForDay.
+func (mr *MockEventWatcherMockRecorder) GetForDay(ctx, userID, day any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForDay", reflect.TypeOf((*MockEventWatcher)(nil).GetForDay), ctx, userID, day)
+}
+
+// GetForMonth mocks base method.
+func (m *MockEventWatcher) GetForMonth(ctx context.Context, userID string, month time.Time) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForMonth", ctx, userID, month)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForMonth indicates an expected call of GetForMonth.
+func (mr *MockEventWatcherMockRecorder) GetForMonth(ctx, userID, month any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForMonth", reflect.TypeOf((*MockEventWatcher)(nil).GetForMonth), ctx, userID, month)
+}
+
+// GetForRange mocks base method.
+func (m *MockEventWatcher) GetForRange(ctx context.Context, userID string, dateStart, dateEnd time.Time) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForRange", ctx, userID, dateStart, dateEnd)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForRange indicates an expected call of GetForRange.
+func (mr *MockEventWatcherMockRecorder) GetForRange(ctx, userID, dateStart, dateEnd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForRange", reflect.TypeOf((*MockEventWatcher)(nil).GetForRange), ctx, userID, dateStart, dateEnd)
+}
+
+// GetForToday mocks base method.
+func (m *MockEventWatcher) GetForToday(ctx context.Context, userID string) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForToday", ctx, userID)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForToday indicates an expected call of GetForToday.
+func (mr *MockEventWatcherMockRecorder) GetForToday(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForToday", reflect.TypeOf((*MockEventWatcher)(nil).GetForToday), ctx, userID)
+}
+
+// GetForWeek mocks base method.
+func (m *MockEventWatcher) GetForWeek(ctx context.Context, userID string, week time.Time) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForWeek", ctx, userID, week)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForWeek indicates an expected call of GetForWeek.
+func (mr *MockEventWatcherMockRecorder) GetForWeek(ctx, userID, week any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForWeek", reflect.TypeOf((*MockEventWatcher)(nil).GetForWeek), ctx, userID, week)
+}
+
+// GetUpcoming mocks base method.
+func (m *MockEventWatcher) GetUpcoming(ctx context.Context, userID string, limit int) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpcoming", ctx, userID, limit)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpcoming indicates an expected call of GetUpcoming.
+func (mr *MockEventWatcherMockRecorder) GetUpcoming(ctx, userID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpcoming", reflect.TypeOf((*MockEventWatcher)(nil).GetUpcoming), ctx, userID, limit)
+}
+
+// Search mocks base method.
+func (m *MockEventWatcher) Search(ctx context.Context, userID, query string) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, userID, query)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockEventWatcherMockRecorder) Search(ctx, userID, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockEventWatcher)(nil).Search), ctx, userID, query)
+}
+
+// Transfer mocks base method.
+func (m *MockEventWatcher) Transfer(ctx context.Context, fromUserID, toUserID string, filter entity.EventFilter) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfer", ctx, fromUserID, toUserID, filter)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Transfer indicates an expected call of Transfer.
+func (mr *MockEventWatcherMockRecorder) Transfer(ctx, fromUserID, toUserID, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockEventWatcher)(nil).Transfer), ctx, fromUserID, toUserID, filter)
+}
+
+// Update mocks base method.
+func (m *MockEventWatcher) Update(ctx context.Context, event entity.Event) (entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, event)
+	ret0, _ := ret[0].(entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockEventWatcherMockRecorder) Update(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockEventWatcher)(nil).Update), ctx, event)
+}
+
+// Watch mocks base method.
+func (m *MockEventWatcher) Watch(ctx context.Context, userID string) <-chan Change {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watch", ctx, userID)
+	ret0, _ := ret[0].(<-chan Change)
+	return ret0
+}
+
+// Watch indicates an expected call of Watch.
+func (mr *MockEventWatcherMockRecorder) Watch(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockEventWatcher)(nil).Watch), ctx, userID)
+}
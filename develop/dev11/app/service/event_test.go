@@ -5,7 +5,10 @@
 package service
 
 import (
+	"context"
+	"dev11/app/repo"
 	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -24,3 +27,42 @@ func TestInternalError_Error(t *testing.T) {
 		t.Errorf("InternalError.Error() = %v, want %v", got, want)
 	}
 }
+
+func TestExternalError_Unwrap(t *testing.T) {
+	want := errors.New("test error")
+	if got := errors.Unwrap(&ExternalError{want}); got != want {
+		t.Errorf("ExternalError.Unwrap() = %v, want %v", got, want)
+	}
+}
+
+func TestInternalError_Unwrap(t *testing.T) {
+	want := errors.New("test error")
+	if got := errors.Unwrap(&InternalError{want}); got != want {
+		t.Errorf("InternalError.Unwrap() = %v, want %v", got, want)
+	}
+}
+
+func Test_repoError(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantExternal bool
+	}{
+		{"Canceled", context.Canceled, true},
+		{"DeadlineExceeded", fmt.Errorf("wal: %w", context.DeadlineExceeded), true},
+		{"TooManyTenants", fmt.Errorf("%w: limit is 1", repo.ErrTooManyTenants), true},
+		{"Other", errors.New("test error"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := repoError(tt.err)
+			var externalErr *ExternalError
+			if got := errors.As(err, &externalErr); got != tt.wantExternal {
+				t.Errorf("repoError() = %v, want external %v", err, tt.wantExternal)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("repoError() = %v, want wrapped %v", err, tt.err)
+			}
+		})
+	}
+}
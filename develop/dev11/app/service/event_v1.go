@@ -2,24 +2,72 @@ package service
 
 import (
 	"context"
+	"dev11/app/clock"
 	"dev11/app/entity"
 	"dev11/app/repo"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
+	"unicode"
+
+	"github.com/google/uuid"
 )
 
 // Структура сервиса (бизнес-логики) для сущности "событие",
 // представляющая первую версию реализации интерфейса.
 type eventV1 struct {
-	repo repo.Event
+	repo   repo.Event
+	quotas *Quotas
+	// countLocks упорядочивают проверку количества событий пользователя и добавление
+	// ему событий. Создаются, только если квоты ограничивают количество событий.
+	countLocks *countLocks
+	// blobs хранит вложения событий, удаляемые вместе с событием.
+	blobs repo.BlobStore
+	// clock задает текущее время для GetUpcoming и GetForToday.
+	clock clock.Clock
+}
+
+// WithClock задает часы сервиса. По умолчанию используются системные часы.
+func WithClock(c clock.Clock) Option {
+	return func(e *eventV1) { e.clock = c }
 }
 
 // NewEventV1 возвращает сервис v1, реализующий интерфейс.
-func NewEventV1(repo repo.Event) Event {
-	if repo != nil {
-		return eventV1{repo}
+func NewEventV1(repo repo.Event, opts ...Option) Event {
+	if repo == nil {
+		return nil
 	}
-	return nil
+
+	e := eventV1{repo: repo}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	e.clock = clock.OrSystem(e.clock)
+	if e.quotas != nil && e.quotas.hasCountLimit() {
+		e.countLocks = new(countLocks)
+	}
+	return e
+}
+
+// now возвращает текущее время часов сервиса в UTC с точностью до микросекунды,
+// сохраняемой при сериализации в protobuf и JSON без потерь.
+func (e eventV1) now() time.Time {
+	return clock.OrSystem(e.clock).Now().UTC().Truncate(time.Microsecond)
+}
+
+// GetByID возвращает Event по его userID и id.
+func (e eventV1) GetByID(ctx context.Context, userID string, id string) (entity.Event, error) {
+	event, err := e.repo.GetByID(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotExist) {
+			return entity.EmptyEvent, &ExternalError{err}
+		}
+		return entity.EmptyEvent, repoError(err)
+	}
+
+	return event, nil
 }
 
 // GetForRange возвращает []Event по его userID и диапазону дат, валидируя входные данные.
@@ -30,7 +78,7 @@ func (e eventV1) GetForRange(ctx context.Context, userID string, dateStart, date
 
 	events, err := e.repo.GetForRange(ctx, userID, dateStart, dateEnd)
 	if err != nil {
-		return nil, &InternalError{err}
+		return nil, repoError(err)
 	}
 
 	return events, nil
@@ -44,7 +92,7 @@ func (e eventV1) GetForDay(ctx context.Context, userID string, day time.Time) ([
 
 	events, err := e.repo.GetForRange(ctx, userID, dateStart, dateEnd)
 	if err != nil {
-		return nil, &InternalError{err}
+		return nil, repoError(err)
 	}
 
 	return events, nil
@@ -58,7 +106,7 @@ func (e eventV1) GetForWeek(ctx context.Context, userID string, week time.Time)
 
 	events, err := e.repo.GetForRange(ctx, userID, dateStart, dateEnd)
 	if err != nil {
-		return nil, &InternalError{err}
+		return nil, repoError(err)
 	}
 
 	return events, nil
@@ -71,52 +119,269 @@ func (e eventV1) GetForMonth(ctx context.Context, userID string, month time.Time
 
 	events, err := e.repo.GetForRange(ctx, userID, dateStart, dateEnd)
 	if err != nil {
-		return nil, &InternalError{err}
+		return nil, repoError(err)
 	}
 
 	return events, nil
 }
 
-// Create валидирует входные данные, создает новый Event и возвращает его.
+// Create валидирует входные данные, проверяет квоты, создает новый Event и возвращает его.
+// Заданный идентификатор сохраняется, если у пользователя еще нет события с таким идентификатором.
 func (e eventV1) Create(ctx context.Context, event entity.Event) (entity.Event, error) {
 	if err := event.ValidateCreate(); err != nil {
 		return entity.EmptyEvent, &ExternalError{err}
 	}
+	if event.ID != "" {
+		if err := uuid.Validate(event.ID); err != nil {
+			return entity.EmptyEvent, &ExternalError{fmt.Errorf("id: %w", entity.ErrIdInvalid)}
+		}
+	}
+	if err := e.checkSize(ctx, event); err != nil {
+		return entity.EmptyEvent, err
+	}
 
+	if e.countLimited(ctx) {
+		defer e.countLocks.lock(ctx, event.UserID)()
+	}
+	if err := e.checkCount(ctx, event.UserID, 1); err != nil {
+		return entity.EmptyEvent, err
+	}
+
+	event.UpdatedAt = e.now()
 	event, err := e.repo.Create(ctx, event)
 	if err != nil {
-		return entity.EmptyEvent, &InternalError{err}
+		if errors.Is(err, repo.ErrExists) {
+			return entity.EmptyEvent, &ExternalError{err}
+		}
+		return entity.EmptyEvent, repoError(err)
 	}
 
 	return event, nil
 }
 
-// Update валидирует входные данные, обновляет существующий Event и возвращает его.
+// Update валидирует входные данные, проверяет квоты, обновляет существующий Event и возвращает его.
 func (e eventV1) Update(ctx context.Context, event entity.Event) (entity.Event, error) {
 	if err := event.ValidateUpdate(); err != nil {
 		return entity.EmptyEvent, &ExternalError{err}
 	}
+	if err := e.checkSize(ctx, event); err != nil {
+		return entity.EmptyEvent, err
+	}
 
+	event.UpdatedAt = e.now()
 	event, err := e.repo.Update(ctx, event)
 	if err != nil {
 		if errors.Is(err, repo.ErrNotExist) {
 			return entity.EmptyEvent, &ExternalError{err}
 		}
-		return entity.EmptyEvent, &InternalError{err}
+		return entity.EmptyEvent, repoError(err)
 	}
 
 	return event, nil
 }
 
-// Delete удаляет существующий Event по его userID и id.
+// Delete удаляет существующий Event по его userID и id вместе с его вложениями.
 func (e eventV1) Delete(ctx context.Context, userID string, id string) error {
 	err := e.repo.Delete(ctx, userID, id)
 	if err != nil {
 		if errors.Is(err, repo.ErrNotExist) {
 			return &ExternalError{err}
 		}
-		return &InternalError{err}
+		return repoError(err)
+	}
+
+	// Вложения удаляются после события, чтобы при ошибке не потерять вложения существующего события.
+	if e.blobs != nil {
+		if err := e.blobs.DeletePrefix(ctx, attachmentPrefix(userID, id)); err != nil {
+			return repoError(err)
+		}
+	}
+
+	return nil
+}
+
+// Search возвращает []Event по его userID, найденные по полнотекстовому запросу query.
+// Запрос без букв и цифр не содержит слов, по которым ищет репозиторий.
+func (e eventV1) Search(ctx context.Context, userID string, query string) ([]entity.Event, error) {
+	if strings.IndexFunc(query, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	events, err := e.repo.Search(ctx, userID, query)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	return events, nil
+}
+
+// Верхняя граница диапазона дат при поиске предстоящих событий.
+var upcomingEnd = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+
+// GetUpcoming возвращает не более limit событий по его userID, начинающихся не раньше
+// текущего времени, в порядке возрастания даты.
+func (e eventV1) GetUpcoming(ctx context.Context, userID string, limit int) ([]entity.Event, error) {
+	if limit < 1 || limit > MaxUpcomingLimit {
+		return nil, ErrInvalidLimit
+	}
+
+	events, err := e.repo.GetUpcoming(ctx, userID, clock.OrSystem(e.clock).Now(), limit)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	return events, nil
+}
+
+// GetForToday возвращает []Event по его userID за текущий день (UTC).
+func (e eventV1) GetForToday(ctx context.Context, userID string) ([]entity.Event, error) {
+	return e.GetForDay(ctx, userID, clock.OrSystem(e.clock).Now())
+}
+
+// validateUserID проверяет идентификатор пользователя userID, переданный в поле field.
+func validateUserID(field string, userID string) error {
+	if err := uuid.Validate(userID); err != nil {
+		return &ExternalError{fmt.Errorf("%s: %w", field, entity.ErrIdInvalid)}
+	}
+	return nil
+}
+
+// Transfer валидирует входные данные, проверяет квоту получателя и передает события
+// пользователя fromUserID, выбранные filter, пользователю toUserID одной операцией репозитория.
+//
+// Вложения переносятся до событий, и при ошибке передачи событий возвращаются обратно, поэтому
+// переданные события не остаются без вложений. На время передачи вложения недоступны прежнему
+// владельцу.
+func (e eventV1) Transfer(ctx context.Context, fromUserID string, toUserID string, filter entity.EventFilter) ([]entity.Event, error) {
+	if err := validateUserID("from_user_id", fromUserID); err != nil {
+		return nil, err
+	}
+	if err := validateUserID("to_user_id", toUserID); err != nil {
+		return nil, err
+	}
+	if fromUserID == toUserID {
+		return nil, ErrSameUser
+	}
+	if !filter.DateStart.IsZero() && !filter.DateEnd.IsZero() && filter.DateEnd.Before(filter.DateStart) {
+		return nil, ErrInvalidRange
+	}
+
+	limited := e.countLimited(ctx)
+	if limited {
+		defer e.countLocks.lock(ctx, toUserID)()
+	}
+	var selected []entity.Event
+	if limited || e.blobs != nil {
+		var err error
+		if selected, err = e.selectTransfer(ctx, fromUserID, filter); err != nil {
+			return nil, err
+		}
+	}
+	if limited {
+		if err := e.checkCount(ctx, toUserID, len(selected)); err != nil {
+			return nil, err
+		}
+	}
+
+	if e.blobs != nil {
+		if len(selected) == 0 {
+			return []entity.Event{}, nil
+		}
+		// Передаются только события, вложения которых уже перенесены.
+		filter.IDs = make([]string, len(selected))
+		for i, event := range selected {
+			filter.IDs[i] = event.ID
+		}
+		if err := e.moveAttachments(ctx, fromUserID, toUserID, filter.IDs); err != nil {
+			return nil, err
+		}
+	}
+
+	events, err := e.repo.Transfer(ctx, fromUserID, toUserID, filter, e.now())
+	if err != nil {
+		if e.blobs != nil {
+			e.restoreAttachments(context.WithoutCancel(ctx), fromUserID, toUserID, filter.IDs, nil)
+		}
+		if errors.Is(err, repo.ErrExists) {
+			return nil, &ExternalError{err}
+		}
+		return nil, repoError(err)
+	}
+	if e.blobs != nil {
+		e.restoreAttachments(context.WithoutCancel(ctx), fromUserID, toUserID, filter.IDs, events)
+	}
+
+	return events, nil
+}
+
+// selectTransfer возвращает события пользователя userID, выбранные filter.
+func (e eventV1) selectTransfer(ctx context.Context, userID string, filter entity.EventFilter) ([]entity.Event, error) {
+	dateEnd := filter.DateEnd
+	if dateEnd.IsZero() {
+		dateEnd = upcomingEnd
+	}
+	events, err := e.repo.GetForRange(ctx, userID, filter.DateStart, dateEnd)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	var selected []entity.Event
+	for _, event := range events {
+		if filter.Match(event) {
+			selected = append(selected, event)
+		}
 	}
+	return selected, nil
+}
 
+// moveAttachments переносит вложения событий ids пользователя fromUserID пользователю toUserID.
+// При ошибке уже перенесенные вложения возвращаются обратно.
+func (e eventV1) moveAttachments(ctx context.Context, fromUserID string, toUserID string, ids []string) error {
+	for i, id := range ids {
+		err := e.blobs.MovePrefix(ctx, attachmentPrefix(fromUserID, id), attachmentPrefix(toUserID, id))
+		if err == nil {
+			continue
+		}
+		e.restoreAttachments(context.WithoutCancel(ctx), fromUserID, toUserID, ids[:i], nil)
+		if errors.Is(err, repo.ErrExists) {
+			return &ExternalError{err}
+		}
+		return repoError(err)
+	}
 	return nil
 }
+
+// restoreAttachments возвращает пользователю fromUserID вложения событий ids, кроме событий
+// transferred, переданных пользователю toUserID. Вложения событий, удаленных во время передачи,
+// удаляются. Ошибки игнорируются, так как результат передачи событий уже определен.
+func (e eventV1) restoreAttachments(ctx context.Context, fromUserID string, toUserID string, ids []string, transferred []entity.Event) {
+	for _, id := range ids {
+		if slices.ContainsFunc(transferred, func(event entity.Event) bool { return event.ID == id }) {
+			continue
+		}
+		if _, err := e.repo.GetByID(ctx, fromUserID, id); errors.Is(err, repo.ErrNotExist) {
+			e.blobs.DeletePrefix(ctx, attachmentPrefix(toUserID, id))
+			continue
+		}
+		e.blobs.MovePrefix(ctx, attachmentPrefix(toUserID, id), attachmentPrefix(fromUserID, id))
+	}
+}
+
+// DeleteByUser удаляет все события пользователя userID одной операцией репозитория,
+// а затем все его вложения.
+func (e eventV1) DeleteByUser(ctx context.Context, userID string) ([]entity.Event, error) {
+	if err := validateUserID("user_id", userID); err != nil {
+		return nil, err
+	}
+
+	events, err := e.repo.DeleteByUser(ctx, userID)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	if e.blobs != nil {
+		if err := e.blobs.DeletePrefix(ctx, userID); err != nil {
+			return nil, &InternalError{err}
+		}
+	}
+
+	return events, nil
+}
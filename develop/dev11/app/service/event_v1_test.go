@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"dev11/app/clock"
 	"dev11/app/entity"
 	"dev11/app/repo"
 	"fmt"
@@ -26,7 +27,7 @@ func TestNewEventV1(t *testing.T) {
 		defer ctrl.Finish()
 
 		repo := repo.NewMockEvent(ctrl)
-		want := eventV1{repo: repo}
+		want := eventV1{repo: repo, clock: clock.System()}
 
 		if got := NewEventV1(repo); !reflect.DeepEqual(got, want) {
 			t.Errorf("NewEventV1() = %v, want %v", got, want)
@@ -34,6 +35,55 @@ func TestNewEventV1(t *testing.T) {
 	})
 }
 
+func Test_eventV1_GetByID(t *testing.T) {
+	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	validEvent := entity.Event{ID: validUUID, Title: "event", UserID: validUUID}
+
+	type args struct {
+		userID string
+		id     string
+	}
+	tests := []struct {
+		name    string
+		prepare func(repo *repo.MockEvent)
+		args    args
+		want    entity.Event
+		wantErr bool
+	}{
+		{"ValidEvent", func(repo *repo.MockEvent) {
+			repo.EXPECT().GetByID(gomock.Any(), gomock.Eq(validUUID), gomock.Eq(validUUID)).Return(validEvent, nil)
+		}, args{validUUID, validUUID}, validEvent, false},
+		{"RepoError", func(repo *repo.MockEvent) {
+			repo.EXPECT().GetByID(gomock.Any(), gomock.Eq(validUUID), gomock.Eq(validUUID)).Return(entity.EmptyEvent, fmt.Errorf(""))
+		}, args{validUUID, validUUID}, entity.EmptyEvent, true},
+		{"RepoErrorNotExist", func(r *repo.MockEvent) {
+			r.EXPECT().GetByID(gomock.Any(), gomock.Eq(validUUID), gomock.Eq(validUUID)).Return(entity.EmptyEvent, repo.ErrNotExist)
+		}, args{validUUID, validUUID}, entity.EmptyEvent, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repo.NewMockEvent(ctrl)
+			tt.prepare(repo)
+			e := eventV1{repo: repo}
+
+			got, err := e.GetByID(ctx, tt.args.userID, tt.args.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("eventV1.GetByID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("eventV1.GetByID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_eventV1_GetForRange(t *testing.T) {
 	dateStart, _ := time.Parse(time.DateOnly, "2010-05-20")
 	dateEnd, _ := time.Parse(time.DateOnly, "2010-05-25")
@@ -226,6 +276,9 @@ func Test_eventV1_GetForMonth(t *testing.T) {
 func Test_eventV1_Create(t *testing.T) {
 	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
 	validEvent := entity.Event{Title: "event", UserID: validUUID}
+	now := time.Date(2010, 5, 17, 12, 0, 0, 0, time.UTC)
+	stamped := validEvent
+	stamped.UpdatedAt = now
 
 	type args struct {
 		event entity.Event
@@ -238,12 +291,16 @@ func Test_eventV1_Create(t *testing.T) {
 		wantErr bool
 	}{
 		{"ValidEvent", func(repo *repo.MockEvent) {
-			repo.EXPECT().Create(gomock.Any(), gomock.Eq(validEvent)).Return(validEvent, nil)
-		}, args{validEvent}, validEvent, false},
+			repo.EXPECT().Create(gomock.Any(), gomock.Eq(stamped)).Return(stamped, nil)
+		}, args{validEvent}, stamped, false},
 		{"InvalidEvent", func(repo *repo.MockEvent) {}, args{entity.EmptyEvent}, entity.EmptyEvent, true},
 		{"RepoError", func(repo *repo.MockEvent) {
-			repo.EXPECT().Create(gomock.Any(), gomock.Eq(validEvent)).Return(entity.EmptyEvent, fmt.Errorf(""))
+			repo.EXPECT().Create(gomock.Any(), gomock.Eq(stamped)).Return(entity.EmptyEvent, fmt.Errorf(""))
 		}, args{validEvent}, entity.EmptyEvent, true},
+		{"InvalidID", func(repo *repo.MockEvent) {}, args{entity.Event{ID: "1", Title: "event", UserID: validUUID}}, entity.EmptyEvent, true},
+		{"Exists", func(r *repo.MockEvent) {
+			r.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.EmptyEvent, repo.ErrExists)
+		}, args{entity.Event{ID: validUUID, Title: "event", UserID: validUUID}}, entity.EmptyEvent, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -255,7 +312,7 @@ func Test_eventV1_Create(t *testing.T) {
 
 			repo := repo.NewMockEvent(ctrl)
 			tt.prepare(repo)
-			e := eventV1{repo: repo}
+			e := eventV1{repo: repo, clock: clock.NewFake(now)}
 
 			got, err := e.Create(ctx, tt.args.event)
 			if (err != nil) != tt.wantErr {
@@ -272,6 +329,9 @@ func Test_eventV1_Create(t *testing.T) {
 func Test_eventV1_Update(t *testing.T) {
 	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
 	validEvent := entity.Event{ID: validUUID, Title: "event", UserID: validUUID}
+	now := time.Date(2010, 5, 17, 12, 0, 0, 0, time.UTC)
+	stamped := validEvent
+	stamped.UpdatedAt = now
 
 	type args struct {
 		event entity.Event
@@ -284,14 +344,14 @@ func Test_eventV1_Update(t *testing.T) {
 		wantErr bool
 	}{
 		{"ValidEvent", func(repo *repo.MockEvent) {
-			repo.EXPECT().Update(gomock.Any(), gomock.Eq(validEvent)).Return(validEvent, nil)
-		}, args{validEvent}, validEvent, false},
+			repo.EXPECT().Update(gomock.Any(), gomock.Eq(stamped)).Return(stamped, nil)
+		}, args{validEvent}, stamped, false},
 		{"InvalidEvent", func(repo *repo.MockEvent) {}, args{entity.EmptyEvent}, entity.EmptyEvent, true},
 		{"RepoError", func(repo *repo.MockEvent) {
-			repo.EXPECT().Update(gomock.Any(), gomock.Eq(validEvent)).Return(entity.EmptyEvent, fmt.Errorf(""))
+			repo.EXPECT().Update(gomock.Any(), gomock.Eq(stamped)).Return(entity.EmptyEvent, fmt.Errorf(""))
 		}, args{validEvent}, entity.EmptyEvent, true},
 		{"RepoErrorNotExist", func(r *repo.MockEvent) {
-			r.EXPECT().Update(gomock.Any(), gomock.Eq(validEvent)).Return(entity.EmptyEvent, repo.ErrNotExist)
+			r.EXPECT().Update(gomock.Any(), gomock.Eq(stamped)).Return(entity.EmptyEvent, repo.ErrNotExist)
 		}, args{validEvent}, entity.EmptyEvent, true},
 	}
 	for _, tt := range tests {
@@ -304,7 +364,7 @@ func Test_eventV1_Update(t *testing.T) {
 
 			repo := repo.NewMockEvent(ctrl)
 			tt.prepare(repo)
-			e := eventV1{repo: repo}
+			e := eventV1{repo: repo, clock: clock.NewFake(now)}
 
 			got, err := e.Update(ctx, tt.args.event)
 			if (err != nil) != tt.wantErr {
@@ -357,3 +417,219 @@ func Test_eventV1_Delete(t *testing.T) {
 		})
 	}
 }
+
+func Test_eventV1_Search(t *testing.T) {
+	type args struct {
+		userID string
+		query  string
+	}
+	tests := []struct {
+		name    string
+		prepare func(repo *repo.MockEvent)
+		args    args
+		want    []entity.Event
+		wantErr bool
+	}{
+		{"ValidQuery", func(repo *repo.MockEvent) {
+			repo.EXPECT().Search(gomock.Any(), gomock.Eq(""), gomock.Eq("standup")).Return([]entity.Event{}, nil)
+		}, args{"", "standup"}, []entity.Event{}, false},
+		{"EmptyQuery", func(repo *repo.MockEvent) {}, args{"", " "}, nil, true},
+		{"PunctuationQuery", func(repo *repo.MockEvent) {}, args{"", `" , !"`}, nil, true},
+		{"RepoError", func(repo *repo.MockEvent) {
+			repo.EXPECT().Search(gomock.Any(), gomock.Eq(""), gomock.Eq("standup")).Return(nil, fmt.Errorf(""))
+		}, args{"", "standup"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repo.NewMockEvent(ctrl)
+			tt.prepare(repo)
+			e := eventV1{repo: repo}
+
+			got, err := e.Search(ctx, tt.args.userID, tt.args.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("eventV1.Search() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("eventV1.Search() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_eventV1_GetUpcoming(t *testing.T) {
+	now := time.Date(2010, 5, 17, 12, 0, 0, 0, time.UTC)
+	first := entity.Event{ID: "a", Date: now.Add(time.Hour)}
+	second := entity.Event{ID: "b", Date: now.Add(time.Hour)}
+
+	type args struct {
+		userID string
+		limit  int
+	}
+	tests := []struct {
+		name    string
+		prepare func(repo *repo.MockEvent)
+		args    args
+		want    []entity.Event
+		wantErr bool
+	}{
+		{"Limit", func(repo *repo.MockEvent) {
+			repo.EXPECT().GetUpcoming(gomock.Any(), gomock.Eq(""), gomock.Eq(now), gomock.Eq(2)).Return([]entity.Event{first, second}, nil)
+		}, args{"", 2}, []entity.Event{first, second}, false},
+		{"ZeroLimit", func(repo *repo.MockEvent) {}, args{"", 0}, nil, true},
+		{"LimitTooLarge", func(repo *repo.MockEvent) {}, args{"", MaxUpcomingLimit + 1}, nil, true},
+		{"RepoError", func(repo *repo.MockEvent) {
+			repo.EXPECT().GetUpcoming(gomock.Any(), gomock.Eq(""), gomock.Eq(now), gomock.Eq(10)).Return(nil, fmt.Errorf(""))
+		}, args{"", 10}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repo.NewMockEvent(ctrl)
+			tt.prepare(repo)
+			e := eventV1{repo: repo, clock: clock.NewFake(now)}
+
+			got, err := e.GetUpcoming(ctx, tt.args.userID, tt.args.limit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("eventV1.GetUpcoming() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("eventV1.GetUpcoming() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_eventV1_GetForToday(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dateStart, _ := time.Parse(time.DateOnly, "2010-05-17")
+	dateEnd := dateStart.Add(24*time.Hour - time.Nanosecond)
+	events := []entity.Event{{ID: "a", Date: dateStart.Add(10 * time.Hour)}}
+
+	repo := repo.NewMockEvent(ctrl)
+	repo.EXPECT().GetForRange(gomock.Any(), gomock.Eq(""), gomock.Eq(dateStart), gomock.Eq(dateEnd)).Return(events, nil)
+	fake := clock.NewFake(dateStart.Add(23 * time.Hour))
+	e := eventV1{repo: repo, clock: fake}
+
+	got, err := e.GetForToday(context.Background(), "")
+	if err != nil {
+		t.Fatalf("eventV1.GetForToday() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, events) {
+		t.Errorf("eventV1.GetForToday() = %v, want %v", got, events)
+	}
+}
+
+func Test_eventV1_Transfer(t *testing.T) {
+	from, to := "18310e71-4df6-42c0-adf4-1a280013dd08", "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	now := time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC)
+	event := entity.Event{ID: "1", Title: "event", Date: now, UserID: to, UpdatedAt: now}
+	type args struct {
+		fromUserID string
+		toUserID   string
+		filter     entity.EventFilter
+	}
+	tests := []struct {
+		name    string
+		opts    []Option
+		prepare func(r *repo.MockEvent)
+		args    args
+		want    []entity.Event
+		wantErr any
+	}{
+		{"Transferred", nil, func(r *repo.MockEvent) {
+			r.EXPECT().Transfer(gomock.Any(), from, to, entity.EventFilter{IDs: []string{"1"}}, now).Return([]entity.Event{event}, nil)
+		}, args{from, to, entity.EventFilter{IDs: []string{"1"}}}, []entity.Event{event}, nil},
+		{"InvalidFromUser", nil, func(r *repo.MockEvent) {}, args{"1", to, entity.EventFilter{}}, nil, &ExternalError{}},
+		{"InvalidToUser", nil, func(r *repo.MockEvent) {}, args{from, "", entity.EventFilter{}}, nil, &ExternalError{}},
+		{"SameUser", nil, func(r *repo.MockEvent) {}, args{from, from, entity.EventFilter{}}, nil, &ExternalError{}},
+		{"InvalidRange", nil, func(r *repo.MockEvent) {},
+			args{from, to, entity.EventFilter{DateStart: now, DateEnd: now.Add(-time.Hour)}}, nil, &ExternalError{}},
+		{"Exists", nil, func(r *repo.MockEvent) {
+			r.EXPECT().Transfer(gomock.Any(), from, to, entity.EventFilter{}, now).Return(nil, repo.ErrExists)
+		}, args{from, to, entity.EventFilter{}}, nil, &ExternalError{}},
+		{"RepoError", nil, func(r *repo.MockEvent) {
+			r.EXPECT().Transfer(gomock.Any(), from, to, entity.EventFilter{}, now).Return(nil, fmt.Errorf(""))
+		}, args{from, to, entity.EventFilter{}}, nil, &InternalError{}},
+		{"UnderQuota", []Option{WithQuotas(Quotas{Default: Quota{MaxEventsPerUser: 2}})}, func(r *repo.MockEvent) {
+			r.EXPECT().GetForRange(gomock.Any(), from, now, upcomingEnd).Return([]entity.Event{{ID: "1", Date: now}, {ID: "2", Date: now}}, nil)
+			r.EXPECT().CountByUser(gomock.Any(), to).Return(1, nil)
+			r.EXPECT().Transfer(gomock.Any(), from, to, entity.EventFilter{IDs: []string{"1"}, DateStart: now}, now).
+				Return([]entity.Event{event}, nil)
+		}, args{from, to, entity.EventFilter{IDs: []string{"1"}, DateStart: now}}, []entity.Event{event}, nil},
+		{"OverQuota", []Option{WithQuotas(Quotas{Default: Quota{MaxEventsPerUser: 2}})}, func(r *repo.MockEvent) {
+			r.EXPECT().GetForRange(gomock.Any(), from, now, upcomingEnd).Return([]entity.Event{{ID: "1", Date: now}, {ID: "2", Date: now}}, nil)
+			r.EXPECT().CountByUser(gomock.Any(), to).Return(1, nil)
+		}, args{from, to, entity.EventFilter{DateStart: now}}, nil, &ExternalError{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := repo.NewMockEvent(ctrl)
+			tt.prepare(r)
+			e := NewEventV1(r, append(tt.opts, WithClock(clock.NewFake(now)))...)
+
+			got, err := e.Transfer(context.Background(), tt.args.fromUserID, tt.args.toUserID, tt.args.filter)
+			if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErr) {
+				t.Fatalf("eventV1.Transfer() error = %v, want type %v", err, reflect.TypeOf(tt.wantErr))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("eventV1.Transfer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_eventV1_DeleteByUser(t *testing.T) {
+	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	event := entity.Event{ID: "1", Title: "event", UserID: validUUID}
+	tests := []struct {
+		name    string
+		prepare func(r *repo.MockEvent)
+		userID  string
+		want    []entity.Event
+		wantErr any
+	}{
+		{"Deleted", func(r *repo.MockEvent) {
+			r.EXPECT().DeleteByUser(gomock.Any(), validUUID).Return([]entity.Event{event}, nil)
+		}, validUUID, []entity.Event{event}, nil},
+		{"InvalidUser", func(r *repo.MockEvent) {}, "1", nil, &ExternalError{}},
+		{"RepoError", func(r *repo.MockEvent) {
+			r.EXPECT().DeleteByUser(gomock.Any(), validUUID).Return(nil, fmt.Errorf(""))
+		}, validUUID, nil, &InternalError{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := repo.NewMockEvent(ctrl)
+			tt.prepare(r)
+			e := NewEventV1(r)
+
+			got, err := e.DeleteByUser(context.Background(), tt.userID)
+			if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErr) {
+				t.Fatalf("eventV1.DeleteByUser() error = %v, want type %v", err, reflect.TypeOf(tt.wantErr))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("eventV1.DeleteByUser() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
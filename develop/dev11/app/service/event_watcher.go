@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/tenant"
+	"hash/fnv"
+	"slices"
+	"sync"
+)
+
+// Размер буфера канала изменений подписчика.
+const watchBufferSize = 64
+
+// Количество блокировок, упорядочивающих изменения пользователей. Пользователи
+// распределяются по блокировкам по хешу ключа watchKey.
+const watchWriteLocks = 64
+
+// Структура сервиса, оповещающего подписчиков об изменениях событий в сервисе Event.
+type eventWatcher struct {
+	Event
+	// writeMu упорядочивает изменения событий пользователя: изменение и оповещение о нем
+	// выполняются под одной блокировкой, поэтому подписчики получают изменения
+	// в порядке их применения.
+	writeMu [watchWriteLocks]sync.Mutex
+	mu      sync.Mutex
+	// subs хранит подписчиков по ключу watchKey.
+	subs map[string]map[chan Change]struct{}
+}
+
+// watchKey возвращает ключ подписчиков пользователя userID арендатора из ctx.
+func watchKey(ctx context.Context, userID string) string {
+	return tenant.FromContext(ctx) + "/" + userID
+}
+
+// NewEventWatcher возвращает EventWatcher поверх service, если service не равен nil.
+func NewEventWatcher(service Event) EventWatcher {
+	if service != nil {
+		return &eventWatcher{Event: service, subs: make(map[string]map[chan Change]struct{})}
+	}
+	return nil
+}
+
+// Watch возвращает канал изменений событий пользователя userID.
+func (e *eventWatcher) Watch(ctx context.Context, userID string) <-chan Change {
+	ch := make(chan Change, watchBufferSize)
+	key := watchKey(ctx, userID)
+
+	e.mu.Lock()
+	if e.subs[key] == nil {
+		e.subs[key] = make(map[chan Change]struct{})
+	}
+	e.subs[key][ch] = struct{}{}
+	e.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		e.mu.Lock()
+		e.unsubscribe(key, ch)
+		e.mu.Unlock()
+	}()
+
+	return ch
+}
+
+// unsubscribe удаляет подписчика ch с ключом key и закрывает его канал.
+// Должен вызываться под e.mu.
+func (e *eventWatcher) unsubscribe(key string, ch chan Change) {
+	if _, ok := e.subs[key][ch]; !ok {
+		return
+	}
+	delete(e.subs[key], ch)
+	if len(e.subs[key]) == 0 {
+		delete(e.subs, key)
+	}
+	close(ch)
+}
+
+// lockUsers блокирует изменения пользователей userIDs арендатора из ctx в порядке номеров
+// блокировок, чтобы одновременные изменения пар пользователей не взаимоблокировались.
+// Возвращает функцию снятия блокировки.
+func (e *eventWatcher) lockUsers(ctx context.Context, userIDs ...string) (unlock func()) {
+	locks := make([]int, 0, len(userIDs))
+	for _, userID := range userIDs {
+		h := fnv.New32a()
+		h.Write([]byte(watchKey(ctx, userID)))
+		locks = append(locks, int(h.Sum32()%watchWriteLocks))
+	}
+	slices.Sort(locks)
+	locks = slices.Compact(locks)
+
+	for _, i := range locks {
+		e.writeMu[i].Lock()
+	}
+	return func() {
+		for _, i := range slices.Backward(locks) {
+			e.writeMu[i].Unlock()
+		}
+	}
+}
+
+// publish отправляет изменение change подписчикам пользователя события арендатора из ctx.
+// Подписчики с заполненным буфером отписываются, чтобы не блокировать сервис.
+func (e *eventWatcher) publish(ctx context.Context, change Change) {
+	key := watchKey(ctx, change.Event.UserID)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for ch := range e.subs[key] {
+		select {
+		case ch <- change:
+		default:
+			e.unsubscribe(key, ch)
+		}
+	}
+}
+
+// Create создает Event и оповещает подписчиков.
+func (e *eventWatcher) Create(ctx context.Context, event entity.Event) (entity.Event, error) {
+	defer e.lockUsers(ctx, event.UserID)()
+	event, err := e.Event.Create(ctx, event)
+	if err == nil {
+		e.publish(ctx, Change{ChangeCreated, event})
+	}
+	return event, err
+}
+
+// Update обновляет Event и оповещает подписчиков.
+func (e *eventWatcher) Update(ctx context.Context, event entity.Event) (entity.Event, error) {
+	defer e.lockUsers(ctx, event.UserID)()
+	event, err := e.Event.Update(ctx, event)
+	if err == nil {
+		e.publish(ctx, Change{ChangeUpdated, event})
+	}
+	return event, err
+}
+
+// Delete удаляет Event и оповещает подписчиков.
+func (e *eventWatcher) Delete(ctx context.Context, userID string, id string) error {
+	defer e.lockUsers(ctx, userID)()
+	err := e.Event.Delete(ctx, userID, id)
+	if err == nil {
+		e.publish(ctx, Change{ChangeDeleted, entity.Event{ID: id, UserID: userID}})
+	}
+	return err
+}
+
+// Transfer передает события и оповещает подписчиков обоих пользователей: у отправителя
+// события удаляются, у получателя создаются.
+func (e *eventWatcher) Transfer(ctx context.Context, fromUserID string, toUserID string, filter entity.EventFilter) ([]entity.Event, error) {
+	defer e.lockUsers(ctx, fromUserID, toUserID)()
+	events, err := e.Event.Transfer(ctx, fromUserID, toUserID, filter)
+	for _, event := range events {
+		e.publish(ctx, Change{ChangeDeleted, entity.Event{ID: event.ID, UserID: fromUserID}})
+		e.publish(ctx, Change{ChangeCreated, event})
+	}
+	return events, err
+}
+
+// DeleteByUser удаляет все события пользователя и оповещает подписчиков.
+func (e *eventWatcher) DeleteByUser(ctx context.Context, userID string) ([]entity.Event, error) {
+	defer e.lockUsers(ctx, userID)()
+	events, err := e.Event.DeleteByUser(ctx, userID)
+	for _, event := range events {
+		e.publish(ctx, Change{ChangeDeleted, entity.Event{ID: event.ID, UserID: userID}})
+	}
+	return events, err
+}
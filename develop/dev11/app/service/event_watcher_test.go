@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/repo"
+	"dev11/app/tenant"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewEventWatcher(t *testing.T) {
+	t.Run("NilService", func(t *testing.T) {
+		var want EventWatcher = nil
+
+		if got := NewEventWatcher(nil); got != want {
+			t.Errorf("NewEventWatcher() = %v, want %v", got, want)
+		}
+	})
+}
+
+func Test_eventWatcher_Watch(t *testing.T) {
+	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	otherUUID := "28310e71-4df6-42c0-adf4-1a280013dd08"
+	validEvent := entity.Event{ID: validUUID, Title: "event", UserID: validUUID}
+
+	tests := []struct {
+		name    string
+		prepare func(s *MockEvent)
+		call    func(w EventWatcher) error
+		want    []Change
+	}{
+		{"Create", func(s *MockEvent) {
+			s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(validEvent, nil)
+		}, func(w EventWatcher) error {
+			_, err := w.Create(context.Background(), validEvent)
+			return err
+		}, []Change{{ChangeCreated, validEvent}}},
+		{"Update", func(s *MockEvent) {
+			s.EXPECT().Update(gomock.Any(), gomock.Any()).Return(validEvent, nil)
+		}, func(w EventWatcher) error {
+			_, err := w.Update(context.Background(), validEvent)
+			return err
+		}, []Change{{ChangeUpdated, validEvent}}},
+		{"Delete", func(s *MockEvent) {
+			s.EXPECT().Delete(gomock.Any(), validUUID, validUUID).Return(nil)
+		}, func(w EventWatcher) error {
+			return w.Delete(context.Background(), validUUID, validUUID)
+		}, []Change{{ChangeDeleted, entity.Event{ID: validUUID, UserID: validUUID}}}},
+		{"TransferFrom", func(s *MockEvent) {
+			s.EXPECT().Transfer(gomock.Any(), validUUID, otherUUID, entity.EventFilter{}).
+				Return([]entity.Event{{ID: validUUID, Title: "event", UserID: otherUUID}}, nil)
+		}, func(w EventWatcher) error {
+			_, err := w.Transfer(context.Background(), validUUID, otherUUID, entity.EventFilter{})
+			return err
+		}, []Change{{ChangeDeleted, entity.Event{ID: validUUID, UserID: validUUID}}}},
+		{"TransferTo", func(s *MockEvent) {
+			s.EXPECT().Transfer(gomock.Any(), otherUUID, validUUID, entity.EventFilter{}).Return([]entity.Event{validEvent}, nil)
+		}, func(w EventWatcher) error {
+			_, err := w.Transfer(context.Background(), otherUUID, validUUID, entity.EventFilter{})
+			return err
+		}, []Change{{ChangeCreated, validEvent}}},
+		{"DeleteByUser", func(s *MockEvent) {
+			s.EXPECT().DeleteByUser(gomock.Any(), validUUID).Return([]entity.Event{validEvent}, nil)
+		}, func(w EventWatcher) error {
+			_, err := w.DeleteByUser(context.Background(), validUUID)
+			return err
+		}, []Change{{ChangeDeleted, entity.Event{ID: validUUID, UserID: validUUID}}}},
+		{"ServiceError", func(s *MockEvent) {
+			s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.EmptyEvent, fmt.Errorf(""))
+		}, func(w EventWatcher) error {
+			w.Create(context.Background(), validEvent)
+			return nil
+		}, nil},
+		{"OtherUser", func(s *MockEvent) {
+			s.EXPECT().Delete(gomock.Any(), otherUUID, validUUID).Return(nil)
+		}, func(w EventWatcher) error {
+			return w.Delete(context.Background(), otherUUID, validUUID)
+		}, nil},
+		{"OtherTenant", func(s *MockEvent) {
+			s.EXPECT().Delete(gomock.Any(), validUUID, validUUID).Return(nil)
+		}, func(w EventWatcher) error {
+			return w.Delete(tenant.WithTenant(context.Background(), "sales"), validUUID, validUUID)
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := NewMockEvent(ctrl)
+			tt.prepare(service)
+			w := NewEventWatcher(service)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			ch := w.Watch(ctx, validUUID)
+			if err := tt.call(w); err != nil {
+				t.Fatalf("call error = %v", err)
+			}
+			cancel()
+
+			var got []Change
+			for change := range ch {
+				got = append(got, change)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Watch() changes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_eventWatcher_WatchLagged(t *testing.T) {
+	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	validEvent := entity.Event{ID: validUUID, Title: "event", UserID: validUUID}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := NewMockEvent(ctrl)
+	service.EXPECT().Update(gomock.Any(), gomock.Any()).Return(validEvent, nil).Times(watchBufferSize + 1)
+	w := NewEventWatcher(service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := w.Watch(ctx, validUUID)
+	for range watchBufferSize + 1 {
+		w.Update(ctx, validEvent)
+	}
+
+	got := 0
+	for range ch {
+		got++
+	}
+	if got != watchBufferSize {
+		t.Errorf("Watch() changes = %v, want %v", got, watchBufferSize)
+	}
+}
+
+// Структура сервиса, возвращающего результат изменения события со случайной задержкой,
+// как если бы поток был вытеснен между изменением и оповещением о нем.
+type delayedEvent struct {
+	Event
+}
+
+// Update обновляет событие и возвращает результат через случайное время до миллисекунды.
+func (s delayedEvent) Update(ctx context.Context, event entity.Event) (entity.Event, error) {
+	event, err := s.Event.Update(ctx, event)
+	time.Sleep(rand.N(time.Millisecond))
+	return event, err
+}
+
+func Test_eventWatcher_WatchOrdered(t *testing.T) {
+	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := NewEventWatcher(delayedEvent{NewEventV1(repo.NewEventMemory())})
+	event, err := w.Create(ctx, entity.Event{Title: "event", UserID: validUUID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := w.Watch(ctx, validUUID)
+
+	// Одновременные изменения одного события должны доходить до подписчика в порядке
+	// их применения, поэтому последнее изменение в канале совпадает с сохраненным событием.
+	var wg sync.WaitGroup
+	for i := range watchBufferSize {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			updated := event
+			updated.Title = fmt.Sprintf("event %d", i)
+			w.Update(ctx, updated)
+		}()
+	}
+	wg.Wait()
+	want, err := w.GetByID(ctx, validUUID, event.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	var last Change
+	for change := range ch {
+		last = change
+	}
+	if !reflect.DeepEqual(last.Event, want) {
+		t.Errorf("Watch() last change = %v, want %v", last.Event, want)
+	}
+}
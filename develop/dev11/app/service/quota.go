@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/tenant"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// Ошибка превышения квоты арендатора.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Структура квоты арендатора. Нулевое значение ограничения означает его отсутствие.
+type Quota struct {
+	// MaxEventsPerUser ограничивает количество событий одного пользователя.
+	MaxEventsPerUser int
+	// MaxEventSize ограничивает суммарный размер названия и описания события в байтах.
+	MaxEventSize int
+}
+
+// Структура квот арендаторов: квота по умолчанию и квоты отдельных арендаторов.
+type Quotas struct {
+	Default Quota
+	Tenants map[string]Quota
+}
+
+// For возвращает квоту арендатора name.
+func (q Quotas) For(name string) Quota {
+	if quota, ok := q.Tenants[name]; ok {
+		return quota
+	}
+	return q.Default
+}
+
+// hasCountLimit сообщает, ограничивает ли количество событий хотя бы одна квота.
+func (q Quotas) hasCountLimit() bool {
+	if q.Default.MaxEventsPerUser > 0 {
+		return true
+	}
+	for _, quota := range q.Tenants {
+		if quota.MaxEventsPerUser > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Количество блокировок проверки количества событий. Пользователи распределяются
+// по блокировкам по хешу арендатора и идентификатора.
+const countLockStripes = 64
+
+// Структура блокировок проверки количества событий пользователей.
+type countLocks [countLockStripes]sync.Mutex
+
+// lock блокирует проверку количества событий пользователя userID арендатора из ctx
+// и возвращает функцию снятия блокировки.
+func (l *countLocks) lock(ctx context.Context, userID string) (unlock func()) {
+	h := fnv.New32a()
+	h.Write([]byte(tenant.FromContext(ctx) + "/" + userID))
+	mu := &l[h.Sum32()%countLockStripes]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// Тип функции, изменяющей параметры сервиса v1.
+type Option func(e *eventV1)
+
+// WithQuotas задает квоты арендаторов, проверяемые при создании, обновлении и передаче событий.
+func WithQuotas(quotas Quotas) Option {
+	return func(e *eventV1) { e.quotas = &quotas }
+}
+
+// eventSize возвращает размер события event, ограничиваемый квотой.
+func eventSize(event entity.Event) int { return len(event.Title) + len(event.Description) }
+
+// checkSize проверяет размер события event по квоте арендатора из ctx.
+func (e eventV1) checkSize(ctx context.Context, event entity.Event) error {
+	if e.quotas == nil {
+		return nil
+	}
+	quota := e.quotas.For(tenant.FromContext(ctx))
+	if size := eventSize(event); quota.MaxEventSize > 0 && size > quota.MaxEventSize {
+		return &ExternalError{fmt.Errorf("%w: event size is %d bytes, limit is %d bytes", ErrQuotaExceeded, size, quota.MaxEventSize)}
+	}
+	return nil
+}
+
+// countLimited сообщает, ограничивает ли квота арендатора из ctx количество событий.
+func (e eventV1) countLimited(ctx context.Context) bool {
+	return e.countLocks != nil && e.quotas.For(tenant.FromContext(ctx)).MaxEventsPerUser > 0
+}
+
+// checkCount проверяет, что пользователь userID может получить еще added событий
+// по квоте арендатора из ctx.
+func (e eventV1) checkCount(ctx context.Context, userID string, added int) error {
+	if e.quotas == nil {
+		return nil
+	}
+	quota := e.quotas.For(tenant.FromContext(ctx))
+	if quota.MaxEventsPerUser <= 0 {
+		return nil
+	}
+
+	n, err := e.repo.CountByUser(ctx, userID)
+	if err != nil {
+		return repoError(err)
+	}
+	if n+added > quota.MaxEventsPerUser {
+		return &ExternalError{fmt.Errorf("%w: user has %d events, limit is %d", ErrQuotaExceeded, n, quota.MaxEventsPerUser)}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/repo"
+	"dev11/app/tenant"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestQuotas_For(t *testing.T) {
+	quotas := Quotas{Default: Quota{MaxEventsPerUser: 10}, Tenants: map[string]Quota{"sales": {MaxEventSize: 100}}}
+
+	if got, want := quotas.For("sales"), (Quota{MaxEventSize: 100}); got != want {
+		t.Errorf("Quotas.For() = %v, want %v", got, want)
+	}
+	if got, want := quotas.For("support"), (Quota{MaxEventsPerUser: 10}); got != want {
+		t.Errorf("Quotas.For() = %v, want %v", got, want)
+	}
+}
+
+func Test_eventV1_CreateQuota(t *testing.T) {
+	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	validEvent := entity.Event{Title: "event", UserID: validUUID}
+	quotas := Quotas{
+		Default: Quota{MaxEventsPerUser: 2, MaxEventSize: 10},
+		Tenants: map[string]Quota{"sales": {}},
+	}
+	sales := tenant.WithTenant(context.Background(), "sales")
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		prepare   func(repo *repo.MockEvent)
+		event     entity.Event
+		wantErr   bool
+		wantQuota bool
+	}{
+		{"UnderQuota", context.Background(), func(repo *repo.MockEvent) {
+			repo.EXPECT().CountByUser(gomock.Any(), validUUID).Return(1, nil)
+			repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(validEvent, nil)
+		}, validEvent, false, false},
+		{"TooManyEvents", context.Background(), func(repo *repo.MockEvent) {
+			repo.EXPECT().CountByUser(gomock.Any(), validUUID).Return(2, nil)
+		}, validEvent, true, true},
+		{"TooLarge", context.Background(), func(repo *repo.MockEvent) {},
+			entity.Event{Title: "event", Description: "description", UserID: validUUID}, true, true},
+		{"CountError", context.Background(), func(repo *repo.MockEvent) {
+			repo.EXPECT().CountByUser(gomock.Any(), validUUID).Return(0, fmt.Errorf(""))
+		}, validEvent, true, false},
+		{"TenantWithoutLimits", sales, func(repo *repo.MockEvent) {
+			repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(validEvent, nil)
+		}, entity.Event{Title: strings.Repeat("event", 10), UserID: validUUID}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repo.NewMockEvent(ctrl)
+			tt.prepare(repo)
+			e := NewEventV1(repo, WithQuotas(quotas))
+
+			_, err := e.Create(tt.ctx, tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("eventV1.Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var externalErr *ExternalError
+			if got := errors.Is(err, ErrQuotaExceeded) && errors.As(err, &externalErr); got != tt.wantQuota {
+				t.Errorf("eventV1.Create() error = %v, want quota error %v", err, tt.wantQuota)
+			}
+		})
+	}
+}
+
+func Test_eventV1_CountLocks(t *testing.T) {
+	t.Run("NoCountLimit", func(t *testing.T) {
+		e := NewEventV1(repo.NewEventMemory(), WithQuotas(Quotas{Default: Quota{MaxEventSize: 10}})).(eventV1)
+		if e.countLocks != nil {
+			t.Errorf("NewEventV1() count locks are created without a count limit")
+		}
+	})
+
+	t.Run("OtherUser", func(t *testing.T) {
+		userA := "18310e71-4df6-42c0-adf4-1a280013dd08"
+		userB := "28310e71-4df6-42c0-adf4-1a280013dd08"
+		blocking := &blockingCreateRepo{Event: repo.NewEventMemory(), userID: userA, started: make(chan struct{}), release: make(chan struct{})}
+		e := NewEventV1(blocking, WithQuotas(Quotas{Default: Quota{MaxEventsPerUser: 10}}))
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := e.Create(context.Background(), entity.Event{Title: "event", UserID: userA})
+			done <- err
+		}()
+		<-blocking.started
+		// Создание события другим пользователем не ждет проверки квоты первого.
+		if _, err := e.Create(context.Background(), entity.Event{Title: "event", UserID: userB}); err != nil {
+			t.Errorf("eventV1.Create() error = %v", err)
+		}
+		close(blocking.release)
+		if err := <-done; err != nil {
+			t.Errorf("eventV1.Create() error = %v", err)
+		}
+	})
+}
+
+// Структура репозитория, в котором создание события пользователя userID ждет закрытия release.
+type blockingCreateRepo struct {
+	repo.Event
+	userID  string
+	started chan struct{}
+	release chan struct{}
+}
+
+// Create создает событие, для пользователя userID — после закрытия release.
+func (r *blockingCreateRepo) Create(ctx context.Context, event entity.Event) (entity.Event, error) {
+	if event.UserID == r.userID {
+		close(r.started)
+		<-r.release
+	}
+	return r.Event.Create(ctx, event)
+}
+
+func Test_eventV1_UpdateQuota(t *testing.T) {
+	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := NewEventV1(repo.NewMockEvent(ctrl), WithQuotas(Quotas{Default: Quota{MaxEventSize: 4}}))
+	_, err := e.Update(context.Background(), entity.Event{ID: validUUID, Title: "event", UserID: validUUID})
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("eventV1.Update() error = %v, want %v", err, ErrQuotaExceeded)
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/workcal"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Ограничения поиска времени встречи.
+const (
+	// MaxSlotParticipants ограничивает количество участников встречи.
+	MaxSlotParticipants = 100
+	// MaxSlotWindow ограничивает длительность интервала поиска.
+	MaxSlotWindow = 31 * 24 * time.Hour
+	// DefaultSlotLimit задает количество возвращаемых промежутков по умолчанию.
+	DefaultSlotLimit = 10
+)
+
+// Ошибки поиска времени встречи.
+var (
+	ErrNoParticipants      error = &ExternalError{errors.New("participants are empty")}
+	ErrTooManyParticipants error = &ExternalError{fmt.Errorf("at most %d participants are allowed", MaxSlotParticipants)}
+	ErrInvalidDuration     error = &ExternalError{errors.New("duration must be positive")}
+	ErrInvalidBuffer       error = &ExternalError{errors.New("buffer must not be negative")}
+	ErrWindowTooLong       error = &ExternalError{fmt.Errorf("time window must not exceed %s", MaxSlotWindow)}
+)
+
+// Структура запроса поиска времени встречи.
+type SlotQuery struct {
+	// UserIDs задает идентификаторы участников встречи.
+	UserIDs []string
+	// Duration задает длительность встречи.
+	Duration time.Duration
+	// Start и End задают интервал поиска [Start, End).
+	Start time.Time
+	End   time.Time
+	// Buffer задает минимальный перерыв между встречей и событиями участников.
+	Buffer time.Duration
+	// WorkingHours ограничивает поиск рабочим временем графиков всех участников.
+	WorkingHours bool
+	// Limit ограничивает количество возвращаемых промежутков, нулевое значение означает DefaultSlotLimit.
+	Limit int
+}
+
+// Интерфейс сервиса (бизнес-логики) для поиска времени встречи нескольких пользователей.
+type Slot interface {
+	// Find возвращает промежутки длительностью query.Duration, в которые свободны все участники,
+	// в порядке убывания предпочтительности.
+	Find(ctx context.Context, query SlotQuery) ([]entity.Slot, error)
+}
+
+// Параметры поиска времени встречи. Нулевые значения заменяются значениями по умолчанию.
+type SlotOptions struct {
+	// EventDuration задает длительность события, так как у событий есть только время начала.
+	EventDuration time.Duration
+	// Step задает шаг начала промежутков от полуночи UTC.
+	Step time.Duration
+	// Schedules задает рабочие графики пользователей. По умолчанию используется workcal.DefaultSchedule.
+	Schedules *workcal.Schedules
+}
+
+// Параметры поиска времени встречи по умолчанию.
+var DefaultSlotOptions = SlotOptions{
+	EventDuration: time.Hour,
+	Step:          15 * time.Minute,
+}
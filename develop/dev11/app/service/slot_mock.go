@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: app/service/slot.go
+//
+// Generated by this command:
+//
+//	mockgen -source app/service/slot.go -destination app/service/slot_mock.go -package service
+//
+
+// Package service is a generated GoMock package.
+package service
+
+import (
+	context "context"
+	entity "dev11/app/entity"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSlot is a mock of Slot interface.
+type MockSlot struct {
+	ctrl     *gomock.Controller
+	recorder *MockSlotMockRecorder
+}
+
+// MockSlotMockRecorder is the mock recorder for MockSlot.
+type MockSlotMockRecorder struct {
+	mock *MockSlot
+}
+
+// NewMockSlot creates a new mock instance.
+func NewMockSlot(ctrl *gomock.Controller) *MockSlot {
+	mock := &MockSlot{ctrl: ctrl}
+	mock.recorder = &MockSlotMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSlot) EXPECT() *MockSlotMockRecorder {
+	return m.recorder
+}
+
+// Find mocks base method.
+func (m *MockSlot) Find(ctx context.Context, query SlotQuery) ([]entity.Slot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", ctx, query)
+	ret0, _ := ret[0].([]entity.Slot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockSlotMockRecorder) Find(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockSlot)(nil).Find), ctx, query)
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"dev11/app/entity"
+	"dev11/app/repo"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Структура сервиса поиска времени встречи, представляющая первую версию реализации интерфейса.
+// События участников получаются из репозитория.
+type slotV1 struct {
+	events repo.Event
+	opts   SlotOptions
+}
+
+// NewSlotV1 возвращает сервис v1, реализующий интерфейс.
+func NewSlotV1(events repo.Event, opts SlotOptions) Slot {
+	if events == nil {
+		return nil
+	}
+
+	if opts.EventDuration <= 0 {
+		opts.EventDuration = DefaultSlotOptions.EventDuration
+	}
+	if opts.Step <= 0 {
+		opts.Step = DefaultSlotOptions.Step
+	}
+	return slotV1{events: events, opts: opts}
+}
+
+// Структура интервала времени [start, end).
+type interval struct {
+	start time.Time
+	end   time.Time
+}
+
+// Find возвращает промежутки, в которые свободны все участники query.UserIDs.
+// Интервалы занятости участников объединяются заметающей прямой, свободные промежутки
+// ранжируются по времени, которое они оставляют непригодным для встречи такой же
+// длительности, а при равенстве — по времени начала.
+func (s slotV1) Find(ctx context.Context, query SlotQuery) ([]entity.Slot, error) {
+	userIDs, limit, err := validateSlotQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	busy, err := s.busy(ctx, query, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	free := freeIntervals(busy, query.Start, query.End)
+
+	type candidate struct {
+		slot  entity.Slot
+		waste time.Duration
+	}
+	var candidates []candidate
+	for _, f := range free {
+		start := f.start.Truncate(s.opts.Step)
+		if start.Before(f.start) {
+			start = start.Add(s.opts.Step)
+		}
+		for ; !start.Add(query.Duration).After(f.end); start = start.Add(s.opts.Step) {
+			end := start.Add(query.Duration)
+			candidates = append(candidates, candidate{
+				slot:  entity.Slot{Start: start, End: end},
+				waste: fragment(start.Sub(f.start), query.Duration) + fragment(f.end.Sub(end), query.Duration),
+			})
+		}
+	}
+	slices.SortStableFunc(candidates, func(x, y candidate) int { return cmp.Compare(x.waste, y.waste) })
+
+	slots := make([]entity.Slot, 0, min(limit, len(candidates)))
+	for _, c := range candidates[:min(limit, len(candidates))] {
+		slots = append(slots, c.slot)
+	}
+	return slots, nil
+}
+
+// validateSlotQuery проверяет запрос query и возвращает участников без повторов
+// и количество возвращаемых промежутков.
+func validateSlotQuery(query SlotQuery) ([]string, int, error) {
+	userIDs := slices.Compact(slices.Sorted(slices.Values(query.UserIDs)))
+	switch {
+	case len(userIDs) == 0:
+		return nil, 0, ErrNoParticipants
+	case len(userIDs) > MaxSlotParticipants:
+		return nil, 0, ErrTooManyParticipants
+	case query.Duration <= 0:
+		return nil, 0, ErrInvalidDuration
+	case query.Buffer < 0:
+		return nil, 0, ErrInvalidBuffer
+	case !query.End.After(query.Start):
+		return nil, 0, ErrInvalidRange
+	case query.End.Sub(query.Start) > MaxSlotWindow:
+		return nil, 0, ErrWindowTooLong
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = DefaultSlotLimit
+	}
+	if limit < 1 || limit > MaxUpcomingLimit {
+		return nil, 0, ErrInvalidLimit
+	}
+	return userIDs, limit, nil
+}
+
+// busy возвращает интервалы занятости участников userIDs: события с перерывом query.Buffer
+// и, если требуется, нерабочее время. События участников получаются параллельно.
+func (s slotV1) busy(ctx context.Context, query SlotQuery, userIDs []string) ([]interval, error) {
+	busy := make([][]interval, len(userIDs))
+	errs := make([]error, len(userIDs))
+	var wg sync.WaitGroup
+	for i, userID := range userIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			busy[i], errs[i] = s.userBusy(ctx, query, userID)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, repoError(err)
+		}
+	}
+	return slices.Concat(busy...), nil
+}
+
+// userBusy возвращает интервалы занятости пользователя userID в интервале поиска query.
+func (s slotV1) userBusy(ctx context.Context, query SlotQuery, userID string) ([]interval, error) {
+	// События, начавшиеся до интервала поиска, могут занимать его начало.
+	from := query.Start.Add(-s.opts.EventDuration - query.Buffer)
+	events, err := s.events.GetForRange(ctx, userID, from, query.End.Add(query.Buffer))
+	if err != nil {
+		return nil, err
+	}
+
+	busy := make([]interval, 0, len(events))
+	for _, event := range events {
+		busy = append(busy, interval{
+			start: event.Date.Add(-query.Buffer),
+			end:   event.Date.Add(s.opts.EventDuration + query.Buffer),
+		})
+	}
+	if !query.WorkingHours {
+		return busy, nil
+	}
+
+	// Дни отсчитываются в часовом поясе графика пользователя.
+	schedule := s.opts.Schedules.For(userID)
+	for day := schedule.Day(query.Start); day.Before(query.End); {
+		next := day.AddDate(0, 0, 1)
+		start, end, ok := schedule.Hours(day)
+		if !ok {
+			busy = append(busy, interval{start: day, end: next})
+		} else {
+			busy = append(busy, interval{start: day, end: start}, interval{start: end, end: next})
+		}
+		day = next
+	}
+	return busy, nil
+}
+
+// freeIntervals возвращает промежутки интервала [start, end), не пересекающиеся с интервалами busy.
+// Интервалы сортируются по началу и объединяются за один проход.
+func freeIntervals(busy []interval, start time.Time, end time.Time) []interval {
+	slices.SortFunc(busy, func(x, y interval) int { return x.start.Compare(y.start) })
+
+	var free []interval
+	cursor := start
+	for _, b := range busy {
+		if !b.start.Before(end) {
+			break
+		}
+		if b.start.After(cursor) {
+			free = append(free, interval{start: cursor, end: b.start})
+		}
+		if b.end.After(cursor) {
+			cursor = b.end
+		}
+	}
+	if cursor.Before(end) {
+		free = append(free, interval{start: cursor, end: end})
+	}
+	return free
+}
+
+// fragment возвращает остаток свободного времени d, если в нем не помещается встреча длительностью duration.
+func fragment(d time.Duration, duration time.Duration) time.Duration {
+	if d >= duration {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/repo"
+	"dev11/app/workcal"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewSlotV1(t *testing.T) {
+	var want Slot = nil
+
+	if got := NewSlotV1(nil, DefaultSlotOptions); got != want {
+		t.Errorf("NewSlotV1() = %v, want %v", got, want)
+	}
+}
+
+func Test_slotV1_Find(t *testing.T) {
+	alice, bob := "18310e71-4df6-42c0-adf4-1a280013dd08", "28310e71-4df6-42c0-adf4-1a280013dd08"
+	// 2010-05-17 — понедельник.
+	monday := time.Date(2010, 5, 17, 0, 0, 0, 0, time.UTC)
+	at := func(day int, hour int, minute int) time.Time {
+		return monday.AddDate(0, 0, day).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	slot := func(start time.Time, d time.Duration) entity.Slot {
+		return entity.Slot{Start: start, End: start.Add(d)}
+	}
+
+	events := repo.NewEventMemory()
+	for _, e := range []entity.Event{
+		{ID: "1", UserID: alice, Date: at(0, 9, 0)},
+		{ID: "2", UserID: alice, Date: at(0, 13, 0)},
+		{ID: "3", UserID: bob, Date: at(0, 10, 30)},
+		{ID: "4", UserID: bob, Date: at(0, 15, 0)},
+		// Событие предыдущего дня, заканчивающееся в интервале поиска.
+		{ID: "5", UserID: bob, Date: at(-1, 23, 30)},
+	} {
+		if _, err := events.Create(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	holidays := workcal.NewCalendar()
+	holidays.AddHoliday(at(1, 0, 0), "holiday")
+	schedules := &workcal.Schedules{
+		Default: workcal.DefaultSchedule,
+		Users:   map[string]workcal.Schedule{bob: {Start: 10 * time.Hour, End: 17 * time.Hour, Weekdays: workcal.DefaultSchedule.Weekdays, Calendar: holidays}},
+	}
+	s := NewSlotV1(events, SlotOptions{Schedules: schedules})
+
+	tests := []struct {
+		name    string
+		query   SlotQuery
+		want    []entity.Slot
+		wantErr error
+	}{
+		// Свободно всем: 11:30-13:00, 14:00-15:00, 16:00-17:00. Промежутки без остатков идут первыми.
+		{"WorkingHours", SlotQuery{UserIDs: []string{alice, bob}, Duration: time.Hour, Start: at(0, 0, 0), End: at(1, 0, 0), WorkingHours: true, Limit: 5},
+			[]entity.Slot{slot(at(0, 14, 0), time.Hour), slot(at(0, 16, 0), time.Hour), slot(at(0, 11, 30), time.Hour), slot(at(0, 11, 45), time.Hour), slot(at(0, 12, 0), time.Hour)}, nil},
+		{"Buffer", SlotQuery{UserIDs: []string{alice, bob, alice}, Duration: time.Hour, Start: at(0, 0, 0), End: at(1, 0, 0), Buffer: 15 * time.Minute, WorkingHours: true},
+			[]entity.Slot{slot(at(0, 11, 45), time.Hour)}, nil},
+		{"NoWorkingHours", SlotQuery{UserIDs: []string{bob}, Duration: 2 * time.Hour, Start: at(0, 0, 0), End: at(0, 10, 30), Limit: 2},
+			[]entity.Slot{slot(at(0, 0, 30), 2*time.Hour), slot(at(0, 2, 30), 2*time.Hour)}, nil},
+		{"Holiday", SlotQuery{UserIDs: []string{alice, bob}, Duration: time.Hour, Start: at(1, 0, 0), End: at(2, 0, 0), WorkingHours: true},
+			[]entity.Slot{}, nil},
+		{"NoParticipants", SlotQuery{Duration: time.Hour, Start: at(0, 0, 0), End: at(1, 0, 0)}, nil, ErrNoParticipants},
+		{"InvalidDuration", SlotQuery{UserIDs: []string{alice}, Start: at(0, 0, 0), End: at(1, 0, 0)}, nil, ErrInvalidDuration},
+		{"InvalidBuffer", SlotQuery{UserIDs: []string{alice}, Duration: time.Hour, Start: at(0, 0, 0), End: at(1, 0, 0), Buffer: -time.Minute}, nil, ErrInvalidBuffer},
+		{"InvalidRange", SlotQuery{UserIDs: []string{alice}, Duration: time.Hour, Start: at(1, 0, 0), End: at(0, 0, 0)}, nil, ErrInvalidRange},
+		{"WindowTooLong", SlotQuery{UserIDs: []string{alice}, Duration: time.Hour, Start: at(0, 0, 0), End: at(32, 0, 0)}, nil, ErrWindowTooLong},
+		{"InvalidLimit", SlotQuery{UserIDs: []string{alice}, Duration: time.Hour, Start: at(0, 0, 0), End: at(1, 0, 0), Limit: -1}, nil, ErrInvalidLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Find(context.Background(), tt.query)
+			if err != tt.wantErr {
+				t.Fatalf("slotV1.Find() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("slotV1.Find() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Location", func(t *testing.T) {
+		newYork, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			t.Skip(err)
+		}
+		// Рабочее время 21:00-23:00 по Нью-Йорку: в понедельник 01:00 UTC там еще воскресенье.
+		schedule := workcal.Schedule{Start: 21 * time.Hour, End: 23 * time.Hour, Weekdays: workcal.DefaultSchedule.Weekdays, Location: newYork}
+		s := NewSlotV1(repo.NewEventMemory(), SlotOptions{Schedules: &workcal.Schedules{Default: schedule}})
+		query := SlotQuery{UserIDs: []string{alice}, Duration: 2 * time.Hour, Start: at(0, 0, 0), End: at(1, 3, 0), WorkingHours: true}
+
+		want := []entity.Slot{slot(at(1, 1, 0), 2*time.Hour)}
+		if got, err := s.Find(context.Background(), query); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("slotV1.Find() = %v, %v, want %v", got, err, want)
+		}
+	})
+
+	t.Run("TooManyParticipants", func(t *testing.T) {
+		userIDs := make([]string, MaxSlotParticipants+1)
+		for i := range userIDs {
+			userIDs[i] = fmt.Sprint(i)
+		}
+		query := SlotQuery{UserIDs: userIDs, Duration: time.Hour, Start: at(0, 0, 0), End: at(1, 0, 0)}
+		if _, err := s.Find(context.Background(), query); err != ErrTooManyParticipants {
+			t.Errorf("slotV1.Find() error = %v, wantErr %v", err, ErrTooManyParticipants)
+		}
+	})
+
+	t.Run("RepoError", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		errRepo := errors.New("repo")
+		r := repo.NewMockEvent(ctrl)
+		r.EXPECT().GetForRange(gomock.Any(), alice, gomock.Any(), gomock.Any()).Return(nil, errRepo)
+		query := SlotQuery{UserIDs: []string{alice}, Duration: time.Hour, Start: at(0, 0, 0), End: at(1, 0, 0)}
+
+		var internal *InternalError
+		if _, err := NewSlotV1(r, DefaultSlotOptions).Find(context.Background(), query); !errors.As(err, &internal) || !errors.Is(err, errRepo) {
+			t.Errorf("slotV1.Find() error = %v, want internal %v", err, errRepo)
+		}
+	})
+}
+
+func BenchmarkSlotV1_Find(b *testing.B) {
+	events := repo.NewEventMemory()
+	start := time.Date(2010, 5, 17, 0, 0, 0, 0, time.UTC)
+	userIDs := make([]string, 50)
+	for i := range userIDs {
+		userIDs[i] = fmt.Sprintf("user-%d", i)
+		for j := range 100 {
+			date := start.Add(time.Duration((i*7+j*13)%(14*24*4)) * 15 * time.Minute)
+			events.Create(context.Background(), entity.Event{ID: fmt.Sprint(i, "-", j), UserID: userIDs[i], Date: date})
+		}
+	}
+	s := NewSlotV1(events, DefaultSlotOptions)
+	query := SlotQuery{UserIDs: userIDs, Duration: 30 * time.Minute, Start: start, End: start.AddDate(0, 0, 14), WorkingHours: true}
+
+	b.ResetTimer()
+	for range b.N {
+		if _, err := s.Find(context.Background(), query); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
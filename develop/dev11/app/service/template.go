@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"time"
+)
+
+// Структура изменений шаблона при создании события. Nil-значения не изменяют поля шаблона.
+type TemplateOverrides struct {
+	Title       *string
+	Description *string
+	Duration    *time.Duration
+	Tags        []string
+	Attendees   []string
+	Reminders   []time.Duration
+}
+
+// apply возвращает шаблон template с примененными изменениями.
+func (o TemplateOverrides) apply(template entity.Template) entity.Template {
+	if o.Title != nil {
+		template.Title = *o.Title
+	}
+	if o.Description != nil {
+		template.Description = *o.Description
+	}
+	if o.Duration != nil {
+		template.Duration = entity.Duration(*o.Duration)
+	}
+	if o.Tags != nil {
+		template.Tags = o.Tags
+	}
+	if o.Attendees != nil {
+		template.Attendees = o.Attendees
+	}
+	if o.Reminders != nil {
+		template.Reminders = make([]entity.Duration, len(o.Reminders))
+		for i, reminder := range o.Reminders {
+			template.Reminders[i] = entity.Duration(reminder)
+		}
+	}
+	return template
+}
+
+// Интерфейс сервиса (бизнес-логики) для сущности "шаблон события".
+type Template interface {
+	GetByID(ctx context.Context, userID string, id string) (entity.Template, error)
+	GetByUser(ctx context.Context, userID string) ([]entity.Template, error)
+	Create(ctx context.Context, template entity.Template) (entity.Template, error)
+	Update(ctx context.Context, template entity.Template) (entity.Template, error)
+	Delete(ctx context.Context, userID string, id string) error
+	// DeleteByUser удаляет все шаблоны пользователя userID и возвращает удаленные шаблоны.
+	DeleteByUser(ctx context.Context, userID string) ([]entity.Template, error)
+	// CreateEvent создает событие пользователя userID в момент date по шаблону id с изменениями
+	// overrides и возвращает событие и примененный шаблон с длительностью, тегами, участниками
+	// и напоминаниями встречи.
+	CreateEvent(ctx context.Context, userID string, id string, date time.Time, overrides TemplateOverrides) (entity.Event, entity.Template, error)
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/repo"
+	"errors"
+	"time"
+)
+
+// Структура сервиса (бизнес-логики) для сущности "шаблон события",
+// представляющая первую версию реализации интерфейса.
+// События создаются сервисом событий, поэтому к ним применяются его проверки и квоты.
+type templateV1 struct {
+	repo   repo.Template
+	events Event
+}
+
+// NewTemplateV1 возвращает сервис v1, реализующий интерфейс.
+func NewTemplateV1(repo repo.Template, events Event) Template {
+	if repo == nil || events == nil {
+		return nil
+	}
+
+	return templateV1{repo: repo, events: events}
+}
+
+// templateError возвращает внешнюю ошибку, если шаблон не найден, иначе внутреннюю.
+func templateError(err error) error {
+	if errors.Is(err, repo.ErrNotExist) {
+		return &ExternalError{err}
+	}
+	return repoError(err)
+}
+
+// GetByID возвращает Template по его userID и id.
+func (t templateV1) GetByID(ctx context.Context, userID string, id string) (entity.Template, error) {
+	template, err := t.repo.GetByID(ctx, userID, id)
+	if err != nil {
+		return entity.Template{}, templateError(err)
+	}
+
+	return template, nil
+}
+
+// GetByUser возвращает []Template по его userID.
+func (t templateV1) GetByUser(ctx context.Context, userID string) ([]entity.Template, error) {
+	templates, err := t.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	return templates, nil
+}
+
+// Create валидирует входные данные, создает новый Template и возвращает его.
+func (t templateV1) Create(ctx context.Context, template entity.Template) (entity.Template, error) {
+	if err := template.ValidateCreate(); err != nil {
+		return entity.Template{}, &ExternalError{err}
+	}
+
+	template, err := t.repo.Create(ctx, template)
+	if err != nil {
+		return entity.Template{}, repoError(err)
+	}
+
+	return template, nil
+}
+
+// Update валидирует входные данные, обновляет существующий Template и возвращает его.
+func (t templateV1) Update(ctx context.Context, template entity.Template) (entity.Template, error) {
+	if err := template.ValidateUpdate(); err != nil {
+		return entity.Template{}, &ExternalError{err}
+	}
+
+	template, err := t.repo.Update(ctx, template)
+	if err != nil {
+		return entity.Template{}, templateError(err)
+	}
+
+	return template, nil
+}
+
+// Delete удаляет существующий Template по его userID и id.
+func (t templateV1) Delete(ctx context.Context, userID string, id string) error {
+	if err := t.repo.Delete(ctx, userID, id); err != nil {
+		return templateError(err)
+	}
+
+	return nil
+}
+
+// DeleteByUser удаляет все Template пользователя userID.
+func (t templateV1) DeleteByUser(ctx context.Context, userID string) ([]entity.Template, error) {
+	templates, err := t.repo.DeleteByUser(ctx, userID)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	return templates, nil
+}
+
+// CreateEvent применяет изменения overrides к шаблону, валидирует его и создает событие,
+// заменяя подстановки в заголовке и описании датой date.
+func (t templateV1) CreateEvent(ctx context.Context, userID string, id string, date time.Time, overrides TemplateOverrides) (entity.Event, entity.Template, error) {
+	template, err := t.GetByID(ctx, userID, id)
+	if err != nil {
+		return entity.EmptyEvent, entity.Template{}, err
+	}
+
+	template = overrides.apply(template)
+	if err := template.ValidateCreate(); err != nil {
+		return entity.EmptyEvent, entity.Template{}, &ExternalError{err}
+	}
+
+	event, err := t.events.Create(ctx, template.Event(date))
+	if err != nil {
+		return entity.EmptyEvent, entity.Template{}, err
+	}
+
+	return event, template, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/repo"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewTemplateV1(t *testing.T) {
+	var want Template = nil
+
+	if got := NewTemplateV1(nil, nil); got != want {
+		t.Errorf("NewTemplateV1() = %v, want %v", got, want)
+	}
+}
+
+func Test_templateV1_Create(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	errRepo := errors.New("repo")
+
+	tests := []struct {
+		name     string
+		template entity.Template
+		prepare  func(r *repo.MockTemplate)
+		wantErr  any
+	}{
+		{"Created", entity.Template{UserID: userID, Title: "standup"}, func(r *repo.MockTemplate) {
+			r.EXPECT().Create(gomock.Any(), entity.Template{UserID: userID, Title: "standup"}).
+				Return(entity.Template{ID: "1", UserID: userID, Title: "standup"}, nil)
+		}, nil},
+		{"InvalidTemplate", entity.Template{UserID: userID}, func(r *repo.MockTemplate) {}, &ExternalError{}},
+		{"RepoError", entity.Template{UserID: userID, Title: "standup"}, func(r *repo.MockTemplate) {
+			r.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.Template{}, errRepo)
+		}, &InternalError{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := repo.NewMockTemplate(ctrl)
+			tt.prepare(r)
+			_, err := NewTemplateV1(r, NewMockEvent(ctrl)).Create(context.Background(), tt.template)
+			if got := reflect.TypeOf(err); got != reflect.TypeOf(tt.wantErr) {
+				t.Errorf("templateV1.Create() error = %v, want type %v", err, reflect.TypeOf(tt.wantErr))
+			}
+		})
+	}
+}
+
+func Test_templateV1_Update(t *testing.T) {
+	id, userID := "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "18310e71-4df6-42c0-adf4-1a280013dd08"
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := repo.NewMockTemplate(ctrl)
+	r.EXPECT().Update(gomock.Any(), gomock.Any()).Return(entity.Template{}, repo.ErrNotExist)
+	s := NewTemplateV1(r, NewMockEvent(ctrl))
+
+	var external *ExternalError
+	if _, err := s.Update(context.Background(), entity.Template{ID: id, UserID: userID, Title: "standup"}); !errors.As(err, &external) {
+		t.Errorf("templateV1.Update() error = %v, want external", err)
+	}
+	if _, err := s.Update(context.Background(), entity.Template{ID: "1", UserID: userID, Title: "standup"}); !errors.Is(err, entity.ErrIdInvalid) {
+		t.Errorf("templateV1.Update() error = %v, want %v", err, entity.ErrIdInvalid)
+	}
+}
+
+func Test_templateV1_CreateEvent(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC)
+	template := entity.Template{ID: "1", UserID: userID, Title: "Standup {{date}}", Description: "daily",
+		Duration: entity.Duration(15 * time.Minute), Tags: []string{"work"}}
+	title, duration := "Retro {{weekday}}", time.Hour
+
+	tests := []struct {
+		name         string
+		overrides    TemplateOverrides
+		prepare      func(r *repo.MockTemplate, e *MockEvent)
+		wantEvent    entity.Event
+		wantTemplate entity.Template
+		wantErr      error
+	}{
+		{"Template", TemplateOverrides{}, func(r *repo.MockTemplate, e *MockEvent) {
+			r.EXPECT().GetByID(gomock.Any(), userID, "1").Return(template, nil)
+			e.EXPECT().Create(gomock.Any(), entity.Event{Title: "Standup 2010-05-17", Description: "daily", Date: date, UserID: userID}).
+				Return(entity.Event{ID: "2", Title: "Standup 2010-05-17", Description: "daily", Date: date, UserID: userID}, nil)
+		}, entity.Event{ID: "2", Title: "Standup 2010-05-17", Description: "daily", Date: date, UserID: userID}, template, nil},
+		{"Overrides", TemplateOverrides{Title: &title, Duration: &duration, Tags: []string{}, Reminders: []time.Duration{time.Minute}},
+			func(r *repo.MockTemplate, e *MockEvent) {
+				r.EXPECT().GetByID(gomock.Any(), userID, "1").Return(template, nil)
+				e.EXPECT().Create(gomock.Any(), entity.Event{Title: "Retro Monday", Description: "daily", Date: date, UserID: userID}).
+					Return(entity.Event{ID: "2", Title: "Retro Monday", Description: "daily", Date: date, UserID: userID}, nil)
+			}, entity.Event{ID: "2", Title: "Retro Monday", Description: "daily", Date: date, UserID: userID},
+			entity.Template{ID: "1", UserID: userID, Title: title, Description: "daily", Duration: entity.Duration(time.Hour),
+				Tags: []string{}, Reminders: []entity.Duration{entity.Duration(time.Minute)}}, nil},
+		{"InvalidOverrides", TemplateOverrides{Title: new(string)}, func(r *repo.MockTemplate, e *MockEvent) {
+			r.EXPECT().GetByID(gomock.Any(), userID, "1").Return(template, nil)
+		}, entity.EmptyEvent, entity.Template{}, entity.ErrTitleEmpty},
+		{"NotExist", TemplateOverrides{}, func(r *repo.MockTemplate, e *MockEvent) {
+			r.EXPECT().GetByID(gomock.Any(), userID, "1").Return(entity.Template{}, repo.ErrNotExist)
+		}, entity.EmptyEvent, entity.Template{}, repo.ErrNotExist},
+		{"EventError", TemplateOverrides{}, func(r *repo.MockTemplate, e *MockEvent) {
+			r.EXPECT().GetByID(gomock.Any(), userID, "1").Return(template, nil)
+			e.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.EmptyEvent, ErrQuotaExceeded)
+		}, entity.EmptyEvent, entity.Template{}, ErrQuotaExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r, e := repo.NewMockTemplate(ctrl), NewMockEvent(ctrl)
+			tt.prepare(r, e)
+			event, template, err := NewTemplateV1(r, e).CreateEvent(context.Background(), userID, "1", date, tt.overrides)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("templateV1.CreateEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(event, tt.wantEvent) {
+				t.Errorf("templateV1.CreateEvent() event = %v, want %v", event, tt.wantEvent)
+			}
+			if !reflect.DeepEqual(template, tt.wantTemplate) {
+				t.Errorf("templateV1.CreateEvent() template = %v, want %v", template, tt.wantTemplate)
+			}
+		})
+	}
+}
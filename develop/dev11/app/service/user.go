@@ -0,0 +1,14 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+)
+
+// Интерфейс сервиса (бизнес-логики) для всех данных пользователя.
+type User interface {
+	// Export возвращает все данные пользователя userID.
+	Export(ctx context.Context, userID string) (entity.UserData, error)
+	// Delete удаляет все данные пользователя userID и возвращает их экспорт.
+	Delete(ctx context.Context, userID string) (entity.UserData, error)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"time"
+)
+
+// Структура сервиса всех данных пользователя, представляющая первую версию реализации интерфейса.
+// Данные получаются и удаляются через сервисы событий, шаблонов и вложений,
+// поэтому к ним применяются их проверки.
+type userV1 struct {
+	events Event
+	// templates и attachments необязательны, nil-значения означают отсутствие этих данных.
+	templates   Template
+	attachments Attachment
+}
+
+// NewUserV1 возвращает сервис v1, реализующий интерфейс.
+func NewUserV1(events Event, templates Template, attachments Attachment) User {
+	if events == nil {
+		return nil
+	}
+
+	return userV1{events: events, templates: templates, attachments: attachments}
+}
+
+// Export возвращает все события, шаблоны и метаданные вложений пользователя userID.
+func (u userV1) Export(ctx context.Context, userID string) (entity.UserData, error) {
+	if err := validateUserID("user_id", userID); err != nil {
+		return entity.UserData{}, err
+	}
+
+	events, err := u.events.GetForRange(ctx, userID, time.Time{}, upcomingEnd)
+	if err != nil {
+		return entity.UserData{}, err
+	}
+	data := entity.UserData{
+		UserID:      userID,
+		Events:      append([]entity.Event{}, events...),
+		Templates:   []entity.Template{},
+		Attachments: []entity.Attachment{},
+	}
+
+	if u.templates != nil {
+		templates, err := u.templates.GetByUser(ctx, userID)
+		if err != nil {
+			return entity.UserData{}, err
+		}
+		data.Templates = append(data.Templates, templates...)
+	}
+
+	if u.attachments != nil {
+		for _, event := range events {
+			attachments, err := u.attachments.List(ctx, userID, event.ID)
+			if err != nil {
+				return entity.UserData{}, err
+			}
+			data.Attachments = append(data.Attachments, attachments...)
+		}
+	}
+
+	return data, nil
+}
+
+// Delete удаляет все данные пользователя userID и возвращает удаленные данные.
+// Данные экспортируются до удаления, так как вложения удаляются вместе с событиями.
+func (u userV1) Delete(ctx context.Context, userID string) (entity.UserData, error) {
+	data, err := u.Export(ctx, userID)
+	if err != nil {
+		return entity.UserData{}, err
+	}
+
+	events, err := u.events.DeleteByUser(ctx, userID)
+	if err != nil {
+		return entity.UserData{}, err
+	}
+	data.Events = append([]entity.Event{}, events...)
+
+	if u.templates != nil {
+		templates, err := u.templates.DeleteByUser(ctx, userID)
+		if err != nil {
+			return entity.UserData{}, err
+		}
+		data.Templates = append([]entity.Template{}, templates...)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"dev11/app/entity"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewUserV1(t *testing.T) {
+	var want User = nil
+
+	if got := NewUserV1(nil, nil, nil); got != want {
+		t.Errorf("NewUserV1() = %v, want %v", got, want)
+	}
+}
+
+func Test_userV1_Export(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	event := entity.Event{ID: "1", Title: "event", UserID: userID}
+	template := entity.Template{ID: "2", Title: "standup", UserID: userID}
+	attachment := entity.Attachment{ID: "3", EventID: "1", UserID: userID, Name: "a.txt"}
+
+	t.Run("All", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		events, templates, attachments := NewMockEvent(ctrl), NewMockTemplate(ctrl), NewMockAttachment(ctrl)
+		events.EXPECT().GetForRange(gomock.Any(), userID, time.Time{}, upcomingEnd).Return([]entity.Event{event}, nil)
+		templates.EXPECT().GetByUser(gomock.Any(), userID).Return([]entity.Template{template}, nil)
+		attachments.EXPECT().List(gomock.Any(), userID, "1").Return([]entity.Attachment{attachment}, nil)
+
+		got, err := NewUserV1(events, templates, attachments).Export(context.Background(), userID)
+		want := entity.UserData{
+			UserID:      userID,
+			Events:      []entity.Event{event},
+			Templates:   []entity.Template{template},
+			Attachments: []entity.Attachment{attachment},
+		}
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("userV1.Export() = %+v, %v, want %+v", got, err, want)
+		}
+	})
+
+	t.Run("EventsOnly", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		events := NewMockEvent(ctrl)
+		events.EXPECT().GetForRange(gomock.Any(), userID, time.Time{}, upcomingEnd).Return(nil, nil)
+
+		got, err := NewUserV1(events, nil, nil).Export(context.Background(), userID)
+		want := entity.UserData{UserID: userID, Events: []entity.Event{}, Templates: []entity.Template{}, Attachments: []entity.Attachment{}}
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("userV1.Export() = %+v, %v, want %+v", got, err, want)
+		}
+	})
+
+	t.Run("InvalidUser", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		_, err := NewUserV1(NewMockEvent(ctrl), nil, nil).Export(context.Background(), "1")
+		if _, ok := err.(*ExternalError); !ok {
+			t.Errorf("userV1.Export() error = %v, want ExternalError", err)
+		}
+	})
+}
+
+func Test_userV1_Delete(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	event := entity.Event{ID: "1", Title: "event", UserID: userID}
+	template := entity.Template{ID: "2", Title: "standup", UserID: userID}
+	attachment := entity.Attachment{ID: "3", EventID: "1", UserID: userID, Name: "a.txt"}
+
+	tests := []struct {
+		name    string
+		prepare func(events *MockEvent, templates *MockTemplate)
+		want    entity.UserData
+		wantErr bool
+	}{
+		{"Deleted", func(events *MockEvent, templates *MockTemplate) {
+			events.EXPECT().DeleteByUser(gomock.Any(), userID).Return([]entity.Event{event}, nil)
+			templates.EXPECT().DeleteByUser(gomock.Any(), userID).Return([]entity.Template{template}, nil)
+		}, entity.UserData{
+			UserID:      userID,
+			Events:      []entity.Event{event},
+			Templates:   []entity.Template{template},
+			Attachments: []entity.Attachment{attachment},
+		}, false},
+		{"EventsError", func(events *MockEvent, templates *MockTemplate) {
+			events.EXPECT().DeleteByUser(gomock.Any(), userID).Return(nil, fmt.Errorf(""))
+		}, entity.UserData{}, true},
+		{"TemplatesError", func(events *MockEvent, templates *MockTemplate) {
+			events.EXPECT().DeleteByUser(gomock.Any(), userID).Return([]entity.Event{event}, nil)
+			templates.EXPECT().DeleteByUser(gomock.Any(), userID).Return(nil, fmt.Errorf(""))
+		}, entity.UserData{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			events, templates, attachments := NewMockEvent(ctrl), NewMockTemplate(ctrl), NewMockAttachment(ctrl)
+			events.EXPECT().GetForRange(gomock.Any(), userID, time.Time{}, upcomingEnd).Return([]entity.Event{event}, nil)
+			templates.EXPECT().GetByUser(gomock.Any(), userID).Return([]entity.Template{template}, nil)
+			attachments.EXPECT().List(gomock.Any(), userID, "1").Return([]entity.Attachment{attachment}, nil)
+			tt.prepare(events, templates)
+
+			got, err := NewUserV1(events, templates, attachments).Delete(context.Background(), userID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("userV1.Delete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("userV1.Delete() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
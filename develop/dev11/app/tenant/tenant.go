@@ -0,0 +1,43 @@
+// Пакет tenant предоставляет идентификацию арендатора (подразделения), в рамках которого
+// выполняется запрос. Арендатор передается между слоями через контекст запроса.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Арендатор по умолчанию для запросов без явно заданного арендатора.
+const Default = "default"
+
+// Ошибка неверного имени арендатора.
+var ErrInvalid = errors.New("invalid tenant")
+
+// Допустимое имя арендатора: метка DNS в нижнем регистре.
+var namePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Validate проверяет имя арендатора name.
+func Validate(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalid, name)
+	}
+	return nil
+}
+
+// Ключ арендатора в контексте.
+type contextKey struct{}
+
+// WithTenant возвращает копию ctx с арендатором name.
+func WithTenant(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, contextKey{}, name)
+}
+
+// FromContext возвращает арендатора из ctx или Default, если арендатор не задан.
+func FromContext(ctx context.Context) string {
+	if name, ok := ctx.Value(contextKey{}).(string); ok && name != "" {
+		return name
+	}
+	return Default
+}
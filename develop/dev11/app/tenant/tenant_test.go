@@ -0,0 +1,40 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tenant  string
+		wantErr bool
+	}{
+		{"Valid", "sales-2", false},
+		{"Default", Default, false},
+		{"Empty", "", true},
+		{"Upper", "Sales", true},
+		{"Dot", "sales.eu", true},
+		{"LeadingHyphen", "-sales", true},
+		{"Path", "../sales", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.tenant)
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrInvalid)) {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != Default {
+		t.Errorf("FromContext() = %v, want %v", got, Default)
+	}
+	if got := FromContext(WithTenant(context.Background(), "sales")); got != "sales" {
+		t.Errorf("FromContext() = %v, want %v", got, "sales")
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Структура приложения, запущенного функцией startTestServer.
+type testServer struct {
+	// URL задает базовый адрес HTTP-сервера без завершающего слеша, например http://127.0.0.1:41234.
+	URL string
+	// Logs содержит журнал приложения в формате JSON, по одной записи в строке.
+	Logs *syncBuffer
+	// Shutdown останавливает приложение и возвращает ошибку run.
+	// Повторные вызовы возвращают ту же ошибку.
+	Shutdown func() error
+}
+
+// startTestServer запускает приложение с реальными репозиториями, сервисами и HTTP-сервером
+// с конфигурацией cfg на случайном свободном порту 127.0.0.1. Приложение останавливается
+// при завершении теста, если Shutdown не был вызван раньше.
+func startTestServer(t *testing.T, cfg Config) *testServer {
+	t.Helper()
+	cfg.Host, cfg.Port = "127.0.0.1", "0"
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = 5 * time.Second
+	}
+
+	logs := new(syncBuffer)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, cfg, slog.New(slog.NewJSONHandler(logs, nil))) }()
+
+	var (
+		once sync.Once
+		err  error
+	)
+	s := &testServer{Logs: logs, Shutdown: func() error {
+		once.Do(func() {
+			cancel()
+			select {
+			case err = <-done:
+			case <-time.After(cfg.ShutdownTimeout + 5*time.Second):
+				t.Errorf("run() did not return after shutdown:\n%s", logs)
+			}
+		})
+		return err
+	}}
+	t.Cleanup(func() {
+		if err := s.Shutdown(); err != nil {
+			t.Errorf("run() error = %v", err)
+		}
+	})
+
+	waitFor(t, "http server address", func() bool {
+		select {
+		case runErr := <-done:
+			once.Do(func() { err = runErr })
+			t.Fatalf("run() returned before the server started: %v\n%s", runErr, logs)
+		default:
+		}
+		addr := httpAddr(logs.String())
+		s.URL = "http://" + addr
+		return addr != ""
+	})
+	return s
+}
+
+// httpAddr возвращает адрес HTTP-сервера из записи о его запуске в журнале logs
+// или пустую строку, если сервер еще не запущен.
+func httpAddr(logs string) string {
+	dec := json.NewDecoder(strings.NewReader(logs))
+	for {
+		var record struct {
+			Msg  string `json:"msg"`
+			Addr string `json:"addr"`
+		}
+		if err := dec.Decode(&record); err != nil {
+			return ""
+		}
+		if record.Msg == "http server started" {
+			return record.Addr
+		}
+	}
+}
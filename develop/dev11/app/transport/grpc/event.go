@@ -0,0 +1,229 @@
+package grpc
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/markdown"
+	"dev11/app/repo"
+	"dev11/app/service"
+	"dev11/app/transport/grpc/eventpb"
+	"dev11/app/workcal"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Ошибка отставания подписчика от изменений событий.
+var ErrWatchLagged = status.Error(codes.Aborted, "watch lagged behind event changes, resubscribe")
+
+// Ошибка потока изменений, завершенного при остановке сервера.
+var ErrShuttingDown = status.Error(codes.Unavailable, "server is shutting down, resubscribe")
+
+// Структура gRPC-сервиса событий.
+type EventServer struct {
+	eventpb.UnimplementedEventServiceServer
+	Service service.EventWatcher
+	// Shutdown закрывается при остановке сервера и завершает потоки WatchEvents.
+	Shutdown <-chan struct{}
+	// Schedules задает рабочие графики пользователей, по производственным календарям которых
+	// событие в праздник создается с предупреждением.
+	Schedules *workcal.Schedules
+}
+
+// statusError преобразует ошибку бизнес-логики err в ошибку gRPC.
+// Внешние ошибки возвращаются с кодами NotFound, AlreadyExists, PermissionDenied, ResourceExhausted,
+// Canceled, DeadlineExceeded или InvalidArgument, иначе вызывается паника для обработки перехватчиком.
+func statusError(err error) error {
+	var externalErr *service.ExternalError
+	if !errors.As(err, &externalErr) {
+		// Паника будет обработана RecovererUnaryInterceptor.
+		panic(err)
+	}
+	switch {
+	case errors.Is(err, repo.ErrNotExist):
+		return status.Error(codes.NotFound, externalErr.Err.Error())
+	case errors.Is(err, repo.ErrExists):
+		return status.Error(codes.AlreadyExists, externalErr.Err.Error())
+	case errors.Is(err, repo.ErrTenantNotAllowed):
+		return status.Error(codes.PermissionDenied, externalErr.Err.Error())
+	case errors.Is(err, service.ErrQuotaExceeded), errors.Is(err, repo.ErrTooManyTenants):
+		return status.Error(codes.ResourceExhausted, externalErr.Err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(externalErr.Err).Err()
+	}
+	return status.Error(codes.InvalidArgument, externalErr.Err.Error())
+}
+
+// toTime возвращает время из ts. Незаданное время преобразуется в нулевое.
+func toTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// toProto преобразует Event в сообщение protobuf. Нулевое время не передается.
+func toProto(event entity.Event) *eventpb.Event {
+	pb := &eventpb.Event{
+		Id:                event.ID,
+		Title:             event.Title,
+		Description:       event.Description,
+		UserId:            event.UserID,
+		DescriptionFormat: event.DescriptionFormat,
+	}
+	if event.DescriptionFormat == entity.FormatMarkdown {
+		pb.DescriptionHtml = markdown.ToHTML(event.Description)
+	}
+	if !event.Date.IsZero() {
+		pb.Date = timestamppb.New(event.Date)
+	}
+	if !event.UpdatedAt.IsZero() {
+		pb.UpdatedAt = timestamppb.New(event.UpdatedAt)
+	}
+	return pb
+}
+
+// fromProto преобразует сообщение protobuf в Event.
+func fromProto(event *eventpb.Event) entity.Event {
+	return entity.Event{
+		ID:                event.GetId(),
+		Title:             event.GetTitle(),
+		Description:       event.GetDescription(),
+		DescriptionFormat: event.GetDescriptionFormat(),
+		Date:              toTime(event.GetDate()),
+		UserID:            event.GetUserId(),
+	}
+}
+
+// eventsResponse возвращает ответ со списком событий events или ошибку gRPC.
+func eventsResponse(events []entity.Event, err error) (*eventpb.EventsResponse, error) {
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp := &eventpb.EventsResponse{Events: make([]*eventpb.Event, len(events))}
+	for i, event := range events {
+		resp.Events[i] = toProto(event)
+	}
+	return resp, nil
+}
+
+// GetEvent возвращает событие по его идентификатору.
+func (s *EventServer) GetEvent(ctx context.Context, req *eventpb.GetEventRequest) (*eventpb.Event, error) {
+	event, err := s.Service.GetByID(ctx, req.GetUserId(), req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProto(event), nil
+}
+
+// CreateEvent создает событие.
+func (s *EventServer) CreateEvent(ctx context.Context, req *eventpb.CreateEventRequest) (*eventpb.Event, error) {
+	event, err := s.Service.Create(ctx, entity.Event{
+		Title:             req.GetTitle(),
+		Description:       req.GetDescription(),
+		DescriptionFormat: req.GetDescriptionFormat(),
+		Date:              toTime(req.GetDate()),
+		UserID:            req.GetUserId(),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp := toProto(event)
+	resp.Warnings = s.Schedules.Warnings(event.UserID, event.Date)
+	return resp, nil
+}
+
+// UpdateEvent обновляет событие.
+func (s *EventServer) UpdateEvent(ctx context.Context, req *eventpb.UpdateEventRequest) (*eventpb.Event, error) {
+	event, err := s.Service.Update(ctx, fromProto(req.GetEvent()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProto(event), nil
+}
+
+// DeleteEvent удаляет событие.
+func (s *EventServer) DeleteEvent(ctx context.Context, req *eventpb.DeleteEventRequest) (*emptypb.Empty, error) {
+	if err := s.Service.Delete(ctx, req.GetUserId(), req.GetId()); err != nil {
+		return nil, statusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// GetEventsForDay возвращает события пользователя за день.
+func (s *EventServer) GetEventsForDay(ctx context.Context, req *eventpb.GetEventsRequest) (*eventpb.EventsResponse, error) {
+	return eventsResponse(s.Service.GetForDay(ctx, req.GetUserId(), toTime(req.GetDate())))
+}
+
+// GetEventsForWeek возвращает события пользователя за неделю.
+func (s *EventServer) GetEventsForWeek(ctx context.Context, req *eventpb.GetEventsRequest) (*eventpb.EventsResponse, error) {
+	return eventsResponse(s.Service.GetForWeek(ctx, req.GetUserId(), toTime(req.GetDate())))
+}
+
+// GetEventsForMonth возвращает события пользователя за месяц.
+func (s *EventServer) GetEventsForMonth(ctx context.Context, req *eventpb.GetEventsRequest) (*eventpb.EventsResponse, error) {
+	return eventsResponse(s.Service.GetForMonth(ctx, req.GetUserId(), toTime(req.GetDate())))
+}
+
+// GetEventsForRange возвращает события пользователя с датами от start до end включительно.
+func (s *EventServer) GetEventsForRange(ctx context.Context, req *eventpb.GetEventsForRangeRequest) (*eventpb.EventsResponse, error) {
+	return eventsResponse(s.Service.GetForRange(ctx, req.GetUserId(), toTime(req.GetStart()), toTime(req.GetEnd())))
+}
+
+// GetUpcomingEvents возвращает не более limit ближайших событий пользователя.
+func (s *EventServer) GetUpcomingEvents(ctx context.Context, req *eventpb.GetUpcomingEventsRequest) (*eventpb.EventsResponse, error) {
+	return eventsResponse(s.Service.GetUpcoming(ctx, req.GetUserId(), int(req.GetLimit())))
+}
+
+// GetEventsForToday возвращает события пользователя за текущий день.
+func (s *EventServer) GetEventsForToday(ctx context.Context, req *eventpb.GetEventsForTodayRequest) (*eventpb.EventsResponse, error) {
+	return eventsResponse(s.Service.GetForToday(ctx, req.GetUserId()))
+}
+
+// SearchEvents возвращает события пользователя, найденные по полнотекстовому запросу.
+func (s *EventServer) SearchEvents(ctx context.Context, req *eventpb.SearchEventsRequest) (*eventpb.EventsResponse, error) {
+	return eventsResponse(s.Service.Search(ctx, req.GetUserId(), req.GetQuery()))
+}
+
+// Типы изменений событий protobuf.
+var changeTypes = map[service.ChangeType]eventpb.EventChange_Type{
+	service.ChangeCreated: eventpb.EventChange_TYPE_CREATED,
+	service.ChangeUpdated: eventpb.EventChange_TYPE_UPDATED,
+	service.ChangeDeleted: eventpb.EventChange_TYPE_DELETED,
+}
+
+// WatchEvents отправляет клиенту изменения событий пользователя, пока клиент не отменит вызов
+// или сервер не начнет остановку.
+// Заголовки ответа отправляются после подписки, поэтому изменения, сделанные после их получения
+// клиентом, попадут в поток.
+func (s *EventServer) WatchEvents(req *eventpb.WatchEventsRequest, stream grpc.ServerStreamingServer[eventpb.EventChange]) error {
+	ctx := stream.Context()
+	changes := s.Service.Watch(ctx, req.GetUserId())
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case change, ok := <-changes:
+			if !ok {
+				if err := ctx.Err(); err != nil {
+					return status.FromContextError(err).Err()
+				}
+				return ErrWatchLagged
+			}
+			err := stream.Send(&eventpb.EventChange{Type: changeTypes[change.Type], Event: toProto(change.Event)})
+			if err != nil {
+				return err
+			}
+		case <-s.Shutdown:
+			return ErrShuttingDown
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package grpc
+
+import (
+	"context"
+	"dev11/app/entity"
+	"dev11/app/repo"
+	"dev11/app/service"
+	"dev11/app/transport/grpc/eventpb"
+	"dev11/app/workcal"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// newTestClient запускает gRPC-сервер с сервисом service и опциями opts на bufconn и возвращает клиента к нему.
+func newTestClient(t *testing.T, service service.EventWatcher, opts ...Option) eventpb.EventServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	server := NewServer("", "", service, slog.New(slog.NewJSONHandler(io.Discard, nil)), opts...)
+	server.serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		server.Stop(context.Background())
+	})
+	return eventpb.NewEventServiceClient(conn)
+}
+
+func TestNewServer(t *testing.T) {
+	t.Run("NilService", func(t *testing.T) {
+		if got := NewServer("", "", nil, slog.Default()); got != nil {
+			t.Errorf("NewServer() = %v, want nil", got)
+		}
+	})
+}
+
+func TestEventServer(t *testing.T) {
+	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 20, 16, 0, 0, 0, time.UTC)
+	validEvent := entity.Event{ID: validUUID, Title: "event", Date: date, UserID: validUUID}
+	validProto := &eventpb.Event{Id: validUUID, Title: "event", Date: timestamppb.New(date), UserId: validUUID}
+	events := &eventpb.EventsResponse{Events: []*eventpb.Event{validProto}}
+
+	tests := []struct {
+		name     string
+		prepare  func(s *service.MockEventWatcher)
+		call     func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error)
+		want     proto.Message
+		wantCode codes.Code
+	}{
+		{"GetEvent", func(s *service.MockEventWatcher) {
+			s.EXPECT().GetByID(gomock.Any(), validUUID, validUUID).Return(validEvent, nil)
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.GetEvent(ctx, &eventpb.GetEventRequest{UserId: validUUID, Id: validUUID})
+		}, validProto, codes.OK},
+		{"GetEventUpdatedAt", func(s *service.MockEventWatcher) {
+			event := validEvent
+			event.UpdatedAt = date.Add(-time.Hour)
+			s.EXPECT().GetByID(gomock.Any(), validUUID, validUUID).Return(event, nil)
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.GetEvent(ctx, &eventpb.GetEventRequest{UserId: validUUID, Id: validUUID})
+		}, &eventpb.Event{Id: validUUID, Title: "event", Date: timestamppb.New(date), UserId: validUUID, UpdatedAt: timestamppb.New(date.Add(-time.Hour))}, codes.OK},
+		{"GetEventNotFound", func(s *service.MockEventWatcher) {
+			s.EXPECT().GetByID(gomock.Any(), validUUID, validUUID).Return(entity.EmptyEvent, &service.ExternalError{Err: repo.ErrNotExist})
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.GetEvent(ctx, &eventpb.GetEventRequest{UserId: validUUID, Id: validUUID})
+		}, nil, codes.NotFound},
+		{"CreateEvent", func(s *service.MockEventWatcher) {
+			s.EXPECT().Create(gomock.Any(), entity.Event{Title: "event", Date: date, UserID: validUUID}).Return(validEvent, nil)
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.CreateEvent(ctx, &eventpb.CreateEventRequest{Title: "event", Date: timestamppb.New(date), UserId: validUUID})
+		}, validProto, codes.OK},
+		{"CreateEventMarkdown", func(s *service.MockEventWatcher) {
+			event := entity.Event{Title: "event", Description: "**a**", DescriptionFormat: entity.FormatMarkdown, Date: date, UserID: validUUID}
+			s.EXPECT().Create(gomock.Any(), event).Return(event, nil)
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.CreateEvent(ctx, &eventpb.CreateEventRequest{Title: "event", Description: "**a**", DescriptionFormat: "markdown", Date: timestamppb.New(date), UserId: validUUID})
+		}, &eventpb.Event{Title: "event", Description: "**a**", DescriptionFormat: "markdown", DescriptionHtml: "<p><strong>a</strong></p>\n",
+			Date: timestamppb.New(date), UserId: validUUID}, codes.OK},
+		{"CreateEventInvalid", func(s *service.MockEventWatcher) {
+			s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.EmptyEvent, &service.ExternalError{Err: entity.ErrTitleEmpty})
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.CreateEvent(ctx, &eventpb.CreateEventRequest{UserId: validUUID})
+		}, nil, codes.InvalidArgument},
+		{"CreateEventInternal", func(s *service.MockEventWatcher) {
+			s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.EmptyEvent, &service.InternalError{Err: fmt.Errorf("")})
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.CreateEvent(ctx, &eventpb.CreateEventRequest{UserId: validUUID})
+		}, nil, codes.Internal},
+		{"CreateEventQuota", func(s *service.MockEventWatcher) {
+			s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.EmptyEvent, &service.ExternalError{Err: service.ErrQuotaExceeded})
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.CreateEvent(ctx, &eventpb.CreateEventRequest{UserId: validUUID})
+		}, nil, codes.ResourceExhausted},
+		{"InvalidTenant", func(s *service.MockEventWatcher) {}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			ctx = metadata.AppendToOutgoingContext(ctx, TenantMetadataKey, "../sales")
+			return c.GetEvent(ctx, &eventpb.GetEventRequest{UserId: validUUID, Id: validUUID})
+		}, nil, codes.InvalidArgument},
+		{"UpdateEvent", func(s *service.MockEventWatcher) {
+			s.EXPECT().Update(gomock.Any(), validEvent).Return(validEvent, nil)
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.UpdateEvent(ctx, &eventpb.UpdateEventRequest{Event: validProto})
+		}, validProto, codes.OK},
+		{"DeleteEventNotFound", func(s *service.MockEventWatcher) {
+			s.EXPECT().Delete(gomock.Any(), validUUID, validUUID).Return(&service.ExternalError{Err: repo.ErrNotExist})
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.DeleteEvent(ctx, &eventpb.DeleteEventRequest{UserId: validUUID, Id: validUUID})
+		}, nil, codes.NotFound},
+		{"GetEventsForDay", func(s *service.MockEventWatcher) {
+			s.EXPECT().GetForDay(gomock.Any(), validUUID, date).Return([]entity.Event{validEvent}, nil)
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.GetEventsForDay(ctx, &eventpb.GetEventsRequest{UserId: validUUID, Date: timestamppb.New(date)})
+		}, events, codes.OK},
+		{"GetEventsForWeek", func(s *service.MockEventWatcher) {
+			s.EXPECT().GetForWeek(gomock.Any(), validUUID, date).Return([]entity.Event{validEvent}, nil)
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.GetEventsForWeek(ctx, &eventpb.GetEventsRequest{UserId: validUUID, Date: timestamppb.New(date)})
+		}, events, codes.OK},
+		{"GetEventsForMonth", func(s *service.MockEventWatcher) {
+			s.EXPECT().GetForMonth(gomock.Any(), validUUID, date).Return([]entity.Event{validEvent}, nil)
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.GetEventsForMonth(ctx, &eventpb.GetEventsRequest{UserId: validUUID, Date: timestamppb.New(date)})
+		}, events, codes.OK},
+		{"GetEventsForRange", func(s *service.MockEventWatcher) {
+			s.EXPECT().GetForRange(gomock.Any(), validUUID, date, date.AddDate(0, 0, 3)).Return([]entity.Event{validEvent}, nil)
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.GetEventsForRange(ctx, &eventpb.GetEventsForRangeRequest{UserId: validUUID, Start: timestamppb.New(date), End: timestamppb.New(date.AddDate(0, 0, 3))})
+		}, events, codes.OK},
+		{"GetEventsForRangeInvalid", func(s *service.MockEventWatcher) {
+			s.EXPECT().GetForRange(gomock.Any(), validUUID, date, date.AddDate(0, 0, -1)).Return(nil, service.ErrInvalidRange)
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.GetEventsForRange(ctx, &eventpb.GetEventsForRangeRequest{UserId: validUUID, Start: timestamppb.New(date), End: timestamppb.New(date.AddDate(0, 0, -1))})
+		}, nil, codes.InvalidArgument},
+		{"GetUpcomingEvents", func(s *service.MockEventWatcher) {
+			s.EXPECT().GetUpcoming(gomock.Any(), validUUID, 5).Return([]entity.Event{validEvent}, nil)
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.GetUpcomingEvents(ctx, &eventpb.GetUpcomingEventsRequest{UserId: validUUID, Limit: 5})
+		}, events, codes.OK},
+		{"GetEventsForToday", func(s *service.MockEventWatcher) {
+			s.EXPECT().GetForToday(gomock.Any(), validUUID).Return([]entity.Event{validEvent}, nil)
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.GetEventsForToday(ctx, &eventpb.GetEventsForTodayRequest{UserId: validUUID})
+		}, events, codes.OK},
+		{"SearchEventsEmpty", func(s *service.MockEventWatcher) {
+			s.EXPECT().Search(gomock.Any(), validUUID, "").Return(nil, service.ErrEmptyQuery)
+		}, func(ctx context.Context, c eventpb.EventServiceClient) (proto.Message, error) {
+			return c.SearchEvents(ctx, &eventpb.SearchEventsRequest{UserId: validUUID})
+		}, nil, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := service.NewMockEventWatcher(ctrl)
+			tt.prepare(service)
+			client := newTestClient(t, service)
+
+			got, err := tt.call(context.Background(), client)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code = %v, want %v (%v)", code, tt.wantCode, err)
+			}
+			if tt.want != nil && !proto.Equal(got, tt.want) {
+				t.Errorf("response = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventServer_WatchEvents(t *testing.T) {
+	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 20, 16, 0, 0, 0, time.UTC)
+
+	watcher := service.NewEventWatcher(service.NewEventV1(repo.NewEventMemory()))
+	client := newTestClient(t, watcher)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.WatchEvents(ctx, &eventpb.WatchEventsRequest{UserId: validUUID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Заголовки отправляются после подписки на изменения.
+	if _, err := stream.Header(); err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := client.CreateEvent(ctx, &eventpb.CreateEventRequest{Title: "event", Date: timestamppb.New(date), UserId: validUUID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DeleteEvent(ctx, &eventpb.DeleteEventRequest{UserId: validUUID, Id: created.GetId()}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*eventpb.EventChange{
+		{Type: eventpb.EventChange_TYPE_CREATED, Event: created},
+		{Type: eventpb.EventChange_TYPE_DELETED, Event: &eventpb.Event{Id: created.GetId(), UserId: validUUID}},
+	}
+	for _, w := range want {
+		got, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !proto.Equal(got, w) {
+			t.Errorf("WatchEvents() = %v, want %v", got, w)
+		}
+	}
+
+	cancel()
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Errorf("WatchEvents() after cancel error = %v, want %v", err, codes.Canceled)
+	}
+}
+
+func TestEventServer_Tenants(t *testing.T) {
+	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 20, 16, 0, 0, 0, time.UTC)
+
+	eventRepo := repo.NewEventTenants(func(string) (repo.Event, error) { return repo.NewEventMemory(), nil }, repo.TenantLimits{})
+	client := newTestClient(t, service.NewEventWatcher(service.NewEventV1(eventRepo)))
+	sales := metadata.AppendToOutgoingContext(context.Background(), TenantMetadataKey, "sales")
+
+	created, err := client.CreateEvent(sales, &eventpb.CreateEventRequest{Title: "event", Date: timestamppb.New(date), UserId: validUUID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetEvent(sales, &eventpb.GetEventRequest{UserId: validUUID, Id: created.GetId()}); err != nil {
+		t.Errorf("GetEvent() error = %v", err)
+	}
+	_, err = client.GetEvent(context.Background(), &eventpb.GetEventRequest{UserId: validUUID, Id: created.GetId()})
+	if code := status.Code(err); code != codes.NotFound {
+		t.Errorf("GetEvent() default tenant code = %v, want %v", code, codes.NotFound)
+	}
+}
+
+func TestEventServer_HolidayWarnings(t *testing.T) {
+	validUUID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 20, 16, 0, 0, 0, time.UTC)
+
+	holidays := workcal.NewCalendar()
+	holidays.AddHoliday(time.Date(2010, 5, 20, 0, 0, 0, 0, time.UTC), "Ascension Day")
+	client := newTestClient(t, service.NewEventWatcher(service.NewEventV1(repo.NewEventMemory())),
+		WithSchedules(&workcal.Schedules{Default: workcal.Schedule{Calendar: holidays}}))
+
+	created, err := client.CreateEvent(context.Background(), &eventpb.CreateEventRequest{Title: "event", Date: timestamppb.New(date), UserId: validUUID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"2010-05-20 is a holiday: Ascension Day"}
+	if got := created.GetWarnings(); !reflect.DeepEqual(got, want) {
+		t.Errorf("CreateEvent() warnings = %v, want %v", got, want)
+	}
+}
+
+func TestServer_StopWatchEvents(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	watcher := service.NewEventWatcher(service.NewEventV1(repo.NewEventMemory()))
+	server := NewServer("", "", watcher, slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	server.serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	stream, err := eventpb.NewEventServiceClient(conn).WatchEvents(context.Background(), &eventpb.WatchEventsRequest{UserId: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Header(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Stop(ctx); err != nil {
+		t.Errorf("Server.Stop() error = %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("WatchEvents() after stop error = %v, want %v", err, codes.Unavailable)
+	}
+	if err := <-server.Err(); err != nil {
+		t.Errorf("Server.Err() = %v, want nil", err)
+	}
+}
+
+func Test_statusError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{"NotFound", &service.ExternalError{Err: repo.ErrNotExist}, codes.NotFound},
+		{"QuotaExceeded", &service.ExternalError{Err: service.ErrQuotaExceeded}, codes.ResourceExhausted},
+		{"Exists", &service.ExternalError{Err: repo.ErrExists}, codes.AlreadyExists},
+		{"TenantNotAllowed", &service.ExternalError{Err: fmt.Errorf("%w: %q", repo.ErrTenantNotAllowed, "sales")}, codes.PermissionDenied},
+		{"TooManyTenants", &service.ExternalError{Err: fmt.Errorf("%w: limit is %d", repo.ErrTooManyTenants, 1)}, codes.ResourceExhausted},
+		{"Canceled", &service.ExternalError{Err: context.Canceled}, codes.Canceled},
+		{"DeadlineExceeded", &service.ExternalError{Err: context.DeadlineExceeded}, codes.DeadlineExceeded},
+		{"InvalidArgument", service.ErrInvalidRange, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(statusError(tt.err)); got != tt.want {
+				t.Errorf("statusError() code = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
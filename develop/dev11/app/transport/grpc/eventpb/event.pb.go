@@ -0,0 +1,984 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: event.proto
+
+package eventpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EventChange_Type int32
+
+const (
+	EventChange_TYPE_UNSPECIFIED EventChange_Type = 0
+	EventChange_TYPE_CREATED     EventChange_Type = 1
+	EventChange_TYPE_UPDATED     EventChange_Type = 2
+	EventChange_TYPE_DELETED     EventChange_Type = 3
+)
+
+// Enum value maps for EventChange_Type.
+var (
+	EventChange_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_CREATED",
+		2: "TYPE_UPDATED",
+		3: "TYPE_DELETED",
+	}
+	EventChange_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"TYPE_CREATED":     1,
+		"TYPE_UPDATED":     2,
+		"TYPE_DELETED":     3,
+	}
+)
+
+func (x EventChange_Type) Enum() *EventChange_Type {
+	p := new(EventChange_Type)
+	*p = x
+	return p
+}
+
+func (x EventChange_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventChange_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_event_proto_enumTypes[0].Descriptor()
+}
+
+func (EventChange_Type) Type() protoreflect.EnumType {
+	return &file_event_proto_enumTypes[0]
+}
+
+func (x EventChange_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventChange_Type.Descriptor instead.
+func (EventChange_Type) EnumDescriptor() ([]byte, []int) {
+	return file_event_proto_rawDescGZIP(), []int{12, 0}
+}
+
+type Event struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title       string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Description string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Date        *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=date,proto3" json:"date,omitempty"`
+	UserId      string                 `protobuf:"bytes,5,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// "text" (default) or "markdown".
+	DescriptionFormat string `protobuf:"bytes,6,opt,name=description_format,json=descriptionFormat,proto3" json:"description_format,omitempty"`
+	// Sanitized HTML rendering of a Markdown description, ignored on input.
+	DescriptionHtml string `protobuf:"bytes,7,opt,name=description_html,json=descriptionHtml,proto3" json:"description_html,omitempty"`
+	// Time of the last creation or update, ignored on input.
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Non-fatal warnings about a created event, e.g. an event on a holiday, ignored on input.
+	Warnings      []string `protobuf:"bytes,9,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_event_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_event_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_event_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Event) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Event) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Event) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Event) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *Event) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Event) GetDescriptionFormat() string {
+	if x != nil {
+		return x.DescriptionFormat
+	}
+	return ""
+}
+
+func (x *Event) GetDescriptionHtml() string {
+	if x != nil {
+		return x.DescriptionHtml
+	}
+	return ""
+}
+
+func (x *Event) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Event) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+type GetEventRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEventRequest) Reset() {
+	*x = GetEventRequest{}
+	mi := &file_event_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEventRequest) ProtoMessage() {}
+
+func (x *GetEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_event_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEventRequest.ProtoReflect.Descriptor instead.
+func (*GetEventRequest) Descriptor() ([]byte, []int) {
+	return file_event_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetEventRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetEventRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CreateEventRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Title             string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Description       string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Date              *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=date,proto3" json:"date,omitempty"`
+	UserId            string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	DescriptionFormat string                 `protobuf:"bytes,5,opt,name=description_format,json=descriptionFormat,proto3" json:"description_format,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *CreateEventRequest) Reset() {
+	*x = CreateEventRequest{}
+	mi := &file_event_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateEventRequest) ProtoMessage() {}
+
+func (x *CreateEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_event_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateEventRequest.ProtoReflect.Descriptor instead.
+func (*CreateEventRequest) Descriptor() ([]byte, []int) {
+	return file_event_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateEventRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *CreateEventRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreateEventRequest) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *CreateEventRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateEventRequest) GetDescriptionFormat() string {
+	if x != nil {
+		return x.DescriptionFormat
+	}
+	return ""
+}
+
+type UpdateEventRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Event         *Event                 `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateEventRequest) Reset() {
+	*x = UpdateEventRequest{}
+	mi := &file_event_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateEventRequest) ProtoMessage() {}
+
+func (x *UpdateEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_event_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateEventRequest.ProtoReflect.Descriptor instead.
+func (*UpdateEventRequest) Descriptor() ([]byte, []int) {
+	return file_event_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UpdateEventRequest) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+type DeleteEventRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteEventRequest) Reset() {
+	*x = DeleteEventRequest{}
+	mi := &file_event_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteEventRequest) ProtoMessage() {}
+
+func (x *DeleteEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_event_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteEventRequest.ProtoReflect.Descriptor instead.
+func (*DeleteEventRequest) Descriptor() ([]byte, []int) {
+	return file_event_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DeleteEventRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *DeleteEventRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetEventsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Any moment of the day, week or month.
+	Date          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=date,proto3" json:"date,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEventsRequest) Reset() {
+	*x = GetEventsRequest{}
+	mi := &file_event_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEventsRequest) ProtoMessage() {}
+
+func (x *GetEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_event_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEventsRequest.ProtoReflect.Descriptor instead.
+func (*GetEventsRequest) Descriptor() ([]byte, []int) {
+	return file_event_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetEventsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetEventsRequest) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+type GetEventsForRangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Start         *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=start,proto3" json:"start,omitempty"`
+	End           *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEventsForRangeRequest) Reset() {
+	*x = GetEventsForRangeRequest{}
+	mi := &file_event_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEventsForRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEventsForRangeRequest) ProtoMessage() {}
+
+func (x *GetEventsForRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_event_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEventsForRangeRequest.ProtoReflect.Descriptor instead.
+func (*GetEventsForRangeRequest) Descriptor() ([]byte, []int) {
+	return file_event_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetEventsForRangeRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetEventsForRangeRequest) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *GetEventsForRangeRequest) GetEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
+type GetUpcomingEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUpcomingEventsRequest) Reset() {
+	*x = GetUpcomingEventsRequest{}
+	mi := &file_event_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUpcomingEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUpcomingEventsRequest) ProtoMessage() {}
+
+func (x *GetUpcomingEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_event_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUpcomingEventsRequest.ProtoReflect.Descriptor instead.
+func (*GetUpcomingEventsRequest) Descriptor() ([]byte, []int) {
+	return file_event_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetUpcomingEventsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetUpcomingEventsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type GetEventsForTodayRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEventsForTodayRequest) Reset() {
+	*x = GetEventsForTodayRequest{}
+	mi := &file_event_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEventsForTodayRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEventsForTodayRequest) ProtoMessage() {}
+
+func (x *GetEventsForTodayRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_event_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEventsForTodayRequest.ProtoReflect.Descriptor instead.
+func (*GetEventsForTodayRequest) Descriptor() ([]byte, []int) {
+	return file_event_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetEventsForTodayRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type SearchEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Query         string                 `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchEventsRequest) Reset() {
+	*x = SearchEventsRequest{}
+	mi := &file_event_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchEventsRequest) ProtoMessage() {}
+
+func (x *SearchEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_event_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchEventsRequest.ProtoReflect.Descriptor instead.
+func (*SearchEventsRequest) Descriptor() ([]byte, []int) {
+	return file_event_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SearchEventsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SearchEventsRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+type EventsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*Event               `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventsResponse) Reset() {
+	*x = EventsResponse{}
+	mi := &file_event_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventsResponse) ProtoMessage() {}
+
+func (x *EventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_event_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventsResponse.ProtoReflect.Descriptor instead.
+func (*EventsResponse) Descriptor() ([]byte, []int) {
+	return file_event_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *EventsResponse) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type WatchEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEventsRequest) Reset() {
+	*x = WatchEventsRequest{}
+	mi := &file_event_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEventsRequest) ProtoMessage() {}
+
+func (x *WatchEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_event_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchEventsRequest) Descriptor() ([]byte, []int) {
+	return file_event_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *WatchEventsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type EventChange struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  EventChange_Type       `protobuf:"varint,1,opt,name=type,proto3,enum=dev11.event.v1.EventChange_Type" json:"type,omitempty"`
+	// For deleted events only id and user_id are set.
+	Event         *Event `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventChange) Reset() {
+	*x = EventChange{}
+	mi := &file_event_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventChange) ProtoMessage() {}
+
+func (x *EventChange) ProtoReflect() protoreflect.Message {
+	mi := &file_event_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventChange.ProtoReflect.Descriptor instead.
+func (*EventChange) Descriptor() ([]byte, []int) {
+	return file_event_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *EventChange) GetType() EventChange_Type {
+	if x != nil {
+		return x.Type
+	}
+	return EventChange_TYPE_UNSPECIFIED
+}
+
+func (x *EventChange) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+var File_event_proto protoreflect.FileDescriptor
+
+const file_event_proto_rawDesc = "" +
+	"\n" +
+	"\vevent.proto\x12\x0edev11.event.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc9\x02\n" +
+	"\x05Event\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12.\n" +
+	"\x04date\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12\x17\n" +
+	"\auser_id\x18\x05 \x01(\tR\x06userId\x12-\n" +
+	"\x12description_format\x18\x06 \x01(\tR\x11descriptionFormat\x12)\n" +
+	"\x10description_html\x18\a \x01(\tR\x0fdescriptionHtml\x129\n" +
+	"\n" +
+	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1a\n" +
+	"\bwarnings\x18\t \x03(\tR\bwarnings\":\n" +
+	"\x0fGetEventRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\"\xc4\x01\n" +
+	"\x12CreateEventRequest\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12.\n" +
+	"\x04date\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\tR\x06userId\x12-\n" +
+	"\x12description_format\x18\x05 \x01(\tR\x11descriptionFormat\"A\n" +
+	"\x12UpdateEventRequest\x12+\n" +
+	"\x05event\x18\x01 \x01(\v2\x15.dev11.event.v1.EventR\x05event\"=\n" +
+	"\x12DeleteEventRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\"[\n" +
+	"\x10GetEventsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12.\n" +
+	"\x04date\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04date\"\x93\x01\n" +
+	"\x18GetEventsForRangeRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x120\n" +
+	"\x05start\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12,\n" +
+	"\x03end\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x03end\"I\n" +
+	"\x18GetUpcomingEventsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"3\n" +
+	"\x18GetEventsForTodayRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"D\n" +
+	"\x13SearchEventsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05query\x18\x02 \x01(\tR\x05query\"?\n" +
+	"\x0eEventsResponse\x12-\n" +
+	"\x06events\x18\x01 \x03(\v2\x15.dev11.event.v1.EventR\x06events\"-\n" +
+	"\x12WatchEventsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"\xc4\x01\n" +
+	"\vEventChange\x124\n" +
+	"\x04type\x18\x01 \x01(\x0e2 .dev11.event.v1.EventChange.TypeR\x04type\x12+\n" +
+	"\x05event\x18\x02 \x01(\v2\x15.dev11.event.v1.EventR\x05event\"R\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fTYPE_CREATED\x10\x01\x12\x10\n" +
+	"\fTYPE_UPDATED\x10\x02\x12\x10\n" +
+	"\fTYPE_DELETED\x10\x032\xf7\a\n" +
+	"\fEventService\x12B\n" +
+	"\bGetEvent\x12\x1f.dev11.event.v1.GetEventRequest\x1a\x15.dev11.event.v1.Event\x12H\n" +
+	"\vCreateEvent\x12\".dev11.event.v1.CreateEventRequest\x1a\x15.dev11.event.v1.Event\x12H\n" +
+	"\vUpdateEvent\x12\".dev11.event.v1.UpdateEventRequest\x1a\x15.dev11.event.v1.Event\x12I\n" +
+	"\vDeleteEvent\x12\".dev11.event.v1.DeleteEventRequest\x1a\x16.google.protobuf.Empty\x12S\n" +
+	"\x0fGetEventsForDay\x12 .dev11.event.v1.GetEventsRequest\x1a\x1e.dev11.event.v1.EventsResponse\x12T\n" +
+	"\x10GetEventsForWeek\x12 .dev11.event.v1.GetEventsRequest\x1a\x1e.dev11.event.v1.EventsResponse\x12U\n" +
+	"\x11GetEventsForMonth\x12 .dev11.event.v1.GetEventsRequest\x1a\x1e.dev11.event.v1.EventsResponse\x12]\n" +
+	"\x11GetEventsForRange\x12(.dev11.event.v1.GetEventsForRangeRequest\x1a\x1e.dev11.event.v1.EventsResponse\x12]\n" +
+	"\x11GetUpcomingEvents\x12(.dev11.event.v1.GetUpcomingEventsRequest\x1a\x1e.dev11.event.v1.EventsResponse\x12]\n" +
+	"\x11GetEventsForToday\x12(.dev11.event.v1.GetEventsForTodayRequest\x1a\x1e.dev11.event.v1.EventsResponse\x12S\n" +
+	"\fSearchEvents\x12#.dev11.event.v1.SearchEventsRequest\x1a\x1e.dev11.event.v1.EventsResponse\x12P\n" +
+	"\vWatchEvents\x12\".dev11.event.v1.WatchEventsRequest\x1a\x1b.dev11.event.v1.EventChange0\x01B\"Z dev11/app/transport/grpc/eventpbb\x06proto3"
+
+var (
+	file_event_proto_rawDescOnce sync.Once
+	file_event_proto_rawDescData []byte
+)
+
+func file_event_proto_rawDescGZIP() []byte {
+	file_event_proto_rawDescOnce.Do(func() {
+		file_event_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_event_proto_rawDesc), len(file_event_proto_rawDesc)))
+	})
+	return file_event_proto_rawDescData
+}
+
+var file_event_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_event_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_event_proto_goTypes = []any{
+	(EventChange_Type)(0),            // 0: dev11.event.v1.EventChange.Type
+	(*Event)(nil),                    // 1: dev11.event.v1.Event
+	(*GetEventRequest)(nil),          // 2: dev11.event.v1.GetEventRequest
+	(*CreateEventRequest)(nil),       // 3: dev11.event.v1.CreateEventRequest
+	(*UpdateEventRequest)(nil),       // 4: dev11.event.v1.UpdateEventRequest
+	(*DeleteEventRequest)(nil),       // 5: dev11.event.v1.DeleteEventRequest
+	(*GetEventsRequest)(nil),         // 6: dev11.event.v1.GetEventsRequest
+	(*GetEventsForRangeRequest)(nil), // 7: dev11.event.v1.GetEventsForRangeRequest
+	(*GetUpcomingEventsRequest)(nil), // 8: dev11.event.v1.GetUpcomingEventsRequest
+	(*GetEventsForTodayRequest)(nil), // 9: dev11.event.v1.GetEventsForTodayRequest
+	(*SearchEventsRequest)(nil),      // 10: dev11.event.v1.SearchEventsRequest
+	(*EventsResponse)(nil),           // 11: dev11.event.v1.EventsResponse
+	(*WatchEventsRequest)(nil),       // 12: dev11.event.v1.WatchEventsRequest
+	(*EventChange)(nil),              // 13: dev11.event.v1.EventChange
+	(*timestamppb.Timestamp)(nil),    // 14: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),            // 15: google.protobuf.Empty
+}
+var file_event_proto_depIdxs = []int32{
+	14, // 0: dev11.event.v1.Event.date:type_name -> google.protobuf.Timestamp
+	14, // 1: dev11.event.v1.Event.updated_at:type_name -> google.protobuf.Timestamp
+	14, // 2: dev11.event.v1.CreateEventRequest.date:type_name -> google.protobuf.Timestamp
+	1,  // 3: dev11.event.v1.UpdateEventRequest.event:type_name -> dev11.event.v1.Event
+	14, // 4: dev11.event.v1.GetEventsRequest.date:type_name -> google.protobuf.Timestamp
+	14, // 5: dev11.event.v1.GetEventsForRangeRequest.start:type_name -> google.protobuf.Timestamp
+	14, // 6: dev11.event.v1.GetEventsForRangeRequest.end:type_name -> google.protobuf.Timestamp
+	1,  // 7: dev11.event.v1.EventsResponse.events:type_name -> dev11.event.v1.Event
+	0,  // 8: dev11.event.v1.EventChange.type:type_name -> dev11.event.v1.EventChange.Type
+	1,  // 9: dev11.event.v1.EventChange.event:type_name -> dev11.event.v1.Event
+	2,  // 10: dev11.event.v1.EventService.GetEvent:input_type -> dev11.event.v1.GetEventRequest
+	3,  // 11: dev11.event.v1.EventService.CreateEvent:input_type -> dev11.event.v1.CreateEventRequest
+	4,  // 12: dev11.event.v1.EventService.UpdateEvent:input_type -> dev11.event.v1.UpdateEventRequest
+	5,  // 13: dev11.event.v1.EventService.DeleteEvent:input_type -> dev11.event.v1.DeleteEventRequest
+	6,  // 14: dev11.event.v1.EventService.GetEventsForDay:input_type -> dev11.event.v1.GetEventsRequest
+	6,  // 15: dev11.event.v1.EventService.GetEventsForWeek:input_type -> dev11.event.v1.GetEventsRequest
+	6,  // 16: dev11.event.v1.EventService.GetEventsForMonth:input_type -> dev11.event.v1.GetEventsRequest
+	7,  // 17: dev11.event.v1.EventService.GetEventsForRange:input_type -> dev11.event.v1.GetEventsForRangeRequest
+	8,  // 18: dev11.event.v1.EventService.GetUpcomingEvents:input_type -> dev11.event.v1.GetUpcomingEventsRequest
+	9,  // 19: dev11.event.v1.EventService.GetEventsForToday:input_type -> dev11.event.v1.GetEventsForTodayRequest
+	10, // 20: dev11.event.v1.EventService.SearchEvents:input_type -> dev11.event.v1.SearchEventsRequest
+	12, // 21: dev11.event.v1.EventService.WatchEvents:input_type -> dev11.event.v1.WatchEventsRequest
+	1,  // 22: dev11.event.v1.EventService.GetEvent:output_type -> dev11.event.v1.Event
+	1,  // 23: dev11.event.v1.EventService.CreateEvent:output_type -> dev11.event.v1.Event
+	1,  // 24: dev11.event.v1.EventService.UpdateEvent:output_type -> dev11.event.v1.Event
+	15, // 25: dev11.event.v1.EventService.DeleteEvent:output_type -> google.protobuf.Empty
+	11, // 26: dev11.event.v1.EventService.GetEventsForDay:output_type -> dev11.event.v1.EventsResponse
+	11, // 27: dev11.event.v1.EventService.GetEventsForWeek:output_type -> dev11.event.v1.EventsResponse
+	11, // 28: dev11.event.v1.EventService.GetEventsForMonth:output_type -> dev11.event.v1.EventsResponse
+	11, // 29: dev11.event.v1.EventService.GetEventsForRange:output_type -> dev11.event.v1.EventsResponse
+	11, // 30: dev11.event.v1.EventService.GetUpcomingEvents:output_type -> dev11.event.v1.EventsResponse
+	11, // 31: dev11.event.v1.EventService.GetEventsForToday:output_type -> dev11.event.v1.EventsResponse
+	11, // 32: dev11.event.v1.EventService.SearchEvents:output_type -> dev11.event.v1.EventsResponse
+	13, // 33: dev11.event.v1.EventService.WatchEvents:output_type -> dev11.event.v1.EventChange
+	22, // [22:34] is the sub-list for method output_type
+	10, // [10:22] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_event_proto_init() }
+func file_event_proto_init() {
+	if File_event_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_event_proto_rawDesc), len(file_event_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_event_proto_goTypes,
+		DependencyIndexes: file_event_proto_depIdxs,
+		EnumInfos:         file_event_proto_enumTypes,
+		MessageInfos:      file_event_proto_msgTypes,
+	}.Build()
+	File_event_proto = out.File
+	file_event_proto_goTypes = nil
+	file_event_proto_depIdxs = nil
+}
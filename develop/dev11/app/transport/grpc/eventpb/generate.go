@@ -0,0 +1,4 @@
+// Пакет eventpb содержит сгенерированный код protobuf и gRPC сервиса событий.
+package eventpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative event.proto
@@ -0,0 +1,120 @@
+package grpc
+
+import (
+	"context"
+	"dev11/app/tenant"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Ключ метаданных вызова с именем арендатора.
+const TenantMetadataKey = "x-tenant-id"
+
+// peerAddr возвращает адрес клиента из ctx.
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// LoggerUnaryInterceptor возвращает перехватчик для логирования unary-вызовов.
+func LoggerUnaryInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logger.Info(peerAddr(ctx), "method", info.FullMethod, "code", status.Code(err).String(), "time", time.Since(start))
+		return resp, err
+	}
+}
+
+// LoggerStreamInterceptor возвращает перехватчик для логирования stream-вызовов.
+func LoggerStreamInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logger.Info(peerAddr(ss.Context()), "method", info.FullMethod, "code", status.Code(err).String(), "time", time.Since(start))
+		return err
+	}
+}
+
+// recoverError преобразует значение паники rc в ошибку с кодом codes.Internal и логирует его.
+func recoverError(ctx context.Context, logger *slog.Logger, rc any) error {
+	logger.Error(peerAddr(ctx), "err", fmt.Sprint(rc))
+	return status.Error(codes.Internal, "internal error")
+}
+
+// RecovererUnaryInterceptor возвращает перехватчик для обработки паник unary-вызовов.
+func RecovererUnaryInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if rc := recover(); rc != nil {
+				err = recoverError(ctx, logger, rc)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// RecovererStreamInterceptor возвращает перехватчик для обработки паник stream-вызовов.
+func RecovererStreamInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if rc := recover(); rc != nil {
+				err = recoverError(ss.Context(), logger, rc)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// tenantContext возвращает копию ctx с арендатором из метаданных вызова или ошибку
+// с кодом InvalidArgument, если имя арендатора неверно.
+func tenantContext(ctx context.Context) (context.Context, error) {
+	name := tenant.Default
+	if values := metadata.ValueFromIncomingContext(ctx, TenantMetadataKey); len(values) > 0 {
+		name = values[0]
+	}
+	if err := tenant.Validate(name); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return tenant.WithTenant(ctx, name), nil
+}
+
+// TenantUnaryInterceptor возвращает перехватчик, помещающий арендатора unary-вызова в контекст.
+func TenantUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := tenantContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Обертка над grpc.ServerStream с измененным контекстом.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context возвращает контекст вызова.
+func (s contextStream) Context() context.Context { return s.ctx }
+
+// TenantStreamInterceptor возвращает перехватчик, помещающий арендатора stream-вызова в контекст.
+func TenantStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := tenantContext(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, contextStream{ss, ctx})
+	}
+}
@@ -0,0 +1,105 @@
+// Пакет grpc предоставляет gRPC-транспорт для бизнес-логики событий.
+package grpc
+
+import (
+	"context"
+	"dev11/app/service"
+	"dev11/app/transport/grpc/eventpb"
+	"dev11/app/workcal"
+	"log/slog"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+// Обертка над gRPC-сервером с сервисами, перехватчиками и методами Start, Stop, Err.
+type Server struct {
+	grpcServer *grpc.Server
+	addr       string
+	errCh      chan error
+	// shutdown закрывается в начале остановки и завершает потоки WatchEvents.
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+}
+
+// Параметры gRPC-сервера.
+type options struct {
+	schedules *workcal.Schedules
+}
+
+// Тип функции, изменяющей параметры gRPC-сервера.
+type Option func(o *options)
+
+// WithSchedules задает рабочие графики пользователей для предупреждений о событиях в праздники.
+func WithSchedules(schedules *workcal.Schedules) Option {
+	return func(o *options) { o.schedules = schedules }
+}
+
+// NewServer возвращает новый gRPC-сервер, если service и logger не равны nil.
+func NewServer(host string, port string, service service.EventWatcher, logger *slog.Logger, opts ...Option) *Server {
+	if service == nil || logger == nil {
+		return nil
+	}
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(LoggerUnaryInterceptor(logger), RecovererUnaryInterceptor(logger), TenantUnaryInterceptor()),
+		grpc.ChainStreamInterceptor(LoggerStreamInterceptor(logger), RecovererStreamInterceptor(logger), TenantStreamInterceptor()),
+	)
+	shutdown := make(chan struct{})
+	eventpb.RegisterEventServiceServer(grpcServer, &EventServer{Service: service, Shutdown: shutdown, Schedules: o.schedules})
+
+	return &Server{
+		grpcServer: grpcServer,
+		addr:       net.JoinHostPort(host, port),
+		errCh:      make(chan error, 1),
+		shutdown:   shutdown,
+	}
+}
+
+// Start запускает gRPC-сервер в отдельной горутине.
+func (s *Server) Start(_ context.Context) {
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		s.errCh <- err
+		close(s.errCh)
+		return
+	}
+	s.serve(lis)
+}
+
+// serve запускает gRPC-сервер на lis в отдельной горутине.
+func (s *Server) serve(lis net.Listener) {
+	go func() {
+		s.errCh <- s.grpcServer.Serve(lis)
+		close(s.errCh)
+	}()
+}
+
+// Stop останавливает gRPC-сервер, дожидаясь завершения вызовов, пока не отменен ctx.
+// Бесконечные потоки WatchEvents завершаются ошибкой ErrShuttingDown, чтобы клиенты
+// переподключились к другому экземпляру.
+func (s *Server) Stop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(done)
+	}()
+	s.shutdownOnce.Do(func() { close(s.shutdown) })
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
+
+// Err возвращает канал с ошибками gRPC-сервера.
+func (s *Server) Err() <-chan error { return s.errCh }
@@ -0,0 +1,533 @@
+// Пакет caldav предоставляет HTTP-обработчик подмножества протокола CalDAV (RFC 4791)
+// поверх бизнес-логики событий для синхронизации с клиентами календарей.
+//
+// Ресурсы обработчика относительно префикса:
+//
+//	principals/{user_id}/              принципал пользователя
+//	calendars/{user_id}/               домашняя коллекция календарей
+//	calendars/{user_id}/default/       календарь пользователя
+//	calendars/{user_id}/default/{id}.ics  событие календаря
+//
+// Событие, созданное PUT-запросом по адресу с произвольным именем, доступно и по этому адресу,
+// и по адресу с идентификатором события.
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"dev11/app/entity"
+	"dev11/app/repo"
+	"dev11/app/service"
+	"dev11/app/workcal"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Имя единственного календаря пользователя.
+const calendarName = "default"
+
+// Расширение имени ресурса события.
+const objectExt = ".ics"
+
+// Максимальный размер тела запроса в байтах.
+const maxBodySize = 1 << 20
+
+// Границы диапазона дат для получения всех событий календаря.
+var (
+	minTime = time.Time{}
+	maxTime = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+)
+
+// Виды ресурсов CalDAV.
+type resourceKind int
+
+const (
+	kindRoot resourceKind = iota
+	kindPrincipal
+	kindHome
+	kindCalendar
+	kindObject
+)
+
+// Структура ресурса CalDAV, определенного по пути запроса.
+type resource struct {
+	kind   resourceKind
+	userID string
+	id     string
+}
+
+// Структура HTTP-обработчика CalDAV.
+type Handler struct {
+	Service service.Event
+	// Prefix задает путь, по которому смонтирован обработчик, например "/dav/".
+	Prefix string
+	// Schedules задает рабочие графики пользователей, по производственным календарям которых
+	// ответ на создание события в праздник содержит заголовок Warning.
+	Schedules *workcal.Schedules
+}
+
+// ServeHTTP обрабатывает запрос r и записывает ответ в w.
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	res, ok := h.parsePath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodOptions:
+		h.options(w)
+	case "PROPFIND":
+		h.propfind(w, r, res)
+	case "REPORT":
+		h.report(w, r, res)
+	case http.MethodGet, http.MethodHead:
+		h.get(w, r, res)
+	case http.MethodPut:
+		h.put(w, r, res)
+	case http.MethodDelete:
+		h.delete(w, r, res)
+	default:
+		w.Header().Set("Allow", allowedMethods)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// Методы, поддерживаемые обработчиком.
+const allowedMethods = "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT"
+
+// parsePath определяет ресурс по пути запроса path.
+func (h Handler) parsePath(path string) (resource, bool) {
+	rest, ok := strings.CutPrefix(path, h.Prefix)
+	if !ok {
+		// Путь без завершающего слеша, например "/dav".
+		if path+"/" == h.Prefix {
+			return resource{kind: kindRoot}, true
+		}
+		return resource{}, false
+	}
+
+	parts := strings.Split(strings.TrimSuffix(rest, "/"), "/")
+	switch {
+	case rest == "":
+		return resource{kind: kindRoot}, true
+	case len(parts) == 2 && parts[0] == "principals":
+		return resource{kind: kindPrincipal, userID: parts[1]}, true
+	case len(parts) == 2 && parts[0] == "calendars":
+		return resource{kind: kindHome, userID: parts[1]}, true
+	case len(parts) == 3 && parts[0] == "calendars" && parts[2] == calendarName:
+		return resource{kind: kindCalendar, userID: parts[1]}, true
+	case len(parts) == 4 && parts[0] == "calendars" && parts[2] == calendarName && strings.HasSuffix(parts[3], objectExt):
+		return resource{kind: kindObject, userID: parts[1], id: objectID(parts[1], strings.TrimSuffix(parts[3], objectExt))}, true
+	}
+	return resource{}, false
+}
+
+// objectID возвращает идентификатор события для имени ресурса name. Имя, являющееся UUID,
+// совпадает с идентификатором, а для других имен, выбранных клиентом при создании события,
+// идентификатор вычисляется по имени, поэтому событие остается доступным по адресу PUT-запроса.
+func objectID(userID string, name string) string {
+	if uuid.Validate(name) == nil {
+		return name
+	}
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("calendars/"+userID+"/"+calendarName+"/"+name+objectExt)).String()
+}
+
+// Пути к ресурсам пользователя.
+func (h Handler) principalHref(userID string) string { return h.Prefix + "principals/" + userID + "/" }
+func (h Handler) homeHref(userID string) string      { return h.Prefix + "calendars/" + userID + "/" }
+func (h Handler) calendarHref(userID string) string {
+	return h.homeHref(userID) + calendarName + "/"
+}
+func (h Handler) objectHref(userID string, id string) string {
+	return h.calendarHref(userID) + id + objectExt
+}
+
+// ETag возвращает тег версии события, вычисленный по его содержимому.
+func ETag(event entity.Event) string {
+	data, _ := json.Marshal(event)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// CTag возвращает тег версии календаря, изменяющийся при изменении любого события.
+func CTag(events []entity.Event) string {
+	etags := make([]string, len(events))
+	for i, event := range events {
+		etags[i] = event.ID + ETag(event)
+	}
+	slices.Sort(etags)
+
+	h := sha256.New()
+	for _, etag := range etags {
+		io.WriteString(h, etag)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// handleError записывает ответ по ошибке сервиса err. Отсутствующее событие
+// возвращается с кодом 404, внешние ошибки - с кодом 400, иначе вызывается паника
+// для обработки промежуточным слоем.
+func handleError(w http.ResponseWriter, err error) {
+	if errors.Is(err, repo.ErrNotExist) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var externalErr *service.ExternalError
+	if errors.As(err, &externalErr) {
+		http.Error(w, externalErr.Err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Паника будет обработана RecovererMiddleware.
+	panic(err)
+}
+
+// options записывает возможности обработчика.
+func (h Handler) options(w http.ResponseWriter) {
+	w.Header().Set("DAV", "1, 3, calendar-access")
+	w.Header().Set("Allow", allowedMethods)
+	w.WriteHeader(http.StatusOK)
+}
+
+// propfind возвращает свойства ресурса и, при Depth: 1, его дочерних ресурсов.
+func (h Handler) propfind(w http.ResponseWriter, r *http.Request, res resource) {
+	var req propfindRequest
+	if _, err := decodeXML(io.LimitReader(r.Body, maxBodySize), &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	names := req.Prop.names()
+	depth1 := r.Header.Get("Depth") != "0"
+
+	var responses []response
+	switch res.kind {
+	case kindRoot:
+		responses = append(responses, h.rootResponse(names))
+	case kindPrincipal:
+		responses = append(responses, h.principalResponse(res.userID, names))
+	case kindHome:
+		responses = append(responses, h.homeResponse(res.userID, names))
+		if depth1 {
+			events, err := h.Service.GetForRange(r.Context(), res.userID, minTime, maxTime)
+			if err != nil {
+				handleError(w, err)
+				return
+			}
+			responses = append(responses, h.calendarResponse(res.userID, events, names))
+		}
+	case kindCalendar:
+		events, err := h.Service.GetForRange(r.Context(), res.userID, minTime, maxTime)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+		responses = append(responses, h.calendarResponse(res.userID, events, names))
+		if depth1 {
+			for _, event := range events {
+				responses = append(responses, h.objectResponse(event, names))
+			}
+		}
+	case kindObject:
+		event, err := h.Service.GetByID(r.Context(), res.userID, res.id)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+		responses = append(responses, h.objectResponse(event, names))
+	}
+
+	writeMultistatus(w, responses)
+}
+
+// rootResponse возвращает свойства корневой коллекции.
+func (h Handler) rootResponse(names []xml.Name) response {
+	props := map[xml.Name]propValue{
+		propResourceType: rawValue("<D:collection/>"),
+	}
+	return newResponse(h.Prefix, props, []xml.Name{propResourceType}, names)
+}
+
+// principalResponse возвращает свойства принципала пользователя userID.
+func (h Handler) principalResponse(userID string, names []xml.Name) response {
+	props := map[xml.Name]propValue{
+		propResourceType:         rawValue("<D:principal/>"),
+		propDisplayName:          textValue(userID),
+		propCurrentUserPrincipal: hrefValue(h.principalHref(userID)),
+		propPrincipalURL:         hrefValue(h.principalHref(userID)),
+		propCalendarHomeSet:      hrefValue(h.homeHref(userID)),
+	}
+	order := []xml.Name{propResourceType, propDisplayName, propCurrentUserPrincipal, propPrincipalURL, propCalendarHomeSet}
+	return newResponse(h.principalHref(userID), props, order, names)
+}
+
+// homeResponse возвращает свойства домашней коллекции пользователя userID.
+func (h Handler) homeResponse(userID string, names []xml.Name) response {
+	props := map[xml.Name]propValue{
+		propResourceType:         rawValue("<D:collection/>"),
+		propCurrentUserPrincipal: hrefValue(h.principalHref(userID)),
+	}
+	order := []xml.Name{propResourceType, propCurrentUserPrincipal}
+	return newResponse(h.homeHref(userID), props, order, names)
+}
+
+// calendarResponse возвращает свойства календаря пользователя userID с событиями events.
+func (h Handler) calendarResponse(userID string, events []entity.Event, names []xml.Name) response {
+	ctag := CTag(events)
+	props := map[xml.Name]propValue{
+		propResourceType:          rawValue("<D:collection/><C:calendar/>"),
+		propDisplayName:           textValue("Calendar"),
+		propGetCTag:               textValue(ctag),
+		propGetETag:               textValue(ctag),
+		propCurrentUserPrincipal:  hrefValue(h.principalHref(userID)),
+		propSupportedComponentSet: rawValue(`<C:comp name="VEVENT"/>`),
+		propSupportedReportSet: rawValue(
+			"<D:supported-report><D:report><C:calendar-query/></D:report></D:supported-report>" +
+				"<D:supported-report><D:report><C:calendar-multiget/></D:report></D:supported-report>"),
+	}
+	order := []xml.Name{propResourceType, propDisplayName, propGetCTag, propGetETag,
+		propCurrentUserPrincipal, propSupportedComponentSet, propSupportedReportSet}
+	return newResponse(h.calendarHref(userID), props, order, names)
+}
+
+// objectResponse возвращает свойства ресурса события event.
+// Данные календаря возвращаются только при явном запросе свойства calendar-data.
+func (h Handler) objectResponse(event entity.Event, names []xml.Name) response {
+	var data bytes.Buffer
+	EncodeEvent(&data, event)
+
+	props := map[xml.Name]propValue{
+		propResourceType:   rawValue(""),
+		propGetETag:        textValue(ETag(event)),
+		propGetContentType: textValue(calendarObjectContentType),
+		propCalendarData:   textValue(data.String()),
+	}
+	order := []xml.Name{propResourceType, propGetETag, propGetContentType}
+	return newResponse(h.objectHref(event.UserID, event.ID), props, order, names)
+}
+
+// report выполняет отчеты calendar-query и calendar-multiget над календарем.
+func (h Handler) report(w http.ResponseWriter, r *http.Request, res resource) {
+	if res.kind != kindCalendar {
+		http.Error(w, "REPORT is supported only for calendar collections", http.StatusForbidden)
+		return
+	}
+
+	var req reportRequest
+	if ok, err := decodeXML(io.LimitReader(r.Body, maxBodySize), &req); !ok {
+		if err == nil {
+			err = errors.New("empty REPORT body")
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	names := req.Prop.names()
+
+	var responses []response
+	switch req.XMLName {
+	case reportCalendarQuery:
+		events, err := h.query(r.Context(), res.userID, req)
+		if err != nil {
+			var parseErr *time.ParseError
+			if errors.As(err, &parseErr) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			handleError(w, err)
+			return
+		}
+		for _, event := range events {
+			responses = append(responses, h.objectResponse(event, names))
+		}
+	case reportCalendarMultiget:
+		for _, href := range req.Hrefs {
+			responses = append(responses, h.multigetResponse(r.Context(), res.userID, href, names))
+		}
+	default:
+		http.Error(w, "unsupported report "+req.XMLName.Local, http.StatusForbidden)
+		return
+	}
+
+	writeMultistatus(w, responses)
+}
+
+// query возвращает события пользователя userID, подходящие под фильтр отчета calendar-query.
+func (h Handler) query(ctx context.Context, userID string, req reportRequest) ([]entity.Event, error) {
+	start, end := minTime, maxTime
+	if req.Filter != nil {
+		if tr := req.Filter.CompFilter.findTimeRange(); tr != nil {
+			var err error
+			if tr.Start != "" {
+				if start, err = time.Parse(icalDateTimeUTC, tr.Start); err != nil {
+					return nil, err
+				}
+			}
+			if tr.End != "" {
+				if end, err = time.Parse(icalDateTimeUTC, tr.End); err != nil {
+					return nil, err
+				}
+				// Конец диапазона CalDAV не включается в диапазон.
+				end = end.Add(-time.Nanosecond)
+			}
+		}
+	}
+	return h.Service.GetForRange(ctx, userID, start, end)
+}
+
+// multigetResponse возвращает свойства события по ссылке href или статус 404.
+func (h Handler) multigetResponse(ctx context.Context, userID string, href string, names []xml.Name) response {
+	if u, err := url.Parse(href); err == nil {
+		href = u.Path
+	}
+	res, ok := h.parsePath(href)
+	if !ok || res.kind != kindObject || res.userID != userID {
+		return response{href: href, status: http.StatusNotFound}
+	}
+
+	event, err := h.Service.GetByID(ctx, res.userID, res.id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotExist) {
+			return response{href: href, status: http.StatusNotFound}
+		}
+		panic(err)
+	}
+	return h.objectResponse(event, names)
+}
+
+// get возвращает событие в формате iCalendar.
+func (h Handler) get(w http.ResponseWriter, r *http.Request, res resource) {
+	if res.kind != kindObject {
+		http.Error(w, "GET is supported only for calendar objects", http.StatusMethodNotAllowed)
+		return
+	}
+
+	event, err := h.Service.GetByID(r.Context(), res.userID, res.id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", calendarDataContentType)
+	w.Header().Set("ETag", ETag(event))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		EncodeEvent(w, event)
+	}
+}
+
+// checkPreconditions проверяет заголовки If-Match и If-None-Match запроса r
+// для текущей версии события current (nil, если событие не существует).
+func checkPreconditions(r *http.Request, current *entity.Event) bool {
+	if match := r.Header.Get("If-Match"); match != "" {
+		if current == nil || (match != "*" && match != ETag(*current)) {
+			return false
+		}
+	}
+	if noneMatch := r.Header.Get("If-None-Match"); noneMatch != "" && current != nil {
+		if noneMatch == "*" || noneMatch == ETag(*current) {
+			return false
+		}
+	}
+	return true
+}
+
+// put создает или обновляет событие из тела запроса в формате iCalendar.
+// Новое событие получает идентификатор, определенный по имени ресурса, поэтому остается
+// доступным по адресу запроса. Если этот адрес отличается от адреса события в календаре,
+// последний возвращается в заголовке Location.
+func (h Handler) put(w http.ResponseWriter, r *http.Request, res resource) {
+	if res.kind != kindObject {
+		http.Error(w, "PUT is supported only for calendar objects", http.StatusMethodNotAllowed)
+		return
+	}
+
+	event, err := DecodeEvent(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	event.UserID = res.userID
+
+	var current *entity.Event
+	existing, err := h.Service.GetByID(r.Context(), res.userID, res.id)
+	if err == nil {
+		current = &existing
+	} else if !errors.Is(err, repo.ErrNotExist) {
+		handleError(w, err)
+		return
+	}
+
+	if !checkPreconditions(r, current) {
+		http.Error(w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+		return
+	}
+
+	if current != nil {
+		event.ID = current.ID
+		// iCalendar не передает формат описания, поэтому он сохраняется.
+		event.DescriptionFormat = current.DescriptionFormat
+		event, err = h.Service.Update(r.Context(), event)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+		w.Header().Set("ETag", ETag(event))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	event.ID = res.id
+	event, err = h.Service.Create(r.Context(), event)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	if href := h.objectHref(event.UserID, event.ID); href != r.URL.Path {
+		w.Header().Set("Location", href)
+	}
+	// Предупреждение с кодом 299 (RFC 7234) не мешает клиентам, которые его не разбирают.
+	for _, warning := range h.Schedules.Warnings(event.UserID, event.Date) {
+		w.Header().Add("Warning", fmt.Sprintf("299 - %q", warning))
+	}
+	w.Header().Set("ETag", ETag(event))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// delete удаляет событие.
+func (h Handler) delete(w http.ResponseWriter, r *http.Request, res resource) {
+	if res.kind != kindObject {
+		http.Error(w, "DELETE is supported only for calendar objects", http.StatusForbidden)
+		return
+	}
+
+	if r.Header.Get("If-Match") != "" {
+		event, err := h.Service.GetByID(r.Context(), res.userID, res.id)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+		if !checkPreconditions(r, &event) {
+			http.Error(w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+			return
+		}
+	}
+
+	if err := h.Service.Delete(r.Context(), res.userID, res.id); err != nil {
+		handleError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
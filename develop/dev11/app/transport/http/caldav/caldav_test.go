@@ -0,0 +1,246 @@
+package caldav
+
+import (
+	"dev11/app/repo"
+	"dev11/app/service"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Структура элемента response ответа multistatus для проверок в тестах.
+type testResponse struct {
+	Href     string `xml:"href"`
+	Status   string `xml:"status"`
+	Propstat []struct {
+		Prop struct {
+			Inner   string `xml:",innerxml"`
+			GetETag string `xml:"getetag"`
+			GetCTag string `xml:"getctag"`
+		} `xml:"prop"`
+		Status string `xml:"status"`
+	} `xml:"propstat"`
+}
+
+// parseMultistatus разбирает тело ответа multistatus.
+func parseMultistatus(t *testing.T, body io.Reader) []testResponse {
+	t.Helper()
+	var ms struct {
+		Responses []testResponse `xml:"response"`
+	}
+	if err := xml.NewDecoder(body).Decode(&ms); err != nil {
+		t.Fatal(err)
+	}
+	return ms.Responses
+}
+
+// Сценарий синхронизации клиента календаря с сервером. Каждый шаг использует
+// состояние, созданное предыдущими шагами.
+func TestHandler_Interop(t *testing.T) {
+	const userID = "18310e71-4df6-42c0-adf4-1a280013dd08"
+	const calendar = "/dav/calendars/" + userID + "/default/"
+	const ics = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nUID:client-uid\r\n" +
+		"DTSTART:20100520T160000Z\r\nSUMMARY:%s\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	h := Handler{Service: service.NewEventV1(repo.NewEventMemory()), Prefix: "/dav/"}
+	do := func(method string, target string, body string, header map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	event := func(title string) string { return strings.Replace(ics, "%s", title, 1) }
+
+	// Обнаружение возможностей сервера и домашней коллекции.
+	w := do(http.MethodOptions, calendar, "", nil)
+	if got := w.Header().Get("DAV"); !strings.Contains(got, "calendar-access") {
+		t.Fatalf("OPTIONS DAV = %q, want calendar-access", got)
+	}
+
+	w = do("PROPFIND", "/dav/principals/"+userID+"/",
+		`<D:propfind xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><C:calendar-home-set/><D:owner/></D:prop></D:propfind>`,
+		map[string]string{"Depth": "0"})
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("PROPFIND principal code = %v, want %v", w.Code, http.StatusMultiStatus)
+	}
+	responses := parseMultistatus(t, w.Body)
+	if len(responses) != 1 || len(responses[0].Propstat) != 2 ||
+		!strings.Contains(responses[0].Propstat[0].Prop.Inner, "/dav/calendars/"+userID+"/") ||
+		!strings.Contains(responses[0].Propstat[1].Status, "404") {
+		t.Fatalf("PROPFIND principal = %+v, want home set and 404 for owner", responses)
+	}
+
+	w = do("PROPFIND", "/dav/calendars/"+userID+"/", "", map[string]string{"Depth": "1"})
+	responses = parseMultistatus(t, w.Body)
+	if len(responses) != 2 || responses[1].Href != calendar || responses[1].Propstat[0].Prop.GetCTag != CTag(nil) ||
+		!strings.Contains(responses[1].Propstat[0].Prop.Inner, "calendar") {
+		t.Fatalf("PROPFIND home = %+v, want home and calendar", responses)
+	}
+
+	// Создание события.
+	w = do(http.MethodPut, calendar+"new.ics", event("event"), map[string]string{"If-None-Match": "*"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("PUT code = %v, want %v: %s", w.Code, http.StatusCreated, w.Body)
+	}
+	location, etag := w.Header().Get("Location"), w.Header().Get("ETag")
+	if !strings.HasPrefix(location, calendar) || etag == "" {
+		t.Fatalf("PUT Location = %q, ETag = %q", location, etag)
+	}
+
+	w = do(http.MethodGet, location, "", nil)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != etag || !strings.Contains(w.Body.String(), "SUMMARY:event") {
+		t.Fatalf("GET = %v %q %q", w.Code, w.Header().Get("ETag"), w.Body)
+	}
+
+	w = do("PROPFIND", calendar, "", map[string]string{"Depth": "1"})
+	responses = parseMultistatus(t, w.Body)
+	if len(responses) != 2 || responses[1].Href != location ||
+		responses[0].Propstat[0].Prop.GetCTag == CTag(nil) {
+		t.Fatalf("PROPFIND calendar = %+v, want event and changed ctag", responses)
+	}
+
+	// Получение событий отчетами.
+	query := func(start, end string) []testResponse {
+		w := do("REPORT", calendar, `<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+			<D:prop><D:getetag/><C:calendar-data/></D:prop>
+			<C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VEVENT">
+			<C:time-range start="`+start+`" end="`+end+`"/>
+			</C:comp-filter></C:comp-filter></C:filter></C:calendar-query>`, map[string]string{"Depth": "1"})
+		if w.Code != http.StatusMultiStatus {
+			t.Fatalf("REPORT calendar-query code = %v, want %v", w.Code, http.StatusMultiStatus)
+		}
+		return parseMultistatus(t, w.Body)
+	}
+	if responses = query("20100520T000000Z", "20100521T000000Z"); len(responses) != 1 ||
+		!strings.Contains(responses[0].Propstat[0].Prop.Inner, "SUMMARY:event") {
+		t.Fatalf("REPORT calendar-query = %+v, want event", responses)
+	}
+	if responses = query("20100521T000000Z", "20100522T000000Z"); len(responses) != 0 {
+		t.Fatalf("REPORT calendar-query = %+v, want no events", responses)
+	}
+
+	w = do("REPORT", calendar, `<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+		<D:prop><D:getetag/></D:prop><D:href>`+location+`</D:href><D:href>`+calendar+`missing.ics</D:href>
+		</C:calendar-multiget>`, nil)
+	responses = parseMultistatus(t, w.Body)
+	if len(responses) != 2 || responses[0].Propstat[0].Prop.GetETag != etag ||
+		!strings.Contains(responses[1].Status, "404") {
+		t.Fatalf("REPORT calendar-multiget = %+v, want event and 404", responses)
+	}
+
+	// Обновление события с проверкой версии.
+	w = do(http.MethodPut, location, event("updated"), map[string]string{"If-Match": etag})
+	if w.Code != http.StatusNoContent || w.Header().Get("ETag") == etag {
+		t.Fatalf("PUT update = %v %q", w.Code, w.Header().Get("ETag"))
+	}
+	w = do(http.MethodPut, location, event("stale"), map[string]string{"If-Match": etag})
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("PUT stale code = %v, want %v", w.Code, http.StatusPreconditionFailed)
+	}
+	w = do(http.MethodDelete, location, "", map[string]string{"If-Match": etag})
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("DELETE stale code = %v, want %v", w.Code, http.StatusPreconditionFailed)
+	}
+
+	// Удаление события.
+	w = do(http.MethodDelete, location, "", nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE code = %v, want %v", w.Code, http.StatusNoContent)
+	}
+	if w = do(http.MethodGet, location, "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("GET deleted code = %v, want %v", w.Code, http.StatusNotFound)
+	}
+	if w = do(http.MethodDelete, location, "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("DELETE deleted code = %v, want %v", w.Code, http.StatusNotFound)
+	}
+}
+
+// Событие, созданное по адресу с именем, выбранным клиентом, доступно по этому адресу.
+func TestHandler_PutByName(t *testing.T) {
+	const userID = "18310e71-4df6-42c0-adf4-1a280013dd08"
+	const href = "/dav/calendars/" + userID + "/default/client-event.ics"
+	const ics = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nUID:client-uid\r\n" +
+		"DTSTART:20100520T160000Z\r\nSUMMARY:event\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	h := Handler{Service: service.NewEventV1(repo.NewEventMemory()), Prefix: "/dav/"}
+	do := func(method string, header map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, href, strings.NewReader(ics))
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := do(http.MethodPut, map[string]string{"If-None-Match": "*"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("PUT code = %v, want %v: %s", w.Code, http.StatusCreated, w.Body)
+	}
+	etag, location := w.Header().Get("ETag"), w.Header().Get("Location")
+
+	if w = do(http.MethodGet, nil); w.Code != http.StatusOK || w.Header().Get("ETag") != etag {
+		t.Fatalf("GET = %v %q, want %v %q", w.Code, w.Header().Get("ETag"), http.StatusOK, etag)
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, location, nil))
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != etag {
+		t.Fatalf("GET Location = %v %q, want %v %q", w.Code, w.Header().Get("ETag"), http.StatusOK, etag)
+	}
+	if w = do(http.MethodPut, map[string]string{"If-None-Match": "*"}); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("PUT existing code = %v, want %v", w.Code, http.StatusPreconditionFailed)
+	}
+	if w = do(http.MethodPut, map[string]string{"If-Match": etag}); w.Code != http.StatusNoContent {
+		t.Fatalf("PUT update code = %v, want %v", w.Code, http.StatusNoContent)
+	}
+
+	if w = do(http.MethodDelete, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE code = %v, want %v", w.Code, http.StatusNoContent)
+	}
+	if w = do(http.MethodGet, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("GET deleted code = %v, want %v", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	const userID = "18310e71-4df6-42c0-adf4-1a280013dd08"
+	const calendar = "/dav/calendars/" + userID + "/default/"
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"UnknownPath", http.MethodGet, "/dav/unknown/", "", http.StatusNotFound},
+		{"OtherPrefix", http.MethodGet, "/other/", "", http.StatusNotFound},
+		{"UnsupportedMethod", http.MethodPatch, calendar, "", http.StatusMethodNotAllowed},
+		{"GetCollection", http.MethodGet, calendar, "", http.StatusMethodNotAllowed},
+		{"DeleteCollection", http.MethodDelete, calendar, "", http.StatusForbidden},
+		{"ReportPrincipal", "REPORT", "/dav/principals/" + userID + "/", "", http.StatusForbidden},
+		{"ReportEmpty", "REPORT", calendar, "", http.StatusBadRequest},
+		{"ReportUnsupported", "REPORT", calendar, `<D:sync-collection xmlns:D="DAV:"/>`, http.StatusForbidden},
+		{"PropfindInvalid", "PROPFIND", calendar, "<D:propfind", http.StatusBadRequest},
+		{"PropfindRoot", "PROPFIND", "/dav", "", http.StatusMultiStatus},
+		{"PutInvalid", http.MethodPut, calendar + "1.ics", "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n", http.StatusBadRequest},
+		{"PutInvalidEvent", http.MethodPut, calendar + "1.ics",
+			"BEGIN:VEVENT\r\nDTSTART:20100520T160000Z\r\nEND:VEVENT\r\n", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Handler{Service: service.NewEventV1(repo.NewEventMemory()), Prefix: "/dav/"}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Errorf("Handler.ServeHTTP() code = %v, want %v", w.Code, tt.want)
+			}
+		})
+	}
+}
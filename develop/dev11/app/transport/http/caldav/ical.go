@@ -0,0 +1,241 @@
+package caldav
+
+import (
+	"bufio"
+	"dev11/app/entity"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Ошибки разбора iCalendar.
+var (
+	ErrNoEvent      = errors.New("calendar has no VEVENT")
+	ErrNoStart      = errors.New("VEVENT has no DTSTART")
+	ErrInvalidStart = errors.New("DTSTART is invalid")
+)
+
+// Форматы дат iCalendar.
+const (
+	icalDateTimeUTC = "20060102T150405Z"
+	icalDateTime    = "20060102T150405"
+	icalDate        = "20060102"
+)
+
+// Максимальная длина строки iCalendar в байтах без учета перевода строки.
+const icalLineLen = 75
+
+// Структура свойства iCalendar: имя, параметры и значение.
+type icalProp struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// EncodeEvent сериализует Event в календарь iCalendar с одним VEVENT.
+func EncodeEvent(w io.Writer, event entity.Event) error {
+	bw := bufio.NewWriter(w)
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//dev11//calendar//EN",
+		"BEGIN:VEVENT",
+		"UID:" + escapeText(event.ID),
+		"DTSTAMP:" + event.Date.UTC().Format(icalDateTimeUTC),
+		"DTSTART:" + event.Date.UTC().Format(icalDateTimeUTC),
+		"SUMMARY:" + escapeText(event.Title),
+	}
+	if event.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+escapeText(event.Description))
+	}
+	lines = append(lines, "END:VEVENT", "END:VCALENDAR")
+
+	for _, line := range lines {
+		writeFolded(bw, line)
+	}
+	return bw.Flush()
+}
+
+// writeFolded записывает строку line, перенося ее по icalLineLen байт без разрыва символов UTF-8.
+func writeFolded(w *bufio.Writer, line string) {
+	for len(line) > icalLineLen {
+		i := icalLineLen
+		for i > 0 && !isRuneStart(line[i]) {
+			i--
+		}
+		w.WriteString(line[:i])
+		w.WriteString("\r\n ")
+		line = line[i:]
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// isRuneStart проверяет, что байт b является началом символа UTF-8.
+func isRuneStart(b byte) bool { return b&0xc0 != 0x80 }
+
+// escapeText экранирует значение текстового свойства iCalendar.
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// unescapeText восстанавливает экранированное значение текстового свойства iCalendar.
+func unescapeText(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
+
+// DecodeEvent читает календарь iCalendar из r и возвращает Event из первого VEVENT.
+// Используются свойства UID, SUMMARY, DESCRIPTION и DTSTART, остальные свойства игнорируются.
+func DecodeEvent(r io.Reader) (entity.Event, error) {
+	vevents, err := readVEvents(r)
+	if err != nil {
+		return entity.EmptyEvent, err
+	}
+	return decodeVEvent(vevents[0])
+}
+
+// DecodeEvents читает календарь iCalendar из r и возвращает Event из всех VEVENT
+// в порядке их следования. Свойства используются так же, как в DecodeEvent.
+func DecodeEvents(r io.Reader) ([]entity.Event, error) {
+	vevents, err := readVEvents(r)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]entity.Event, len(vevents))
+	for i, props := range vevents {
+		if events[i], err = decodeVEvent(props); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// decodeVEvent возвращает Event по свойствам VEVENT props.
+func decodeVEvent(props []icalProp) (entity.Event, error) {
+	var event entity.Event
+	var start *icalProp
+	for i, prop := range props {
+		switch prop.name {
+		case "UID":
+			event.ID = unescapeText(prop.value)
+		case "SUMMARY":
+			event.Title = unescapeText(prop.value)
+		case "DESCRIPTION":
+			event.Description = unescapeText(prop.value)
+		case "DTSTART":
+			start = &props[i]
+		}
+	}
+	if start == nil {
+		return entity.EmptyEvent, ErrNoStart
+	}
+
+	var err error
+	event.Date, err = parseDateTime(*start)
+	if err != nil {
+		return entity.EmptyEvent, err
+	}
+	return event, nil
+}
+
+// readVEvents возвращает свойства всех VEVENT календаря из r без вложенных компонентов
+// или ErrNoEvent, если в календаре нет VEVENT.
+func readVEvents(r io.Reader) ([][]icalProp, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var vevents [][]icalProp
+	var props []icalProp
+	depth := 0
+	for _, line := range lines {
+		prop := parseProp(line)
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT") && depth == 0:
+			depth = 1
+			props = nil
+		case prop.name == "BEGIN" && depth > 0:
+			depth++
+		case prop.name == "END" && depth > 1:
+			depth--
+		case prop.name == "END" && depth == 1:
+			depth = 0
+			vevents = append(vevents, props)
+		case depth == 1:
+			props = append(props, prop)
+		}
+	}
+	if len(vevents) == 0 {
+		return nil, ErrNoEvent
+	}
+	return vevents, nil
+}
+
+// unfold читает строки iCalendar из r, объединяя перенесенные строки.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, s.Err()
+}
+
+// parseProp разбирает строку свойства вида NAME;PARAM=VALUE:value.
+func parseProp(line string) icalProp {
+	prop := icalProp{params: make(map[string]string)}
+
+	// Двоеточие внутри параметров в кавычках не отделяет значение.
+	quoted := false
+	colon := len(line)
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	head := line[:colon]
+	if colon < len(line) {
+		prop.value = line[colon+1:]
+	}
+
+	parts := strings.Split(head, ";")
+	prop.name = strings.ToUpper(parts[0])
+	for _, part := range parts[1:] {
+		k, v, _ := strings.Cut(part, "=")
+		prop.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return prop
+}
+
+// parseDateTime разбирает значение свойства даты с учетом параметров VALUE и TZID.
+// Даты без часового пояса считаются датами в UTC.
+func parseDateTime(prop icalProp) (time.Time, error) {
+	loc := time.UTC
+	if tzid, ok := prop.params["TZID"]; ok && !strings.HasSuffix(prop.value, "Z") {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	for _, layout := range []string{icalDateTimeUTC, icalDateTime, icalDate} {
+		if t, err := time.ParseInLocation(layout, prop.value, loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidStart, prop.value)
+}
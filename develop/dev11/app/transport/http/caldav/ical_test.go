@@ -0,0 +1,152 @@
+package caldav
+
+import (
+	"bytes"
+	"dev11/app/entity"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeEvent(t *testing.T) {
+	date := time.Date(2010, 5, 20, 16, 0, 0, 0, time.UTC)
+	long := strings.Repeat("абв", 40)
+
+	tests := []struct {
+		name  string
+		event entity.Event
+		want  []string
+	}{
+		{
+			"Escaped",
+			entity.Event{ID: "1", Title: "a, b; c", Description: "line1\nline2", Date: date},
+			[]string{"UID:1", "DTSTART:20100520T160000Z", `SUMMARY:a\, b\; c`, `DESCRIPTION:line1\nline2`},
+		},
+		{
+			"NoDescription",
+			entity.Event{ID: "1", Title: "event", Date: date.In(time.FixedZone("MSK", 3*60*60))},
+			[]string{"DTSTART:20100520T160000Z", "SUMMARY:event"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := EncodeEvent(&b, tt.event); err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(b.String(), "\r\n")
+			for _, want := range tt.want {
+				if !slices.Contains(lines, want) {
+					t.Errorf("EncodeEvent() = %q, want line %q", b.String(), want)
+				}
+			}
+			if tt.event.Description == "" && strings.Contains(b.String(), "DESCRIPTION") {
+				t.Errorf("EncodeEvent() = %q, want no DESCRIPTION", b.String())
+			}
+		})
+	}
+
+	t.Run("Folded", func(t *testing.T) {
+		var b bytes.Buffer
+		EncodeEvent(&b, entity.Event{ID: "1", Title: long, Date: date})
+		for _, line := range strings.Split(b.String(), "\r\n") {
+			if len(line) > icalLineLen {
+				t.Errorf("EncodeEvent() line length = %d, want <= %d", len(line), icalLineLen)
+			}
+		}
+		got, err := DecodeEvent(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Title != long {
+			t.Errorf("DecodeEvent() title = %q, want %q", got.Title, long)
+		}
+	})
+}
+
+func TestDecodeEvent(t *testing.T) {
+	date := time.Date(2010, 5, 20, 16, 0, 0, 0, time.UTC)
+	calendar := func(lines ...string) string {
+		lines = append([]string{"BEGIN:VCALENDAR", "VERSION:2.0", "BEGIN:VEVENT"}, lines...)
+		lines = append(lines, "END:VEVENT", "END:VCALENDAR")
+		return strings.Join(lines, "\r\n") + "\r\n"
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		want    entity.Event
+		wantErr error
+	}{
+		{
+			"UTC",
+			calendar("UID:1", "DTSTART:20100520T160000Z", `SUMMARY:a\, b`, `DESCRIPTION:x\ny`),
+			entity.Event{ID: "1", Title: "a, b", Description: "x\ny", Date: date},
+			nil,
+		},
+		{
+			"TZID",
+			calendar("UID:1", "DTSTART;TZID=Europe/Moscow:20100520T200000", "SUMMARY:event"),
+			entity.Event{ID: "1", Title: "event", Date: date},
+			nil,
+		},
+		{
+			"Date",
+			calendar("DTSTART;VALUE=DATE:20100520", "SUMMARY:event"),
+			entity.Event{Title: "event", Date: date.Truncate(24 * time.Hour)},
+			nil,
+		},
+		{
+			"NestedAlarm",
+			calendar("DTSTART:20100520T160000Z", "SUMMARY:event", "BEGIN:VALARM", "DESCRIPTION:alarm", "END:VALARM"),
+			entity.Event{Title: "event", Date: date},
+			nil,
+		},
+		{
+			"FoldedLine",
+			calendar("DTSTART:20100520T160000Z", "SUMMARY:ev", " ent"),
+			entity.Event{Title: "event", Date: date},
+			nil,
+		},
+		{"NoEvent", "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n", entity.EmptyEvent, ErrNoEvent},
+		{"NoStart", calendar("SUMMARY:event"), entity.EmptyEvent, ErrNoStart},
+		{"InvalidStart", calendar("DTSTART:tomorrow"), entity.EmptyEvent, ErrInvalidStart},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeEvent(strings.NewReader(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DecodeEvent() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeEvent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeEvents(t *testing.T) {
+	data := strings.Join([]string{
+		"BEGIN:VCALENDAR", "VERSION:2.0",
+		"BEGIN:VEVENT", "DTSTART;VALUE=DATE:20250101", "SUMMARY:Новый год", "END:VEVENT",
+		"BEGIN:VEVENT", "DTSTART;VALUE=DATE:20250223", "SUMMARY:День защитника Отечества", "END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	want := []entity.Event{
+		{Title: "Новый год", Date: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Title: "День защитника Отечества", Date: time.Date(2025, 2, 23, 0, 0, 0, 0, time.UTC)},
+	}
+	got, err := DecodeEvents(strings.NewReader(data))
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeEvents() = %v, %v, want %v", got, err, want)
+	}
+
+	if _, err := DecodeEvents(strings.NewReader(strings.Replace(data, "DTSTART;VALUE=DATE:20250223", "SUMMARY:x", 1))); !errors.Is(err, ErrNoStart) {
+		t.Errorf("DecodeEvents() error = %v, want %v", err, ErrNoStart)
+	}
+}
@@ -0,0 +1,243 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Пространства имен XML, используемые CalDAV.
+const (
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	nsCS     = "http://calendarserver.org/ns/"
+)
+
+// Префиксы известных пространств имен в ответах.
+var prefixes = map[string]string{nsDAV: "D", nsCalDAV: "C", nsCS: "CS"}
+
+// Имена свойств WebDAV и CalDAV.
+var (
+	propResourceType          = xml.Name{Space: nsDAV, Local: "resourcetype"}
+	propDisplayName           = xml.Name{Space: nsDAV, Local: "displayname"}
+	propGetETag               = xml.Name{Space: nsDAV, Local: "getetag"}
+	propGetContentType        = xml.Name{Space: nsDAV, Local: "getcontenttype"}
+	propCurrentUserPrincipal  = xml.Name{Space: nsDAV, Local: "current-user-principal"}
+	propPrincipalURL          = xml.Name{Space: nsDAV, Local: "principal-URL"}
+	propSupportedReportSet    = xml.Name{Space: nsDAV, Local: "supported-report-set"}
+	propCalendarHomeSet       = xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}
+	propCalendarData          = xml.Name{Space: nsCalDAV, Local: "calendar-data"}
+	propSupportedComponentSet = xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}
+	propGetCTag               = xml.Name{Space: nsCS, Local: "getctag"}
+)
+
+// Имена отчетов CalDAV.
+var (
+	reportCalendarQuery    = xml.Name{Space: nsCalDAV, Local: "calendar-query"}
+	reportCalendarMultiget = xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}
+)
+
+// Имя компонента события в фильтрах CalDAV.
+const compFilterVEvent = "VEVENT"
+
+// Типы содержимого календаря и ресурса события.
+const (
+	calendarDataContentType   = "text/calendar; charset=utf-8"
+	calendarObjectContentType = calendarDataContentType + "; component=vevent"
+)
+
+// Структура имени элемента XML произвольного свойства.
+type anyProp struct {
+	XMLName xml.Name
+}
+
+// Структура списка запрашиваемых свойств.
+type propList struct {
+	Props []anyProp `xml:",any"`
+}
+
+// names возвращает имена запрашиваемых свойств.
+func (p *propList) names() []xml.Name {
+	if p == nil {
+		return nil
+	}
+	names := make([]xml.Name, len(p.Props))
+	for i, prop := range p.Props {
+		names[i] = prop.XMLName
+	}
+	return names
+}
+
+// Структура тела запроса PROPFIND.
+type propfindRequest struct {
+	XMLName xml.Name  `xml:"DAV: propfind"`
+	AllProp *struct{} `xml:"DAV: allprop"`
+	Prop    *propList `xml:"DAV: prop"`
+}
+
+// Структура диапазона времени фильтра CalDAV.
+type timeRange struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
+
+// Структура фильтра компонента CalDAV.
+type compFilter struct {
+	Name        string       `xml:"name,attr"`
+	TimeRange   *timeRange   `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	CompFilters []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+// findTimeRange возвращает диапазон времени фильтра компонента VEVENT, если он задан.
+func (f compFilter) findTimeRange() *timeRange {
+	if f.Name == compFilterVEvent && f.TimeRange != nil {
+		return f.TimeRange
+	}
+	for _, sub := range f.CompFilters {
+		if tr := sub.findTimeRange(); tr != nil {
+			return tr
+		}
+	}
+	return nil
+}
+
+// Структура тела запроса REPORT calendar-query или calendar-multiget.
+type reportRequest struct {
+	XMLName xml.Name
+	Prop    *propList `xml:"DAV: prop"`
+	Hrefs   []string  `xml:"DAV: href"`
+	Filter  *struct {
+		CompFilter compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	} `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+// decodeXML разбирает тело запроса r в v. Пустое тело не считается ошибкой.
+func decodeXML(r io.Reader, v any) (bool, error) {
+	err := xml.NewDecoder(r).Decode(v)
+	if err == io.EOF {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Тип значения свойства: функция, записывающая содержимое элемента свойства.
+type propValue func(w *strings.Builder)
+
+// textValue возвращает значение свойства с экранированным текстом s.
+func textValue(s string) propValue {
+	return func(w *strings.Builder) { xml.EscapeText(w, []byte(s)) }
+}
+
+// hrefValue возвращает значение свойства с элементом href.
+func hrefValue(href string) propValue {
+	return func(w *strings.Builder) {
+		w.WriteString("<D:href>")
+		xml.EscapeText(w, []byte(href))
+		w.WriteString("</D:href>")
+	}
+}
+
+// rawValue возвращает значение свойства с готовой разметкой s.
+func rawValue(s string) propValue {
+	return func(w *strings.Builder) { w.WriteString(s) }
+}
+
+// Структура ответа multistatus о свойствах одного ресурса.
+type response struct {
+	href   string
+	status int
+	found  map[xml.Name]propValue
+	// order задает порядок вывода найденных свойств.
+	order    []xml.Name
+	notFound []xml.Name
+}
+
+// newResponse возвращает ответ для ресурса href со свойствами props,
+// выбранными по запрошенным именам names (все свойства, если names пуст).
+func newResponse(href string, props map[xml.Name]propValue, order []xml.Name, names []xml.Name) response {
+	resp := response{href: href, found: make(map[xml.Name]propValue)}
+	if len(names) == 0 {
+		names = order
+	}
+	for _, name := range names {
+		if value, ok := props[name]; ok {
+			resp.found[name] = value
+			resp.order = append(resp.order, name)
+		} else {
+			resp.notFound = append(resp.notFound, name)
+		}
+	}
+	return resp
+}
+
+// writeMultistatus записывает ответ 207 Multi-Status с ответами responses.
+func writeMultistatus(w http.ResponseWriter, responses []response) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<D:multistatus xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav" xmlns:CS="http://calendarserver.org/ns/">`)
+	for _, resp := range responses {
+		b.WriteString("<D:response><D:href>")
+		xml.EscapeText(&b, []byte(resp.href))
+		b.WriteString("</D:href>")
+		if resp.status != 0 {
+			writeStatus(&b, resp.status)
+		}
+		if len(resp.order) > 0 {
+			b.WriteString("<D:propstat><D:prop>")
+			for _, name := range resp.order {
+				writeProp(&b, name, resp.found[name])
+			}
+			b.WriteString("</D:prop>")
+			writeStatus(&b, http.StatusOK)
+			b.WriteString("</D:propstat>")
+		}
+		if len(resp.notFound) > 0 {
+			b.WriteString("<D:propstat><D:prop>")
+			for _, name := range resp.notFound {
+				writeProp(&b, name, nil)
+			}
+			b.WriteString("</D:prop>")
+			writeStatus(&b, http.StatusNotFound)
+			b.WriteString("</D:propstat>")
+		}
+		b.WriteString("</D:response>")
+	}
+	b.WriteString("</D:multistatus>")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, b.String())
+}
+
+// writeStatus записывает элемент status с кодом code.
+func writeStatus(b *strings.Builder, code int) {
+	fmt.Fprintf(b, "<D:status>HTTP/1.1 %d %s</D:status>", code, http.StatusText(code))
+}
+
+// writeProp записывает элемент свойства name со значением value.
+// Свойства из неизвестных пространств имен объявляют пространство имен в самом элементе.
+func writeProp(b *strings.Builder, name xml.Name, value propValue) {
+	tag, attr := name.Local, ""
+	if prefix, ok := prefixes[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+	} else if name.Space != "" {
+		tag, attr = "X:"+name.Local, ` xmlns:X="`+escapeAttr(name.Space)+`"`
+	}
+
+	if value == nil {
+		fmt.Fprintf(b, "<%s%s/>", tag, attr)
+		return
+	}
+	fmt.Fprintf(b, "<%s%s>", tag, attr)
+	value(b)
+	fmt.Fprintf(b, "</%s>", tag)
+}
+
+// escapeAttr экранирует значение атрибута XML.
+func escapeAttr(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
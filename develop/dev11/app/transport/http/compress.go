@@ -0,0 +1,234 @@
+package http
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Минимальный размер тела ответа в байтах, начиная с которого ответ сжимается, по умолчанию.
+const DefaultCompressMinSize = 1024
+
+// Поддерживаемые кодировки сжатия в порядке предпочтения сервера при равном весе.
+var encodings = []string{"br", "gzip"}
+
+// Тип кодировщика сжатия, переиспользуемого для разных ответов.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Пулы кодировщиков сжатия по кодировкам.
+var encoderPools = map[string]*sync.Pool{
+	"br":   {New: func() any { return brotli.NewWriterLevel(nil, brotli.DefaultCompression) }},
+	"gzip": {New: func() any { return gzip.NewWriter(nil) }},
+}
+
+// CompressionMiddleware возвращает middleware, сжимающее тела ответов размером
+// не меньше minSize байт в кодировке br или gzip, выбранной по заголовку Accept-Encoding.
+// Сжимаются только текстовые форматы. Ответы с сильным ETag не сжимаются,
+// так как сильный ETag должен различаться для разных кодировок тела.
+func CompressionMiddleware(minSize int) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding выбирает кодировку сжатия по заголовку Accept-Encoding с учетом весов q.
+// Возвращает пустую строку, если клиент не принимает ни одну из поддерживаемых кодировок.
+func negotiateEncoding(accept string) string {
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		weight := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					weight = q
+				}
+			}
+		}
+		weights[name] = weight
+	}
+
+	best, bestWeight := "", 0.0
+	for _, encoding := range encodings {
+		weight, ok := weights[encoding]
+		if !ok {
+			weight = weights["*"]
+		}
+		if weight > bestWeight {
+			best, bestWeight = encoding, weight
+		}
+	}
+	return best
+}
+
+// compressible сообщает, имеет ли смысл сжимать тело с типом contentType.
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript":
+		return true
+	}
+	return false
+}
+
+// Кастомный http.ResponseWriter для сжатия тела ответа. Тело накапливается в буфере,
+// пока его размер не достигнет minSize, после чего отправляется сжатым.
+// Тело меньшего размера отправляется без сжатия при закрытии.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+	code     int
+	buf      []byte
+	// started устанавливается после отправки заголовка ответа.
+	started bool
+	encoder encoder
+}
+
+// eligible сообщает, может ли ответ с заголовками и кодом w.code быть сжат.
+func (w *compressWriter) eligible() bool {
+	h := w.Header()
+	if w.code < http.StatusOK || w.code == http.StatusNoContent || w.code == http.StatusNotModified {
+		return false
+	}
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	return compressible(h.Get("Content-Type"))
+}
+
+// WriteHeader запоминает код ответа. Заголовок ответа, который не может быть сжат,
+// отправляется сразу, иначе после выбора между сжатием и отправкой без сжатия.
+func (w *compressWriter) WriteHeader(statusCode int) {
+	if w.started || w.code != 0 {
+		return
+	}
+	w.code = statusCode
+	if !w.eligible() {
+		w.started = true
+		w.ResponseWriter.WriteHeader(statusCode)
+	}
+}
+
+// Write записывает байты в тело HTTP-ответа.
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.code == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.started {
+		if w.encoder != nil {
+			return w.encoder.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.startCompression(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// startCompression отправляет заголовок ответа со сжатием и сжимает накопленное тело.
+func (w *compressWriter) startCompression() error {
+	h := w.Header()
+	h.Set("Content-Encoding", w.encoding)
+	h.Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.code)
+	w.started = true
+
+	w.encoder = encoderPools[w.encoding].Get().(encoder)
+	w.encoder.Reset(w.ResponseWriter)
+	_, err := w.encoder.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// writePlain отправляет заголовок ответа и накопленное тело без сжатия.
+func (w *compressWriter) writePlain() error {
+	w.started = true
+	w.ResponseWriter.WriteHeader(w.code)
+	_, err := w.ResponseWriter.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// Flush отправляет клиенту накопленную часть тела, реализуя http.Flusher.
+func (w *compressWriter) Flush() { w.FlushError() }
+
+// FlushError отправляет клиенту накопленную часть тела. Ответ, который может быть сжат,
+// с этого момента сжимается независимо от размера.
+func (w *compressWriter) FlushError() error {
+	if !w.started && w.code != 0 {
+		if err := w.startCompression(); err != nil {
+			return err
+		}
+	}
+	if w.encoder != nil {
+		if err := w.encoder.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap возвращает исходный http.ResponseWriter для http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// Close завершает тело ответа: отправляет накопленное тело без сжатия, если оно меньше
+// minSize, или дописывает окончание сжатого потока.
+func (w *compressWriter) Close() error {
+	if !w.started {
+		if w.code == 0 {
+			return nil
+		}
+		return w.writePlain()
+	}
+	if w.encoder == nil {
+		return nil
+	}
+	err := w.encoder.Close()
+	w.encoder.Reset(nil)
+	encoderPools[w.encoding].Put(w.encoder)
+	w.encoder = nil
+	return err
+}
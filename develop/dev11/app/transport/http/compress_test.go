@@ -0,0 +1,187 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func Test_negotiateEncoding(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br", "br"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"br;q=0, gzip;q=0", ""},
+		{"*", "br"},
+		{"*;q=0.1, gzip;q=0.5", "gzip"},
+		{"GZIP ; Q=0.8", "gzip"},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.accept); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func Test_compressible(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/json", true},
+		{"text/plain; charset=utf-8", true},
+		{"application/xml; charset=utf-8", true},
+		{"application/problem+json", true},
+		{"image/png", false},
+		{"application/octet-stream", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := compressible(tt.contentType); got != tt.want {
+			t.Errorf("compressible(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+}
+
+// decode возвращает тело ответа rec, распакованное по заголовку Content-Encoding.
+func decode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var r io.Reader = rec.Body
+	switch rec.Header().Get("Content-Encoding") {
+	case "gzip":
+		gr, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gr
+	case "br":
+		r = brotli.NewReader(rec.Body)
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	large := strings.Repeat(`{"title":"event"}`, 100)
+
+	tests := []struct {
+		name         string
+		method       string
+		accept       string
+		header       http.Header
+		code         int
+		body         string
+		wantEncoding string
+	}{
+		{"Gzip", http.MethodGet, "gzip", http.Header{"Content-Type": {"application/json"}}, http.StatusOK, large, "gzip"},
+		{"Brotli", http.MethodGet, "gzip, br", http.Header{"Content-Type": {"application/json"}}, http.StatusOK, large, "br"},
+		{"NotAccepted", http.MethodGet, "", http.Header{"Content-Type": {"application/json"}}, http.StatusOK, large, ""},
+		{"BelowMinSize", http.MethodGet, "gzip", http.Header{"Content-Type": {"application/json"}}, http.StatusOK, "{}", ""},
+		{"NotCompressible", http.MethodGet, "gzip", http.Header{"Content-Type": {"image/png"}}, http.StatusOK, large, ""},
+		{"StrongETag", http.MethodGet, "gzip", http.Header{"Content-Type": {"text/calendar"}, "Etag": {`"1"`}}, http.StatusOK, large, ""},
+		{"WeakETag", http.MethodGet, "gzip", http.Header{"Content-Type": {"application/json"}, "Etag": {`W/"1"`}}, http.StatusOK, large, "gzip"},
+		{"AlreadyEncoded", http.MethodGet, "gzip", http.Header{"Content-Type": {"text/plain"}, "Content-Encoding": {"identity"}}, http.StatusOK, large, "identity"},
+		{"NotModified", http.MethodGet, "gzip", http.Header{"Content-Type": {"application/json"}}, http.StatusNotModified, "", ""},
+		{"ErrorBody", http.MethodGet, "gzip", http.Header{"Content-Type": {"application/json"}}, http.StatusServiceUnavailable, large, "gzip"},
+		{"Head", http.MethodHead, "gzip", http.Header{"Content-Type": {"application/json"}}, http.StatusOK, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := CompressionMiddleware(DefaultCompressMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(tt.code)
+				// Тело записывается частями, чтобы проверить накопление до минимального размера.
+				for i := 0; i < len(tt.body); i += 100 {
+					io.WriteString(w, tt.body[i:min(i+100, len(tt.body))])
+				}
+			}))
+
+			r := httptest.NewRequest(tt.method, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Encoding", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+
+			if rec.Code != tt.code {
+				t.Errorf("code = %v, want %v", rec.Code, tt.code)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+			if got := decode(t, rec); got != tt.body {
+				t.Errorf("body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestCompressionMiddleware_Flush(t *testing.T) {
+	handler := CompressionMiddleware(DefaultCompressMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "first")
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush() error = %v", err)
+		}
+		io.WriteString(w, "second")
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	if !rec.Flushed || rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("flushed = %v, Content-Encoding = %q, want flushed gzip", rec.Flushed, rec.Header().Get("Content-Encoding"))
+	}
+	if got := decode(t, rec); got != "firstsecond" {
+		t.Errorf("body = %q, want %q", got, "firstsecond")
+	}
+}
+
+func TestCompressionMiddleware_LoggerSize(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	body := strings.Repeat("a", 10*DefaultCompressMinSize)
+	handler := LoggerMiddleware(logger)(CompressionMiddleware(DefaultCompressMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, body)
+	})))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if size := int(m["size"].(float64)); size != rec.Body.Len() || size >= len(body) {
+		t.Errorf("logged size = %v, want compressed size %v", size, rec.Body.Len())
+	}
+	if code := int(m["code"].(float64)); code != http.StatusOK {
+		t.Errorf("logged code = %v, want %v", code, http.StatusOK)
+	}
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Параметры CORS. Пустой список AllowedOrigins отключает CORS.
+type CORSOptions struct {
+	// AllowedOrigins задает источники вида https://app.example.com, которым разрешены
+	// запросы из браузера. Значение "*" разрешает любой источник.
+	AllowedOrigins []string
+	// AllowedMethods задает методы, разрешенные в предварительных запросах.
+	// По умолчанию GET, HEAD и POST.
+	AllowedMethods []string
+	// AllowedHeaders задает заголовки запроса, разрешенные в предварительных запросах.
+	// По умолчанию Content-Type, If-None-Match, If-Modified-Since, IdempotencyKeyHeader и TenantHeader.
+	AllowedHeaders []string
+	// ExposedHeaders задает заголовки ответа, доступные скриптам.
+	// По умолчанию ETag и Last-Modified.
+	ExposedHeaders []string
+	// AllowCredentials разрешает запросы с cookie и заголовком Authorization.
+	// Несовместим с источником "*": источник запроса возвращается явно.
+	AllowCredentials bool
+	// MaxAge задает время кэширования ответа на предварительный запрос браузером.
+	MaxAge time.Duration
+}
+
+// withDefaults возвращает параметры с заполненными значениями по умолчанию.
+func (o CORSOptions) withDefaults() CORSOptions {
+	if len(o.AllowedMethods) == 0 {
+		o.AllowedMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	if len(o.AllowedHeaders) == 0 {
+		o.AllowedHeaders = []string{"Content-Type", "If-None-Match", "If-Modified-Since", IdempotencyKeyHeader, TenantHeader}
+	}
+	if len(o.ExposedHeaders) == 0 {
+		o.ExposedHeaders = []string{"ETag", "Last-Modified"}
+	}
+	return o
+}
+
+// allowOrigin сообщает, разрешен ли источник origin.
+func (o CORSOptions) allowOrigin(origin string) bool {
+	return slices.Contains(o.AllowedOrigins, "*") || slices.Contains(o.AllowedOrigins, origin)
+}
+
+// allowHeaders сообщает, разрешены ли все заголовки из значения Access-Control-Request-Headers.
+func (o CORSOptions) allowHeaders(requested string) bool {
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !slices.ContainsFunc(o.AllowedHeaders, func(h string) bool { return strings.EqualFold(h, name) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// CORSMiddleware возвращает middleware, разрешающее запросы из браузера с источников opts.AllowedOrigins.
+// Предварительные запросы OPTIONS обрабатываются без вызова следующего обработчика
+// и отклоняются с кодом 403, если источник, метод или заголовки не разрешены.
+// Остальные запросы с неразрешенных источников обрабатываются без заголовков CORS,
+// поэтому браузер не передает ответ скрипту.
+func CORSMiddleware(opts CORSOptions) Middleware {
+	opts = opts.withDefaults()
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	headers := strings.Join(opts.AllowedHeaders, ", ")
+	exposed := strings.Join(opts.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+				if origin == "" || !opts.allowOrigin(origin) ||
+					!slices.Contains(opts.AllowedMethods, r.Header.Get("Access-Control-Request-Method")) ||
+					!opts.allowHeaders(r.Header.Get("Access-Control-Request-Headers")) {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				setAllowOrigin(h, origin, opts)
+				h.Set("Access-Control-Allow-Methods", methods)
+				h.Set("Access-Control-Allow-Headers", headers)
+				if opts.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", maxAge)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if origin != "" && opts.allowOrigin(origin) {
+				setAllowOrigin(h, origin, opts)
+				h.Set("Access-Control-Expose-Headers", exposed)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// setAllowOrigin записывает в h разрешенный источник origin и разрешение передачи учетных данных.
+func setAllowOrigin(h http.Header, origin string, opts CORSOptions) {
+	if slices.Contains(opts.AllowedOrigins, "*") && !opts.AllowCredentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if opts.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
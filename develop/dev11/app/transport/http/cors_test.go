@@ -0,0 +1,87 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	origin := "https://app.example.com"
+	allowed := CORSOptions{AllowedOrigins: []string{origin}, MaxAge: 10 * time.Minute}
+
+	tests := []struct {
+		name    string
+		opts    CORSOptions
+		method  string
+		header  http.Header
+		want    int
+		next    bool
+		wantHdr map[string]string
+	}{
+		{"Preflight", allowed, http.MethodOptions, http.Header{
+			"Origin": {origin}, "Access-Control-Request-Method": {"POST"}, "Access-Control-Request-Headers": {"content-type, x-tenant-id"},
+		}, http.StatusNoContent, false, map[string]string{
+			"Access-Control-Allow-Origin":  origin,
+			"Access-Control-Allow-Methods": "GET, HEAD, POST",
+			"Access-Control-Allow-Headers": "Content-Type, If-None-Match, If-Modified-Since, Idempotency-Key, X-Tenant-ID",
+			"Access-Control-Max-Age":       "600",
+		}},
+		{"PreflightOriginNotAllowed", allowed, http.MethodOptions, http.Header{
+			"Origin": {"https://evil.example"}, "Access-Control-Request-Method": {"POST"},
+		}, http.StatusForbidden, false, map[string]string{"Access-Control-Allow-Origin": ""}},
+		{"PreflightMethodNotAllowed", allowed, http.MethodOptions, http.Header{
+			"Origin": {origin}, "Access-Control-Request-Method": {"DELETE"},
+		}, http.StatusForbidden, false, map[string]string{"Access-Control-Allow-Methods": ""}},
+		{"PreflightHeaderNotAllowed", allowed, http.MethodOptions, http.Header{
+			"Origin": {origin}, "Access-Control-Request-Method": {"POST"}, "Access-Control-Request-Headers": {"X-Debug"},
+		}, http.StatusForbidden, false, nil},
+		{"PreflightCustom", CORSOptions{AllowedOrigins: []string{origin}, AllowedMethods: []string{"PUT"}, AllowedHeaders: []string{"X-Debug"}}, http.MethodOptions, http.Header{
+			"Origin": {origin}, "Access-Control-Request-Method": {"PUT"}, "Access-Control-Request-Headers": {"x-debug"},
+		}, http.StatusNoContent, false, map[string]string{
+			"Access-Control-Allow-Methods": "PUT",
+			"Access-Control-Allow-Headers": "X-Debug",
+			"Access-Control-Max-Age":       "",
+		}},
+		{"Simple", allowed, http.MethodGet, http.Header{"Origin": {origin}}, http.StatusOK, true, map[string]string{
+			"Access-Control-Allow-Origin":   origin,
+			"Access-Control-Expose-Headers": "ETag, Last-Modified",
+			"Vary":                          "Origin",
+		}},
+		{"SimpleOriginNotAllowed", allowed, http.MethodGet, http.Header{"Origin": {"https://evil.example"}}, http.StatusOK, true, map[string]string{
+			"Access-Control-Allow-Origin": "",
+		}},
+		{"Wildcard", CORSOptions{AllowedOrigins: []string{"*"}}, http.MethodGet, http.Header{"Origin": {origin}}, http.StatusOK, true, map[string]string{
+			"Access-Control-Allow-Origin":      "*",
+			"Access-Control-Allow-Credentials": "",
+		}},
+		{"WildcardCredentials", CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}, http.MethodGet, http.Header{"Origin": {origin}}, http.StatusOK, true, map[string]string{
+			"Access-Control-Allow-Origin":      origin,
+			"Access-Control-Allow-Credentials": "true",
+		}},
+		{"OptionsWithoutPreflight", allowed, http.MethodOptions, http.Header{"Origin": {origin}}, http.StatusOK, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var next bool
+			handler := CORSMiddleware(tt.opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next = true
+			}))
+
+			r := httptest.NewRequest(tt.method, "/events_for_day", nil)
+			r.Header = tt.header
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.want || next != tt.next {
+				t.Errorf("CORSMiddleware() code = %v, next %v, want %v, %v", w.Code, next, tt.want, tt.next)
+			}
+			for k, v := range tt.wantHdr {
+				if got := w.Header().Get(k); got != v {
+					t.Errorf("CORSMiddleware() %s = %q, want %q", k, got, v)
+				}
+			}
+		})
+	}
+}
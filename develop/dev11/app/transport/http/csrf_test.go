@@ -0,0 +1,52 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	form := "application/x-www-form-urlencoded"
+	trusted := []string{"https://app.example.com"}
+
+	tests := []struct {
+		name   string
+		method string
+		header http.Header
+		want   int
+	}{
+		{"SafeMethod", http.MethodGet, http.Header{"Origin": {"https://evil.example"}, "Sec-Fetch-Site": {"cross-site"}}, http.StatusOK},
+		{"NotBrowser", http.MethodPost, http.Header{"Content-Type": {form}}, http.StatusOK},
+		{"SameOrigin", http.MethodPost, http.Header{"Content-Type": {form}, "Origin": {"http://calendar.example.com:3000"}}, http.StatusOK},
+		{"SecFetchSameOrigin", http.MethodPost, http.Header{"Content-Type": {form}, "Sec-Fetch-Site": {"same-origin"}}, http.StatusOK},
+		{"SecFetchNone", http.MethodPost, http.Header{"Content-Type": {form}, "Sec-Fetch-Site": {"none"}}, http.StatusOK},
+		{"TrustedOrigin", http.MethodPost, http.Header{"Content-Type": {form}, "Origin": {"https://app.example.com"}, "Sec-Fetch-Site": {"same-site"}}, http.StatusOK},
+		{"JSON", http.MethodPost, http.Header{"Content-Type": {"application/json"}, "Origin": {"https://evil.example"}}, http.StatusOK},
+		{"CrossOrigin", http.MethodPost, http.Header{"Content-Type": {form}, "Origin": {"https://evil.example"}}, http.StatusForbidden},
+		{"CrossOriginMultipart", http.MethodPost, http.Header{"Content-Type": {"multipart/form-data; boundary=x"}, "Origin": {"https://evil.example"}}, http.StatusForbidden},
+		{"CrossOriginTextPlain", http.MethodPost, http.Header{"Content-Type": {"text/plain;charset=UTF-8"}, "Origin": {"https://evil.example"}}, http.StatusForbidden},
+		{"CrossOriginWithoutContentType", http.MethodPost, http.Header{"Origin": {"https://evil.example"}}, http.StatusForbidden},
+		{"NullOrigin", http.MethodPost, http.Header{"Content-Type": {form}, "Origin": {"null"}}, http.StatusForbidden},
+		{"SecFetchCrossSiteWithoutOrigin", http.MethodPost, http.Header{"Content-Type": {form}, "Sec-Fetch-Site": {"cross-site"}}, http.StatusForbidden},
+		{"SecFetchCrossSiteSameHost", http.MethodPost, http.Header{"Content-Type": {form}, "Origin": {"http://calendar.example.com:3000"}, "Sec-Fetch-Site": {"cross-site"}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var next bool
+			handler := CSRFMiddleware(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next = true
+			}))
+
+			r := httptest.NewRequest(tt.method, "/create_event", nil)
+			r.Host = "calendar.example.com:3000"
+			r.Header = tt.header
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.want || next != (tt.want == http.StatusOK) {
+				t.Errorf("CSRFMiddleware() code = %v, next %v, want %v", w.Code, next, tt.want)
+			}
+		})
+	}
+}
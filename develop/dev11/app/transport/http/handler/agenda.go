@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"bytes"
+	"dev11/app/agenda"
+	"dev11/app/clock"
+	"dev11/app/entity"
+	"dev11/app/service"
+	"net/http"
+	"time"
+)
+
+// Структура HTTP-обработчика для метода /agenda.
+type AgendaGet struct {
+	Service service.Agenda
+	// Clock задает текущую дату повестки по умолчанию. Если не задан, используются системные часы.
+	Clock clock.Clock
+}
+
+// ServeHTTP записывает в w повестку пользователя за день или неделю в формате text, html или markdown.
+// По умолчанию возвращается повестка на текущий день в формате text.
+func (h AgendaGet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	date := clock.OrSystem(h.Clock).Now()
+	if dateValue := query.Get("date"); dateValue != "" {
+		var err error
+		if date, err = time.Parse("2006-01-02", dateValue); err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	period := query.Get("period")
+	if period == "" {
+		period = entity.PeriodDay
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = agenda.FormatText
+	}
+	contentType, err := agenda.ContentType(format)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	a, err := h.Service.Build(r.Context(), query.Get("user_id"), period, date)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	var b bytes.Buffer
+	if err := agenda.Render(&b, a, format); err != nil {
+		// Шаблоны повестки проверены при запуске, ошибка будет обработана RecovererMiddleware.
+		panic(err)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	b.WriteTo(w)
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"dev11/app/clock"
+	"dev11/app/entity"
+	"dev11/app/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestAgendaGet_ServeHTTP(t *testing.T) {
+	date := time.Date(2010, 5, 17, 0, 0, 0, 0, time.UTC)
+	now := date.Add(15 * time.Hour)
+	agenda := entity.Agenda{Period: entity.PeriodWeek, Start: date, Days: []entity.AgendaDay{{Date: date}}}
+
+	tests := []struct {
+		name        string
+		prepare     func(s *service.MockAgenda)
+		target      string
+		want        int
+		contentType string
+	}{
+		{"Markdown", func(s *service.MockAgenda) {
+			s.EXPECT().Build(gomock.Any(), "1", entity.PeriodWeek, date).Return(agenda, nil)
+		}, "/agenda?user_id=1&period=week&date=2010-05-17&format=markdown", http.StatusOK, "text/markdown; charset=utf-8"},
+		{"Defaults", func(s *service.MockAgenda) {
+			s.EXPECT().Build(gomock.Any(), "1", entity.PeriodDay, now).Return(agenda, nil)
+		}, "/agenda?user_id=1", http.StatusOK, "text/plain; charset=utf-8"},
+		{"InvalidDate", func(s *service.MockAgenda) {}, "/agenda?user_id=1&date=May", http.StatusBadRequest, "application/json"},
+		{"InvalidFormat", func(s *service.MockAgenda) {}, "/agenda?user_id=1&format=pdf", http.StatusBadRequest, "application/json"},
+		{"InvalidPeriod", func(s *service.MockAgenda) {
+			s.EXPECT().Build(gomock.Any(), "1", "month", gomock.Any()).Return(entity.Agenda{}, &service.ExternalError{Err: entity.ErrPeriodInvalid})
+		}, "/agenda?user_id=1&period=month", http.StatusServiceUnavailable, "application/json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := service.NewMockAgenda(ctrl)
+			tt.prepare(s)
+			w := httptest.NewRecorder()
+			AgendaGet{Service: s, Clock: clock.NewFake(now)}.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != tt.want || w.Header().Get("Content-Type") != tt.contentType {
+				t.Errorf("AgendaGet.ServeHTTP() = %v %q, want %v %q", w.Code, w.Header().Get("Content-Type"), tt.want, tt.contentType)
+			}
+			if w.Code == http.StatusOK && !strings.Contains(w.Body.String(), "Agenda for") {
+				t.Errorf("AgendaGet.ServeHTTP() body = %q", w.Body)
+			}
+		})
+	}
+}
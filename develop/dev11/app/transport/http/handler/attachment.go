@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"dev11/app/service"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// Запас размера тела запроса с файлом на поля формы и заголовки частей.
+const MultipartOverhead = 1 << 20
+
+// Объем памяти для разбора формы с вложением, остальное сохраняется во временные файлы.
+const multipartMemory = 1 << 20
+
+// Ошибки обработчиков вложений.
+var (
+	ErrAttachmentsDisabled = errors.New("attachments are not configured")
+	ErrFileMissing         = errors.New("file is missing")
+)
+
+// handleAttachmentError обрабатывает ошибку сервиса вложений err и записывает в w.
+func handleAttachmentError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrAttachmentTooLarge) {
+		WriteError(w, http.StatusRequestEntityTooLarge, errors.Unwrap(err))
+		return
+	}
+	HandleServiceError(w, err)
+}
+
+// Структура HTTP-обработчика для метода /create_event_attachment.
+type AttachmentCreate struct {
+	Service service.Attachment
+	// MaxSize ограничивает размер вложения в байтах, нулевое значение означает отсутствие ограничения.
+	MaxSize int64
+}
+
+// ServeHTTP обрабатывает запрос r в формате multipart/form-data и записывает ответ в w.
+func (h AttachmentCreate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		WriteError(w, http.StatusNotImplemented, ErrAttachmentsDisabled)
+		return
+	}
+
+	if h.MaxSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.MaxSize+MultipartOverhead)
+	}
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		WriteRequestError(w, err)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, ErrFileMissing)
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.Service.Upload(r.Context(), r.FormValue("user_id"), r.FormValue("event_id"), header.Filename, file)
+	if err != nil {
+		handleAttachmentError(w, err)
+		return
+	}
+
+	WriteResult(w, http.StatusCreated, attachment)
+}
+
+// Структура HTTP-обработчика для метода /event_attachment.
+type AttachmentGet struct {
+	Service service.Attachment
+}
+
+// ServeHTTP записывает в w содержимое вложения как файл для скачивания.
+func (h AttachmentGet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		WriteError(w, http.StatusNotImplemented, ErrAttachmentsDisabled)
+		return
+	}
+	query := r.URL.Query()
+
+	attachment, body, err := h.Service.Download(r.Context(), query.Get("user_id"), query.Get("event_id"), query.Get("id"))
+	if err != nil {
+		handleAttachmentError(w, err)
+		return
+	}
+	defer body.Close()
+
+	header := w.Header()
+	header.Set("Content-Type", attachment.ContentType)
+	header.Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	// Браузер не должен исполнять загруженное пользователем содержимое.
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Content-Security-Policy", "sandbox")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, body)
+}
+
+// Структура HTTP-обработчика для метода /event_attachments.
+type AttachmentList struct {
+	Service service.Attachment
+}
+
+// ServeHTTP обрабатывает запрос r и записывает ответ в w.
+func (h AttachmentList) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		WriteError(w, http.StatusNotImplemented, ErrAttachmentsDisabled)
+		return
+	}
+	query := r.URL.Query()
+
+	attachments, err := h.Service.List(r.Context(), query.Get("user_id"), query.Get("event_id"))
+	if err != nil {
+		handleAttachmentError(w, err)
+		return
+	}
+
+	WriteResult(w, http.StatusOK, attachments)
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"dev11/app/entity"
+	"dev11/app/service"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestAttachmentGet_ServeHTTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	attachment := entity.Attachment{ID: "3", EventID: "2", UserID: "1", Name: "отчет.html", ContentType: "text/html; charset=utf-8", Size: 4}
+	s := service.NewMockAttachment(ctrl)
+	s.EXPECT().Download(gomock.Any(), "1", "2", "3").Return(attachment, io.NopCloser(strings.NewReader("<p/>")), nil)
+
+	w := httptest.NewRecorder()
+	AttachmentGet{Service: s}.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/event_attachment?user_id=1&event_id=2&id=3", nil))
+
+	want := map[string]string{
+		"Content-Type":           attachment.ContentType,
+		"Content-Length":         "4",
+		"Content-Disposition":    "attachment; filename*=utf-8''%D0%BE%D1%82%D1%87%D0%B5%D1%82.html",
+		"X-Content-Type-Options": "nosniff",
+	}
+	for k, v := range want {
+		if got := w.Header().Get(k); got != v {
+			t.Errorf("AttachmentGet.ServeHTTP() header %s = %q, want %q", k, got, v)
+		}
+	}
+	if w.Code != http.StatusOK || w.Body.String() != "<p/>" {
+		t.Errorf("AttachmentGet.ServeHTTP() = %v %q, want %v %q", w.Code, w.Body, http.StatusOK, "<p/>")
+	}
+}
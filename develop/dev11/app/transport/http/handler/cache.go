@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"bytes"
+	"dev11/app/entity"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Значение заголовка Cache-Control для списков событий: ответы зависят от пользователя
+// и должны проверяться на сервере перед каждым использованием.
+const eventsCacheControl = "private, no-cache"
+
+// WriteEvents записывает в w список событий events с кодом 200 и заголовками
+// Cache-Control, ETag и Last-Modified. Если версия списка у клиента из условного
+// запроса r актуальна, записывает ответ с кодом 304 без тела.
+//
+// Last-Modified равен наибольшему UpdatedAt событий списка. Удаление события
+// или его перенос за пределы списка не меняют UpdatedAt остальных событий,
+// поэтому дополнительно передается слабый ETag тела ответа, который при проверке
+// имеет приоритет над Last-Modified.
+func WriteEvents(w http.ResponseWriter, r *http.Request, events []entity.Event) {
+	var body bytes.Buffer
+	json.NewEncoder(&body).Encode(map[string]any{"result": NewEventViews(events)})
+	hash := fnv.New64a()
+	hash.Write(body.Bytes())
+	etag := fmt.Sprintf(`W/"%016x"`, hash.Sum64())
+	modified := lastModified(events)
+
+	h := w.Header()
+	h.Set("Cache-Control", eventsCacheControl)
+	h.Set("ETag", etag)
+	if !modified.IsZero() {
+		h.Set("Last-Modified", modified.Format(http.TimeFormat))
+	}
+	if notModified(r, etag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	body.WriteTo(w)
+}
+
+// lastModified возвращает наибольшее UpdatedAt событий events с точностью до секунды
+// или нулевое время, если оно не задано ни у одного события.
+func lastModified(events []entity.Event) time.Time {
+	var t time.Time
+	for _, event := range events {
+		if event.UpdatedAt.After(t) {
+			t = event.UpdatedAt
+		}
+	}
+	return t.UTC().Truncate(time.Second)
+}
+
+// notModified сообщает, актуальна ли версия ответа у клиента по заголовкам
+// If-None-Match или, если он не задан, If-Modified-Since запроса r.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		return etagMatch(match, etag)
+	}
+	if since := r.Header.Get("If-Modified-Since"); since != "" && !modified.IsZero() {
+		t, err := http.ParseTime(since)
+		return err == nil && !modified.After(t)
+	}
+	return false
+}
+
+// etagMatch сравнивает список тегов match из If-None-Match с тегом etag
+// по правилам слабого сравнения.
+func etagMatch(match, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(match, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"dev11/app/entity"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWriteEvents(t *testing.T) {
+	updated := time.Date(2010, 5, 17, 12, 0, 0, 500, time.UTC)
+	events := []entity.Event{
+		{ID: "a", Title: "a", UpdatedAt: updated.Add(-time.Hour)},
+		{ID: "b", Title: "b", UpdatedAt: updated},
+	}
+	lastModified := "Mon, 17 May 2010 12:00:00 GMT"
+
+	rec := httptest.NewRecorder()
+	WriteEvents(rec, httptest.NewRequest(http.MethodGet, "/", nil), events)
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" || rec.Header().Get("Last-Modified") != lastModified ||
+		rec.Header().Get("Cache-Control") != eventsCacheControl {
+		t.Fatalf("WriteEvents() = %v %v", rec.Code, rec.Header())
+	}
+
+	tests := []struct {
+		name   string
+		events []entity.Event
+		header http.Header
+		want   int
+	}{
+		{"ETagMatch", events, http.Header{"If-None-Match": {`"x", ` + etag}}, http.StatusNotModified},
+		{"ETagStrongMatch", events, http.Header{"If-None-Match": {etag[2:]}}, http.StatusNotModified},
+		{"ETagAny", events, http.Header{"If-None-Match": {"*"}}, http.StatusNotModified},
+		{"ETagMismatch", events, http.Header{"If-None-Match": {`W/"x"`}}, http.StatusOK},
+		{"EventDeleted", events[1:], http.Header{"If-None-Match": {etag}, "If-Modified-Since": {lastModified}}, http.StatusOK},
+		{"NotModifiedSince", events, http.Header{"If-Modified-Since": {lastModified}}, http.StatusNotModified},
+		{"ModifiedSince", events, http.Header{"If-Modified-Since": {"Mon, 17 May 2010 11:59:59 GMT"}}, http.StatusOK},
+		{"InvalidModifiedSince", events, http.Header{"If-Modified-Since": {"yesterday"}}, http.StatusOK},
+		{"NoUpdatedAt", []entity.Event{{ID: "a"}}, http.Header{"If-Modified-Since": {lastModified}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = tt.header
+			w := httptest.NewRecorder()
+			WriteEvents(w, r, tt.events)
+
+			if w.Code != tt.want {
+				t.Errorf("WriteEvents() code = %v, want %v", w.Code, tt.want)
+			}
+			if w.Code == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("WriteEvents() 304 body = %q, want empty", w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"dev11/app/eventcsv"
+	"dev11/app/service"
+	"dev11/app/workcal"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Максимальный размер импортируемого CSV-файла в байтах.
+const MaxImportSize = 10 << 20
+
+// Конец диапазона выгрузки по умолчанию.
+var maxExportDate = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+
+// Ошибки импорта и выгрузки CSV.
+var (
+	ErrInvalidDelimiter = errors.New("delimiter must be a single character")
+	ErrImportAborted    = errors.New("internal error, the import was stopped")
+)
+
+// parseCSVOptions возвращает параметры CSV из значений values: разделитель delimiter,
+// повторяющийся формат дат date_format, часовой пояс tz и заголовки колонок column_<поле>.
+func parseCSVOptions(values url.Values) (eventcsv.Options, error) {
+	var opts eventcsv.Options
+	if delimiter := values.Get("delimiter"); delimiter != "" {
+		r, size := utf8.DecodeRuneInString(delimiter)
+		if size != len(delimiter) {
+			return opts, ErrInvalidDelimiter
+		}
+		opts.Delimiter = r
+	}
+	opts.DateFormats = values["date_format"]
+
+	var err error
+	if opts.Location, err = time.LoadLocation(values.Get("tz")); err != nil {
+		return opts, err
+	}
+
+	for _, field := range []string{eventcsv.FieldID, eventcsv.FieldTitle, eventcsv.FieldDescription, eventcsv.FieldDate, eventcsv.FieldEnd, eventcsv.FieldTags} {
+		if column := values.Get("column_" + field); column != "" {
+			if opts.Columns == nil {
+				opts.Columns = make(map[string]string)
+			}
+			opts.Columns[field] = column
+		}
+	}
+	return opts, nil
+}
+
+// Структура HTTP-обработчика для метода /export.csv.
+type EventExportCSV struct {
+	Service service.Event
+}
+
+// ServeHTTP записывает в w события пользователя в диапазоне [start, end] как CSV-файл.
+// По умолчанию выгружаются все события пользователя.
+func (h EventExportCSV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	opts, err := parseCSVOptions(query)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	start, end := time.Time{}, maxExportDate
+	if value := query.Get("start"); value != "" {
+		if start, err = time.Parse(time.RFC3339, value); err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if value := query.Get("end"); value != "" {
+		if end, err = time.Parse(time.RFC3339, value); err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	// Параметры проверяются до записи заголовков, так как CSV записывается в w по мере формирования.
+	if err := opts.Validate(); err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	events, err := h.Service.GetForRange(r.Context(), query.Get("user_id"), start, end)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/csv; charset=utf-8")
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "events.csv"}))
+	w.WriteHeader(http.StatusOK)
+	// Ошибка записи означает разрыв соединения, ответ уже начат.
+	eventcsv.Write(w, events, opts)
+}
+
+// Структура ошибки строки импорта.
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// Структура отчета об импорте событий.
+type ImportReport struct {
+	DryRun bool `json:"dry_run"`
+	// Total задает количество непустых строк файла без заголовка.
+	Total int `json:"total"`
+	// Events содержит созданные события, а при DryRun — события, прошедшие проверку.
+	Events []EventView   `json:"events"`
+	Errors []ImportError `json:"errors"`
+	// Aborted сообщает, что импорт остановлен внутренней ошибкой на строке последней ошибки,
+	// а следующие строки не обработаны.
+	Aborted bool `json:"aborted,omitempty"`
+}
+
+// Структура HTTP-обработчика для метода /import_csv. Если в графиках Schedules заданы
+// производственные календари, события в праздники получают предупреждения.
+type EventImportCSV struct {
+	Service   service.Event
+	Schedules *workcal.Schedules
+	// Logger записывает внутренние ошибки, остановившие импорт. Может быть nil.
+	Logger *slog.Logger
+}
+
+// ServeHTTP создает события пользователя user_id из CSV-файла file в формате multipart/form-data
+// и записывает в w отчет с ошибками отдельных строк. Строки с ошибками пропускаются.
+// С параметром dry_run=true события только проверяются и не создаются.
+//
+// Внутренняя ошибка останавливает импорт, но отчет с уже созданными событиями все равно
+// возвращается с кодом 200: ответ сохраняется по ключу идемпотентности, и повтор запроса
+// не создает эти события еще раз.
+func (h EventImportCSV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportSize+MultipartOverhead)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		WriteRequestError(w, err)
+		return
+	}
+
+	opts, err := parseCSVOptions(r.Form)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	report := ImportReport{Events: []EventView{}, Errors: []ImportError{}}
+	if value := r.FormValue("dry_run"); value != "" {
+		if report.DryRun, err = strconv.ParseBool(value); err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, ErrFileMissing)
+		return
+	}
+	defer file.Close()
+
+	rows, err := eventcsv.Read(file, opts)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	userID := r.FormValue("user_id")
+	report.Total = len(rows)
+	for _, row := range rows {
+		event, err := row.Event, row.Err
+		event.UserID = userID
+		if err == nil {
+			err = event.ValidateCreate()
+		}
+		if err == nil && !report.DryRun {
+			event, err = h.Service.Create(r.Context(), event)
+			// Внутренние ошибки прерывают импорт, ошибки бизнес-логики попадают в отчет.
+			var externalErr *service.ExternalError
+			if errors.As(err, &externalErr) {
+				err = externalErr.Err
+			} else if err != nil {
+				if h.Logger != nil {
+					h.Logger.Error(r.RemoteAddr, "err", err, "line", row.Line)
+				}
+				report.Errors = append(report.Errors, ImportError{Line: row.Line, Error: ErrImportAborted.Error()})
+				report.Aborted = true
+				break
+			}
+		}
+		if err != nil {
+			report.Errors = append(report.Errors, ImportError{Line: row.Line, Error: err.Error()})
+			continue
+		}
+		view := NewEventView(event)
+		view.Warnings = append(row.Warnings, h.Schedules.Warnings(event.UserID, event.Date)...)
+		report.Events = append(report.Events, view)
+	}
+
+	WriteResult(w, http.StatusOK, report)
+}
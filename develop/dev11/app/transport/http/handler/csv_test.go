@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"bytes"
+	"dev11/app/entity"
+	"dev11/app/service"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestEventExportCSV_ServeHTTP(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	events := []entity.Event{{ID: "1", Title: "Standup", Date: time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC), UserID: userID}}
+	start := time.Date(2010, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		prepare func(s *service.MockEvent)
+		query   url.Values
+		want    int
+		body    string
+	}{
+		{"All", func(s *service.MockEvent) {
+			s.EXPECT().GetForRange(gomock.Any(), userID, time.Time{}, maxExportDate).Return(events, nil)
+		}, url.Values{"user_id": {userID}}, http.StatusOK, "id,title,description,date\n1,Standup,,2010-05-17T10:00:00Z\n"},
+		{"Options", func(s *service.MockEvent) {
+			s.EXPECT().GetForRange(gomock.Any(), userID, start, start.AddDate(0, 1, 0)).Return(events, nil)
+		}, url.Values{"user_id": {userID}, "start": {"2010-05-01T00:00:00Z"}, "end": {"2010-06-01T00:00:00Z"},
+			"delimiter": {";"}, "date_format": {"02.01.2006 15:04"}, "tz": {"Europe/Moscow"}, "column_title": {"Событие"}},
+			http.StatusOK, "id;Событие;description;date\n1;Standup;;17.05.2010 14:00\n"},
+		{"InvalidDelimiter", func(s *service.MockEvent) {}, url.Values{"delimiter": {";;"}}, http.StatusBadRequest, ""},
+		{"QuoteDelimiter", func(s *service.MockEvent) {}, url.Values{"delimiter": {`"`}}, http.StatusBadRequest, ""},
+		{"InvalidTimeZone", func(s *service.MockEvent) {}, url.Values{"tz": {"Mars/Olympus"}}, http.StatusBadRequest, ""},
+		{"InvalidStart", func(s *service.MockEvent) {}, url.Values{"start": {"2010-05-01"}}, http.StatusBadRequest, ""},
+		{"ServiceError", func(s *service.MockEvent) {
+			s.EXPECT().GetForRange(gomock.Any(), userID, start, maxExportDate).Return(nil, service.ErrInvalidRange)
+		}, url.Values{"user_id": {userID}, "start": {"2010-05-01T00:00:00Z"}}, http.StatusServiceUnavailable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := service.NewMockEvent(ctrl)
+			tt.prepare(s)
+			w := httptest.NewRecorder()
+			EventExportCSV{Service: s}.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export.csv?"+tt.query.Encode(), nil))
+
+			if w.Code != tt.want {
+				t.Fatalf("EventExportCSV.ServeHTTP() code = %v, want %v: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.body == "" {
+				return
+			}
+			if got := w.Body.String(); got != tt.body {
+				t.Errorf("EventExportCSV.ServeHTTP() body = %q, want %q", got, tt.body)
+			}
+			if got := w.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+				t.Errorf("EventExportCSV.ServeHTTP() Content-Type = %q", got)
+			}
+		})
+	}
+}
+
+// newImportRequest возвращает запрос /import_csv с полями fields и файлом data.
+func newImportRequest(fields url.Values, data string) *http.Request {
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	for k, values := range fields {
+		for _, v := range values {
+			mw.WriteField(k, v)
+		}
+	}
+	if data != "" {
+		fw, _ := mw.CreateFormFile("file", "events.csv")
+		fw.Write([]byte(data))
+	}
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/import_csv", &b)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestEventImportCSV_ServeHTTP(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC)
+	data := "Событие;Начало\nStandup;17.05.2010 14:00\n;17.05.2010 14:00\nReview;tomorrow\nPlanning;17.05.2010 14:00\n"
+	fields := url.Values{"user_id": {userID}, "delimiter": {";"}, "date_format": {"02.01.2006 15:04"}, "tz": {"Europe/Moscow"},
+		"column_title": {"Событие"}, "column_date": {"Начало"}}
+	with := func(k string, v string) url.Values {
+		values := url.Values{k: {v}}
+		for k, v := range fields {
+			if _, ok := values[k]; !ok {
+				values[k] = v
+			}
+		}
+		return values
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *service.MockEvent)
+		r       *http.Request
+		want    int
+		report  ImportReport
+	}{
+		{"Import", func(s *service.MockEvent) {
+			s.EXPECT().Create(gomock.Any(), entity.Event{Title: "Standup", Date: date, UserID: userID}).
+				Return(entity.Event{ID: "1", Title: "Standup", Date: date, UserID: userID}, nil)
+			s.EXPECT().Create(gomock.Any(), entity.Event{Title: "Planning", Date: date, UserID: userID}).
+				Return(entity.EmptyEvent, &service.ExternalError{Err: service.ErrQuotaExceeded})
+		}, newImportRequest(fields, data), http.StatusOK, ImportReport{
+			Total:  4,
+			Events: []EventView{{Event: entity.Event{ID: "1", Title: "Standup", Date: date, UserID: userID}}},
+			Errors: []ImportError{
+				{Line: 3, Error: "title: title is empty"},
+				{Line: 4, Error: `Начало: date does not match any format: "tomorrow"`},
+				{Line: 5, Error: service.ErrQuotaExceeded.Error()},
+			},
+		}},
+		{"DryRun", func(s *service.MockEvent) {}, newImportRequest(with("dry_run", "true"), data), http.StatusOK, ImportReport{
+			DryRun: true,
+			Total:  4,
+			Events: []EventView{
+				{Event: entity.Event{Title: "Standup", Date: date, UserID: userID}},
+				{Event: entity.Event{Title: "Planning", Date: date, UserID: userID}},
+			},
+			Errors: []ImportError{
+				{Line: 3, Error: "title: title is empty"},
+				{Line: 4, Error: `Начало: date does not match any format: "tomorrow"`},
+			},
+		}},
+		{"Warnings", func(s *service.MockEvent) {
+			s.EXPECT().Create(gomock.Any(), entity.Event{Title: "A", Date: date, UserID: userID}).
+				Return(entity.Event{ID: "1", Title: "A", Date: date, UserID: userID}, nil)
+		}, newImportRequest(url.Values{"user_id": {userID}}, "title,date,end,tags\nA,2010-05-17T10:00:00Z,2010-05-17T11:00:00Z,work\n"),
+			http.StatusOK, ImportReport{Total: 1, Events: []EventView{{
+				Event:    entity.Event{ID: "1", Title: "A", Date: date, UserID: userID},
+				Warnings: []string{"end: not stored, events have only a start date", "tags: not stored, events have no tags"},
+			}}, Errors: []ImportError{}}},
+		{"InternalError", func(s *service.MockEvent) {
+			s.EXPECT().Create(gomock.Any(), entity.Event{Title: "Standup", Date: date, UserID: userID}).
+				Return(entity.Event{ID: "1", Title: "Standup", Date: date, UserID: userID}, nil)
+			s.EXPECT().Create(gomock.Any(), entity.Event{Title: "Planning", Date: date, UserID: userID}).
+				Return(entity.EmptyEvent, &service.InternalError{Err: errors.New("repo")})
+		}, newImportRequest(fields, data+"Retro;17.05.2010 14:00\n"), http.StatusOK, ImportReport{
+			Total:  5,
+			Events: []EventView{{Event: entity.Event{ID: "1", Title: "Standup", Date: date, UserID: userID}}},
+			Errors: []ImportError{
+				{Line: 3, Error: "title: title is empty"},
+				{Line: 4, Error: `Начало: date does not match any format: "tomorrow"`},
+				{Line: 5, Error: ErrImportAborted.Error()},
+			},
+			Aborted: true,
+		}},
+		{"DryRunInvalidUser", func(s *service.MockEvent) {}, newImportRequest(url.Values{"dry_run": {"1"}}, "title,date\nA,2010-05-17\n"),
+			http.StatusOK, ImportReport{DryRun: true, Total: 1, Events: []EventView{}, Errors: []ImportError{{Line: 2, Error: "user_id: id is invalid"}}}},
+		{"MissingColumn", func(s *service.MockEvent) {}, newImportRequest(fields, "title,date\nA,2010-05-17\n"), http.StatusBadRequest, ImportReport{}},
+		{"MissingFile", func(s *service.MockEvent) {}, newImportRequest(fields, ""), http.StatusBadRequest, ImportReport{}},
+		{"InvalidDryRun", func(s *service.MockEvent) {}, newImportRequest(with("dry_run", "maybe"), data), http.StatusBadRequest, ImportReport{}},
+		{"TooLarge", func(s *service.MockEvent) {}, newImportRequest(fields, strings.Repeat("a", MaxImportSize+MultipartOverhead)), http.StatusRequestEntityTooLarge, ImportReport{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := service.NewMockEvent(ctrl)
+			tt.prepare(s)
+			w := httptest.NewRecorder()
+			EventImportCSV{Service: s}.ServeHTTP(w, tt.r)
+
+			if w.Code != tt.want {
+				t.Fatalf("EventImportCSV.ServeHTTP() code = %v, want %v: %s", w.Code, tt.want, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var body struct{ Result ImportReport }
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(body.Result, tt.report) {
+				t.Errorf("EventImportCSV.ServeHTTP() = %+v, want %+v", body.Result, tt.report)
+			}
+		})
+	}
+}
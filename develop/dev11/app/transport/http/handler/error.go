@@ -34,6 +34,17 @@ func WriteError(w http.ResponseWriter, code int, err error) {
 	})
 }
 
+// WriteRequestError записывает ошибку разбора запроса err в w: превышение размера тела
+// запроса с кодом 413, остальные ошибки с кодом 400.
+func WriteRequestError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		WriteError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	WriteError(w, http.StatusBadRequest, err)
+}
+
 // HandleServiceError обрабатывает ошибку сервиса err и записывает в w, если она внешняя,
 // иначе вызывает панику для обработки промежуточным слоем.
 func HandleServiceError(w http.ResponseWriter, err error) {
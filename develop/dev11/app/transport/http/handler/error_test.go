@@ -82,6 +82,28 @@ func TestWriteError(t *testing.T) {
 	})
 }
 
+func TestWriteRequestError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{"TooLarge", fmt.Errorf("read: %w", &http.MaxBytesError{Limit: 1}), http.StatusRequestEntityTooLarge},
+		{"Invalid", fmt.Errorf("test"), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			WriteRequestError(w, tt.err)
+
+			if got := w.Code; got != tt.wantCode {
+				t.Errorf("WriteRequestError() code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
 func TestHandleServiceError(t *testing.T) {
 	t.Run("NilError", func(t *testing.T) {
 		HandleServiceError(nil, nil)
@@ -2,11 +2,18 @@ package handler
 
 import (
 	"dev11/app/entity"
+	"dev11/app/markdown"
 	"dev11/app/service"
+	"dev11/app/workcal"
 	"net/http"
+	"slices"
+	"strconv"
 	"time"
 )
 
+// Количество предстоящих событий, возвращаемых методом /upcoming_events по умолчанию.
+const DefaultUpcomingLimit = 10
+
 // ParseFormEvent парсит Event, переданный в виде www-url-form-encoded,
 // возвращает ошибку, если данные нелья распарсить.
 func ParseFormEvent(r *http.Request) (entity.Event, error) {
@@ -21,26 +28,61 @@ func ParseFormEvent(r *http.Request) (entity.Event, error) {
 	}
 
 	return entity.Event{
-		ID:          r.FormValue("id"),
-		Title:       r.FormValue("title"),
-		Description: r.FormValue("description"),
-		Date:        date,
-		UserID:      r.FormValue("user_id"),
+		ID:                r.FormValue("id"),
+		Title:             r.FormValue("title"),
+		Description:       r.FormValue("description"),
+		DescriptionFormat: r.FormValue("description_format"),
+		Date:              date,
+		UserID:            r.FormValue("user_id"),
 	}, nil
 }
 
-// Структура HTTP-обработчика для метода /create_event.
+// Структура представления Event в ответе. Описание в формате Markdown
+// дополнительно выводится в виде безопасного HTML.
+type EventView struct {
+	entity.Event
+	DescriptionHTML string `json:"description_html,omitempty"`
+	// Warnings содержит предупреждения о созданном событии, которые не мешают его созданию.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// NewEventView возвращает представление события event.
+func NewEventView(event entity.Event) EventView {
+	view := EventView{Event: event}
+	if event.DescriptionFormat == entity.FormatMarkdown {
+		view.DescriptionHTML = markdown.ToHTML(event.Description)
+	}
+	return view
+}
+
+// NewEventViews возвращает представления событий events.
+func NewEventViews(events []entity.Event) []EventView {
+	if events == nil {
+		return nil
+	}
+	views := make([]EventView, len(events))
+	for i, event := range events {
+		views[i] = NewEventView(event)
+	}
+	return views
+}
+
+// Структура HTTP-обработчика для метода /create_event. Если в графиках Schedules заданы
+// производственные календари, событие в праздник создается с предупреждением.
 type EventCreate struct {
-	Service service.Event
+	Service   service.Event
+	Schedules *workcal.Schedules
 }
 
 // ServeHTTP обрабатывает запрос r и записывает ответ в w.
 func (h EventCreate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	event, err := ParseFormEvent(r)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, err)
+		WriteRequestError(w, err)
 		return
 	}
+	// Идентификатор нового события генерирует репозиторий.
+	event.ID = ""
 
 	event, err = h.Service.Create(r.Context(), event)
 	if err != nil {
@@ -48,7 +90,9 @@ func (h EventCreate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	WriteResult(w, http.StatusCreated, event)
+	view := NewEventView(event)
+	view.Warnings = h.Schedules.Warnings(event.UserID, event.Date)
+	WriteResult(w, http.StatusCreated, view)
 }
 
 // Структура HTTP-обработчика для метода /update_event.
@@ -60,7 +104,7 @@ type EventUpdate struct {
 func (h EventUpdate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	event, err := ParseFormEvent(r)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, err)
+		WriteRequestError(w, err)
 		return
 	}
 
@@ -70,7 +114,7 @@ func (h EventUpdate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	WriteResult(w, http.StatusOK, event)
+	WriteResult(w, http.StatusOK, NewEventView(event))
 }
 
 // Структура HTTP-обработчика для метода /delete_event.
@@ -81,7 +125,7 @@ type EventDelete struct {
 // ServeHTTP обрабатывает запрос r и записывает ответ в w.
 func (h EventDelete) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
-		WriteError(w, http.StatusBadRequest, err)
+		WriteRequestError(w, err)
 		return
 	}
 
@@ -94,6 +138,53 @@ func (h EventDelete) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	WriteResult(w, http.StatusNoContent, nil)
 }
 
+// Структура HTTP-обработчика для метода /event.
+type EventGet struct {
+	Service service.Event
+}
+
+// ServeHTTP обрабатывает запрос r и записывает ответ в w.
+func (h EventGet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	event, err := h.Service.GetByID(r.Context(), query.Get("user_id"), query.Get("id"))
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	WriteResult(w, http.StatusOK, NewEventView(event))
+}
+
+// Структура HTTP-обработчика для метода /events_for_range.
+type EventGetForRange struct {
+	Service service.Event
+}
+
+// ServeHTTP обрабатывает запрос r и записывает ответ в w.
+func (h EventGetForRange) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	start, err := time.Parse(time.RFC3339, query.Get("start"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	end, err := time.Parse(time.RFC3339, query.Get("end"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	events, err := h.Service.GetForRange(r.Context(), query.Get("user_id"), start, end)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	WriteEvents(w, r, events)
+}
+
 // Структура HTTP-обработчика для метода /events_for_day.
 type EventGetForDay struct {
 	Service service.Event
@@ -116,12 +207,14 @@ func (h EventGetForDay) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	WriteResult(w, http.StatusOK, events)
+	WriteEvents(w, r, events)
 }
 
-// Структура HTTP-обработчика для метода /events_for_week.
+// Структура HTTP-обработчика для метода /events_for_week. С параметром business_days=true
+// возвращаются только события рабочих дней графика пользователя из Schedules.
 type EventGetForWeek struct {
-	Service service.Event
+	Service   service.Event
+	Schedules *workcal.Schedules
 }
 
 // ServeHTTP обрабатывает запрос r и записывает ответ в w.
@@ -135,13 +228,26 @@ func (h EventGetForWeek) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	events, err := h.Service.GetForWeek(r.Context(), query.Get("user_id"), week)
+	businessDays := false
+	if value := query.Get("business_days"); value != "" {
+		if businessDays, err = strconv.ParseBool(value); err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	userID := query.Get("user_id")
+	events, err := h.Service.GetForWeek(r.Context(), userID, week)
 	if err != nil {
 		HandleServiceError(w, err)
 		return
 	}
 
-	WriteResult(w, http.StatusOK, events)
+	if businessDays {
+		schedule := h.Schedules.For(userID)
+		events = slices.DeleteFunc(slices.Clone(events), func(event entity.Event) bool { return !schedule.IsBusinessDay(event.Date) })
+	}
+	WriteEvents(w, r, events)
 }
 
 // Структура HTTP-обработчика для метода /events_for_month.
@@ -166,5 +272,66 @@ func (h EventGetForMonth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	WriteResult(w, http.StatusOK, events)
+	WriteEvents(w, r, events)
+}
+
+// Структура HTTP-обработчика для метода /search_events.
+type EventSearch struct {
+	Service service.Event
+}
+
+// ServeHTTP обрабатывает запрос r и записывает ответ в w.
+func (h EventSearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	events, err := h.Service.Search(r.Context(), query.Get("user_id"), query.Get("q"))
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	WriteEvents(w, r, events)
+}
+
+// Структура HTTP-обработчика для метода /upcoming_events.
+type EventGetUpcoming struct {
+	Service service.Event
+}
+
+// ServeHTTP обрабатывает запрос r и записывает ответ в w.
+func (h EventGetUpcoming) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := DefaultUpcomingLimit
+	if limitValue := query.Get("limit"); limitValue != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitValue); err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	events, err := h.Service.GetUpcoming(r.Context(), query.Get("user_id"), limit)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	WriteEvents(w, r, events)
+}
+
+// Структура HTTP-обработчика для метода /events_for_today.
+type EventGetForToday struct {
+	Service service.Event
+}
+
+// ServeHTTP обрабатывает запрос r и записывает ответ в w.
+func (h EventGetForToday) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	events, err := h.Service.GetForToday(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	WriteEvents(w, r, events)
 }
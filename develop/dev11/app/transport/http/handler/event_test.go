@@ -3,6 +3,7 @@ package handler
 import (
 	"dev11/app/entity"
 	"dev11/app/service"
+	"dev11/app/workcal"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -106,7 +107,7 @@ func TestEventCreate_ServeHTTP(t *testing.T) {
 
 			service := service.NewMockEvent(ctrl)
 			tt.prepare(service)
-			h := EventCreate{service}
+			h := EventCreate{Service: service}
 
 			w := httptest.NewRecorder()
 			h.ServeHTTP(w, tt.r())
@@ -118,6 +119,45 @@ func TestEventCreate_ServeHTTP(t *testing.T) {
 	}
 }
 
+func TestEventCreate_HolidayWarning(t *testing.T) {
+	holidays := workcal.NewCalendar()
+	holidays.AddHoliday(time.Date(2010, 5, 20, 0, 0, 0, 0, time.UTC), "Ascension Day")
+	schedules := &workcal.Schedules{Default: workcal.Schedule{Calendar: holidays}}
+
+	tests := []struct {
+		name      string
+		schedules *workcal.Schedules
+		date      string
+		want      string
+	}{
+		{"Holiday", schedules, "2010-05-20T16:00:00Z", `"warnings":["2010-05-20 is a holiday: Ascension Day"]`},
+		{"Workday", schedules, "2010-05-21T16:00:00Z", ""},
+		{"NoCalendar", nil, "2010-05-20T16:00:00Z", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			date, _ := time.Parse(time.RFC3339, tt.date)
+			service := service.NewMockEvent(ctrl)
+			service.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.Event{ID: "1", Title: "0", Date: date, UserID: "0"}, nil)
+			h := EventCreate{Service: service, Schedules: tt.schedules}
+
+			data := url.Values{"title": {"0"}, "date": {tt.date}, "user_id": {"0"}}
+			r := httptest.NewRequest("POST", "/", strings.NewReader(data.Encode()))
+			r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			body := w.Body.String()
+			if w.Code != http.StatusCreated || (tt.want == "") == strings.Contains(body, "warnings") || !strings.Contains(body, tt.want) {
+				t.Errorf("EventCreate.ServeHTTP() = %v %s, want warnings %s", w.Code, body, tt.want)
+			}
+		})
+	}
+}
+
 func TestEventUpdate_ServeHTTP(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -244,6 +284,76 @@ func TestEventDelete_ServeHTTP(t *testing.T) {
 	}
 }
 
+func TestEventGet_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(s *service.MockEvent)
+		want    int
+	}{
+		{"ServiceError", func(s *service.MockEvent) {
+			s.EXPECT().GetByID(gomock.Any(), gomock.Eq("0"), gomock.Eq("1")).Return(entity.EmptyEvent, &service.ExternalError{})
+		}, http.StatusServiceUnavailable},
+		{"ValidForm", func(s *service.MockEvent) {
+			s.EXPECT().GetByID(gomock.Any(), gomock.Eq("0"), gomock.Eq("1")).Return(entity.Event{ID: "1", UserID: "0"}, nil)
+		}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := service.NewMockEvent(ctrl)
+			tt.prepare(service)
+			h := EventGet{service}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/?user_id=0&id=1", nil))
+
+			if got := w.Code; got != tt.want {
+				t.Errorf("EventGet.ServeHTTP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventGetForRange_ServeHTTP(t *testing.T) {
+	start := time.Date(2010, 5, 17, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2010, 5, 24, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		prepare func(s *service.MockEvent)
+		target  string
+		want    int
+	}{
+		{"InvalidStart", func(s *service.MockEvent) {}, "/?start=2010-05-17&end=2010-05-24T00:00:00Z", http.StatusBadRequest},
+		{"InvalidEnd", func(s *service.MockEvent) {}, "/?start=2010-05-17T00:00:00Z", http.StatusBadRequest},
+		{"ServiceError", func(s *service.MockEvent) {
+			s.EXPECT().GetForRange(gomock.Any(), gomock.Eq("0"), end, start).Return(nil, service.ErrInvalidRange)
+		}, "/?user_id=0&start=2010-05-24T00:00:00Z&end=2010-05-17T00:00:00Z", http.StatusServiceUnavailable},
+		{"ValidForm", func(s *service.MockEvent) {
+			s.EXPECT().GetForRange(gomock.Any(), gomock.Eq("0"), start, end).Return([]entity.Event{}, nil)
+		}, "/?user_id=0&start=2010-05-17T00:00:00Z&end=2010-05-24T00:00:00Z", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := service.NewMockEvent(ctrl)
+			tt.prepare(service)
+			h := EventGetForRange{service}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+
+			if got := w.Code; got != tt.want {
+				t.Errorf("EventGetForRange.ServeHTTP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestEventGetForDay_ServeHTTP(t *testing.T) {
 	const layout = "2006-01-02"
 	day, _ := time.Parse(layout, "2010-05-20")
@@ -366,7 +476,7 @@ func TestEventGetForWeek_ServeHTTP(t *testing.T) {
 
 			service := service.NewMockEvent(ctrl)
 			tt.prepare(service)
-			h := EventGetForWeek{service}
+			h := EventGetForWeek{Service: service}
 
 			w := httptest.NewRecorder()
 			h.ServeHTTP(w, tt.r())
@@ -378,6 +488,65 @@ func TestEventGetForWeek_ServeHTTP(t *testing.T) {
 	}
 }
 
+func TestEventGetForWeek_BusinessDays(t *testing.T) {
+	monday := time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC)
+	events := []entity.Event{
+		{ID: "monday", Date: monday},
+		{ID: "thursday", Date: monday.AddDate(0, 0, 3)},
+		{ID: "saturday", Date: monday.AddDate(0, 0, 5)},
+	}
+	holidays := workcal.NewCalendar()
+	holidays.AddHoliday(monday.AddDate(0, 0, 3), "Ascension Day")
+	holidays.AddWorkday(monday.AddDate(0, 0, 5))
+	schedules := &workcal.Schedules{Default: workcal.Schedule{Weekdays: workcal.DefaultSchedule.Weekdays, Calendar: holidays}}
+
+	tests := []struct {
+		name      string
+		schedules *workcal.Schedules
+		query     string
+		want      []string
+	}{
+		{"AllDays", schedules, "", []string{"monday", "thursday", "saturday"}},
+		{"DefaultSchedule", nil, "&business_days=true", []string{"monday", "thursday"}},
+		{"Calendar", schedules, "&business_days=1", []string{"monday", "saturday"}},
+		{"Invalid", schedules, "&business_days=yes", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := service.NewMockEvent(ctrl)
+			if tt.want != nil {
+				service.EXPECT().GetForWeek(gomock.Any(), "0", gomock.Any()).Return(events, nil)
+			}
+			h := EventGetForWeek{Service: service, Schedules: tt.schedules}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/?user_id=0&week=2010-05-17"+tt.query, nil))
+
+			if tt.want == nil {
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("EventGetForWeek.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+				}
+				return
+			}
+			var got []string
+			for _, id := range []string{"monday", "thursday", "saturday"} {
+				if strings.Contains(w.Body.String(), `"id":"`+id+`"`) {
+					got = append(got, id)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EventGetForWeek.ServeHTTP() events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if len(events) != 3 {
+		t.Errorf("EventGetForWeek.ServeHTTP() modified service result: %v", events)
+	}
+}
+
 func TestEventGetForMonth_ServeHTTP(t *testing.T) {
 	const layout = "2006-01"
 	month, _ := time.Parse(layout, "2010-05")
@@ -444,3 +613,144 @@ func TestEventGetForMonth_ServeHTTP(t *testing.T) {
 		})
 	}
 }
+
+func TestEventSearch_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(s *service.MockEvent)
+		r       func() *http.Request
+		want    int
+	}{
+		{
+			"ServiceError",
+			func(s *service.MockEvent) {
+				s.EXPECT().Search(gomock.Any(), gomock.Any(), gomock.Eq("")).Return(nil, &service.ExternalError{})
+			},
+			func() *http.Request {
+				r := httptest.NewRequest("GET", "/", nil)
+				return r
+			},
+			http.StatusServiceUnavailable,
+		},
+		{
+			"ValidForm",
+			func(s *service.MockEvent) {
+				s.EXPECT().Search(gomock.Any(), gomock.Eq("0"), gomock.Eq(`"code review"`)).Return([]entity.Event{}, nil)
+			},
+			func() *http.Request {
+				data := url.Values{"user_id": {"0"}, "q": {`"code review"`}}
+				target, _ := url.Parse("/")
+				target.RawQuery = data.Encode()
+				r := httptest.NewRequest("GET", target.String(), nil)
+				return r
+			},
+			http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := service.NewMockEvent(ctrl)
+			tt.prepare(service)
+			h := EventSearch{service}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, tt.r())
+
+			if got := w.Code; got != tt.want {
+				t.Errorf("EventSearch.ServeHTTP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventGetUpcoming_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(s *service.MockEvent)
+		target  string
+		want    int
+	}{
+		{"InvalidLimit", func(s *service.MockEvent) {}, "/?limit=a", http.StatusBadRequest},
+		{"ServiceError", func(s *service.MockEvent) {
+			s.EXPECT().GetUpcoming(gomock.Any(), gomock.Any(), gomock.Eq(0)).Return(nil, &service.ExternalError{})
+		}, "/?limit=0", http.StatusServiceUnavailable},
+		{"DefaultLimit", func(s *service.MockEvent) {
+			s.EXPECT().GetUpcoming(gomock.Any(), gomock.Eq("0"), gomock.Eq(DefaultUpcomingLimit)).Return([]entity.Event{}, nil)
+		}, "/?user_id=0", http.StatusOK},
+		{"ValidForm", func(s *service.MockEvent) {
+			s.EXPECT().GetUpcoming(gomock.Any(), gomock.Eq("0"), gomock.Eq(3)).Return([]entity.Event{}, nil)
+		}, "/?user_id=0&limit=3", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := service.NewMockEvent(ctrl)
+			tt.prepare(service)
+			h := EventGetUpcoming{service}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+
+			if got := w.Code; got != tt.want {
+				t.Errorf("EventGetUpcoming.ServeHTTP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventGetForToday_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(s *service.MockEvent)
+		want    int
+	}{
+		{"ServiceError", func(s *service.MockEvent) {
+			s.EXPECT().GetForToday(gomock.Any(), gomock.Eq("0")).Return(nil, &service.ExternalError{})
+		}, http.StatusServiceUnavailable},
+		{"ValidForm", func(s *service.MockEvent) {
+			s.EXPECT().GetForToday(gomock.Any(), gomock.Eq("0")).Return([]entity.Event{}, nil)
+		}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := service.NewMockEvent(ctrl)
+			tt.prepare(service)
+			h := EventGetForToday{service}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/?user_id=0", nil))
+
+			if got := w.Code; got != tt.want {
+				t.Errorf("EventGetForToday.ServeHTTP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewEventView(t *testing.T) {
+	tests := []struct {
+		name  string
+		event entity.Event
+		want  string
+	}{
+		{"Text", entity.Event{Description: "**a**"}, ""},
+		{"Markdown", entity.Event{Description: "**a**", DescriptionFormat: entity.FormatMarkdown}, "<p><strong>a</strong></p>\n"},
+		{"Sanitized", entity.Event{Description: "[a](javascript:alert(1))", DescriptionFormat: entity.FormatMarkdown}, "<p>a</p>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewEventView(tt.event)
+			if got.Event != tt.event || got.DescriptionHTML != tt.want {
+				t.Errorf("NewEventView() = %v, want description_html %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Ошибка готовности сервера, останавливающегося и не принимающего новые запросы.
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// Структура HTTP-обработчика для метода /healthz.
+type Healthz struct{}
+
+// ServeHTTP сообщает, что процесс сервера работает.
+func (h Healthz) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	WriteResult(w, http.StatusOK, "ok")
+}
+
+// Структура HTTP-обработчика для метода /readyz.
+type Readyz struct {
+	// Ready сообщает, готов ли сервер принимать новые запросы.
+	Ready func() bool
+}
+
+// ServeHTTP отвечает кодом 200, если сервер готов принимать новые запросы, иначе кодом 503.
+func (h Readyz) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.Ready() {
+		WriteError(w, http.StatusServiceUnavailable, ErrShuttingDown)
+		return
+	}
+	WriteResult(w, http.StatusOK, "ready")
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthz_ServeHTTP(t *testing.T) {
+	w := httptest.NewRecorder()
+	Healthz{}.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Healthz.ServeHTTP() = %v, want %v", w.Code, http.StatusOK)
+	}
+}
+
+func TestReadyz_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name  string
+		ready bool
+		want  int
+	}{
+		{"Ready", true, http.StatusOK},
+		{"ShuttingDown", false, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Readyz{Ready: func() bool { return tt.ready }}.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if w.Code != tt.want {
+				t.Errorf("Readyz.ServeHTTP() = %v, want %v", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"dev11/app/clock"
+	"dev11/app/entity"
+	"dev11/app/quickadd"
+	"dev11/app/service"
+	"dev11/app/workcal"
+	"net/http"
+	"time"
+)
+
+// Структура HTTP-обработчика для метода /quick_add. Если в графиках Schedules заданы
+// производственные календари, событие в праздник создается с предупреждением.
+// События не имеют длительности и повторения, поэтому создается одно событие,
+// а распознанные длительность и повторение сопровождаются предупреждениями.
+type QuickAdd struct {
+	Service service.Event
+	// Clock задает текущее время, относительно которого разбирается текст.
+	// Если не задан, используются системные часы.
+	Clock     clock.Clock
+	Schedules *workcal.Schedules
+}
+
+// ServeHTTP разбирает описание события на естественном языке, создает событие
+// и записывает в w созданное событие вместе с интерпретацией текста.
+func (h QuickAdd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		WriteRequestError(w, err)
+		return
+	}
+
+	// Относительные даты и время интерпретируются в часовом поясе пользователя, по умолчанию UTC.
+	location, err := time.LoadLocation(r.FormValue("tz"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := quickadd.Parse(r.FormValue("text"), clock.OrSystem(h.Clock).Now().In(location))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	event, err := h.Service.Create(r.Context(), entity.Event{
+		Title:  result.Title,
+		Date:   result.Date.UTC(),
+		UserID: r.FormValue("user_id"),
+	})
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	view := NewEventView(event)
+	view.Warnings = append(h.Schedules.Warnings(event.UserID, event.Date), unstoredWarnings(result)...)
+	WriteResult(w, http.StatusCreated, map[string]any{
+		"event":          view,
+		"interpretation": result,
+	})
+}
+
+// unstoredWarnings возвращает предупреждения о частях разбора result, которые не сохраняются в событии.
+func unstoredWarnings(result quickadd.Result) []string {
+	var warnings []string
+	if result.Recurrence != nil {
+		warnings = append(warnings, "recurrence: not stored, a single event was created")
+	}
+	if result.Duration != 0 {
+		warnings = append(warnings, "duration: not stored, events have only a start date")
+	}
+	return warnings
+}
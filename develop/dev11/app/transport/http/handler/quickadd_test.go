@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"dev11/app/clock"
+	"dev11/app/entity"
+	"dev11/app/service"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestQuickAdd_ServeHTTP(t *testing.T) {
+	now := time.Date(2010, 5, 19, 11, 0, 0, 0, time.UTC)
+	tomorrow := time.Date(2010, 5, 20, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		prepare func(s *service.MockEvent)
+		form    url.Values
+		want    int
+		body    string
+	}{
+		{"Created", func(s *service.MockEvent) {
+			event := entity.Event{Title: "Standup", Date: tomorrow, UserID: "1"}
+			created := event
+			created.UpdatedAt = now
+			s.EXPECT().Create(gomock.Any(), event).Return(created, nil)
+		}, url.Values{"user_id": {"1"}, "text": {"Standup tomorrow 10:00 for 15m"}}, http.StatusCreated,
+			`{"result":{"event":{"id":"","title":"Standup","description":"","date":"2010-05-20T10:00:00Z","user_id":"1","updated_at":"2010-05-19T11:00:00Z",` +
+				`"warnings":["duration: not stored, events have only a start date"]},` +
+				`"interpretation":{"title":"Standup","date":"2010-05-20T10:00:00Z","duration":"15m0s"}}}`},
+		{"Recurring", func(s *service.MockEvent) {
+			event := entity.Event{Title: "Standup", Date: time.Date(2010, 5, 24, 10, 0, 0, 0, time.UTC), UserID: "1"}
+			s.EXPECT().Create(gomock.Any(), event).Return(event, nil)
+		}, url.Values{"user_id": {"1"}, "text": {"Standup every Monday 10:00"}}, http.StatusCreated,
+			`{"result":{"event":{"id":"","title":"Standup","description":"","date":"2010-05-24T10:00:00Z","user_id":"1",` +
+				`"updated_at":"0001-01-01T00:00:00Z","warnings":["recurrence: not stored, a single event was created"]},` +
+				`"interpretation":{"title":"Standup","date":"2010-05-24T10:00:00Z","recurrence":{"frequency":"weekly","weekday":"monday"}}}}`},
+		{"TimeZone", func(s *service.MockEvent) {
+			event := entity.Event{Title: "Standup", Date: tomorrow.Add(-9 * time.Hour), UserID: "1"}
+			s.EXPECT().Create(gomock.Any(), event).Return(event, nil)
+		}, url.Values{"user_id": {"1"}, "text": {"Standup завтра в 10:00"}, "tz": {"Asia/Tokyo"}}, http.StatusCreated, ""},
+		{"InvalidTimeZone", func(s *service.MockEvent) {}, url.Values{"text": {"Standup"}, "tz": {"Mars/Olympus"}}, http.StatusBadRequest, ""},
+		{"EmptyTitle", func(s *service.MockEvent) {}, url.Values{"text": {"tomorrow"}}, http.StatusBadRequest, ""},
+		{"ServiceError", func(s *service.MockEvent) {
+			s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.EmptyEvent, &service.ExternalError{Err: entity.ErrIdInvalid})
+		}, url.Values{"text": {"Standup"}}, http.StatusServiceUnavailable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := service.NewMockEvent(ctrl)
+			tt.prepare(s)
+			r := httptest.NewRequest(http.MethodPost, "/quick_add", strings.NewReader(tt.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			QuickAdd{Service: s, Clock: clock.NewFake(now)}.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("QuickAdd.ServeHTTP() code = %v, want %v: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.body != "" {
+				var got, want any
+				json.Unmarshal(w.Body.Bytes(), &got)
+				json.Unmarshal([]byte(tt.body), &want)
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("QuickAdd.ServeHTTP() body = %s, want %s", w.Body, tt.body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"dev11/app/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Ошибка отключенного поиска времени встречи.
+var ErrSlotsDisabled = errors.New("slot finder is not configured")
+
+// Структура HTTP-обработчика для метода /find_slot.
+type SlotFind struct {
+	Service service.Slot
+}
+
+// ServeHTTP ищет время встречи пользователей, переданных повторяющимся параметром user_id,
+// и записывает в w найденные промежутки. По умолчанию поиск ограничен рабочим временем.
+func (h SlotFind) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		WriteError(w, http.StatusNotImplemented, ErrSlotsDisabled)
+		return
+	}
+
+	query, err := parseSlotQuery(r)
+	if err != nil {
+		WriteRequestError(w, err)
+		return
+	}
+
+	slots, err := h.Service.Find(r.Context(), query)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	WriteResult(w, http.StatusOK, slots)
+}
+
+// parseSlotQuery парсит запрос поиска времени встречи, переданный в виде www-url-form-encoded.
+func parseSlotQuery(r *http.Request) (service.SlotQuery, error) {
+	if err := r.ParseForm(); err != nil {
+		return service.SlotQuery{}, err
+	}
+
+	query := service.SlotQuery{UserIDs: r.PostForm["user_id"], WorkingHours: true}
+	var err error
+	if query.Duration, err = time.ParseDuration(r.PostFormValue("duration")); err != nil {
+		return query, err
+	}
+	if query.Start, err = time.Parse(time.RFC3339, r.PostFormValue("start")); err != nil {
+		return query, err
+	}
+	if query.End, err = time.Parse(time.RFC3339, r.PostFormValue("end")); err != nil {
+		return query, err
+	}
+	if value := r.PostFormValue("buffer"); value != "" {
+		if query.Buffer, err = time.ParseDuration(value); err != nil {
+			return query, err
+		}
+	}
+	if value := r.PostFormValue("working_hours"); value != "" {
+		if query.WorkingHours, err = strconv.ParseBool(value); err != nil {
+			return query, err
+		}
+	}
+	if value := r.PostFormValue("limit"); value != "" {
+		if query.Limit, err = strconv.Atoi(value); err != nil {
+			return query, err
+		}
+	}
+	return query, nil
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"dev11/app/entity"
+	"dev11/app/service"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestSlotFind_ServeHTTP(t *testing.T) {
+	start := time.Date(2010, 5, 17, 9, 0, 0, 0, time.UTC)
+	end := start.Add(9 * time.Hour)
+	form := func(extra url.Values) url.Values {
+		values := url.Values{"user_id": {"1", "2"}, "duration": {"30m"}, "start": {start.Format(time.RFC3339)}, "end": {end.Format(time.RFC3339)}}
+		for k, v := range extra {
+			values[k] = v
+		}
+		return values
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *service.MockSlot)
+		form    url.Values
+		want    int
+		body    string
+	}{
+		{"Found", func(s *service.MockSlot) {
+			query := service.SlotQuery{UserIDs: []string{"1", "2"}, Duration: 30 * time.Minute, Start: start, End: end, WorkingHours: true}
+			s.EXPECT().Find(gomock.Any(), query).Return([]entity.Slot{{Start: start, End: start.Add(30 * time.Minute)}}, nil)
+		}, form(nil), http.StatusOK, `{"result":[{"start":"2010-05-17T09:00:00Z","end":"2010-05-17T09:30:00Z"}]}`},
+		{"Constraints", func(s *service.MockSlot) {
+			query := service.SlotQuery{UserIDs: []string{"1", "2"}, Duration: 30 * time.Minute, Start: start, End: end, Buffer: 10 * time.Minute, Limit: 3}
+			s.EXPECT().Find(gomock.Any(), query).Return([]entity.Slot{}, nil)
+		}, form(url.Values{"buffer": {"10m"}, "working_hours": {"false"}, "limit": {"3"}}), http.StatusOK, `{"result":[]}`},
+		{"InvalidDuration", func(s *service.MockSlot) {}, form(url.Values{"duration": {"30"}}), http.StatusBadRequest, ""},
+		{"InvalidStart", func(s *service.MockSlot) {}, form(url.Values{"start": {"2010-05-17"}}), http.StatusBadRequest, ""},
+		{"InvalidBuffer", func(s *service.MockSlot) {}, form(url.Values{"buffer": {"soon"}}), http.StatusBadRequest, ""},
+		{"InvalidWorkingHours", func(s *service.MockSlot) {}, form(url.Values{"working_hours": {"maybe"}}), http.StatusBadRequest, ""},
+		{"InvalidLimit", func(s *service.MockSlot) {}, form(url.Values{"limit": {"ten"}}), http.StatusBadRequest, ""},
+		{"ServiceError", func(s *service.MockSlot) {
+			s.EXPECT().Find(gomock.Any(), gomock.Any()).Return(nil, service.ErrTooManyParticipants)
+		}, form(nil), http.StatusServiceUnavailable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := service.NewMockSlot(ctrl)
+			tt.prepare(s)
+			r := httptest.NewRequest(http.MethodPost, "/find_slot", strings.NewReader(tt.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			SlotFind{Service: s}.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("SlotFind.ServeHTTP() code = %v, want %v: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.body != "" && strings.TrimSpace(w.Body.String()) != tt.body {
+				t.Errorf("SlotFind.ServeHTTP() body = %s, want %s", w.Body, tt.body)
+			}
+		})
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		SlotFind{}.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/find_slot", nil))
+		if w.Code != http.StatusNotImplemented {
+			t.Errorf("SlotFind.ServeHTTP() code = %v, want %v", w.Code, http.StatusNotImplemented)
+		}
+	})
+}
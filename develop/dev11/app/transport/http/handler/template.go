@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"dev11/app/entity"
+	"dev11/app/service"
+	"dev11/app/workcal"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Ошибка отсутствия сервиса шаблонов событий.
+var ErrTemplatesDisabled = errors.New("templates are not configured")
+
+// formValues возвращает непустые значения повторяющегося параметра key формы form
+// и сообщает, передан ли параметр. Параметр с пустым значением задает пустой список.
+func formValues(form url.Values, key string) ([]string, bool) {
+	values, ok := form[key]
+	if !ok {
+		return nil, false
+	}
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result, true
+}
+
+// parseDurations разбирает длительности values в формате time.ParseDuration.
+func parseDurations(values []string) ([]time.Duration, error) {
+	durations := make([]time.Duration, len(values))
+	for i, v := range values {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, err
+		}
+		durations[i] = d
+	}
+	return durations, nil
+}
+
+// ParseFormTemplate парсит Template, переданный в виде www-url-form-encoded,
+// возвращает ошибку, если данные нельзя распарсить. Теги, участники и напоминания
+// передаются повторяющимися параметрами tag, attendee и reminder.
+func ParseFormTemplate(r *http.Request) (entity.Template, error) {
+	if err := r.ParseForm(); err != nil {
+		return entity.Template{}, err
+	}
+
+	template := entity.Template{
+		ID:                r.FormValue("id"),
+		UserID:            r.FormValue("user_id"),
+		Title:             r.FormValue("title"),
+		Description:       r.FormValue("description"),
+		DescriptionFormat: r.FormValue("description_format"),
+	}
+	if value := r.FormValue("duration"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return entity.Template{}, err
+		}
+		template.Duration = entity.Duration(d)
+	}
+	template.Tags, _ = formValues(r.Form, "tag")
+	template.Attendees, _ = formValues(r.Form, "attendee")
+	reminderValues, _ := formValues(r.Form, "reminder")
+	reminders, err := parseDurations(reminderValues)
+	if err != nil {
+		return entity.Template{}, err
+	}
+	for _, reminder := range reminders {
+		template.Reminders = append(template.Reminders, entity.Duration(reminder))
+	}
+	return template, nil
+}
+
+// Структура HTTP-обработчика для метода /create_template.
+type TemplateCreate struct {
+	Service service.Template
+}
+
+// ServeHTTP обрабатывает запрос r и записывает ответ в w.
+func (h TemplateCreate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		WriteError(w, http.StatusNotImplemented, ErrTemplatesDisabled)
+		return
+	}
+
+	template, err := ParseFormTemplate(r)
+	if err != nil {
+		WriteRequestError(w, err)
+		return
+	}
+
+	template, err = h.Service.Create(r.Context(), template)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	WriteResult(w, http.StatusCreated, template)
+}
+
+// Структура HTTP-обработчика для метода /update_template.
+type TemplateUpdate struct {
+	Service service.Template
+}
+
+// ServeHTTP обрабатывает запрос r и записывает ответ в w.
+func (h TemplateUpdate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		WriteError(w, http.StatusNotImplemented, ErrTemplatesDisabled)
+		return
+	}
+
+	template, err := ParseFormTemplate(r)
+	if err != nil {
+		WriteRequestError(w, err)
+		return
+	}
+
+	template, err = h.Service.Update(r.Context(), template)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	WriteResult(w, http.StatusOK, template)
+}
+
+// Структура HTTP-обработчика для метода /delete_template.
+type TemplateDelete struct {
+	Service service.Template
+}
+
+// ServeHTTP обрабатывает запрос r и записывает ответ в w.
+func (h TemplateDelete) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		WriteError(w, http.StatusNotImplemented, ErrTemplatesDisabled)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		WriteRequestError(w, err)
+		return
+	}
+
+	if err := h.Service.Delete(r.Context(), r.FormValue("user_id"), r.FormValue("id")); err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	WriteResult(w, http.StatusNoContent, nil)
+}
+
+// Структура HTTP-обработчика для метода /templates.
+type TemplateGetByUser struct {
+	Service service.Template
+}
+
+// ServeHTTP обрабатывает запрос r и записывает ответ в w.
+func (h TemplateGetByUser) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		WriteError(w, http.StatusNotImplemented, ErrTemplatesDisabled)
+		return
+	}
+
+	templates, err := h.Service.GetByUser(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	WriteResult(w, http.StatusOK, templates)
+}
+
+// Структура HTTP-обработчика для метода /create_event_from_template. Если в графиках Schedules
+// заданы производственные календари, событие в праздник создается с предупреждением.
+type TemplateCreateEvent struct {
+	Service   service.Template
+	Schedules *workcal.Schedules
+}
+
+// ServeHTTP создает событие пользователя user_id по шаблону template_id в момент date
+// и записывает в w созданное событие вместе с примененным шаблоном. Переданные параметры
+// title, description, duration, tag, attendee и reminder заменяют значения шаблона.
+func (h TemplateCreateEvent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		WriteError(w, http.StatusNotImplemented, ErrTemplatesDisabled)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		WriteRequestError(w, err)
+		return
+	}
+
+	date, err := time.Parse(time.RFC3339, r.FormValue("date"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	overrides, err := parseTemplateOverrides(r.Form)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	event, template, err := h.Service.CreateEvent(r.Context(), r.FormValue("user_id"), r.FormValue("template_id"), date, overrides)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	view := NewEventView(event)
+	view.Warnings = h.Schedules.Warnings(event.UserID, event.Date)
+	WriteResult(w, http.StatusCreated, map[string]any{
+		"event":    view,
+		"template": template,
+	})
+}
+
+// parseTemplateOverrides возвращает изменения шаблона из параметров формы form.
+func parseTemplateOverrides(form url.Values) (service.TemplateOverrides, error) {
+	var overrides service.TemplateOverrides
+	if _, ok := form["title"]; ok {
+		title := form.Get("title")
+		overrides.Title = &title
+	}
+	if _, ok := form["description"]; ok {
+		description := form.Get("description")
+		overrides.Description = &description
+	}
+	if value := form.Get("duration"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return overrides, err
+		}
+		overrides.Duration = &d
+	}
+	overrides.Tags, _ = formValues(form, "tag")
+	overrides.Attendees, _ = formValues(form, "attendee")
+	if values, ok := formValues(form, "reminder"); ok {
+		reminders, err := parseDurations(values)
+		if err != nil {
+			return overrides, err
+		}
+		overrides.Reminders = reminders
+	}
+	return overrides, nil
+}
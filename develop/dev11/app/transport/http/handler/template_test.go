@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"dev11/app/entity"
+	"dev11/app/service"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// newFormRequest возвращает POST-запрос к path с формой form.
+func newFormRequest(path string, form url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestParseFormTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		form    url.Values
+		want    entity.Template
+		wantErr bool
+	}{
+		{"Full", url.Values{"id": {"1"}, "user_id": {"2"}, "title": {"Standup {{date}}"}, "description": {"daily"},
+			"duration": {"15m"}, "tag": {"work", ""}, "attendee": {"team@example.com"}, "reminder": {"10m", "1h"}},
+			entity.Template{ID: "1", UserID: "2", Title: "Standup {{date}}", Description: "daily", Duration: entity.Duration(15 * time.Minute),
+				Tags: []string{"work"}, Attendees: []string{"team@example.com"},
+				Reminders: []entity.Duration{entity.Duration(10 * time.Minute), entity.Duration(time.Hour)}}, false},
+		{"Minimal", url.Values{"title": {"Standup"}}, entity.Template{Title: "Standup"}, false},
+		{"InvalidDuration", url.Values{"duration": {"soon"}}, entity.Template{}, true},
+		{"InvalidReminder", url.Values{"reminder": {"10"}}, entity.Template{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFormTemplate(newFormRequest("/create_template", tt.form))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFormTemplate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateCreateEvent_ServeHTTP(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC)
+	template := entity.Template{ID: "1", UserID: userID, Title: "Standup {{date}}", Duration: entity.Duration(15 * time.Minute)}
+	event := entity.Event{ID: "2", Title: "Standup 2010-05-17", Date: date, UserID: userID}
+	title, duration := "Retro", time.Hour
+
+	tests := []struct {
+		name    string
+		prepare func(s *service.MockTemplate)
+		form    url.Values
+		want    int
+		body    string
+	}{
+		{"Created", func(s *service.MockTemplate) {
+			s.EXPECT().CreateEvent(gomock.Any(), userID, "1", date, service.TemplateOverrides{}).Return(event, template, nil)
+		}, url.Values{"user_id": {userID}, "template_id": {"1"}, "date": {"2010-05-17T10:00:00Z"}}, http.StatusCreated,
+			`{"result":{"event":{"id":"2","title":"Standup 2010-05-17","description":"","date":"2010-05-17T10:00:00Z","user_id":"` + userID + `","updated_at":"0001-01-01T00:00:00Z"},` +
+				`"template":{"id":"1","user_id":"` + userID + `","title":"Standup {{date}}","description":"","duration":"15m0s"}}}`},
+		{"Overrides", func(s *service.MockTemplate) {
+			overrides := service.TemplateOverrides{Title: &title, Duration: &duration, Tags: []string{}, Reminders: []time.Duration{time.Minute}}
+			s.EXPECT().CreateEvent(gomock.Any(), userID, "1", date, overrides).Return(event, template, nil)
+		}, url.Values{"user_id": {userID}, "template_id": {"1"}, "date": {"2010-05-17T10:00:00Z"},
+			"title": {"Retro"}, "duration": {"1h"}, "tag": {""}, "reminder": {"1m"}}, http.StatusCreated, ""},
+		{"InvalidDate", func(s *service.MockTemplate) {}, url.Values{"date": {"2010-05-17"}}, http.StatusBadRequest, ""},
+		{"InvalidDuration", func(s *service.MockTemplate) {}, url.Values{"date": {"2010-05-17T10:00:00Z"}, "duration": {"1"}}, http.StatusBadRequest, ""},
+		{"NotExist", func(s *service.MockTemplate) {
+			s.EXPECT().CreateEvent(gomock.Any(), userID, "1", date, gomock.Any()).Return(entity.EmptyEvent, entity.Template{}, &service.ExternalError{Err: entity.ErrTitleEmpty})
+		}, url.Values{"user_id": {userID}, "template_id": {"1"}, "date": {"2010-05-17T10:00:00Z"}}, http.StatusServiceUnavailable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := service.NewMockTemplate(ctrl)
+			tt.prepare(s)
+			w := httptest.NewRecorder()
+			TemplateCreateEvent{Service: s}.ServeHTTP(w, newFormRequest("/create_event_from_template", tt.form))
+
+			if w.Code != tt.want {
+				t.Fatalf("TemplateCreateEvent.ServeHTTP() code = %v, want %v: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.body != "" && strings.TrimSpace(w.Body.String()) != tt.body {
+				t.Errorf("TemplateCreateEvent.ServeHTTP() body = %s, want %s", w.Body, tt.body)
+			}
+		})
+	}
+}
+
+func TestTemplateHandlers_NotConfigured(t *testing.T) {
+	handlers := map[string]http.Handler{
+		"TemplateCreate":      TemplateCreate{},
+		"TemplateUpdate":      TemplateUpdate{},
+		"TemplateDelete":      TemplateDelete{},
+		"TemplateGetByUser":   TemplateGetByUser{},
+		"TemplateCreateEvent": TemplateCreateEvent{},
+	}
+	for name, h := range handlers {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, newFormRequest("/", nil))
+		if w.Code != http.StatusNotImplemented {
+			t.Errorf("%s.ServeHTTP() code = %v, want %v", name, w.Code, http.StatusNotImplemented)
+		}
+	}
+}
+
+func TestTemplateCreate_ServeHTTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	template := entity.Template{UserID: "1", Title: "Standup", Tags: []string{"work"}}
+	s := service.NewMockTemplate(ctrl)
+	s.EXPECT().Create(gomock.Any(), template).Return(entity.Template{ID: "2", UserID: "1", Title: "Standup", Tags: []string{"work"}}, nil)
+
+	w := httptest.NewRecorder()
+	TemplateCreate{Service: s}.ServeHTTP(w, newFormRequest("/create_template", url.Values{"user_id": {"1"}, "title": {"Standup"}, "tag": {"work"}}))
+	if want := `{"result":{"id":"2","user_id":"1","title":"Standup","description":"","tags":["work"]}}`; w.Code != http.StatusCreated || strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("TemplateCreate.ServeHTTP() = %v %s, want %v %s", w.Code, w.Body, http.StatusCreated, want)
+	}
+}
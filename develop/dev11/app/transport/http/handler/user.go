@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"dev11/app/entity"
+	"dev11/app/service"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Структура представления всех данных пользователя с представлениями событий.
+type UserDataView struct {
+	UserID      string              `json:"user_id"`
+	Events      []EventView         `json:"events"`
+	Templates   []entity.Template   `json:"templates"`
+	Attachments []entity.Attachment `json:"attachments"`
+}
+
+// NewUserDataView возвращает представление данных пользователя data.
+func NewUserDataView(data entity.UserData) UserDataView {
+	return UserDataView{
+		UserID:      data.UserID,
+		Events:      NewEventViews(data.Events),
+		Templates:   data.Templates,
+		Attachments: data.Attachments,
+	}
+}
+
+// parseTransferFilter возвращает фильтр передаваемых событий из параметров формы form:
+// повторяющегося параметра id и необязательных границ start и end в формате RFC3339.
+func parseTransferFilter(form url.Values) (entity.EventFilter, error) {
+	var filter entity.EventFilter
+	filter.IDs, _ = formValues(form, "id")
+	if value := form.Get("start"); value != "" {
+		start, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, err
+		}
+		filter.DateStart = start
+	}
+	if value := form.Get("end"); value != "" {
+		end, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, err
+		}
+		filter.DateEnd = end
+	}
+	return filter, nil
+}
+
+// Структура HTTP-обработчика для метода /transfer_events.
+type EventTransfer struct {
+	Service service.Event
+}
+
+// ServeHTTP передает события пользователя from_user_id пользователю to_user_id и записывает
+// в w переданные события. Без параметров id, start и end передаются все события.
+func (h EventTransfer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		WriteRequestError(w, err)
+		return
+	}
+
+	filter, err := parseTransferFilter(r.Form)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	events, err := h.Service.Transfer(r.Context(), r.FormValue("from_user_id"), r.FormValue("to_user_id"), filter)
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	WriteResult(w, http.StatusOK, NewEventViews(events))
+}
+
+// Структура HTTP-обработчика для метода /export_user.
+type UserExport struct {
+	Service service.User
+}
+
+// ServeHTTP обрабатывает запрос r и записывает ответ в w.
+func (h UserExport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := h.Service.Export(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	WriteResult(w, http.StatusOK, NewUserDataView(data))
+}
+
+// Структура HTTP-обработчика для метода /delete_user.
+type UserDelete struct {
+	Service service.User
+}
+
+// ServeHTTP удаляет все данные пользователя user_id и записывает в w удаленные данные.
+func (h UserDelete) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		WriteRequestError(w, err)
+		return
+	}
+
+	data, err := h.Service.Delete(r.Context(), r.FormValue("user_id"))
+	if err != nil {
+		HandleServiceError(w, err)
+		return
+	}
+
+	WriteResult(w, http.StatusOK, NewUserDataView(data))
+}
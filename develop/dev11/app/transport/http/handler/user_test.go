@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"dev11/app/entity"
+	"dev11/app/service"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestEventTransfer_ServeHTTP(t *testing.T) {
+	from, to := "18310e71-4df6-42c0-adf4-1a280013dd08", "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	start := time.Date(2010, 5, 17, 0, 0, 0, 0, time.UTC)
+	event := entity.Event{ID: "1", Title: "event", Date: start, UserID: to}
+
+	tests := []struct {
+		name    string
+		prepare func(s *service.MockEvent)
+		form    url.Values
+		want    int
+		body    string
+	}{
+		{"All", func(s *service.MockEvent) {
+			s.EXPECT().Transfer(gomock.Any(), from, to, entity.EventFilter{}).Return([]entity.Event{event}, nil)
+		}, url.Values{"from_user_id": {from}, "to_user_id": {to}}, http.StatusOK,
+			`{"result":[{"id":"1","title":"event","description":"","date":"2010-05-17T00:00:00Z","user_id":"` + to + `","updated_at":"0001-01-01T00:00:00Z"}]}`},
+		{"Filter", func(s *service.MockEvent) {
+			filter := entity.EventFilter{IDs: []string{"1", "2"}, DateStart: start, DateEnd: start.AddDate(0, 0, 7)}
+			s.EXPECT().Transfer(gomock.Any(), from, to, filter).Return([]entity.Event{event}, nil)
+		}, url.Values{"from_user_id": {from}, "to_user_id": {to}, "id": {"1", "2"},
+			"start": {"2010-05-17T00:00:00Z"}, "end": {"2010-05-24T00:00:00Z"}}, http.StatusOK, ""},
+		{"InvalidStart", func(s *service.MockEvent) {}, url.Values{"start": {"2010-05-17"}}, http.StatusBadRequest, ""},
+		{"InvalidEnd", func(s *service.MockEvent) {}, url.Values{"end": {"2010-05-24"}}, http.StatusBadRequest, ""},
+		{"SameUser", func(s *service.MockEvent) {
+			s.EXPECT().Transfer(gomock.Any(), from, from, entity.EventFilter{}).Return(nil, service.ErrSameUser)
+		}, url.Values{"from_user_id": {from}, "to_user_id": {from}}, http.StatusServiceUnavailable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := service.NewMockEvent(ctrl)
+			tt.prepare(s)
+			w := httptest.NewRecorder()
+			EventTransfer{Service: s}.ServeHTTP(w, newFormRequest("/transfer_events", tt.form))
+
+			if w.Code != tt.want {
+				t.Fatalf("EventTransfer.ServeHTTP() code = %v, want %v: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.body != "" && strings.TrimSpace(w.Body.String()) != tt.body {
+				t.Errorf("EventTransfer.ServeHTTP() body = %s, want %s", w.Body, tt.body)
+			}
+		})
+	}
+}
+
+func TestUserHandlers_ServeHTTP(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	data := entity.UserData{
+		UserID:      userID,
+		Events:      []entity.Event{{ID: "1", Title: "**event**", DescriptionFormat: entity.FormatMarkdown, Description: "*a*", UserID: userID}},
+		Templates:   []entity.Template{},
+		Attachments: []entity.Attachment{},
+	}
+	want := `{"result":{"user_id":"` + userID + `","events":[{"id":"1","title":"**event**","description":"*a*","description_format":"markdown",` +
+		`"date":"0001-01-01T00:00:00Z","user_id":"` + userID + `","updated_at":"0001-01-01T00:00:00Z","description_html":"\u003cp\u003e\u003cem\u003ea\u003c/em\u003e\u003c/p\u003e\n"}],` +
+		`"templates":[],"attachments":[]}}`
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := service.NewMockUser(ctrl)
+	s.EXPECT().Export(gomock.Any(), userID).Return(data, nil)
+	s.EXPECT().Delete(gomock.Any(), userID).Return(data, nil)
+	s.EXPECT().Delete(gomock.Any(), "1").Return(entity.UserData{}, &service.ExternalError{Err: entity.ErrIdInvalid})
+
+	w := httptest.NewRecorder()
+	UserExport{Service: s}.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export_user?user_id="+userID, nil))
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("UserExport.ServeHTTP() = %v %s, want %v %s", w.Code, w.Body, http.StatusOK, want)
+	}
+
+	w = httptest.NewRecorder()
+	UserDelete{Service: s}.ServeHTTP(w, newFormRequest("/delete_user", url.Values{"user_id": {userID}}))
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("UserDelete.ServeHTTP() = %v %s, want %v %s", w.Code, w.Body, http.StatusOK, want)
+	}
+
+	w = httptest.NewRecorder()
+	UserDelete{Service: s}.ServeHTTP(w, newFormRequest("/delete_user", url.Values{"user_id": {"1"}}))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("UserDelete.ServeHTTP() code = %v, want %v", w.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"dev11/app/tenant"
+	"dev11/app/transport/http/handler"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Заголовок запроса с ключом идемпотентности.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Ошибки ключей идемпотентности.
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key is reused with different request")
+	ErrIdempotencyKeyInProgress = errors.New("request with idempotency key is in progress")
+)
+
+// Структура сохраненного ответа на запрос с ключом идемпотентности.
+type IdempotencyRecord struct {
+	Hash   string
+	Code   int
+	Header http.Header
+	Body   []byte
+}
+
+// Интерфейс хранилища ответов на запросы с ключами идемпотентности.
+type IdempotencyStore interface {
+	Get(ctx context.Context, key string) (IdempotencyRecord, bool, error)
+	Set(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) error
+}
+
+// Структура сохраненного ответа со временем истечения.
+type idempotencyEntry struct {
+	record    IdempotencyRecord
+	expiresAt time.Time
+}
+
+// Структура хранилища ответов, реализующая интерфейс и работающая с данными in-memory.
+type idempotencyMemory struct {
+	mu        sync.Mutex
+	entries   map[string]idempotencyEntry
+	lastPurge time.Time
+	now       func() time.Time
+}
+
+// NewIdempotencyMemory возвращает in-memory хранилище ответов, реализующее интерфейс.
+func NewIdempotencyMemory() IdempotencyStore {
+	return &idempotencyMemory{entries: make(map[string]idempotencyEntry), now: time.Now}
+}
+
+// Get возвращает сохраненный ответ по ключу key, если его время хранения не истекло.
+func (s *idempotencyMemory) Get(ctx context.Context, key string) (IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return IdempotencyRecord{}, false, nil
+	}
+	if !s.now().Before(entry.expiresAt) {
+		delete(s.entries, key)
+		return IdempotencyRecord{}, false, nil
+	}
+	return entry.record, true, nil
+}
+
+// Set сохраняет ответ record по ключу key на время ttl.
+// Не чаще раза в ttl удаляет из хранилища все истекшие ответы.
+func (s *idempotencyMemory) Set(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if now.Sub(s.lastPurge) >= ttl {
+		for k, entry := range s.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastPurge = now
+	}
+	s.entries[key] = idempotencyEntry{record, now.Add(ttl)}
+	return nil
+}
+
+// Кастомный http.ResponseWriter для сохранения ответа.
+type recordWriter struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+// Write записывает байты в тело HTTP-ответа и в буфер.
+func (w *recordWriter) Write(bytes []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	w.body.Write(bytes)
+	return w.ResponseWriter.Write(bytes)
+}
+
+// WriteHeader записывает заголовок HTTP-ответа.
+func (w *recordWriter) WriteHeader(statusCode int) {
+	w.code = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// hashRequest возвращает хеш метода, пути и тела запроса r.
+func hashRequest(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method)
+	io.WriteString(h, r.URL.Path)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotencyKey возвращает ключ хранилища для ключа идемпотентности key арендатора из ctx.
+func idempotencyKey(ctx context.Context, key string) string {
+	return tenant.FromContext(ctx) + "/" + key
+}
+
+// IdempotencyMiddleware возвращает middleware, который сохраняет в store ответы на запросы
+// с заголовком Idempotency-Key на время ttl и возвращает их при повторе запроса.
+// Ключи разных арендаторов не пересекаются.
+// Повтор ключа с другим телом запроса отклоняется с кодом 422.
+// Ответы с кодом 5xx не сохраняются, чтобы запрос можно было повторить.
+func IdempotencyMiddleware(store IdempotencyStore, ttl time.Duration) Middleware {
+	var inProgress sync.Map
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				handler.WriteRequestError(w, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			hash := hashRequest(r, body)
+			key = idempotencyKey(r.Context(), key)
+
+			if _, loaded := inProgress.LoadOrStore(key, struct{}{}); loaded {
+				handler.WriteError(w, http.StatusConflict, ErrIdempotencyKeyInProgress)
+				return
+			}
+			defer inProgress.Delete(key)
+
+			record, ok, err := store.Get(r.Context(), key)
+			if err != nil {
+				panic(err)
+			}
+			if ok {
+				if record.Hash != hash {
+					handler.WriteError(w, http.StatusUnprocessableEntity, ErrIdempotencyKeyReused)
+					return
+				}
+				for k, v := range record.Header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(record.Code)
+				w.Write(record.Body)
+				return
+			}
+
+			rw := &recordWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+			if rw.code >= http.StatusInternalServerError {
+				return
+			}
+
+			if rw.code == 0 {
+				rw.code = http.StatusOK
+			}
+			// Ответ уже отправлен клиенту, поэтому ошибка сохранения не возвращается:
+			// в худшем случае повторный запрос будет выполнен заново.
+			record = IdempotencyRecord{Hash: hash, Code: rw.code, Header: w.Header().Clone(), Body: rw.body.Bytes()}
+			store.Set(r.Context(), key, record, ttl)
+		})
+	}
+}
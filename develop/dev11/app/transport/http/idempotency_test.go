@@ -0,0 +1,175 @@
+package http
+
+import (
+	"context"
+	"dev11/app/tenant"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_idempotencyMemory_Get(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Date(2010, 5, 20, 0, 0, 0, 0, time.UTC)
+	s := &idempotencyMemory{entries: make(map[string]idempotencyEntry), now: func() time.Time { return now }}
+
+	want := IdempotencyRecord{Hash: "hash", Code: http.StatusCreated, Body: []byte("{}")}
+	s.Set(ctx, "key", want, time.Minute)
+
+	t.Run("NotExpired", func(t *testing.T) {
+		got, ok, err := s.Get(ctx, "key")
+		if err != nil || !ok {
+			t.Errorf("idempotencyMemory.Get() ok = %v, error = %v", ok, err)
+			return
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("idempotencyMemory.Get() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		if _, ok, _ := s.Get(ctx, "key"); ok {
+			t.Errorf("idempotencyMemory.Get() ok = %v, want %v", ok, false)
+		}
+		if _, ok := s.entries["key"]; ok {
+			t.Errorf("idempotencyMemory.Get() did not delete expired entry")
+		}
+	})
+}
+
+func Test_idempotencyMemory_Set(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Date(2010, 5, 20, 0, 0, 0, 0, time.UTC)
+	s := &idempotencyMemory{entries: make(map[string]idempotencyEntry), now: func() time.Time { return now }}
+
+	s.Set(ctx, "old", IdempotencyRecord{}, time.Minute)
+	now = now.Add(time.Minute)
+	s.Set(ctx, "new", IdempotencyRecord{}, time.Minute)
+
+	if _, ok := s.entries["old"]; ok {
+		t.Errorf("idempotencyMemory.Set() did not purge expired entry")
+	}
+	if _, ok := s.entries["new"]; !ok {
+		t.Errorf("idempotencyMemory.Set() did not store entry")
+	}
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	calls := 0
+	middleware := IdempotencyMiddleware(NewIdempotencyMemory(), time.Minute)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+
+	serve := func(key string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/create_event", strings.NewReader(body))
+		if key != "" {
+			r.Header.Set(IdempotencyKeyHeader, key)
+		}
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("FirstRequest", func(t *testing.T) {
+		w := serve("key", "title=event")
+		if got, want := w.Code, http.StatusCreated; got != want {
+			t.Errorf("IdempotencyMiddleware() code = %v, want %v", got, want)
+		}
+		if got, want := calls, 1; got != want {
+			t.Errorf("IdempotencyMiddleware() calls = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		w := serve("key", "title=event")
+		if got, want := w.Code, http.StatusCreated; got != want {
+			t.Errorf("IdempotencyMiddleware() code = %v, want %v", got, want)
+		}
+		if got, want := w.Body.String(), "title=event"; got != want {
+			t.Errorf("IdempotencyMiddleware() body = %v, want %v", got, want)
+		}
+		if got, want := w.Header().Get("Content-Type"), "text/plain"; got != want {
+			t.Errorf("IdempotencyMiddleware() content type = %v, want %v", got, want)
+		}
+		if got, want := calls, 1; got != want {
+			t.Errorf("IdempotencyMiddleware() calls = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("DifferentBody", func(t *testing.T) {
+		w := serve("key", "title=other")
+		if got, want := w.Code, http.StatusUnprocessableEntity; got != want {
+			t.Errorf("IdempotencyMiddleware() code = %v, want %v", got, want)
+		}
+		if got, want := calls, 1; got != want {
+			t.Errorf("IdempotencyMiddleware() calls = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("OtherTenant", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/create_event", strings.NewReader("title=other"))
+		r.Header.Set(IdempotencyKeyHeader, "key")
+		handler.ServeHTTP(w, r.WithContext(tenant.WithTenant(r.Context(), "sales")))
+		if got, want := w.Code, http.StatusCreated; got != want {
+			t.Errorf("IdempotencyMiddleware() code = %v, want %v", got, want)
+		}
+		if got, want := calls, 2; got != want {
+			t.Errorf("IdempotencyMiddleware() calls = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/create_event", strings.NewReader("title=large"))
+		r.Header.Set(IdempotencyKeyHeader, "large")
+		BodyLimitMiddleware(4)(handler).ServeHTTP(w, r)
+		if got, want := w.Code, http.StatusRequestEntityTooLarge; got != want {
+			t.Errorf("IdempotencyMiddleware() code = %v, want %v", got, want)
+		}
+		if got, want := calls, 2; got != want {
+			t.Errorf("IdempotencyMiddleware() calls = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("NoKey", func(t *testing.T) {
+		serve("", "title=event")
+		serve("", "title=event")
+		if got, want := calls, 4; got != want {
+			t.Errorf("IdempotencyMiddleware() calls = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestIdempotencyMiddleware_ServerError(t *testing.T) {
+	calls := 0
+	middleware := IdempotencyMiddleware(NewIdempotencyMemory(), time.Minute)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	for range 2 {
+		r := httptest.NewRequest(http.MethodPost, "/create_event", nil)
+		r.Header.Set(IdempotencyKeyHeader, "key")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	if got, want := calls, 2; got != want {
+		t.Errorf("IdempotencyMiddleware() calls = %v, want %v", got, want)
+	}
+}
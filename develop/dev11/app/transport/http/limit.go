@@ -0,0 +1,184 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"dev11/app/transport/http/handler"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Ошибка превышения времени обработки запроса.
+var ErrRequestTimeout = errors.New("request timed out")
+
+// Структура ограничений обработки запросов маршрута. Нулевое значение поля означает
+// ограничение по умолчанию, отрицательное — отсутствие ограничения.
+type RouteLimits struct {
+	// Timeout ограничивает время обработки запроса.
+	Timeout time.Duration
+	// MaxBodySize ограничивает размер тела запроса в байтах.
+	MaxBodySize int64
+	// Stream ограничивает время обработки запроса без накопления ответа в памяти
+	// для маршрутов с большими ответами: по истечении Timeout отменяется контекст запроса
+	// и прерываются чтение и запись соединения, а начатый ответ обрывается.
+	Stream bool
+}
+
+// Ограничения обработки запросов по умолчанию.
+var DefaultRouteLimits = RouteLimits{
+	Timeout:     30 * time.Second,
+	MaxBodySize: 1 << 20,
+}
+
+// or возвращает ограничения l, в которых нулевые поля заменены полями def.
+func (l RouteLimits) or(def RouteLimits) RouteLimits {
+	if l.Timeout == 0 {
+		l.Timeout = def.Timeout
+	}
+	if l.MaxBodySize == 0 {
+		l.MaxBodySize = def.MaxBodySize
+	}
+	l.Stream = l.Stream || def.Stream
+	return l
+}
+
+// middleware возвращает middleware, применяющее положительные ограничения l.
+func (l RouteLimits) middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		switch {
+		case l.Timeout > 0 && l.Stream:
+			next = DeadlineMiddleware(l.Timeout)(next)
+		case l.Timeout > 0:
+			next = TimeoutMiddleware(l.Timeout)(next)
+		}
+		if l.MaxBodySize > 0 {
+			next = BodyLimitMiddleware(l.MaxBodySize)(next)
+		}
+		return next
+	}
+}
+
+// BodyLimitMiddleware возвращает middleware, ограничивающее размер тела запроса maxSize байтами.
+// Обработчики отвечают кодом 413, если чтение тела прервано из-за превышения размера.
+func BodyLimitMiddleware(maxSize int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Кастомный http.ResponseWriter, накапливающий ответ обработчика до его завершения.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	code     int
+	body     bytes.Buffer
+	timedOut bool
+}
+
+// Header возвращает заголовки HTTP-ответа.
+func (w *timeoutWriter) Header() http.Header { return w.header }
+
+// Write записывает байты в тело HTTP-ответа или возвращает ошибку, если время обработки истекло.
+func (w *timeoutWriter) Write(bytes []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.body.Write(bytes)
+}
+
+// WriteHeader записывает заголовок HTTP-ответа.
+func (w *timeoutWriter) WriteHeader(statusCode int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut || w.code != 0 {
+		return
+	}
+	w.code = statusCode
+}
+
+// TimeoutMiddleware возвращает middleware, ограничивающее время обработки запроса timeout.
+// Контекст запроса отменяется по истечении времени, а клиенту возвращается код 503.
+// Ответ обработчика накапливается и передается клиенту только после его завершения,
+// поэтому middleware не подходит для потоковых ответов.
+func TimeoutMiddleware(timeout time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			// Паника после истечения времени не должна завершать процесс, поэтому канал буферизован.
+			panicCh := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicCh <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicCh:
+				// Паника будет обработана RecovererMiddleware.
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				for key, values := range tw.header {
+					w.Header()[key] = values
+				}
+				if tw.code == 0 {
+					tw.code = http.StatusOK
+				}
+				w.WriteHeader(tw.code)
+				w.Write(tw.body.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				tw.timedOut = true
+				tw.mu.Unlock()
+				err := ctx.Err()
+				if errors.Is(err, context.DeadlineExceeded) {
+					err = ErrRequestTimeout
+				}
+				handler.WriteError(w, http.StatusServiceUnavailable, err)
+			}
+		})
+	}
+}
+
+// DeadlineMiddleware возвращает middleware, ограничивающее время обработки запроса timeout
+// без накопления ответа: контекст запроса отменяется по истечении времени, а дедлайны чтения
+// и записи соединения прерывают обмен с медленным клиентом. Ответ передается клиенту
+// по мере записи, поэтому после истечения времени он обрывается без кода 503.
+func DeadlineMiddleware(timeout time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline := time.Now().Add(timeout)
+			ctx, cancel := context.WithDeadline(r.Context(), deadline)
+			defer cancel()
+
+			// Если w не поддерживает дедлайны, время ограничивается только контекстом.
+			rc := http.NewResponseController(w)
+			rc.SetReadDeadline(deadline)
+			rc.SetWriteDeadline(deadline)
+			// Дедлайн записи не сбрасывается сервером без WriteTimeout и действовал бы
+			// на следующие запросы соединения.
+			defer rc.SetWriteDeadline(time.Time{})
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package http
+
+import (
+	"dev11/app/transport/http/handler"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "Done",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Test", "1")
+				handler.WriteResult(w, http.StatusCreated, 1)
+			},
+			wantCode: http.StatusCreated,
+			wantBody: "{\"result\":1}\n",
+		},
+		{
+			name:     "NoResponse",
+			handler:  func(w http.ResponseWriter, r *http.Request) {},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "Timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				handler.WriteResult(w, http.StatusOK, 1)
+			},
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: "{\"error\":\"request timed out\"}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			TimeoutMiddleware(50*time.Millisecond)(tt.handler).ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.wantCode {
+				t.Errorf("TimeoutMiddleware() code = %v, want %v", got, tt.wantCode)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("TimeoutMiddleware() body = %q, want %q", got, tt.wantBody)
+			}
+			if tt.wantCode == http.StatusCreated && w.Header().Get("X-Test") != "1" {
+				t.Errorf("TimeoutMiddleware() header X-Test is lost")
+			}
+		})
+	}
+}
+
+func TestTimeoutMiddleware_Panic(t *testing.T) {
+	want := errors.New("test")
+	h := TimeoutMiddleware(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic(want) }))
+
+	defer func() {
+		if got := recover(); got != want {
+			t.Errorf("TimeoutMiddleware() panic = %v, want %v", got, want)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestDeadlineMiddleware(t *testing.T) {
+	t.Run("Streamed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		DeadlineMiddleware(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("part"))
+			// Ответ передается без накопления, поэтому обработчик может сбросить его клиенту.
+			if err := http.NewResponseController(w).Flush(); err != nil {
+				t.Errorf("Flush() error = %v", err)
+			}
+			if _, ok := r.Context().Deadline(); !ok {
+				t.Errorf("request context has no deadline")
+			}
+		})).ServeHTTP(w, r)
+
+		if !w.Flushed || w.Body.String() != "part" {
+			t.Errorf("DeadlineMiddleware() body = %q, flushed = %v", w.Body, w.Flushed)
+		}
+	})
+
+	t.Run("SlowClient", func(t *testing.T) {
+		readErr := make(chan error, 1)
+		server := httptest.NewServer(DeadlineMiddleware(50 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := io.ReadAll(r.Body)
+			readErr <- err
+		})))
+		defer server.Close()
+
+		// Клиент начинает тело запроса и не завершает его.
+		body, pw := io.Pipe()
+		defer pw.Close()
+		go func() {
+			pw.Write([]byte("start"))
+		}()
+		go http.Post(server.URL, "text/plain", body)
+
+		select {
+		case err := <-readErr:
+			if err == nil {
+				t.Errorf("DeadlineMiddleware() body read error = nil, want deadline error")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("DeadlineMiddleware() did not interrupt the body read")
+		}
+	})
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	h := BodyLimitMiddleware(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			handler.WriteRequestError(w, err)
+			return
+		}
+		handler.WriteResult(w, http.StatusOK, r.FormValue("title"))
+	}))
+
+	tests := []struct {
+		name     string
+		title    string
+		wantCode int
+	}{
+		{"Small", "test", http.StatusOK},
+		{"Large", strings.Repeat("a", 16), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := url.Values{"title": {tt.title}}.Encode()
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.wantCode {
+				t.Errorf("BodyLimitMiddleware() code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func Test_options_routeLimitsOf(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		pattern string
+		want    RouteLimits
+	}{
+		{"Default", nil, "POST /create_event", DefaultRouteLimits},
+		{"Upload", nil, "POST /import_csv", RouteLimits{Timeout: uploadTimeout, MaxBodySize: handler.MaxImportSize + handler.MultipartOverhead}},
+		{"Attachment", nil, "POST /create_event_attachment", RouteLimits{Timeout: uploadTimeout, MaxBodySize: -1}},
+		{
+			name:    "AttachmentMaxSize",
+			opts:    []Option{WithAttachments(nil, 1<<10)},
+			pattern: "POST /create_event_attachment",
+			want:    RouteLimits{Timeout: uploadTimeout, MaxBodySize: 1<<10 + handler.MultipartOverhead},
+		},
+		{"Download", nil, "GET /event_attachment", RouteLimits{Timeout: streamTimeout, MaxBodySize: DefaultRouteLimits.MaxBodySize, Stream: true}},
+		{
+			name:    "Limits",
+			opts:    []Option{WithLimits(RouteLimits{Timeout: time.Second})},
+			pattern: "POST /create_event",
+			want:    RouteLimits{Timeout: time.Second, MaxBodySize: DefaultRouteLimits.MaxBodySize},
+		},
+		{
+			name:    "RouteLimits",
+			opts:    []Option{WithRouteLimits("POST /import_csv", RouteLimits{MaxBodySize: 1 << 30})},
+			pattern: "POST /import_csv",
+			want:    RouteLimits{Timeout: uploadTimeout, MaxBodySize: 1 << 30},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o options
+			for _, opt := range tt.opts {
+				opt(&o)
+			}
+			if got := o.routeLimitsOf(tt.pattern); got != tt.want {
+				t.Errorf("options.routeLimitsOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"dev11/app/accesslog"
 	"log/slog"
 	"net/http"
 	"time"
@@ -16,8 +17,12 @@ type loggerWriter struct {
 	size int
 }
 
-// Write записывает байты в тело HTTP-ответа.
+// Write записывает байты в тело HTTP-ответа. Размер учитывает байты,
+// переданные клиенту, то есть после сжатия тела.
 func (w *loggerWriter) Write(bytes []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
 	n, err := w.ResponseWriter.Write(bytes)
 	w.size += n
 	return n, err
@@ -29,6 +34,9 @@ func (w *loggerWriter) WriteHeader(statusCode int) {
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Unwrap возвращает исходный http.ResponseWriter для http.ResponseController.
+func (w *loggerWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
 // LoggerMiddleware возвращает middleware для логирования запросов.
 func LoggerMiddleware(logger *slog.Logger) Middleware {
 	return func(next http.Handler) http.Handler {
@@ -41,6 +49,37 @@ func LoggerMiddleware(logger *slog.Logger) Middleware {
 	}
 }
 
+// AccessLogMiddleware возвращает middleware для записи запросов в журнал HTTP-запросов l.
+func AccessLogMiddleware(l *accesslog.Logger, logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lw := &loggerWriter{ResponseWriter: w}
+			start := time.Now()
+			next.ServeHTTP(lw, r)
+			code := lw.code
+			if code == 0 {
+				// Обработчик без ответа завершает запрос кодом 200.
+				code = http.StatusOK
+			}
+			err := l.Log(accesslog.Entry{
+				Time:       start,
+				RemoteAddr: r.RemoteAddr,
+				Method:     r.Method,
+				URL:        r.URL.RequestURI(),
+				Proto:      r.Proto,
+				Status:     code,
+				Size:       lw.size,
+				Duration:   time.Since(start),
+				Referer:    r.Referer(),
+				UserAgent:  r.UserAgent(),
+			})
+			if err != nil {
+				logger.Error("failed to write access log", "err", err)
+			}
+		})
+	}
+}
+
 // RecovererMiddleware возвращает middleware для обработки внутренних ошибок.
 func RecovererMiddleware(logger *slog.Logger) Middleware {
 	return func(next http.Handler) http.Handler {
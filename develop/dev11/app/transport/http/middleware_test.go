@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"dev11/app/accesslog"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -73,6 +75,33 @@ func TestLoggerMiddleware(t *testing.T) {
 	}
 }
 
+func TestAccessLogMiddleware(t *testing.T) {
+	var buf, errs bytes.Buffer
+	l := accesslog.New(&buf, accesslog.Options{Format: accesslog.FormatCombined, SampleRate: 0.001})
+	middleware := AccessLogMiddleware(l, slog.New(slog.NewJSONHandler(&errs, nil)))
+
+	// Успешные запросы почти не попадают в выборку, а ответы с ошибками записываются всегда.
+	ok := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	notFound := middleware(http.NotFoundHandler())
+	for range 100 {
+		ok.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	}
+	r := httptest.NewRequest(http.MethodGet, "/event?id=1", nil)
+	r.Header.Set("User-Agent", "test")
+	notFound.ServeHTTP(httptest.NewRecorder(), r)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if got := lines[len(lines)-1]; !strings.HasPrefix(got, "192.0.2.1 - - [") || !strings.HasSuffix(got, `"GET /event?id=1 HTTP/1.1" 404 19 "-" "test"`) {
+		t.Errorf("AccessLogMiddleware() = %q, want combined 404 entry", got)
+	}
+	if len(lines) > 10 {
+		t.Errorf("AccessLogMiddleware() wrote %d entries, want successful requests sampled", len(lines))
+	}
+	if errs.Len() != 0 {
+		t.Errorf("AccessLogMiddleware() errors = %s", errs.String())
+	}
+}
+
 func TestRecovererMiddleware(t *testing.T) {
 	var buf bytes.Buffer
 	logger := slog.New(slog.NewJSONHandler(&buf, nil))
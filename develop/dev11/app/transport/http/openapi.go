@@ -0,0 +1,19 @@
+package http
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// Спецификация OpenAPI маршрутов http-сервера.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// OpenAPIHandler возвращает HTTP-обработчик, отдающий спецификацию OpenAPI.
+func OpenAPIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPISpec)
+	})
+}
@@ -0,0 +1,563 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"dev11/app/entity"
+	"dev11/app/repo"
+	"dev11/app/service"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// loadSpec возвращает спецификацию OpenAPI, отдаваемую OpenAPIHandler.
+func loadSpec(t *testing.T) map[string]any {
+	t.Helper()
+	w := httptest.NewRecorder()
+	OpenAPIHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	var spec map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+// resolve возвращает объект спецификации spec, на который ссылается $ref объекта v.
+func resolve(spec map[string]any, v any) map[string]any {
+	obj, _ := v.(map[string]any)
+	ref, ok := obj["$ref"].(string)
+	if !ok {
+		return obj
+	}
+	var node any = spec
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		node = node.(map[string]any)[part]
+	}
+	return resolve(spec, node)
+}
+
+// validateSchema проверяет соответствие значения value схеме schema спецификации spec.
+// Поддерживает подмножество JSON Schema, используемое в спецификации.
+func validateSchema(spec map[string]any, schema any, value any, path string) error {
+	s := resolve(spec, schema)
+	if allOf, ok := s["allOf"].([]any); ok {
+		for _, sub := range allOf {
+			if err := validateSchema(spec, sub, value, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch s["type"] {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: want object, got %T", path, value)
+		}
+		required, _ := s["required"].([]any)
+		for _, name := range required {
+			if _, ok := obj[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		properties, _ := s["properties"].(map[string]any)
+		for name, prop := range properties {
+			if v, ok := obj[name]; ok {
+				if err := validateSchema(spec, prop, v, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: want array, got %T", path, value)
+		}
+		for i, item := range arr {
+			if err := validateSchema(spec, s["items"], item, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: want string, got %T", path, value)
+		}
+		if s["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	case "integer", "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: want number, got %T", path, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: want boolean, got %T", path, value)
+		}
+	}
+	return nil
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	OpenAPIHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	if got, want := w.Header().Get("Content-Type"), "application/json"; got != want {
+		t.Errorf("OpenAPIHandler() content type = %v, want %v", got, want)
+	}
+	if got, want := loadSpec(t)["openapi"], "3.0.3"; got != want {
+		t.Errorf("OpenAPIHandler() openapi = %v, want %v", got, want)
+	}
+}
+
+func TestOpenAPISpec_Routes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	spec := loadSpec(t)
+	paths := spec["paths"].(map[string]any)
+
+	registered := make(map[string]bool)
+	var mounted []string
+	for _, route := range newRoutes(service.NewMockEvent(ctrl), options{idempotencyStore: NewIdempotencyMemory()}, func() bool { return true }, nil) {
+		method, path, ok := strings.Cut(route.pattern, " ")
+		if !ok {
+			// Смонтированный обработчик описывается путем с параметром {path}.
+			mounted = append(mounted, route.pattern)
+			if _, ok := paths[route.pattern+"{path}"]; !ok {
+				t.Errorf("route %q: path is missing in spec", route.pattern)
+			}
+			continue
+		}
+		registered[method+" "+path] = true
+
+		operations, ok := paths[path].(map[string]any)
+		if !ok {
+			t.Errorf("route %q: path is missing in spec", route.pattern)
+			continue
+		}
+		if _, ok := operations[strings.ToLower(method)]; !ok {
+			t.Errorf("route %q: method is missing in spec", route.pattern)
+		}
+	}
+
+	isMounted := func(path string) bool {
+		return slices.ContainsFunc(mounted, func(prefix string) bool { return path == prefix+"{path}" })
+	}
+	for path, operations := range paths {
+		for method := range operations.(map[string]any) {
+			if pattern := strings.ToUpper(method) + " " + path; !registered[pattern] && !isMounted(path) {
+				t.Errorf("spec operation %q is not registered", pattern)
+			}
+		}
+	}
+}
+
+func TestOpenAPISpec_Responses(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 20, 16, 0, 0, 0, time.UTC)
+	event := entity.Event{ID: userID, Title: "event", Description: "description", Date: date, UserID: userID}
+	form := url.Values{"id": {userID}, "title": {"event"}, "date": {date.Format(time.RFC3339)}, "user_id": {userID}}
+
+	post := func(target string, data url.Values, header http.Header) func() *http.Request {
+		return func() *http.Request {
+			r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(data.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			for k, v := range header {
+				r.Header[k] = v
+			}
+			return r
+		}
+	}
+	get := func(target string) func() *http.Request {
+		return func() *http.Request { return httptest.NewRequest(http.MethodGet, target, nil) }
+	}
+	importCSV := func(fields url.Values, data string) func() *http.Request {
+		return func() *http.Request {
+			var b bytes.Buffer
+			mw := multipart.NewWriter(&b)
+			for k := range fields {
+				mw.WriteField(k, fields.Get(k))
+			}
+			fw, _ := mw.CreateFormFile("file", "events.csv")
+			io.WriteString(fw, data)
+			mw.Close()
+
+			r := httptest.NewRequest(http.MethodPost, "/import_csv", &b)
+			r.Header.Set("Content-Type", mw.FormDataContentType())
+			return r
+		}
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *service.MockEvent)
+		r       func() *http.Request
+		want    int
+	}{
+		{"CreateEvent", func(s *service.MockEvent) {
+			s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(event, nil)
+		}, post("/create_event", form, nil), http.StatusCreated},
+		{"CreateEventBadRequest", func(s *service.MockEvent) {}, post("/create_event", url.Values{}, nil), http.StatusBadRequest},
+		{"CreateEventServiceError", func(s *service.MockEvent) {
+			s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.EmptyEvent, &service.ExternalError{Err: entity.ErrTitleEmpty})
+		}, post("/create_event", form, nil), http.StatusServiceUnavailable},
+		{"CreateEventInternalError", func(s *service.MockEvent) {
+			s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.EmptyEvent, &service.InternalError{Err: io.EOF})
+		}, post("/create_event", form, nil), http.StatusInternalServerError},
+		// Хранилище содержит ответ на другой запрос с ключом "key".
+		{"CreateEventKeyReused", func(s *service.MockEvent) {}, post("/create_event", form, http.Header{IdempotencyKeyHeader: {"key"}}), http.StatusUnprocessableEntity},
+		{"CreateEventCrossOrigin", func(s *service.MockEvent) {}, post("/create_event", form, http.Header{"Origin": {"https://evil.example"}}), http.StatusForbidden},
+		{"UpdateEvent", func(s *service.MockEvent) {
+			s.EXPECT().Update(gomock.Any(), gomock.Any()).Return(event, nil)
+		}, post("/update_event", form, nil), http.StatusOK},
+		{"DeleteEvent", func(s *service.MockEvent) {
+			s.EXPECT().Delete(gomock.Any(), userID, userID).Return(nil)
+		}, post("/delete_event", form, nil), http.StatusNoContent},
+		{"Event", func(s *service.MockEvent) {
+			s.EXPECT().GetByID(gomock.Any(), userID, userID).Return(event, nil)
+		}, get("/event?user_id=" + userID + "&id=" + userID), http.StatusOK},
+		{"EventServiceError", func(s *service.MockEvent) {
+			s.EXPECT().GetByID(gomock.Any(), userID, userID).Return(entity.EmptyEvent, &service.ExternalError{Err: repo.ErrNotExist})
+		}, get("/event?user_id=" + userID + "&id=" + userID), http.StatusServiceUnavailable},
+		{"EventsForRange", func(s *service.MockEvent) {
+			s.EXPECT().GetForRange(gomock.Any(), userID, date, date.Add(time.Hour)).Return([]entity.Event{event}, nil)
+		}, get("/events_for_range?user_id=" + userID + "&start=2010-05-20T16:00:00Z&end=2010-05-20T17:00:00Z"), http.StatusOK},
+		{"EventsForRangeBadRequest", func(s *service.MockEvent) {}, get("/events_for_range?user_id=" + userID + "&start=2010-05-20"), http.StatusBadRequest},
+		{"EventsForDay", func(s *service.MockEvent) {
+			s.EXPECT().GetForDay(gomock.Any(), userID, gomock.Any()).Return([]entity.Event{event}, nil)
+		}, get("/events_for_day?user_id=" + userID + "&day=2010-05-20"), http.StatusOK},
+		{"EventsForWeek", func(s *service.MockEvent) {
+			s.EXPECT().GetForWeek(gomock.Any(), userID, gomock.Any()).Return([]entity.Event{}, nil)
+		}, get("/events_for_week?user_id=" + userID + "&week=2010-05-20"), http.StatusOK},
+		{"EventsForWeekBusinessDays", func(s *service.MockEvent) {
+			s.EXPECT().GetForWeek(gomock.Any(), userID, gomock.Any()).Return([]entity.Event{event}, nil)
+		}, get("/events_for_week?user_id=" + userID + "&week=2010-05-20&business_days=true"), http.StatusOK},
+		{"EventsForWeekBadRequest", func(s *service.MockEvent) {}, get("/events_for_week?user_id=" + userID + "&week=2010-05-20&business_days=maybe"), http.StatusBadRequest},
+		{"EventsForMonth", func(s *service.MockEvent) {
+			s.EXPECT().GetForMonth(gomock.Any(), userID, gomock.Any()).Return([]entity.Event{event}, nil)
+		}, get("/events_for_month?user_id=" + userID + "&month=2010-05"), http.StatusOK},
+		{"EventsForMonthNotModified", func(s *service.MockEvent) {
+			updated := event
+			updated.UpdatedAt = date
+			s.EXPECT().GetForMonth(gomock.Any(), userID, gomock.Any()).Return([]entity.Event{updated}, nil)
+		}, func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/events_for_month?user_id="+userID+"&month=2010-05", nil)
+			r.Header.Set("If-Modified-Since", date.Format(http.TimeFormat))
+			return r
+		}, http.StatusNotModified},
+		{"EventsForMonthBadRequest", func(s *service.MockEvent) {}, get("/events_for_month?month=May"), http.StatusBadRequest},
+		{"SearchEvents", func(s *service.MockEvent) {
+			s.EXPECT().Search(gomock.Any(), userID, "event").Return([]entity.Event{event}, nil)
+		}, get("/search_events?user_id=" + userID + "&q=event"), http.StatusOK},
+		{"UpcomingEvents", func(s *service.MockEvent) {
+			s.EXPECT().GetUpcoming(gomock.Any(), userID, 5).Return([]entity.Event{event}, nil)
+		}, get("/upcoming_events?user_id=" + userID + "&limit=5"), http.StatusOK},
+		{"UpcomingEventsBadRequest", func(s *service.MockEvent) {}, get("/upcoming_events?limit=five"), http.StatusBadRequest},
+		{"EventsForToday", func(s *service.MockEvent) {
+			s.EXPECT().GetForToday(gomock.Any(), userID).Return([]entity.Event{event}, nil)
+		}, get("/events_for_today?user_id=" + userID), http.StatusOK},
+		{"Agenda", func(s *service.MockEvent) {
+			s.EXPECT().GetForWeek(gomock.Any(), userID, gomock.Any()).Return([]entity.Event{event}, nil)
+		}, get("/agenda?user_id=" + userID + "&period=week&date=2010-05-20&format=html"), http.StatusOK},
+		{"AgendaBadRequest", func(s *service.MockEvent) {}, get("/agenda?user_id=" + userID + "&format=pdf"), http.StatusBadRequest},
+		{"ExportCSV", func(s *service.MockEvent) {
+			s.EXPECT().GetForRange(gomock.Any(), userID, gomock.Any(), gomock.Any()).Return([]entity.Event{event}, nil)
+		}, get("/export.csv?user_id=" + userID + "&delimiter=%3B&date_format=02.01.2006+15:04"), http.StatusOK},
+		{"ExportCSVBadRequest", func(s *service.MockEvent) {}, get("/export.csv?user_id=" + userID + "&tz=Mars"), http.StatusBadRequest},
+		{"ImportCSV", func(s *service.MockEvent) {
+			s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(event, nil)
+		}, importCSV(url.Values{"user_id": {userID}}, "title,date\nevent,2010-05-20 16:00\n,2010-05-20\n"), http.StatusOK},
+		{"ImportCSVDryRun", func(s *service.MockEvent) {}, importCSV(url.Values{"user_id": {userID}, "dry_run": {"true"}}, "title,date\nevent,2010-05-20\n"), http.StatusOK},
+		{"ImportCSVBadRequest", func(s *service.MockEvent) {}, importCSV(url.Values{"user_id": {userID}}, "title\nevent\n"), http.StatusBadRequest},
+		{"QuickAdd", func(s *service.MockEvent) {
+			s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(event, nil)
+		}, post("/quick_add", url.Values{"user_id": {userID}, "text": {"Standup every Monday 10:00 for 15m"}}, nil), http.StatusCreated},
+		{"QuickAddBadRequest", func(s *service.MockEvent) {}, post("/quick_add", url.Values{"text": {"tomorrow"}}, nil), http.StatusBadRequest},
+		{"OpenAPI", func(s *service.MockEvent) {}, get("/openapi.json"), http.StatusOK},
+		{"Healthz", func(s *service.MockEvent) {}, get("/healthz"), http.StatusOK},
+		{"Readyz", func(s *service.MockEvent) {}, get("/readyz"), http.StatusOK},
+	}
+
+	spec := loadSpec(t)
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := service.NewMockEvent(ctrl)
+			tt.prepare(service)
+			store := NewIdempotencyMemory()
+			store.Set(context.Background(), idempotencyKey(context.Background(), "key"), IdempotencyRecord{Hash: "hash"}, time.Minute)
+			handler := NewServer("", "", service, logger, WithIdempotencyStore(store, time.Minute)).httpServer.Handler
+
+			checkResponse(t, spec, handler, tt.r(), tt.want)
+		})
+	}
+}
+
+// checkResponse выполняет запрос r обработчиком handler и проверяет код ответа want
+// и соответствие ответа спецификации spec.
+func checkResponse(t *testing.T, spec map[string]any, handler http.Handler, r *http.Request, want int) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != want {
+		t.Fatalf("%s %s code = %v, want %v", r.Method, r.URL.Path, w.Code, want)
+	}
+
+	paths := spec["paths"].(map[string]any)
+	operation := paths[r.URL.Path].(map[string]any)[strings.ToLower(r.Method)].(map[string]any)
+	responses := operation["responses"].(map[string]any)
+	response := resolve(spec, responses[strconv.Itoa(w.Code)])
+	if response == nil {
+		t.Fatalf("%s %s: response %d is missing in spec", r.Method, r.URL.Path, w.Code)
+	}
+
+	content, ok := response["content"].(map[string]any)
+	if !ok {
+		// Сервер не отправляет тело ответа с кодом 204, в отличие от httptest.ResponseRecorder.
+		if w.Code != http.StatusNoContent && w.Body.Len() != 0 {
+			t.Errorf("%s %s: body = %q, want empty", r.Method, r.URL.Path, w.Body.String())
+		}
+		return
+	}
+	media, ok := content["application/json"].(map[string]any)
+	if !ok {
+		return
+	}
+
+	var body any
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateSchema(spec, media["schema"], body, "body"); err != nil {
+		t.Errorf("%s %s: %v", r.Method, r.URL.Path, err)
+	}
+}
+
+func TestOpenAPISpec_AttachmentResponses(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	attachment := entity.Attachment{ID: userID, EventID: userID, UserID: userID, Name: "a.txt", ContentType: "text/plain", Size: 1}
+
+	upload := func(data string) func() *http.Request {
+		return func() *http.Request {
+			var b bytes.Buffer
+			mw := multipart.NewWriter(&b)
+			mw.WriteField("user_id", userID)
+			mw.WriteField("event_id", userID)
+			fw, _ := mw.CreateFormFile("file", "a.txt")
+			io.WriteString(fw, data)
+			mw.Close()
+
+			r := httptest.NewRequest(http.MethodPost, "/create_event_attachment", &b)
+			r.Header.Set("Content-Type", mw.FormDataContentType())
+			return r
+		}
+	}
+	get := func(target string) func() *http.Request {
+		return func() *http.Request { return httptest.NewRequest(http.MethodGet, target, nil) }
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *service.MockAttachment)
+		r       func() *http.Request
+		want    int
+	}{
+		{"Upload", func(s *service.MockAttachment) {
+			s.EXPECT().Upload(gomock.Any(), userID, userID, "a.txt", gomock.Any()).Return(attachment, nil)
+		}, upload("a"), http.StatusCreated},
+		{"UploadTooLarge", func(s *service.MockAttachment) {}, upload(strings.Repeat("a", 2<<20)), http.StatusRequestEntityTooLarge},
+		{"UploadServiceTooLarge", func(s *service.MockAttachment) {
+			s.EXPECT().Upload(gomock.Any(), userID, userID, "a.txt", gomock.Any()).
+				Return(entity.Attachment{}, &service.ExternalError{Err: service.ErrAttachmentTooLarge})
+		}, upload("a"), http.StatusRequestEntityTooLarge},
+		{"UploadNoFile", func(s *service.MockAttachment) {}, func() *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "/create_event_attachment", strings.NewReader("--b--\r\n"))
+			r.Header.Set("Content-Type", "multipart/form-data; boundary=b")
+			return r
+		}, http.StatusBadRequest},
+		{"Download", func(s *service.MockAttachment) {
+			s.EXPECT().Download(gomock.Any(), userID, userID, userID).Return(attachment, io.NopCloser(strings.NewReader("a")), nil)
+		}, get("/event_attachment?user_id=" + userID + "&event_id=" + userID + "&id=" + userID), http.StatusOK},
+		{"DownloadNotExist", func(s *service.MockAttachment) {
+			s.EXPECT().Download(gomock.Any(), userID, userID, "").Return(entity.Attachment{}, nil, &service.ExternalError{Err: io.EOF})
+		}, get("/event_attachment?user_id=" + userID + "&event_id=" + userID), http.StatusServiceUnavailable},
+		{"List", func(s *service.MockAttachment) {
+			s.EXPECT().List(gomock.Any(), userID, userID).Return([]entity.Attachment{attachment}, nil)
+		}, get("/event_attachments?user_id=" + userID + "&event_id=" + userID), http.StatusOK},
+	}
+
+	spec := loadSpec(t)
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			attachments := service.NewMockAttachment(ctrl)
+			tt.prepare(attachments)
+			handler := NewServer("", "", service.NewMockEvent(ctrl), logger, WithAttachments(attachments, 1<<10)).httpServer.Handler
+
+			checkResponse(t, spec, handler, tt.r(), tt.want)
+		})
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		handler := NewServer("", "", service.NewMockEvent(ctrl), logger).httpServer.Handler
+		checkResponse(t, spec, handler, get("/event_attachments?user_id="+userID)(), http.StatusNotImplemented)
+	})
+}
+
+func TestOpenAPISpec_SlotResponses(t *testing.T) {
+	start := time.Date(2010, 5, 17, 9, 0, 0, 0, time.UTC)
+	form := url.Values{
+		"user_id":  {"18310e71-4df6-42c0-adf4-1a280013dd08", "28310e71-4df6-42c0-adf4-1a280013dd08"},
+		"duration": {"30m"},
+		"start":    {start.Format(time.RFC3339)},
+		"end":      {start.Add(8 * time.Hour).Format(time.RFC3339)},
+	}
+	post := func(data url.Values) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/find_slot", strings.NewReader(data.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *service.MockSlot)
+		data    url.Values
+		want    int
+	}{
+		{"FindSlot", func(s *service.MockSlot) {
+			s.EXPECT().Find(gomock.Any(), gomock.Any()).Return([]entity.Slot{{Start: start, End: start.Add(30 * time.Minute)}}, nil)
+		}, form, http.StatusOK},
+		{"FindSlotBadRequest", func(s *service.MockSlot) {}, url.Values{"duration": {"30m"}}, http.StatusBadRequest},
+		{"FindSlotServiceError", func(s *service.MockSlot) {
+			s.EXPECT().Find(gomock.Any(), gomock.Any()).Return(nil, service.ErrWindowTooLong)
+		}, form, http.StatusServiceUnavailable},
+	}
+
+	spec := loadSpec(t)
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			slots := service.NewMockSlot(ctrl)
+			tt.prepare(slots)
+			handler := NewServer("", "", service.NewMockEvent(ctrl), logger, WithSlots(slots)).httpServer.Handler
+
+			checkResponse(t, spec, handler, post(tt.data), tt.want)
+		})
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		handler := NewServer("", "", service.NewMockEvent(ctrl), logger).httpServer.Handler
+		checkResponse(t, spec, handler, post(form), http.StatusNotImplemented)
+	})
+}
+
+func TestOpenAPISpec_TemplateResponses(t *testing.T) {
+	userID, templateID := "18310e71-4df6-42c0-adf4-1a280013dd08", "28310e71-4df6-42c0-adf4-1a280013dd08"
+	date := time.Date(2010, 5, 17, 10, 0, 0, 0, time.UTC)
+	template := entity.Template{ID: templateID, UserID: userID, Title: "Standup {{date}}", Duration: entity.Duration(15 * time.Minute),
+		Tags: []string{"work"}, Reminders: []entity.Duration{entity.Duration(time.Minute)}}
+	event := entity.Event{ID: "38310e71-4df6-42c0-adf4-1a280013dd08", Title: "Standup 2010-05-17", Date: date, UserID: userID}
+	post := func(path string, data url.Values) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(data.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return r
+	}
+	form := url.Values{"user_id": {userID}, "title": {"Standup {{date}}"}, "duration": {"15m"}, "tag": {"work"}, "reminder": {"1m"}}
+	eventForm := url.Values{"user_id": {userID}, "template_id": {templateID}, "date": {date.Format(time.RFC3339)}}
+
+	tests := []struct {
+		name    string
+		prepare func(s *service.MockTemplate)
+		r       *http.Request
+		want    int
+	}{
+		{"CreateTemplate", func(s *service.MockTemplate) {
+			s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(template, nil)
+		}, post("/create_template", form), http.StatusCreated},
+		{"CreateTemplateBadRequest", func(s *service.MockTemplate) {}, post("/create_template", url.Values{"duration": {"soon"}}), http.StatusBadRequest},
+		{"UpdateTemplate", func(s *service.MockTemplate) {
+			s.EXPECT().Update(gomock.Any(), gomock.Any()).Return(template, nil)
+		}, post("/update_template", form), http.StatusOK},
+		{"DeleteTemplate", func(s *service.MockTemplate) {
+			s.EXPECT().Delete(gomock.Any(), userID, templateID).Return(nil)
+		}, post("/delete_template", url.Values{"user_id": {userID}, "id": {templateID}}), http.StatusNoContent},
+		{"Templates", func(s *service.MockTemplate) {
+			s.EXPECT().GetByUser(gomock.Any(), userID).Return([]entity.Template{template}, nil)
+		}, httptest.NewRequest(http.MethodGet, "/templates?user_id="+userID, nil), http.StatusOK},
+		{"CreateEventFromTemplate", func(s *service.MockTemplate) {
+			s.EXPECT().CreateEvent(gomock.Any(), userID, templateID, date, gomock.Any()).Return(event, template, nil)
+		}, post("/create_event_from_template", eventForm), http.StatusCreated},
+		{"CreateEventFromTemplateServiceError", func(s *service.MockTemplate) {
+			s.EXPECT().CreateEvent(gomock.Any(), userID, templateID, date, gomock.Any()).
+				Return(entity.EmptyEvent, entity.Template{}, &service.ExternalError{Err: repo.ErrNotExist})
+		}, post("/create_event_from_template", eventForm), http.StatusServiceUnavailable},
+	}
+
+	spec := loadSpec(t)
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			templates := service.NewMockTemplate(ctrl)
+			tt.prepare(templates)
+			handler := NewServer("", "", service.NewMockEvent(ctrl), logger, WithTemplates(templates)).httpServer.Handler
+
+			checkResponse(t, spec, handler, tt.r, tt.want)
+		})
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		handler := NewServer("", "", service.NewMockEvent(ctrl), logger).httpServer.Handler
+		checkResponse(t, spec, handler, post("/create_event_from_template", eventForm), http.StatusNotImplemented)
+	})
+}
+
+func TestOpenAPISpec_ReadyzShuttingDown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := NewServer("", "", service.NewMockEvent(ctrl), slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	s.Drain()
+	checkResponse(t, loadSpec(t), s.httpServer.Handler, httptest.NewRequest(http.MethodGet, "/readyz", nil), http.StatusServiceUnavailable)
+}
@@ -2,64 +2,325 @@ package http
 
 import (
 	"context"
+	"dev11/app/accesslog"
+	"dev11/app/clock"
 	"dev11/app/service"
+	"dev11/app/transport/http/caldav"
 	"dev11/app/transport/http/handler"
+	"dev11/app/workcal"
 	"log/slog"
 	"net"
 	"net/http"
+	"sync/atomic"
+	"time"
 )
 
+// Время хранения ответов на запросы с ключами идемпотентности по умолчанию.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// Параметры http-сервера.
+type options struct {
+	idempotencyStore IdempotencyStore
+	idempotencyTTL   time.Duration
+	tenantDomain     string
+	attachments      service.Attachment
+	maxAttachment    int64
+	agenda           service.Agenda
+	clock            clock.Clock
+	compressMinSize  int
+	cors             CORSOptions
+	schedules        *workcal.Schedules
+	slots            service.Slot
+	templates        service.Template
+	accessLog        *accesslog.Logger
+	limits           RouteLimits
+	routeLimits      map[string]RouteLimits
+}
+
+// Тип функции, изменяющей параметры http-сервера.
+type Option func(o *options)
+
+// WithIdempotencyStore задает хранилище ответов на запросы с ключами идемпотентности
+// и время их хранения ttl. По умолчанию используется in-memory хранилище.
+func WithIdempotencyStore(store IdempotencyStore, ttl time.Duration) Option {
+	return func(o *options) {
+		o.idempotencyStore = store
+		o.idempotencyTTL = ttl
+	}
+}
+
+// WithTenantDomain задает домен, поддомены которого определяют арендатора запроса.
+// Заголовок TenantHeader имеет приоритет над поддоменом.
+func WithTenantDomain(domain string) Option {
+	return func(o *options) { o.tenantDomain = domain }
+}
+
+// WithAttachments задает сервис вложений событий и максимальный размер вложения maxSize в байтах.
+// Без сервиса методы вложений отвечают кодом 501.
+func WithAttachments(attachments service.Attachment, maxSize int64) Option {
+	return func(o *options) {
+		o.attachments = attachments
+		o.maxAttachment = maxSize
+	}
+}
+
+// WithAgenda задает сервис повестки. По умолчанию повестка строится по сервису событий
+// с параметрами service.DefaultAgendaOptions и графиками из WithSchedules.
+func WithAgenda(agenda service.Agenda) Option {
+	return func(o *options) { o.agenda = agenda }
+}
+
+// WithClock задает часы, относительно которых обработчики определяют текущую дату
+// повестки и разбирают относительные даты быстрого добавления. По умолчанию используются системные часы.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) { o.clock = c }
+}
+
+// WithCompression задает минимальный размер тела ответа в байтах, начиная с которого
+// ответ сжимается. Отрицательное значение отключает сжатие. По умолчанию DefaultCompressMinSize.
+func WithCompression(minSize int) Option {
+	return func(o *options) { o.compressMinSize = minSize }
+}
+
+// WithCORS задает параметры CORS. Источники opts.AllowedOrigins также считаются доверенными
+// при защите от CSRF. По умолчанию CORS отключен, а доверенным считается только адрес сервера.
+func WithCORS(opts CORSOptions) Option {
+	return func(o *options) { o.cors = opts }
+}
+
+// WithSchedules задает рабочие графики пользователей для фильтра рабочих дней недели
+// и предупреждений о событиях в праздники. По умолчанию используется workcal.DefaultSchedule
+// без праздников.
+func WithSchedules(schedules *workcal.Schedules) Option {
+	return func(o *options) { o.schedules = schedules }
+}
+
+// WithSlots задает сервис поиска времени встречи. Без сервиса метод /find_slot отвечает кодом 501.
+func WithSlots(slots service.Slot) Option {
+	return func(o *options) { o.slots = slots }
+}
+
+// WithTemplates задает сервис шаблонов событий. Без сервиса методы шаблонов отвечают кодом 501.
+func WithTemplates(templates service.Template) Option {
+	return func(o *options) { o.templates = templates }
+}
+
+// WithAccessLog задает журнал HTTP-запросов. По умолчанию запросы записываются в журнал сервера.
+func WithAccessLog(l *accesslog.Logger) Option {
+	return func(o *options) { o.accessLog = l }
+}
+
+// WithLimits задает ограничения обработки запросов всех маршрутов. Нулевые поля limits
+// заменяются полями DefaultRouteLimits.
+func WithLimits(limits RouteLimits) Option {
+	return func(o *options) { o.limits = limits }
+}
+
+// WithRouteLimits задает ограничения обработки запросов маршрута с шаблоном pattern,
+// например "POST /create_event". Нулевые поля limits заменяются ограничениями маршрута по умолчанию.
+func WithRouteLimits(pattern string, limits RouteLimits) Option {
+	return func(o *options) {
+		if o.routeLimits == nil {
+			o.routeLimits = make(map[string]RouteLimits)
+		}
+		o.routeLimits[pattern] = limits
+	}
+}
+
 // Обертка над http-сервером с маршрутами, промежуточными слоями и методами Start, Stop, Err.
 type Server struct {
 	httpServer *http.Server
 	errCh      chan error
+	addr       net.Addr
+	// draining устанавливается в начале остановки, после чего /readyz отвечает кодом 503.
+	draining atomic.Bool
+}
+
+// Структура маршрута http-сервера. Шаблон без метода монтирует обработчик
+// на все запросы с заданным префиксом пути.
+type route struct {
+	pattern string
+	handler http.Handler
+}
+
+// Время обработки запросов с загрузкой файлов по умолчанию.
+const uploadTimeout = 5 * time.Minute
+
+// Время обработки запросов с потоковыми ответами по умолчанию.
+const streamTimeout = 10 * time.Minute
+
+// Время чтения заголовков запроса.
+const readHeaderTimeout = 10 * time.Second
+
+// Ограничения маршрутов, отличающиеся от ограничений всех маршрутов. Размер загружаемого
+// файла ограничивается с запасом на поля формы, а размер вложения дополнительно ограничивается
+// параметром WithAttachments. TimeoutMiddleware накапливает ответ в памяти, поэтому маршруты
+// с потенциально большими ответами ограничиваются по времени без накопления ответа.
+var defaultRouteLimits = map[string]RouteLimits{
+	"POST /import_csv":              {Timeout: uploadTimeout, MaxBodySize: handler.MaxImportSize + handler.MultipartOverhead},
+	"POST /create_event_attachment": {Timeout: uploadTimeout, MaxBodySize: -1},
+	"GET /event_attachment":         {Timeout: streamTimeout, Stream: true},
+	"GET /export.csv":               {Timeout: streamTimeout, Stream: true},
+	"GET /export_user":              {Timeout: streamTimeout, Stream: true},
+	"/dav/":                         {Timeout: streamTimeout, Stream: true},
+}
+
+// newRoutes возвращает маршруты http-сервера. Функция ready сообщает,
+// готов ли сервер принимать новые запросы, logger записывает ошибки обработчиков.
+func newRoutes(service service.Event, o options, ready func() bool, logger *slog.Logger) []route {
+	idempotency := IdempotencyMiddleware(o.idempotencyStore, o.idempotencyTTL)
+	agenda := o.agenda
+	if agenda == nil {
+		agenda = newAgenda(service, o.schedules)
+	}
+	users := newUsers(service, o)
+
+	return []route{
+		{"POST /create_event", idempotency(handler.EventCreate{Service: service, Schedules: o.schedules})},
+		{"POST /update_event", handler.EventUpdate{Service: service}},
+		{"POST /delete_event", handler.EventDelete{Service: service}},
+		{"GET /event", handler.EventGet{Service: service}},
+		{"GET /events_for_range", handler.EventGetForRange{Service: service}},
+		{"GET /events_for_day", handler.EventGetForDay{Service: service}},
+		{"GET /events_for_week", handler.EventGetForWeek{Service: service, Schedules: o.schedules}},
+		{"GET /events_for_month", handler.EventGetForMonth{Service: service}},
+		{"GET /search_events", handler.EventSearch{Service: service}},
+		{"GET /upcoming_events", handler.EventGetUpcoming{Service: service}},
+		{"GET /events_for_today", handler.EventGetForToday{Service: service}},
+		{"GET /export.csv", handler.EventExportCSV{Service: service}},
+		{"POST /import_csv", idempotency(handler.EventImportCSV{Service: service, Schedules: o.schedules, Logger: logger})},
+		{"POST /create_event_attachment", handler.AttachmentCreate{Service: o.attachments, MaxSize: o.maxAttachment}},
+		{"GET /event_attachment", handler.AttachmentGet{Service: o.attachments}},
+		{"GET /event_attachments", handler.AttachmentList{Service: o.attachments}},
+		{"GET /agenda", handler.AgendaGet{Service: agenda, Clock: o.clock}},
+		{"POST /find_slot", handler.SlotFind{Service: o.slots}},
+		{"POST /quick_add", idempotency(handler.QuickAdd{Service: service, Clock: o.clock, Schedules: o.schedules})},
+		{"POST /create_template", idempotency(handler.TemplateCreate{Service: o.templates})},
+		{"POST /update_template", handler.TemplateUpdate{Service: o.templates}},
+		{"POST /delete_template", handler.TemplateDelete{Service: o.templates}},
+		{"GET /templates", handler.TemplateGetByUser{Service: o.templates}},
+		{"POST /create_event_from_template", idempotency(handler.TemplateCreateEvent{Service: o.templates, Schedules: o.schedules})},
+		{"POST /transfer_events", handler.EventTransfer{Service: service}},
+		{"GET /export_user", handler.UserExport{Service: users}},
+		{"POST /delete_user", handler.UserDelete{Service: users}},
+		{"GET /openapi.json", OpenAPIHandler()},
+		{"GET /healthz", handler.Healthz{}},
+		{"GET /readyz", handler.Readyz{Ready: ready}},
+		{"/dav/", caldav.Handler{Service: service, Prefix: "/dav/", Schedules: o.schedules}},
+	}
+}
+
+// newAgenda возвращает сервис повестки с параметрами по умолчанию и графиками schedules
+// по сервису событий events.
+func newAgenda(events service.Event, schedules *workcal.Schedules) service.Agenda {
+	opts := service.DefaultAgendaOptions
+	opts.Schedules = schedules
+	return service.NewAgendaV1(events, opts)
+}
+
+// newUsers возвращает сервис данных пользователя по сервису событий events
+// и сервисам шаблонов и вложений из o.
+func newUsers(events service.Event, o options) service.User {
+	return service.NewUserV1(events, o.templates, o.attachments)
+}
+
+// routeLimitsOf возвращает ограничения обработки запросов маршрута с шаблоном pattern.
+func (o options) routeLimitsOf(pattern string) RouteLimits {
+	limits := defaultRouteLimits[pattern]
+	if pattern == "POST /create_event_attachment" && o.maxAttachment > 0 {
+		limits.MaxBodySize = o.maxAttachment + handler.MultipartOverhead
+	}
+	limits = limits.or(o.limits.or(DefaultRouteLimits))
+	return o.routeLimits[pattern].or(limits)
 }
 
 // NewServer возвращает новый http-сервер, если service и logger не равны nil.
-func NewServer(host string, port string, service service.Event, logger *slog.Logger) *Server {
+func NewServer(host string, port string, service service.Event, logger *slog.Logger, opts ...Option) *Server {
 	if service == nil || logger == nil {
 		return nil
 	}
 
+	o := options{idempotencyTTL: DefaultIdempotencyTTL, compressMinSize: DefaultCompressMinSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.idempotencyStore == nil {
+		o.idempotencyStore = NewIdempotencyMemory()
+	}
+
+	s := &Server{errCh: make(chan error, 1)}
 	router := http.NewServeMux()
-	router.Handle("POST /create_event", handler.EventCreate{Service: service})
-	router.Handle("POST /update_event", handler.EventUpdate{Service: service})
-	router.Handle("POST /delete_event", handler.EventDelete{Service: service})
-	router.Handle("GET /events_for_day", handler.EventGetForDay{Service: service})
-	router.Handle("GET /events_for_week", handler.EventGetForWeek{Service: service})
-	router.Handle("GET /events_for_month", handler.EventGetForMonth{Service: service})
+	for _, route := range newRoutes(service, o, s.Ready, logger) {
+		router.Handle(route.pattern, o.routeLimitsOf(route.pattern).middleware()(route.handler))
+	}
 
 	var mux http.Handler = router
-	middlewares := []Middleware{RecovererMiddleware(logger), LoggerMiddleware(logger)}
+	middlewares := []Middleware{TenantMiddleware(o.tenantDomain), RecovererMiddleware(logger), CSRFMiddleware(o.cors.AllowedOrigins)}
+	if len(o.cors.AllowedOrigins) > 0 {
+		middlewares = append(middlewares, CORSMiddleware(o.cors))
+	}
+	if o.compressMinSize >= 0 {
+		// Сжатие выполняется внутри LoggerMiddleware, чтобы в журнал попадал размер переданного тела.
+		middlewares = append(middlewares, CompressionMiddleware(o.compressMinSize))
+	}
+	if o.accessLog != nil {
+		middlewares = append(middlewares, AccessLogMiddleware(o.accessLog, logger))
+	} else {
+		middlewares = append(middlewares, LoggerMiddleware(logger))
+	}
 	for _, middleware := range middlewares {
 		mux = middleware(mux)
 	}
 
-	httpServer := &http.Server{
+	s.httpServer = &http.Server{
 		Addr:    net.JoinHostPort(host, port),
 		Handler: mux,
+		// Время чтения тела и записи ответа ограничивается маршрутами.
+		ReadHeaderTimeout: readHeaderTimeout,
 	}
-
-	return &Server{
-		httpServer: httpServer,
-		errCh:      make(chan error, 1),
-	}
+	return s
 }
 
-// Start запускает http-сервер в отдельной горутине.
+// Start открывает адрес сервера и обслуживает запросы в отдельной горутине.
+// Контексты запросов наследуются от ctx. Ошибка открытия адреса передается в канал Err.
 func (s *Server) Start(ctx context.Context) {
 	s.httpServer.BaseContext = func(_ net.Listener) context.Context {
 		return ctx
 	}
 
+	lis, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		s.errCh <- err
+		close(s.errCh)
+		return
+	}
+	s.addr = lis.Addr()
+
 	go func() {
-		s.errCh <- s.httpServer.ListenAndServe()
+		s.errCh <- s.httpServer.Serve(lis)
 		close(s.errCh)
 	}()
 }
 
-// Stop останавливает http-сервер.
-func (s *Server) Stop(ctx context.Context) error { return s.httpServer.Shutdown(ctx) }
+// Handler возвращает обработчик запросов сервера со всеми маршрутами и промежуточными слоями.
+func (s *Server) Handler() http.Handler { return s.httpServer.Handler }
+
+// Addr возвращает адрес, на котором сервер принимает запросы, или nil, если сервер не запущен.
+func (s *Server) Addr() net.Addr { return s.addr }
+
+// Ready сообщает, готов ли сервер принимать новые запросы.
+func (s *Server) Ready() bool { return !s.draining.Load() }
+
+// Drain переводит сервер в состояние остановки: /readyz начинает отвечать кодом 503,
+// но сервер продолжает обслуживать запросы до вызова Stop.
+func (s *Server) Drain() { s.draining.Store(true) }
+
+// Stop переводит сервер в состояние остановки, закрывает адрес и дожидается завершения
+// обрабатываемых запросов, пока не отменен ctx.
+func (s *Server) Stop(ctx context.Context) error {
+	s.Drain()
+	return s.httpServer.Shutdown(ctx)
+}
 
 // Err возвращает канал с ошибками http-сервера.
 func (s *Server) Err() <-chan error { return s.errCh }
@@ -2,10 +2,22 @@ package http
 
 import (
 	"context"
+	"dev11/app/clock"
+	"dev11/app/entity"
+	"dev11/app/repo"
 	"dev11/app/service"
+	"dev11/app/workcal"
+	"encoding/json"
+	"io"
 	"log/slog"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"go.uber.org/mock/gomock"
 )
@@ -70,3 +82,339 @@ func TestServer_Err(t *testing.T) {
 		t.Errorf("Server.Err() = %v, want %v", got, want)
 	}
 }
+
+func TestServer_Clock(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	now := time.Date(2010, 5, 17, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	events := service.NewEventV1(repo.NewEventMemory(), service.WithClock(fake))
+	for _, date := range []time.Time{now.Add(-time.Hour), now.Add(time.Hour), now.Add(24 * time.Hour)} {
+		if _, err := events.Create(context.Background(), entity.Event{Title: "event", Date: date, UserID: userID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := NewServer("", "", events, slog.New(slog.NewJSONHandler(io.Discard, nil)), WithClock(fake)).httpServer.Handler
+
+	count := func(target string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		var body struct{ Result []entity.Event }
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("GET %s: %v", target, err)
+		}
+		return len(body.Result)
+	}
+
+	if got := count("/upcoming_events?user_id=" + userID); got != 2 {
+		t.Errorf("upcoming events = %d, want 2", got)
+	}
+	if got := count("/events_for_today?user_id=" + userID); got != 2 {
+		t.Errorf("events for today = %d, want 2", got)
+	}
+
+	fake.Advance(12 * time.Hour)
+	if got := count("/upcoming_events?user_id=" + userID); got != 1 {
+		t.Errorf("upcoming events after advance = %d, want 1", got)
+	}
+	if got := count("/events_for_today?user_id=" + userID); got != 1 {
+		t.Errorf("events for today after advance = %d, want 1", got)
+	}
+}
+
+func TestServer_GracefulShutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	events := service.NewMockEvent(ctrl)
+	events.EXPECT().GetForToday(gomock.Any(), "1").DoAndReturn(func(context.Context, string) ([]entity.Event, error) {
+		close(entered)
+		<-release
+		return []entity.Event{}, nil
+	})
+
+	s := NewServer("127.0.0.1", "0", events, slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	s.Start(context.Background())
+	base := "http://" + s.Addr().String()
+
+	get := func(path string) (int, error) {
+		resp, err := http.Get(base + path)
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+
+	// Запрос, обрабатываемый во время остановки сервера.
+	inFlight := make(chan int, 1)
+	go func() {
+		code, err := get("/events_for_today?user_id=1")
+		if err != nil {
+			t.Errorf("in-flight request error = %v", err)
+		}
+		inFlight <- code
+	}()
+	<-entered
+
+	s.Drain()
+	if code, err := get("/readyz"); err != nil || code != http.StatusServiceUnavailable {
+		t.Errorf("GET /readyz while draining = %v, %v, want %v", code, err, http.StatusServiceUnavailable)
+	}
+	if code, err := get("/healthz"); err != nil || code != http.StatusOK {
+		t.Errorf("GET /healthz while draining = %v, %v, want %v", code, err, http.StatusOK)
+	}
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- s.Stop(context.Background()) }()
+	select {
+	case err := <-stopped:
+		t.Fatalf("Server.Stop() returned before in-flight request finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if code := <-inFlight; code != http.StatusOK {
+		t.Errorf("in-flight request = %v, want %v", code, http.StatusOK)
+	}
+	if err := <-stopped; err != nil {
+		t.Errorf("Server.Stop() error = %v", err)
+	}
+	if err := <-s.Err(); err != http.ErrServerClosed {
+		t.Errorf("Server.Err() = %v, want %v", err, http.ErrServerClosed)
+	}
+	if _, err := get("/healthz"); err == nil {
+		t.Errorf("GET /healthz after stop succeeded, want connection error")
+	}
+}
+
+func TestServer_StartError(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	host, port, _ := net.SplitHostPort(lis.Addr().String())
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := NewServer(host, port, service.NewMockEvent(ctrl), slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	s.Start(context.Background())
+	if err := <-s.Err(); err == nil {
+		t.Errorf("Server.Err() = nil, want address in use error")
+	}
+	if s.Addr() != nil {
+		t.Errorf("Server.Addr() = %v, want nil", s.Addr())
+	}
+}
+
+func TestServer_ConditionalGet(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	now := time.Date(2010, 5, 17, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	events := service.NewEventV1(repo.NewEventMemory(), service.WithClock(fake))
+	event, err := events.Create(context.Background(), entity.Event{Title: "event", Date: now.Add(time.Hour), UserID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewServer("", "", events, slog.New(slog.NewJSONHandler(io.Discard, nil)), WithClock(fake)).httpServer.Handler
+
+	get := func(header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/events_for_today?user_id="+userID, nil)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	first := get(nil)
+	etag, lastModified := first.Header().Get("ETag"), first.Header().Get("Last-Modified")
+	if first.Code != http.StatusOK || lastModified != now.Format(http.TimeFormat) {
+		t.Fatalf("GET = %v, Last-Modified %q, want %v, %q", first.Code, lastModified, http.StatusOK, now.Format(http.TimeFormat))
+	}
+	if w := get(http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified {
+		t.Errorf("GET If-None-Match = %v, want %v", w.Code, http.StatusNotModified)
+	}
+
+	fake.Advance(time.Minute)
+	event.Title = "renamed"
+	if _, err := events.Update(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	w := get(http.Header{"If-None-Match": {etag}, "If-Modified-Since": {lastModified}})
+	if w.Code != http.StatusOK || w.Header().Get("Last-Modified") != now.Add(time.Minute).Format(http.TimeFormat) {
+		t.Errorf("GET after update = %v, Last-Modified %q", w.Code, w.Header().Get("Last-Modified"))
+	}
+}
+
+func TestServer_CORS(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	origin := "https://app.example.com"
+	events := service.NewMockEvent(ctrl)
+	events.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.Event{UserID: userID}, nil)
+	handler := NewServer("", "", events, slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		WithCORS(CORSOptions{AllowedOrigins: []string{origin}, MaxAge: time.Minute})).httpServer.Handler
+
+	r := httptest.NewRequest(http.MethodOptions, "/create_event", nil)
+	r.Header.Set("Origin", origin)
+	r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	r.Header.Set("Access-Control-Request-Headers", IdempotencyKeyHeader)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != origin || w.Header().Get("Access-Control-Max-Age") != "60" {
+		t.Errorf("OPTIONS /create_event = %v %v", w.Code, w.Header())
+	}
+
+	post := func(origin string) *httptest.ResponseRecorder {
+		form := url.Values{"title": {"event"}, "date": {"2010-05-17T10:00:00Z"}, "user_id": {userID}}
+		r := httptest.NewRequest(http.MethodPost, "/create_event", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	if w := post(origin); w.Code != http.StatusCreated || w.Header().Get("Access-Control-Allow-Origin") != origin {
+		t.Errorf("POST /create_event from %s = %v %v", origin, w.Code, w.Header())
+	}
+	if w := post("https://evil.example"); w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("POST /create_event from untrusted origin = %v %v", w.Code, w.Header())
+	}
+}
+
+func TestServer_Limits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	events := service.NewMockEvent(ctrl)
+	events.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, event entity.Event) (entity.Event, error) {
+		<-ctx.Done()
+		return entity.EmptyEvent, &service.ExternalError{Err: ctx.Err()}
+	})
+	handler := NewServer("", "", events, slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		WithLimits(RouteLimits{MaxBodySize: 64}),
+		WithRouteLimits("POST /update_event", RouteLimits{Timeout: 50 * time.Millisecond})).httpServer.Handler
+
+	post := func(path string, title string) *httptest.ResponseRecorder {
+		form := url.Values{"id": {"1"}, "title": {title}, "date": {"2010-05-17T10:00:00Z"}, "user_id": {"1"}}
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := post("/create_event", strings.Repeat("a", 64)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("POST /create_event = %v %s, want %v", w.Code, w.Body, http.StatusRequestEntityTooLarge)
+	}
+	if w := post("/update_event", "event"); w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), ErrRequestTimeout.Error()) {
+		t.Errorf("POST /update_event = %v %s, want %v", w.Code, w.Body, http.StatusServiceUnavailable)
+	}
+}
+
+// Кастомный http.ResponseWriter, запоминающий размер самой большой записи тела ответа.
+type chunkRecorder struct {
+	*httptest.ResponseRecorder
+	maxChunk int
+}
+
+// Write записывает байты в тело ответа и запоминает размер записи.
+func (w *chunkRecorder) Write(b []byte) (int, error) {
+	w.maxChunk = max(w.maxChunk, len(b))
+	return w.ResponseRecorder.Write(b)
+}
+
+func TestServer_AttachmentStreaming(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	size := 8 << 20
+	ctx := context.Background()
+
+	events := repo.NewEventMemory()
+	blobs := repo.NewBlobFS(t.TempDir())
+	eventService := service.NewEventV1(events, service.WithBlobStore(blobs))
+	attachments := service.NewAttachmentV1(events, blobs, 0)
+	event, err := eventService.Create(ctx, entity.Event{Title: "event", UserID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachment, err := attachments.Upload(ctx, userID, event.ID, "large.bin", strings.NewReader(strings.Repeat("a", size)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Маленькое ограничение времени всех маршрутов не применяется к скачиванию вложений.
+	handler := NewServer("", "", eventService, slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		WithAttachments(attachments, 0), WithLimits(RouteLimits{Timeout: time.Nanosecond})).httpServer.Handler
+
+	query := url.Values{"user_id": {userID}, "event_id": {event.ID}, "id": {attachment.ID}}
+	w := &chunkRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/event_attachment?"+query.Encode(), nil))
+
+	if w.Code != http.StatusOK || w.Body.Len() != size {
+		t.Fatalf("GET /event_attachment = %v with %d bytes, want %v with %d bytes", w.Code, w.Body.Len(), http.StatusOK, size)
+	}
+	if w.maxChunk >= size {
+		t.Errorf("GET /event_attachment wrote %d bytes at once, want a streamed response", w.maxChunk)
+	}
+}
+
+func TestServer_HolidayWarnings(t *testing.T) {
+	userID := "18310e71-4df6-42c0-adf4-1a280013dd08"
+	ctx := context.Background()
+	const want = "2010-05-20 is a holiday: Ascension Day"
+
+	holidays := workcal.NewCalendar()
+	holidays.AddHoliday(time.Date(2010, 5, 20, 0, 0, 0, 0, time.UTC), "Ascension Day")
+	eventService := service.NewEventV1(repo.NewEventMemory())
+	templates := service.NewTemplateV1(repo.NewTemplateMemory(), eventService)
+	template, err := templates.Create(ctx, entity.Template{Title: "Standup", UserID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewServer("", "", eventService, slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		WithSchedules(&workcal.Schedules{Default: workcal.Schedule{Calendar: holidays}}), WithTemplates(templates),
+		WithClock(clock.NewFake(time.Date(2010, 5, 19, 11, 0, 0, 0, time.UTC)))).httpServer.Handler
+
+	form := func(target string, values url.Values) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(values.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return r
+	}
+	var csv strings.Builder
+	mw := multipart.NewWriter(&csv)
+	mw.WriteField("user_id", userID)
+	part, _ := mw.CreateFormFile("file", "events.csv")
+	io.WriteString(part, "title,date\nRetro,2010-05-20T16:00:00Z\n")
+	mw.Close()
+	importRequest := httptest.NewRequest(http.MethodPost, "/import_csv", strings.NewReader(csv.String()))
+	importRequest.Header.Set("Content-Type", mw.FormDataContentType())
+
+	tests := []struct {
+		name string
+		r    *http.Request
+	}{
+		{"QuickAdd", form("/quick_add", url.Values{"user_id": {userID}, "text": {"Standup tomorrow 10:00"}})},
+		{"Template", form("/create_event_from_template", url.Values{"user_id": {userID}, "template_id": {template.ID}, "date": {"2010-05-20T10:00:00Z"}})},
+		{"ImportCSV", importRequest},
+		{"CalDAV", httptest.NewRequest(http.MethodPut, "/dav/calendars/"+userID+"/default/review.ics", strings.NewReader(
+			"BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nUID:review\r\nDTSTART:20100520T160000Z\r\nSUMMARY:Review\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, tt.r)
+
+			if w.Code != http.StatusOK && w.Code != http.StatusCreated {
+				t.Fatalf("%s %s = %v: %s", tt.r.Method, tt.r.URL.Path, w.Code, w.Body)
+			}
+			if got := w.Header().Get("Warning") + w.Body.String(); !strings.Contains(got, want) {
+				t.Errorf("%s %s = %s, want warning %q", tt.r.Method, tt.r.URL.Path, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"dev11/app/tenant"
+	"dev11/app/transport/http/handler"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Заголовок запроса с именем арендатора.
+const TenantHeader = "X-Tenant-ID"
+
+// resolveTenant возвращает арендатора запроса r из заголовка TenantHeader или, если задан
+// домен domain, из поддомена первого уровня в заголовке Host. Без арендатора возвращает tenant.Default.
+func resolveTenant(r *http.Request, domain string) string {
+	if name := r.Header.Get(TenantHeader); name != "" {
+		return name
+	}
+	if domain == "" {
+		return tenant.Default
+	}
+
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if name, ok := strings.CutSuffix(host, "."+strings.ToLower(domain)); ok && !strings.Contains(name, ".") {
+		return name
+	}
+	return tenant.Default
+}
+
+// TenantMiddleware возвращает middleware, который помещает арендатора запроса в контекст.
+// Поддомены домена domain считаются именами арендаторов. Запросы с неверным именем арендатора
+// отклоняются с кодом 400.
+func TenantMiddleware(domain string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := resolveTenant(r, domain)
+			if err := tenant.Validate(name); err != nil {
+				handler.WriteError(w, http.StatusBadRequest, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(tenant.WithTenant(r.Context(), name)))
+		})
+	}
+}
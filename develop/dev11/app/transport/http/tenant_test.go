@@ -0,0 +1,52 @@
+package http
+
+import (
+	"dev11/app/tenant"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		domain   string
+		host     string
+		header   string
+		want     string
+		wantCode int
+	}{
+		{"Default", "", "localhost:3000", "", tenant.Default, http.StatusOK},
+		{"Header", "", "localhost:3000", "sales", "sales", http.StatusOK},
+		{"Subdomain", "calendar.example.com", "sales.calendar.example.com:3000", "", "sales", http.StatusOK},
+		{"SubdomainUpper", "calendar.example.com", "Sales.Calendar.Example.com", "", "sales", http.StatusOK},
+		{"HeaderOverSubdomain", "calendar.example.com", "sales.calendar.example.com", "support", "support", http.StatusOK},
+		{"BaseDomain", "calendar.example.com", "calendar.example.com", "", tenant.Default, http.StatusOK},
+		{"NestedSubdomain", "calendar.example.com", "eu.sales.calendar.example.com", "", tenant.Default, http.StatusOK},
+		{"SubdomainWithoutDomain", "", "sales.calendar.example.com", "", tenant.Default, http.StatusOK},
+		{"InvalidHeader", "", "localhost", "../sales", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := TenantMiddleware(tt.domain)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = tenant.FromContext(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Host = tt.host
+			if tt.header != "" {
+				r.Header.Set(TenantHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("TenantMiddleware() code = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got != tt.want {
+				t.Errorf("TenantMiddleware() tenant = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
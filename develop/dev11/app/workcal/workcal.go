@@ -0,0 +1,304 @@
+// Пакет workcal предоставляет производственные календари (праздники и перенесенные
+// рабочие дни) и рабочие графики пользователей: рабочие дни недели и рабочее время.
+// Даты календаря задаются в UTC, а дни и рабочее время графика отсчитываются в его
+// часовом поясе, по умолчанию тоже UTC.
+package workcal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Ошибки разбора календарей и графиков.
+var (
+	ErrInvalidCalendar = errors.New("invalid work calendar")
+	ErrInvalidHours    = errors.New("invalid working hours")
+	ErrInvalidWeekdays = errors.New("invalid working days")
+)
+
+// Формат дат календаря.
+const dateLayout = time.DateOnly
+
+// Структура производственного календаря: праздники и рабочие дни, перенесенные на выходные.
+// Нулевой указатель означает календарь без праздников.
+type Calendar struct {
+	holidays map[string]string
+	workdays map[string]bool
+}
+
+// NewCalendar возвращает пустой календарь.
+func NewCalendar() *Calendar {
+	return &Calendar{holidays: make(map[string]string), workdays: make(map[string]bool)}
+}
+
+// AddHoliday добавляет праздник name в день date.
+func (c *Calendar) AddHoliday(date time.Time, name string) {
+	c.holidays[date.UTC().Format(dateLayout)] = name
+}
+
+// AddWorkday добавляет рабочий день date, перенесенный на выходной.
+func (c *Calendar) AddWorkday(date time.Time) {
+	c.workdays[date.UTC().Format(dateLayout)] = true
+}
+
+// Holiday возвращает название праздника в день date, если он есть.
+func (c *Calendar) Holiday(date time.Time) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	name, ok := c.holidays[date.UTC().Format(dateLayout)]
+	return name, ok
+}
+
+// Workday сообщает, перенесен ли на день date рабочий день.
+func (c *Calendar) Workday(date time.Time) bool {
+	return c != nil && c.workdays[date.UTC().Format(dateLayout)]
+}
+
+// Структура календаря в формате JSON.
+type calendarJSON struct {
+	Holidays []struct {
+		Date string `json:"date"`
+		Name string `json:"name"`
+	} `json:"holidays"`
+	Workdays []string `json:"workdays"`
+}
+
+// ParseJSON читает календарь из r в формате
+// {"holidays": [{"date": "2025-01-01", "name": "Новый год"}], "workdays": ["2025-11-01"]}.
+func ParseJSON(r io.Reader) (*Calendar, error) {
+	var data calendarJSON
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCalendar, err)
+	}
+
+	c := NewCalendar()
+	for _, h := range data.Holidays {
+		date, err := time.Parse(dateLayout, h.Date)
+		if err != nil {
+			return nil, fmt.Errorf("%w: holiday %q", ErrInvalidCalendar, h.Date)
+		}
+		c.AddHoliday(date, h.Name)
+	}
+	for _, w := range data.Workdays {
+		date, err := time.Parse(dateLayout, w)
+		if err != nil {
+			return nil, fmt.Errorf("%w: workday %q", ErrInvalidCalendar, w)
+		}
+		c.AddWorkday(date)
+	}
+	return c, nil
+}
+
+// Структура рабочего графика: рабочее время [Start, End) как смещение от полуночи,
+// рабочие дни недели, производственный календарь и часовой пояс Location, в котором
+// отсчитываются дни и рабочее время. Нулевой Location означает UTC.
+type Schedule struct {
+	Start    time.Duration
+	End      time.Duration
+	Weekdays []time.Weekday
+	Calendar *Calendar
+	Location *time.Location
+}
+
+// Рабочий график по умолчанию: с 9:00 до 18:00 с понедельника по пятницу без праздников.
+var DefaultSchedule = Schedule{
+	Start:    9 * time.Hour,
+	End:      18 * time.Hour,
+	Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+}
+
+// location возвращает часовой пояс графика.
+func (s Schedule) location() *time.Location {
+	if s.Location == nil {
+		return time.UTC
+	}
+	return s.Location
+}
+
+// Date возвращает полночь дня year-month-day в часовом поясе графика.
+func (s Schedule) Date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, s.location())
+}
+
+// Day возвращает полночь дня date в часовом поясе графика.
+func (s Schedule) Day(date time.Time) time.Time {
+	return s.Date(date.In(s.location()).Date())
+}
+
+// calendarDate возвращает день date в часовом поясе графика как полночь UTC,
+// в которой задаются даты календаря.
+func (s Schedule) calendarDate(date time.Time) time.Time {
+	year, month, day := date.In(s.location()).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// IsBusinessDay сообщает, является ли день date в часовом поясе графика рабочим: рабочий день
+// недели не праздник, а выходной день рабочий, если на него перенесен рабочий день.
+func (s Schedule) IsBusinessDay(date time.Time) bool {
+	day := s.calendarDate(date)
+	if s.Calendar.Workday(day) {
+		return true
+	}
+	if _, ok := s.Calendar.Holiday(day); ok {
+		return false
+	}
+	return slices.Contains(s.Weekdays, day.Weekday())
+}
+
+// Hours возвращает рабочее время [start, end) дня date в часовом поясе графика
+// или false, если день нерабочий.
+func (s Schedule) Hours(date time.Time) (start time.Time, end time.Time, ok bool) {
+	if !s.IsBusinessDay(date) {
+		return time.Time{}, time.Time{}, false
+	}
+	year, month, day := date.In(s.location()).Date()
+	// Время отсчитывается по часам, а не от полуночи, чтобы переход на летнее время не сдвигал его.
+	clock := func(d time.Duration) time.Time {
+		return time.Date(year, month, day, 0, int(d/time.Minute), 0, 0, s.location()).UTC()
+	}
+	return clock(s.Start), clock(s.End), true
+}
+
+// Holiday возвращает название праздника в день date в часовом поясе графика, если он есть.
+func (s Schedule) Holiday(date time.Time) (string, bool) {
+	return s.Calendar.Holiday(s.calendarDate(date))
+}
+
+// HolidayWarning возвращает предупреждение о событии в праздник, если день date им является.
+func (s Schedule) HolidayWarning(date time.Time) (string, bool) {
+	name, ok := s.Holiday(date)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%s is a holiday: %s", s.calendarDate(date).Format(dateLayout), name), true
+}
+
+// Структура рабочих графиков пользователей. Нулевой указатель означает
+// DefaultSchedule для всех пользователей.
+type Schedules struct {
+	// Default задает график пользователей без собственного графика.
+	Default Schedule
+	// Users задает графики пользователей по их идентификаторам.
+	Users map[string]Schedule
+}
+
+// For возвращает рабочий график пользователя userID.
+func (s *Schedules) For(userID string) Schedule {
+	if s == nil {
+		return DefaultSchedule
+	}
+	if schedule, ok := s.Users[userID]; ok {
+		return schedule
+	}
+	return s.Default
+}
+
+// Warnings возвращает предупреждения о событии пользователя userID в день date,
+// например о событии в праздник по его графику.
+func (s *Schedules) Warnings(userID string, date time.Time) []string {
+	if warning, ok := s.For(userID).HolidayWarning(date); ok {
+		return []string{warning}
+	}
+	return nil
+}
+
+// HasCalendars сообщает, задан ли хотя бы один производственный календарь.
+func (s *Schedules) HasCalendars() bool {
+	if s == nil {
+		return false
+	}
+	if s.Default.Calendar != nil {
+		return true
+	}
+	for _, schedule := range s.Users {
+		if schedule.Calendar != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseHours разбирает рабочее время в формате 09:00-18:00.
+func ParseHours(s string) (start time.Duration, end time.Duration, err error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidHours, s)
+	}
+	start, err1 := parseClock(from)
+	end, err2 := parseClock(to)
+	if err1 != nil || err2 != nil || start >= end {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidHours, s)
+	}
+	return start, end, nil
+}
+
+// ParseZonedHours разбирает рабочее время в формате 09:00-18:00 с необязательным часовым
+// поясом IANA в квадратных скобках, например 09:00-18:00[Europe/Moscow]. Без часового пояса
+// возвращается нулевой loc.
+func ParseZonedHours(s string) (start time.Duration, end time.Duration, loc *time.Location, err error) {
+	hours, zone, ok := strings.Cut(s, "[")
+	if ok {
+		name, ok := strings.CutSuffix(zone, "]")
+		if !ok || name == "" {
+			return 0, 0, nil, fmt.Errorf("%w: %q", ErrInvalidHours, s)
+		}
+		if loc, err = time.LoadLocation(name); err != nil {
+			return 0, 0, nil, fmt.Errorf("%w: %w", ErrInvalidHours, err)
+		}
+	}
+	start, end, err = ParseHours(hours)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return start, end, loc, nil
+}
+
+// parseClock разбирает время суток в формате 15:04 или 24:00.
+func parseClock(s string) (time.Duration, error) {
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Сокращенные названия дней недели.
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseWeekdays разбирает дни недели, перечисленные через запятую, например mon,tue,wed.
+// Диапазоны задаются через дефис, например mon-fri.
+func ParseWeekdays(s string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		first, ok1 := weekdayNames[from]
+		last, ok2 := weekdayNames[to]
+		if !ok1 || (isRange && !ok2) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidWeekdays, s)
+		}
+		if !isRange {
+			last = first
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			if !slices.Contains(days, d) {
+				days = append(days, d)
+			}
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
@@ -0,0 +1,234 @@
+package workcal
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"Valid", `{"holidays": [{"date": "2025-01-01", "name": "Новый год"}], "workdays": ["2025-11-01"]}`, false},
+		{"Empty", `{}`, false},
+		{"InvalidJSON", `{"holidays": `, true},
+		{"InvalidHoliday", `{"holidays": [{"date": "01.01.2025"}]}`, true},
+		{"InvalidWorkday", `{"workdays": ["2025-13-01"]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseJSON(strings.NewReader(tt.data))
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrInvalidCalendar)) {
+				t.Fatalf("ParseJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.name != "Valid" {
+				return
+			}
+			if name, ok := c.Holiday(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)); !ok || name != "Новый год" {
+				t.Errorf("Calendar.Holiday() = %q, %v, want %q", name, ok, "Новый год")
+			}
+			if !c.Workday(time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("Calendar.Workday() = false, want true")
+			}
+		})
+	}
+}
+
+func TestSchedule_IsBusinessDay(t *testing.T) {
+	c := NewCalendar()
+	c.AddHoliday(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), "Новый год")
+	c.AddWorkday(time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC))
+	s := DefaultSchedule
+	s.Calendar = c
+
+	tests := []struct {
+		name string
+		date time.Time
+		want bool
+	}{
+		{"Weekday", time.Date(2025, 1, 9, 10, 0, 0, 0, time.UTC), true},
+		{"Weekend", time.Date(2025, 1, 11, 10, 0, 0, 0, time.UTC), false},
+		{"Holiday", time.Date(2025, 1, 1, 23, 59, 0, 0, time.UTC), false},
+		{"TransferredWorkday", time.Date(2025, 11, 1, 10, 0, 0, 0, time.UTC), true},
+		{"OtherZone", time.Date(2025, 1, 2, 1, 0, 0, 0, time.FixedZone("MSK", 3*60*60)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.IsBusinessDay(tt.date); got != tt.want {
+				t.Errorf("Schedule.IsBusinessDay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_Hours(t *testing.T) {
+	day := time.Date(2010, 5, 17, 0, 0, 0, 0, time.UTC)
+	start, end, ok := DefaultSchedule.Hours(day.Add(15 * time.Hour))
+	if !ok || !start.Equal(day.Add(9*time.Hour)) || !end.Equal(day.Add(18*time.Hour)) {
+		t.Errorf("Schedule.Hours() = %v, %v, %v", start, end, ok)
+	}
+	if _, _, ok := DefaultSchedule.Hours(day.AddDate(0, 0, -1)); ok {
+		t.Errorf("Schedule.Hours() on Sunday ok = true, want false")
+	}
+}
+
+func TestSchedule_Location(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	c := NewCalendar()
+	c.AddHoliday(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), "New Year")
+	s := DefaultSchedule
+	s.Calendar, s.Location = c, newYork
+
+	// Понедельник 03:00 UTC — еще воскресенье в Нью-Йорке.
+	if s.IsBusinessDay(time.Date(2025, 1, 13, 3, 0, 0, 0, time.UTC)) {
+		t.Errorf("Schedule.IsBusinessDay() = true, want false")
+	}
+	tests := []struct {
+		name               string
+		date               time.Time
+		wantStart, wantEnd time.Time
+	}{
+		{"Winter", time.Date(2025, 1, 13, 15, 0, 0, 0, time.UTC),
+			time.Date(2025, 1, 13, 14, 0, 0, 0, time.UTC), time.Date(2025, 1, 13, 23, 0, 0, 0, time.UTC)},
+		{"AfterDST", time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC),
+			time.Date(2025, 3, 10, 13, 0, 0, 0, time.UTC), time.Date(2025, 3, 10, 22, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := s.Hours(tt.date)
+			if !ok || !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("Schedule.Hours() = %v, %v, %v, want %v, %v", start, end, ok, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+
+	// 2 января 03:00 UTC — еще 1 января в Нью-Йорке.
+	want := "2025-01-01 is a holiday: New Year"
+	if got, ok := s.HolidayWarning(time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)); !ok || got != want {
+		t.Errorf("Schedule.HolidayWarning() = %q, %v, want %q", got, ok, want)
+	}
+	if got, ok := s.HolidayWarning(time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC)); ok {
+		t.Errorf("Schedule.HolidayWarning() = %q, want none", got)
+	}
+}
+
+func TestSchedules_For(t *testing.T) {
+	custom := Schedule{Start: 10 * time.Hour, End: 16 * time.Hour, Weekdays: []time.Weekday{time.Saturday}}
+	s := &Schedules{Default: DefaultSchedule, Users: map[string]Schedule{"1": custom}}
+
+	if got := s.For("1"); !reflect.DeepEqual(got, custom) {
+		t.Errorf("Schedules.For() = %v, want %v", got, custom)
+	}
+	if got := s.For("2"); !reflect.DeepEqual(got, DefaultSchedule) {
+		t.Errorf("Schedules.For() = %v, want %v", got, DefaultSchedule)
+	}
+	var empty *Schedules
+	if got := empty.For("1"); !reflect.DeepEqual(got, DefaultSchedule) || empty.HasCalendars() {
+		t.Errorf("Schedules(nil).For() = %v, want %v", got, DefaultSchedule)
+	}
+	s.Users["1"] = Schedule{Calendar: NewCalendar()}
+	if !s.HasCalendars() {
+		t.Errorf("Schedules.HasCalendars() = false, want true")
+	}
+}
+
+func TestParseHours(t *testing.T) {
+	tests := []struct {
+		s         string
+		wantStart time.Duration
+		wantEnd   time.Duration
+		wantErr   bool
+	}{
+		{"09:00-18:00", 9 * time.Hour, 18 * time.Hour, false},
+		{"08:30-24:00", 8*time.Hour + 30*time.Minute, 24 * time.Hour, false},
+		{"18:00-09:00", 0, 0, true},
+		{"09:00", 0, 0, true},
+		{"9-18", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			start, end, err := ParseHours(tt.s)
+			if (err != nil) != tt.wantErr || start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("ParseHours() = %v, %v, %v, want %v, %v, wantErr %v", start, end, err, tt.wantStart, tt.wantEnd, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseZonedHours(t *testing.T) {
+	tests := []struct {
+		s        string
+		wantZone string
+		wantErr  bool
+	}{
+		{"09:00-18:00", "", false},
+		{"09:00-18:00[Europe/Moscow]", "Europe/Moscow", false},
+		{"09:00-18:00[Mars/Olympus]", "", true},
+		{"09:00-18:00[Europe/Moscow", "", true},
+		{"09:00-18:00[]", "", true},
+		{"18:00-09:00[UTC]", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			start, end, loc, err := ParseZonedHours(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseZonedHours() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if start != 9*time.Hour || end != 18*time.Hour || (loc == nil) != (tt.wantZone == "") || (loc != nil && loc.String() != tt.wantZone) {
+				t.Errorf("ParseZonedHours() = %v, %v, %v, want zone %q", start, end, loc, tt.wantZone)
+			}
+		})
+	}
+}
+
+func TestSchedules_Warnings(t *testing.T) {
+	c := NewCalendar()
+	c.AddHoliday(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), "Новый год")
+	s := &Schedules{Default: Schedule{Calendar: c}, Users: map[string]Schedule{"1": {}}}
+
+	want := []string{"2025-01-01 is a holiday: Новый год"}
+	if got := s.Warnings("2", time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Schedules.Warnings() = %v, want %v", got, want)
+	}
+	if got := s.Warnings("1", time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)); got != nil {
+		t.Errorf("Schedules.Warnings() = %v, want none", got)
+	}
+	var empty *Schedules
+	if got := empty.Warnings("1", time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)); got != nil {
+		t.Errorf("Schedules(nil).Warnings() = %v, want none", got)
+	}
+}
+
+func TestParseWeekdays(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    []time.Weekday
+		wantErr bool
+	}{
+		{"mon-fri", DefaultSchedule.Weekdays, false},
+		{"Sat,sun,mon", []time.Weekday{time.Saturday, time.Sunday, time.Monday}, false},
+		{"fri-mon", []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday}, false},
+		{"mon,mon", []time.Weekday{time.Monday}, false},
+		{"monday", nil, true},
+		{"mon-", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseWeekdays(tt.s)
+			if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseWeekdays() = %v, %v, want %v, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
-	"dev11/app"
-	"flag"
+	"dev11/app/cli"
+	"os"
+	// База часовых поясов для разбора параметра tz на системах без tzdata.
+	_ "time/tzdata"
 )
 
 /*
@@ -32,14 +34,6 @@ import (
 	- В случае ошибки бизнес-логики сервер должен возвращать HTTP 503. В случае ошибки входных данных (невалидный int например) сервер должен возвращать HTTP 400. В случае остальных ошибок сервер должен возвращать HTTP 500. Web-сервер должен запускаться на порту указанном в конфиге и выводить в лог каждый обработанный запрос.
 */
 
-var host, port string
-
-func init() {
-	flag.StringVar(&host, "host", "localhost", "host for the server to listen on")
-	flag.StringVar(&port, "port", "3000", "port for the server to listen on")
-}
-
 func main() {
-	flag.Parse()
-	app.Run(host, port)
+	os.Exit(cli.Run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
 }